@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// Control socket protocol
+//
+// An agent started with --control-socket listens on a Unix socket and keeps the barrel after
+// activation until a supervisor tells it to yield. Each request and response is one JSON line:
+//
+//	request:  {"type":"YIELD","to_role":"tester","payload":"Code ready","failed":false,"timeout_seconds":30}
+//	response: {"status":"ok","to_role":"tester"}
+//	          {"status":"error","message":"agent does not hold the barrel"}
+//
+// to_role defaults to --yield-to and payload defaults to --yield-msg of the running agent
+// The response is sent once the server confirms the yield with ACK_YIELD, or refuses it with ERROR;
+// timeout_seconds bounds that wait and defaults to 30 seconds
+// Use `agent --control-yield --control-socket <path>` to send a request from a script
+
+const (
+	controlTypeYield = "YIELD"
+
+	controlStatusOK    = "ok"
+	controlStatusError = "error"
+
+	controlTimeout = 5 * time.Second
+
+	// defaultYieldTimeout is how long a yield waits for the server's confirmation when the request sets none
+	defaultYieldTimeout = 30 * time.Second
+)
+
+// ControlRequest is a command sent to a running agent over its control socket
+type ControlRequest struct {
+	Type    string `json:"type"`
+	ToRole  string `json:"to_role,omitempty"`
+	Payload string `json:"payload,omitempty"`
+	Failed  bool   `json:"failed,omitempty"`
+
+	// Metadata is structured data delivered to the recipient alongside the payload
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// TimeoutSeconds bounds how long the agent waits for the server to confirm the yield (0 = default)
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// yieldTimeout returns how long a yield request waits for the server's confirmation
+func (r ControlRequest) yieldTimeout() time.Duration {
+	if r.TimeoutSeconds <= 0 {
+		return defaultYieldTimeout
+	}
+	return time.Duration(r.TimeoutSeconds) * time.Second
+}
+
+// ControlResponse reports the outcome of a control request
+type ControlResponse struct {
+	Status  string `json:"status"`
+	ToRole  string `json:"to_role,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// listenControl opens the control socket and serves requests until the listener is closed
+// A stale socket file left by a crashed agent is replaced
+func (ac *AgentClient) listenControl(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("control socket %s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale control socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open control socket: %w", err)
+	}
+	// Only the user running the agent may make it yield
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict control socket: %w", err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go ac.serveControl(conn)
+		}
+	}()
+	return listener, nil
+}
+
+// serveControl answers the requests of one control connection
+func (ac *AgentClient) serveControl(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var request ControlRequest
+		var response ControlResponse
+		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			response = ControlResponse{Status: controlStatusError, Message: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			response = ac.handleControl(request)
+		}
+		if err := encoder.Encode(response); err != nil {
+			return
+		}
+	}
+}
+
+// handleControl executes a control request against the agent's connection to the server
+func (ac *AgentClient) handleControl(request ControlRequest) ControlResponse {
+	if request.Type != controlTypeYield {
+		return ControlResponse{Status: controlStatusError, Message: fmt.Sprintf("unknown request type: %s", request.Type)}
+	}
+
+	toRole := strings.TrimSpace(request.ToRole)
+	if toRole == "" {
+		toRole = ac.yieldTo
+	}
+	if toRole == "" {
+		return ControlResponse{Status: controlStatusError, Message: "to_role is required when the agent has no --yield-to"}
+	}
+	payload := request.Payload
+	if payload == "" {
+		payload = ac.yieldMsg
+	}
+
+	if request.TimeoutSeconds < 0 {
+		return ControlResponse{Status: controlStatusError, Message: "timeout_seconds cannot be negative"}
+	}
+	timeout := request.yieldTimeout()
+
+	ac.stateMu.Lock()
+	if !ac.holding {
+		ac.stateMu.Unlock()
+		return ControlResponse{Status: controlStatusError, Message: "agent does not hold the barrel"}
+	}
+
+	yieldMsg := tcp.YieldMessage{
+		Type:     "YIELD",
+		FromRole: ac.role,
+		ToRole:   toRole,
+		Payload:  payload,
+		Failed:   request.Failed,
+		SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
+		Metadata: request.Metadata,
+	}
+	confirmed := make(chan error, 1)
+	ac.pendingYield = confirmed
+	id, err := ac.sendRequest(yieldMsg)
+	if err != nil {
+		ac.pendingYield = nil
+		ac.stateMu.Unlock()
+		return ControlResponse{Status: controlStatusError, Message: fmt.Sprintf("failed to yield barrel: %v", err)}
+	}
+	ac.pendingYieldID = id
+	ac.holding = false
+	ac.stateMu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-confirmed:
+		if err != nil {
+			return ControlResponse{Status: controlStatusError, Message: fmt.Sprintf("server refused the yield: %v", err)}
+		}
+	case <-timer.C:
+		// The yield stays pending: a late refusal still gives the barrel back to confirmYield
+		return ControlResponse{Status: controlStatusError, Message: fmt.Sprintf("timed out after %s waiting for the server to confirm the yield to %s", timeout, toRole)}
+	}
+
+	ac.logEvent(domain.LogLevelInfo,
+		fmt.Sprintf("✅ Barrel yielded to %s on control request. Agent comrade %s is waiting again.\n", toRole, ac.role),
+		"Yielded barrel on control request", map[string]interface{}{
+			"role":    ac.role,
+			"to_role": toRole,
+		})
+	return ControlResponse{Status: controlStatusOK, ToRole: toRole}
+}
+
+// confirmYield hands the server's answer to a yield to the control request waiting for it
+// It reports whether a yield was pending, even one whose request has timed out. An answer to
+// another request is ignored; answers without a request id, from servers that do not echo one,
+// are taken as the yield's
+func (ac *AgentClient) confirmYield(requestID string, err error) bool {
+	ac.stateMu.Lock()
+	defer ac.stateMu.Unlock()
+	if ac.pendingYield == nil || (requestID != "" && requestID != ac.pendingYieldID) {
+		return false
+	}
+	if err != nil {
+		// The server refused the hand-off, so the barrel is still ours to yield again
+		ac.holding = true
+	}
+	ac.pendingYield <- err
+	ac.pendingYield = nil
+	return true
+}
+
+// holdForControl keeps the barrel after activation until a control request yields it
+func (ac *AgentClient) holdForControl() {
+	ac.stateMu.Lock()
+	ac.holding = true
+	ac.stateMu.Unlock()
+
+	fmt.Printf("⏳ Agent comrade %s holds the barrel until a yield arrives on %s\n", ac.role, ac.controlSocket)
+}
+
+// sendControlRequest sends one request to a running agent and returns its response
+// It gives up when ctx is done; the wait for a yield's confirmation is bounded by the request's timeout
+func sendControlRequest(ctx context.Context, path string, request ControlRequest) (ControlResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, request.yieldTimeout()+controlTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return ControlResponse{}, fmt.Errorf("failed to connect to control socket %s: %w", path, err)
+	}
+	defer conn.Close()
+	// Closing the connection unblocks the read below once ctx is done
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return ControlResponse{}, fmt.Errorf("failed to send control request: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	if !scanner.Scan() {
+		if ctx.Err() != nil {
+			return ControlResponse{}, fmt.Errorf("timed out waiting for the agent to yield: %w", ctx.Err())
+		}
+		if err := scanner.Err(); err != nil {
+			return ControlResponse{}, fmt.Errorf("failed to read control response: %w", err)
+		}
+		return ControlResponse{}, errors.New("agent closed the control socket without responding")
+	}
+
+	var response ControlResponse
+	if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+		return ControlResponse{}, fmt.Errorf("failed to parse control response: %w", err)
+	}
+	if response.Status != controlStatusOK {
+		return response, fmt.Errorf("agent did not yield: %s", response.Message)
+	}
+	return response, nil
+}
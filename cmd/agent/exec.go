@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// Policies of --on-failure besides yielding to a named role
+const (
+	onFailurePeople = "people" // Escalate failed work to the people (default)
+	onFailureKeep   = "keep"   // Keep the barrel so someone can look at the failure in place
+)
+
+// maxFailureOutput is how much of a failed command's stderr is sent with the escalation, in bytes
+// The end of the output is kept since that is where errors are usually reported
+const maxFailureOutput = 4096
+
+// execStopGrace bounds how long the children of a stopped command may keep its output open
+const execStopGrace = time.Second
+
+// execResult is the outcome of one --exec run
+type execResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// commandRunner runs the --exec command for one activation
+// An error means the command could not be run at all; a non-zero exit code is reported in the result.
+// The command must be stopped when ctx is done
+type commandRunner interface {
+	Run(ctx context.Context, command, payload string) (execResult, error)
+}
+
+// runnerFunc adapts a function to the commandRunner interface
+type runnerFunc func(ctx context.Context, command, payload string) (execResult, error)
+
+// Run calls f(ctx, command, payload)
+func (f runnerFunc) Run(ctx context.Context, command, payload string) (execResult, error) {
+	return f(ctx, command, payload)
+}
+
+// shellRunner runs commands with sh -c, passing the activation payload on stdin and in $AGENTFARM_PAYLOAD
+// It is used when an AgentClient has no runner
+var shellRunner commandRunner = runnerFunc(func(ctx context.Context, command, payload string) (execResult, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.WaitDelay = execStopGrace
+	cmd.Stdin = strings.NewReader(payload)
+	cmd.Env = append(os.Environ(), "AGENTFARM_PAYLOAD="+payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	result := execResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+		return result, nil
+	}
+	return result, err
+})
+
+// validateOnFailure checks an --on-failure value: people, keep or a role other than the agent's own
+func validateOnFailure(onFailure, role string) error {
+	switch strings.TrimSpace(onFailure) {
+	case "":
+		return fmt.Errorf("--on-failure cannot be empty: expected people, keep or a role")
+	case role:
+		return fmt.Errorf("--on-failure cannot escalate to the agent's own role %s", role)
+	}
+	return nil
+}
+
+// runningExec is an --exec run in progress
+type runningExec struct {
+	cancel     context.CancelFunc
+	activation tcp.ActivateMessage
+}
+
+// startExec runs the --exec command for an activation in the background, so the agent keeps
+// reading messages such as HALT_NOTICE meanwhile. During a halt the run waits for the resume
+func (ac *AgentClient) startExec(activateMsg tcp.ActivateMessage) {
+	ac.stateMu.Lock()
+	defer ac.stateMu.Unlock()
+	if ac.halted {
+		ac.interrupted = &activateMsg
+		fmt.Printf("🛑 Collective is halted; the command runs once the people resume\n")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &runningExec{cancel: cancel, activation: activateMsg}
+	ac.running = run
+	ac.execRuns.Add(1)
+	go func() {
+		defer ac.execRuns.Done()
+		err := ac.runExec(ctx, activateMsg)
+		cancel()
+
+		ac.stateMu.Lock()
+		if ac.running == run {
+			ac.running = nil
+		}
+		ac.stateMu.Unlock()
+
+		if err != nil {
+			ac.logEvent(domain.LogLevelError,
+				fmt.Sprintf("Error handling message: %v\n", err),
+				"Error handling message", map[string]interface{}{
+					"error": err.Error(),
+				})
+		}
+	}()
+}
+
+// waitExec stops the running --exec command, if any, and waits for it to finish
+func (ac *AgentClient) waitExec() {
+	ac.stateMu.Lock()
+	if ac.running != nil {
+		ac.running.cancel()
+	}
+	ac.stateMu.Unlock()
+	ac.execRuns.Wait()
+}
+
+// runExec runs the --exec command for an activation and hands the barrel on according to its outcome
+// Success yields to --yield-to (the people when unset) with the command's output; failure follows --on-failure.
+// A command stopped through ctx yields nothing
+func (ac *AgentClient) runExec(ctx context.Context, activateMsg tcp.ActivateMessage) error {
+	runner := ac.runner
+	if runner == nil {
+		runner = shellRunner
+	}
+
+	fmt.Printf("⚙️  Running: %s\n", ac.execCommand)
+	result, err := runner.Run(ctx, ac.execCommand, activateMsg.Payload)
+	if ctx.Err() != nil {
+		fmt.Printf("⏹️  Command stopped before it finished; keeping the barrel\n")
+		return nil
+	}
+	if err != nil {
+		return ac.handleExecFailure(fmt.Sprintf("Command could not be run: %v", err))
+	}
+	if result.ExitCode != 0 {
+		return ac.handleExecFailure(fmt.Sprintf("Command failed with exit code %d: %s",
+			result.ExitCode, tailOutput(result.Stderr)))
+	}
+
+	toRole := ac.yieldTo
+	if toRole == "" {
+		toRole = "people"
+	}
+	payload := strings.TrimSpace(result.Stdout)
+	if payload == "" {
+		payload = ac.yieldMsg
+	}
+	fmt.Printf("✅ Command succeeded, yielding barrel to: %s\n", toRole)
+	return ac.sendExecYield(toRole, payload, false)
+}
+
+// handleExecFailure escalates a failed --exec run according to --on-failure
+func (ac *AgentClient) handleExecFailure(payload string) error {
+	ac.logEvent(domain.LogLevelWarn,
+		fmt.Sprintf("❌ %s\n", payload),
+		"Exec command failed", map[string]interface{}{
+			"role":       ac.role,
+			"on_failure": ac.onFailureTarget(),
+		})
+
+	if ac.onFailureTarget() == onFailureKeep {
+		fmt.Printf("✋ Keeping the barrel; yield or reassign it once the failure is dealt with\n")
+		return nil
+	}
+	return ac.sendExecYield(ac.onFailureTarget(), payload, true)
+}
+
+// onFailureTarget returns the --on-failure policy, escalating to the people by default
+func (ac *AgentClient) onFailureTarget() string {
+	if ac.onFailure == "" {
+		return onFailurePeople
+	}
+	return ac.onFailure
+}
+
+// sendExecYield yields the barrel after an --exec run
+func (ac *AgentClient) sendExecYield(toRole, payload string, failed bool) error {
+	yieldMsg := tcp.YieldMessage{
+		Type:     "YIELD",
+		FromRole: ac.role,
+		ToRole:   toRole,
+		Payload:  payload,
+		Failed:   failed,
+		SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := ac.sendMessage(yieldMsg); err != nil {
+		return fmt.Errorf("failed to yield barrel: %w", err)
+	}
+	fmt.Printf("⏳ Agent comrade %s waiting for the next activation...\n", ac.role)
+	return nil
+}
+
+// tailOutput trims command output to its last maxFailureOutput bytes
+func tailOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= maxFailureOutput {
+		return output
+	}
+	return "..." + output[len(output)-maxFailureOutput:]
+}
@@ -1,49 +1,162 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
 )
 
 const (
 	defaultServerAddr = "localhost:53646"
 	connectionTimeout = 10 * time.Second
 	reconnectDelay    = 5 * time.Second
+	defaultLogMaxSize = 10 // megabytes
+
+	// defaultHeartbeatInterval keeps agents well inside any sensible server heartbeat timeout
+	defaultHeartbeatInterval = 10 * time.Second
 )
 
+// deregisterTimeout is how long a leaving agent waits for ACK_DEREGISTER before closing the connection itself
+const deregisterTimeout = 5 * time.Second
+
+// errRegistrationRejected marks a registration the server refused; retrying cannot fix it
+var errRegistrationRejected = errors.New("registration rejected by the Central Committee")
+
+// errTaskCompleted ends the agent after it received the barrel with nothing left to yield
+var errTaskCompleted = errors.New("task completed")
+
+// errServerShutdown ends the agent when the server shuts down without advising a reconnect
+var errServerShutdown = errors.New("server shut down")
+
+// errDeregistered ends the agent once the server confirmed it left the collective
+var errDeregistered = errors.New("deregistered")
+
+// dialer opens connections to the Central Committee
+type dialer interface {
+	Dial(addr string, timeout time.Duration) (net.Conn, error)
+}
+
+// dialFunc adapts a function to the dialer interface
+type dialFunc func(addr string, timeout time.Duration) (net.Conn, error)
+
+// Dial calls f(addr, timeout)
+func (f dialFunc) Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	return f(addr, timeout)
+}
+
+// tcpDialer connects over TCP; it is used when an AgentClient has no dialer
+var tcpDialer dialer = dialFunc(func(addr string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("tcp", addr, timeout)
+})
+
+// messageStream reads the frames the server sends on a connection; *bufio.Scanner implements it
+type messageStream interface {
+	Scan() bool
+	Text() string
+	Bytes() []byte
+	Err() error
+}
+
+// newMessageStream splits a connection into frames of whatever codec is in effect
+func newMessageStream(conn net.Conn, codec func() tcp.Codec) messageStream {
+	return tcp.NewFrameScanner(conn, tcp.SplitFrames(codec))
+}
+
 // AgentClient represents an Agent Comrade connection to the Central Committee
 type AgentClient struct {
 	role            string
+	agentType       string // Sent on registration: worker, observer or coordinator (empty = worker)
+	description     string // Sent on registration: what the agent does, for human operators
+	weight          int    // Sent on registration: share of the assignments of weighted groups
 	capabilities    []string
+	tags            map[string]string // Sent on registration: key/value labels such as region=us-east
+	instanceID      string            // Sent on registration: identifies this process across reconnections
 	serverAddr      string
 	yieldTo         string
 	yieldMsg        string
+	yieldFailed     bool
+	expectedHold    time.Duration // Estimate sent with ACTIVATE_ACK of how long the agent holds the barrel (0 = none)
 	morningCallFile string
 	conn            net.Conn
 	done            chan bool
-	hasYielded      bool // Track if we have already yielded
+	hasYielded      bool          // Track if we have already yielded
+	logger          domain.Logger // Optional lifecycle logger; nil keeps diagnostics on stdout
+	codecName       string        // Codec requested from the server during the handshake
+	framing         string        // Framing of JSON messages; must match the server's
+	codec           tcp.Codec     // Codec currently in effect on the connection
+	controlSocket   string        // Unix socket a supervisor uses to trigger yields (optional)
+	writeMu         sync.Mutex    // Serializes writes from the message loop and control requests
+	stateMu         sync.Mutex    // Guards holding and the exec and halt state below
+	holding         bool          // Activated and waiting for a control request to yield
+	pendingYield    chan error    // Receives the server's answer to a control-requested yield (guarded by stateMu)
+	pendingYieldID  string        // Request id of that yield, to tell its answer from others (guarded by stateMu)
+	maxRetries      int           // Consecutive failed connection attempts before giving up (0 = retry forever)
+	retryDelay      time.Duration // Wait between connection attempts
+	heartbeatPeriod time.Duration // How often a registered agent pings the server to show it is alive (0 = never)
+	registered      bool          // The server acknowledged the registration on the current connection
+	dialer          dialer        // Opens connections to the server (nil = TCP)
+	execCommand     string        // Command run on every activation (optional)
+	onFailure       string        // Where a failed --exec run sends the barrel: people, keep or a role
+	runner          commandRunner // Runs execCommand (nil = sh -c)
+
+	// reservationToken is sent on registration to claim a role the server reserved
+	reservationToken string
+
+	// requestSeq numbers the ids sent with every request so replies can be matched to them
+	requestSeq atomic.Uint64
+
+	// running is the --exec command in progress, and execRuns waits for it to finish
+	running  *runningExec
+	execRuns sync.WaitGroup
+	// halted follows HALT_NOTICE; interrupted is the activation a halt stopped, run again on resume
+	halted      bool
+	interrupted *tcp.ActivateMessage
 }
 
 func main() {
 	var (
 		role            = flag.String("role", "", "Agent comrade role (required)")
 		capabilities    = flag.String("capabilities", "", "Agent comrade capabilities (comma-separated)")
+		agentType       = flag.String("type", "worker", "Agent comrade type (worker, observer, coordinator); observers never receive the barrel")
+		description     = flag.String("description", "", "Free-text description of what the agent does, shown to the people")
+		tags            = flag.String("tags", "", "Key/value tags sent on registration (comma-separated key=value, e.g. region=us-east,gpu=true)")
+		instanceID      = flag.String("instance-id", "", "Identity kept across reconnections; another instance registering the role replaces this one (default: hostname-pid)")
+		weight          = flag.Int("weight", domain.DefaultAgentWeight, "Share of the assignments of weighted groups relative to other members")
 		serverAddr      = flag.String("server", defaultServerAddr, "Soviet server address")
 		yieldTo         = flag.String("yield-to", "", "Target role to yield barrel to after activation")
 		yieldMsg        = flag.String("yield-msg", "", "Message to send with yield")
+		yieldFailed     = flag.Bool("yield-failed", false, "Report the work as failed when yielding so the server may requeue it")
+		expectedHold    = flag.Duration("expected-duration", 0, "Tell the server how long this agent expects to hold the barrel once activated")
 		morningCallFile = flag.String("morning-call-file", "", "Optional file to read and print when activated")
 		queryAgents     = flag.Bool("query-agents", false, "Query registered agents and their capabilities (JSON format)")
+		heartbeat       = flag.Duration("heartbeat-interval", defaultHeartbeatInterval, "Ping the server this often so it knows the agent is alive (0 = never)")
+		logFile         = flag.String("log-file", "", "Write lifecycle logs to this file instead of stdout")
+		logLevel        = flag.String("log-level", "info", "Minimum log level for --log-file (debug, info, warn, error)")
+		logMaxSize      = flag.Int("log-max-size", defaultLogMaxSize, "Rotate --log-file after it reaches this size in megabytes (0 = never)")
+		codecName       = flag.String("codec", tcp.CodecJSON, "Wire format negotiated with the server (json, msgpack)")
+		framing         = flag.String("framing", tcp.FramingNewline, "Framing of JSON messages, as configured on the server (newline, length)")
+		execCommand     = flag.String("exec", "", "Run this shell command on every activation and yield its output to --yield-to")
+		onFailure       = flag.String("on-failure", onFailurePeople, "Where --exec sends the barrel when the command fails: people, keep or a role")
+		controlSocket   = flag.String("control-socket", "", "Keep the barrel after activation until a yield arrives on this Unix socket")
+		maxRetries      = flag.Int("max-retries", 0, "Give up after this many consecutive failed connection attempts (0 = retry forever)")
+		reservation     = flag.String("reservation-token", "", "Token claiming a role the server reserved (default: $AGENTFARM_RESERVATION_TOKEN)")
+		controlYield    = flag.Bool("control-yield", false, "Make the agent listening on --control-socket yield the barrel, then exit")
+		yieldTimeout    = flag.Int("yield-timeout", 0, "Seconds --control-yield waits for the server to confirm the yield (0 = 30)")
 		help            = flag.Bool("help", false, "Show help")
 		version         = flag.Bool("version", false, "Show version")
 	)
@@ -60,20 +173,93 @@ func main() {
 	}
 
 	// Handle query-agents operation
+	if err := tcp.ValidateFraming(*framing); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	if *queryAgents {
-		if err := executeQueryAgents(*serverAddr); err != nil {
+		if err := executeQueryAgents(*serverAddr, *framing); err != nil {
 			fmt.Fprintf(os.Stderr, "Error querying agents: %v\n", err)
 			os.Exit(1)
 		}
 		return
 	}
 
+	// Handle control-yield operation against an already running agent
+	if *controlYield {
+		if *controlSocket == "" {
+			fmt.Fprintf(os.Stderr, "Error: --control-yield requires --control-socket\n")
+			os.Exit(1)
+		}
+		if *yieldTimeout < 0 {
+			fmt.Fprintf(os.Stderr, "Error: --yield-timeout cannot be negative\n")
+			os.Exit(1)
+		}
+		response, err := sendControlRequest(context.Background(), *controlSocket, ControlRequest{
+			Type:           controlTypeYield,
+			ToRole:         *yieldTo,
+			Payload:        *yieldMsg,
+			Failed:         *yieldFailed,
+			TimeoutSeconds: *yieldTimeout,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Barrel yielded to %s\n", response.ToRole)
+		return
+	}
+
 	if *role == "" {
 		fmt.Fprintf(os.Stderr, "Error: --role is required\n")
 		showHelp()
 		os.Exit(1)
 	}
 
+	if _, err := tcp.NewCodec(*codecName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	parsedType, err := domain.ParseAgentType(*agentType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := domain.ValidateDescription(*description); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := domain.ValidateWeight(*weight); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	parsedTags, err := domain.ParseTags(*tags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *instanceID == "" {
+		*instanceID = defaultInstanceID()
+	}
+	if err := domain.ValidateInstanceID(*instanceID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := validateOnFailure(*onFailure, *role); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *heartbeat < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --heartbeat-interval cannot be negative\n")
+		os.Exit(1)
+	}
+	if *execCommand != "" && *controlSocket != "" {
+		fmt.Fprintf(os.Stderr, "Error: --exec and --control-socket both decide when to yield; use one\n")
+		os.Exit(1)
+	}
+
 	// Parse capabilities
 	var capsList []string
 	if *capabilities != "" {
@@ -86,12 +272,56 @@ func main() {
 
 	client := &AgentClient{
 		role:            *role,
+		agentType:       parsedType.String(),
+		description:     strings.TrimSpace(*description),
+		weight:          *weight,
 		capabilities:    capsList,
+		tags:            parsedTags,
+		instanceID:      strings.TrimSpace(*instanceID),
 		serverAddr:      *serverAddr,
 		yieldTo:         *yieldTo,
 		yieldMsg:        *yieldMsg,
+		yieldFailed:     *yieldFailed,
+		expectedHold:    *expectedHold,
 		morningCallFile: *morningCallFile,
 		done:            make(chan bool),
+		codecName:       *codecName,
+		framing:         *framing,
+		controlSocket:   *controlSocket,
+		execCommand:     *execCommand,
+		onFailure:       strings.TrimSpace(*onFailure),
+		maxRetries:      *maxRetries,
+		retryDelay:      reconnectDelay,
+		heartbeatPeriod: *heartbeat,
+	}
+	// Prefer the environment so the token stays out of process listings
+	client.reservationToken = *reservation
+	if client.reservationToken == "" {
+		client.reservationToken = os.Getenv("AGENTFARM_RESERVATION_TOKEN")
+	}
+
+	if *logFile != "" {
+		level, err := domain.ParseLogLevel(*logLevel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fileLogger, err := domain.NewFileLogger(*logFile, level, int64(*logMaxSize)*1024*1024)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer fileLogger.Close()
+		client.logger = fileLogger
+	}
+
+	if *controlSocket != "" {
+		listener, err := client.listenControl(*controlSocket)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer listener.Close()
 	}
 
 	if err := client.Run(); err != nil {
@@ -99,6 +329,16 @@ func main() {
 	}
 }
 
+// defaultInstanceID identifies the agent process by host and process ID, so its reconnections
+// resume the role while another process registering the role takes it over
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s-%d", strings.Join(strings.Fields(host), ""), os.Getpid())
+}
+
 func (ac *AgentClient) Run() error {
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -106,10 +346,14 @@ func (ac *AgentClient) Run() error {
 
 	go func() {
 		<-sigChan
-		fmt.Printf("\nAgent comrade %s received shutdown signal, disconnecting...\n", ac.role)
+		ac.logEvent(domain.LogLevelInfo,
+			fmt.Sprintf("\nAgent comrade %s received shutdown signal, leaving the collective...\n", ac.role),
+			"Received shutdown signal, deregistering", nil)
+		ac.deregister()
 		ac.done <- true
 	}()
 
+	failures := 0
 	for {
 		select {
 		case <-ac.done:
@@ -119,8 +363,42 @@ func (ac *AgentClient) Run() error {
 			return nil
 		default:
 			if err := ac.connectAndServe(); err != nil {
-				fmt.Printf("Connection lost: %v. Reconnecting in %v...\n", err, reconnectDelay)
-				time.Sleep(reconnectDelay)
+				if errors.Is(err, errTaskCompleted) || errors.Is(err, errDeregistered) {
+					return nil
+				}
+
+				// The server is not coming back, so reconnecting would only loop
+				if errors.Is(err, errServerShutdown) {
+					ac.logEvent(domain.LogLevelInfo,
+						fmt.Sprintf("🛑 Central Committee shut down. Agent comrade %s is exiting.\n", ac.role),
+						"Server shut down, exiting", map[string]interface{}{
+							"role": ac.role,
+						})
+					return nil
+				}
+
+				// A rejected registration fails the same way on every attempt
+				if errors.Is(err, errRegistrationRejected) {
+					return err
+				}
+
+				// Only consecutive failures count; a registered connection earns a fresh set of retries
+				if ac.registered {
+					failures = 0
+				}
+				failures++
+				if ac.maxRetries > 0 && failures > ac.maxRetries {
+					return fmt.Errorf("giving up after %d retries, last error: %w", ac.maxRetries, err)
+				}
+
+				ac.logEvent(domain.LogLevelWarn,
+					fmt.Sprintf("Connection lost: %v. Reconnecting in %v...\n", err, ac.retryDelay),
+					"Connection lost, reconnecting", map[string]interface{}{
+						"error":   err.Error(),
+						"delay":   ac.retryDelay.String(),
+						"attempt": failures,
+					})
+				time.Sleep(ac.retryDelay)
 				continue
 			}
 		}
@@ -128,65 +406,157 @@ func (ac *AgentClient) Run() error {
 }
 
 func (ac *AgentClient) connectAndServe() error {
+	ac.registered = false
+
 	// Establish connection to Central Committee
+	dialer := ac.dialer
+	if dialer == nil {
+		dialer = tcpDialer
+	}
 	var err error
-	ac.conn, err = net.DialTimeout("tcp", ac.serverAddr, connectionTimeout)
+	ac.conn, err = dialer.Dial(ac.serverAddr, connectionTimeout)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Soviet server at %s: %w", ac.serverAddr, err)
 	}
+	// Registered first so it runs after the connection is closed, which unblocks a command's yield
+	defer ac.waitExec()
 	defer func() {
 		_ = ac.conn.Close()
 	}()
 
-	fmt.Printf("Agent comrade %s connected to Central Committee at %s\n", ac.role, ac.serverAddr)
+	// A new connection holds nothing until the server activates it again
+	ac.stateMu.Lock()
+	ac.holding = false
+	ac.pendingYield = nil
+	ac.halted = false
+	ac.interrupted = nil
+	ac.stateMu.Unlock()
+
+	ac.logEvent(domain.LogLevelInfo,
+		fmt.Sprintf("Agent comrade %s connected to Central Committee at %s\n", ac.role, ac.serverAddr),
+		"Connected to Central Committee", map[string]interface{}{
+			"role":   ac.role,
+			"server": ac.serverAddr,
+		})
+
+	// Every connection starts with JSON until a different codec is negotiated
+	ac.codec = tcp.JSONCodec{Framing: ac.framing}
+	stream := newMessageStream(ac.conn, func() tcp.Codec {
+		return ac.codec
+	})
+
+	if ac.codecName != tcp.CodecJSON {
+		if err := ac.negotiateCodec(stream); err != nil {
+			return err
+		}
+	}
 
 	// Send registration message
 	registerMsg := tcp.RegisterMessage{
 		Type:         "REGISTER",
 		Role:         ac.role,
 		Capabilities: ac.capabilities,
+		AgentType:    ac.agentType,
+		Description:  ac.description,
+		Weight:       ac.weight,
+
+		ReservationToken: ac.reservationToken,
+		Tags:             ac.tags,
+		InstanceID:       ac.instanceID,
 	}
 
 	if err := ac.sendMessage(registerMsg); err != nil {
 		return fmt.Errorf("failed to register: %w", err)
 	}
 
-	fmt.Printf("Agent comrade %s registered successfully. Waiting for barrel assignment...\n", ac.role)
+	ac.logEvent(domain.LogLevelInfo,
+		fmt.Sprintf("Agent comrade %s registered successfully. Waiting for barrel assignment...\n", ac.role),
+		"Registration sent, waiting for barrel assignment", map[string]interface{}{
+			"role":         ac.role,
+			"capabilities": strings.Join(ac.capabilities, ","),
+		})
+
+	// Ping for as long as this connection lasts, also while working on an activation
+	heartbeatDone := make(chan struct{})
+	defer close(heartbeatDone)
+	if ac.heartbeatPeriod > 0 {
+		go ac.heartbeat(ac.conn, ac.codec, heartbeatDone)
+	}
 
 	// Listen for messages from Central Committee
-	scanner := bufio.NewScanner(ac.conn)
-	for scanner.Scan() {
+	for stream.Scan() {
 		select {
 		case <-ac.done:
 			return nil
 		default:
 		}
 
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
+		line := stream.Text()
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
 
 		if err := ac.handleMessage(line); err != nil {
-			fmt.Printf("Error handling message: %v\n", err)
+			if errors.Is(err, errRegistrationRejected) || errors.Is(err, errTaskCompleted) || errors.Is(err, errServerShutdown) || errors.Is(err, errDeregistered) {
+				return err
+			}
+			ac.logEvent(domain.LogLevelError,
+				fmt.Sprintf("Error handling message: %v\n", err),
+				"Error handling message", map[string]interface{}{
+					"error": err.Error(),
+				})
 			continue
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
+	if err := stream.Err(); err != nil {
 		return fmt.Errorf("connection error: %w", err)
 	}
 
 	return fmt.Errorf("connection closed by server")
 }
 
-func (ac *AgentClient) handleMessage(line string) error {
-	// Parse the message to determine type
-	var baseMsg struct {
-		Type string `json:"type"`
+// negotiateCodec asks the server to switch the connection to the requested codec
+func (ac *AgentClient) negotiateCodec(stream messageStream) error {
+	helloMsg := tcp.HelloMessage{
+		Type:  "HELLO",
+		Codec: ac.codecName,
+	}
+	if err := ac.sendMessage(helloMsg); err != nil {
+		return fmt.Errorf("failed to send codec handshake: %w", err)
+	}
+
+	if !stream.Scan() {
+		return fmt.Errorf("no response to codec handshake")
+	}
+
+	var ackMsg tcp.HelloAckMessage
+	if err := ac.codec.Decode(stream.Bytes(), &ackMsg); err != nil {
+		return fmt.Errorf("failed to parse codec handshake response: %w", err)
+	}
+	if ackMsg.Type != "HELLO_ACK" {
+		var errorMsg tcp.ErrorMessage
+		_ = ac.codec.Decode(stream.Bytes(), &errorMsg)
+		return fmt.Errorf("server refused codec %s: %s", ac.codecName, errorMsg.Message)
 	}
 
-	if err := json.Unmarshal([]byte(line), &baseMsg); err != nil {
+	codec, err := tcp.NewCodec(ackMsg.Codec)
+	if err != nil {
+		return err
+	}
+	// The server keeps its framing for JSON connections
+	if jsonCodec, ok := codec.(tcp.JSONCodec); ok {
+		jsonCodec.Framing = ac.framing
+		codec = jsonCodec
+	}
+	ac.codec = codec
+	return nil
+}
+
+func (ac *AgentClient) handleMessage(line string) error {
+	// Parse the message to determine type
+	var baseMsg tcp.TCPMessage
+	if err := ac.codec.Decode([]byte(line), &baseMsg); err != nil {
 		return fmt.Errorf("failed to parse message: %w", err)
 	}
 
@@ -194,11 +564,25 @@ func (ac *AgentClient) handleMessage(line string) error {
 	case "ACTIVATE":
 		return ac.handleActivateMessage(line)
 	case "ERROR":
-		return ac.handleErrorMessage(line)
+		return ac.handleErrorMessage(line, baseMsg.ID)
 	case "ACK_REGISTER":
 		return ac.handleAckRegisterMessage(line)
+	case "ACK_YIELD":
+		return ac.handleAckYieldMessage(line, baseMsg.ID)
+	case "SHUTDOWN":
+		return ac.handleShutdownMessage(line)
+	case "ACK_DEREGISTER":
+		return ac.handleAckDeregisterMessage(line)
+	case "HALT_NOTICE":
+		return ac.handleHaltNoticeMessage(line)
+	case "PONG":
+		// Answers a heartbeat; nothing to do
 	default:
-		fmt.Printf("Received unknown message type: %s\n", baseMsg.Type)
+		ac.logEvent(domain.LogLevelWarn,
+			fmt.Sprintf("Received unknown message type: %s\n", baseMsg.Type),
+			"Received unknown message type", map[string]interface{}{
+				"type": baseMsg.Type,
+			})
 	}
 
 	return nil
@@ -206,10 +590,22 @@ func (ac *AgentClient) handleMessage(line string) error {
 
 func (ac *AgentClient) handleActivateMessage(line string) error {
 	var activateMsg tcp.ActivateMessage
-	if err := json.Unmarshal([]byte(line), &activateMsg); err != nil {
+	if err := ac.codec.Decode([]byte(line), &activateMsg); err != nil {
 		return fmt.Errorf("failed to parse ACTIVATE message: %w", err)
 	}
 
+	// Confirm receipt so the server can move us from offered to working
+	ackMsg := tcp.ActivateAckMessage{
+		Type: "ACTIVATE_ACK",
+		Role: ac.role,
+	}
+	if ac.expectedHold > 0 {
+		ackMsg.ExpectedDuration = ac.expectedHold.String()
+	}
+	if err := ac.sendMessage(ackMsg); err != nil {
+		return fmt.Errorf("failed to acknowledge activation: %w", err)
+	}
+
 	// Print morning call file content if specified
 	if ac.morningCallFile != "" {
 		if err := ac.printMorningCallFile(); err != nil {
@@ -221,6 +617,32 @@ func (ac *AgentClient) handleActivateMessage(line string) error {
 	if activateMsg.Payload != "" {
 		fmt.Printf("📜 Message: %s\n", activateMsg.Payload)
 	}
+	for _, key := range domain.SortedMetadataKeys(activateMsg.Metadata) {
+		fmt.Printf("🏷️  %s: %s\n", key, activateMsg.Metadata[key])
+	}
+	if activateMsg.RetryCount > 0 {
+		fmt.Printf("🔁 Retry attempt: %d\n", activateMsg.RetryCount)
+	}
+	if activateMsg.Receipt != nil {
+		fmt.Printf("🧾 Receipt #%d: %s\n", activateMsg.Receipt.Sequence, activateMsg.Receipt.Hash)
+	}
+
+	// Never start work we are not capable of; hand it straight back to the people
+	if declined, err := ac.declineIfIncapable(activateMsg); declined || err != nil {
+		return err
+	}
+
+	// A supervisor decides when the work is done and yields through the control socket
+	if ac.controlSocket != "" {
+		ac.holdForControl()
+		return nil
+	}
+
+	// Every activation runs the command, whose outcome decides where the barrel goes
+	if ac.execCommand != "" {
+		ac.startExec(activateMsg)
+		return nil
+	}
 
 	// If yield-to is specified and we haven't yielded yet, yield the barrel and wait for it to come back
 	if ac.yieldTo != "" && !ac.hasYielded {
@@ -236,35 +658,206 @@ func (ac *AgentClient) handleActivateMessage(line string) error {
 
 	// Exit when barrel is received (either first time with no yield-to, or after barrel comes back)
 	fmt.Printf("✅ Agent comrade %s task completed. Exiting...\n", ac.role)
-	os.Exit(0)
-	return nil // This line will never be reached, but satisfies the function signature
+	return errTaskCompleted
+}
+
+// declineIfIncapable yields the barrel back to the people when the activation requires a capability
+// this agent lacks. Returns true if the work was declined
+func (ac *AgentClient) declineIfIncapable(activateMsg tcp.ActivateMessage) (bool, error) {
+	required := activateMsg.RequiredCapability
+	if required == "" || ac.hasCapability(required) {
+		return false, nil
+	}
+
+	ac.logEvent(domain.LogLevelWarn,
+		fmt.Sprintf("🚫 Agent comrade %s lacks required capability '%s', declining the work\n", ac.role, required),
+		"Declining work that requires a missing capability", map[string]interface{}{
+			"role":       ac.role,
+			"capability": required,
+		})
+
+	declineMsg := tcp.YieldMessage{
+		Type:     "YIELD",
+		FromRole: ac.role,
+		ToRole:   "people",
+		Payload:  fmt.Sprintf("Cannot perform: agent '%s' lacks required capability '%s'", ac.role, required),
+		SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := ac.sendMessage(declineMsg); err != nil {
+		return true, fmt.Errorf("failed to decline work: %w", err)
+	}
+	return true, nil
+}
+
+// hasCapability checks whether the agent registered with a capability matching the required pattern
+func (ac *AgentClient) hasCapability(capability string) bool {
+	for _, own := range ac.capabilities {
+		if domain.CapabilityMatches(capability, own) {
+			return true
+		}
+	}
+	return false
 }
 
-func (ac *AgentClient) handleErrorMessage(line string) error {
+func (ac *AgentClient) handleErrorMessage(line, requestID string) error {
 	var errorMsg tcp.ErrorMessage
-	if err := json.Unmarshal([]byte(line), &errorMsg); err != nil {
+	if err := ac.codec.Decode([]byte(line), &errorMsg); err != nil {
 		return fmt.Errorf("failed to parse ERROR message: %w", err)
 	}
 
-	fmt.Printf("❌ Error from Central Committee: %s\n", errorMsg.Message)
+	// Until registration is acknowledged, any error is the server refusing to enlist us
+	if !ac.registered {
+		return fmt.Errorf("%w: %s", errRegistrationRejected, errorMsg.Message)
+	}
+	ac.confirmYield(requestID, errors.New(errorMsg.Message))
+
+	ac.logEvent(domain.LogLevelError,
+		fmt.Sprintf("❌ Error from Central Committee: %s\n", errorMsg.Message),
+		"Error from Central Committee", map[string]interface{}{
+			"message": errorMsg.Message,
+		})
 	return nil
 }
 
 func (ac *AgentClient) handleAckRegisterMessage(line string) error {
 	var ackMsg tcp.AckRegisterMessage
-	if err := json.Unmarshal([]byte(line), &ackMsg); err != nil {
+	if err := ac.codec.Decode([]byte(line), &ackMsg); err != nil {
 		return fmt.Errorf("failed to parse ACK_REGISTER message: %w", err)
 	}
+	if ackMsg.Status != "success" {
+		return fmt.Errorf("%w: %s (%s)", errRegistrationRejected, ackMsg.Message, ackMsg.Status)
+	}
+	ac.registered = true
+
+	if ac.logger != nil {
+		ac.logger.Info("Registration acknowledged", map[string]interface{}{
+			"status":  ackMsg.Status,
+			"message": ackMsg.Message,
+		})
+		return nil
+	}
 
 	fmt.Printf("📋 Registration acknowledged: %s\n", ackMsg.Message)
-	if ackMsg.Status == "success" {
-		fmt.Printf("✅ Agent comrade %s successfully enrolled in the collective\n", ac.role)
-	} else {
-		fmt.Printf("⚠️  Registration status: %s\n", ackMsg.Status)
+	fmt.Printf("✅ Agent comrade %s successfully enrolled in the collective\n", ac.role)
+	return nil
+}
+
+func (ac *AgentClient) handleAckYieldMessage(line, requestID string) error {
+	var ackMsg tcp.YieldAckMessage
+	if err := ac.codec.Decode([]byte(line), &ackMsg); err != nil {
+		return fmt.Errorf("failed to parse ACK_YIELD message: %w", err)
+	}
+	ac.confirmYield(requestID, nil)
+
+	if ackMsg.Receipt == nil {
+		return nil
 	}
+
+	ac.logEvent(domain.LogLevelInfo,
+		fmt.Sprintf("🧾 Hand-off receipt #%d to %s: %s\n", ackMsg.Receipt.Sequence, ackMsg.ToRole, ackMsg.Receipt.Hash),
+		"Hand-off receipt", map[string]interface{}{
+			"sequence":      ackMsg.Receipt.Sequence,
+			"to_role":       ackMsg.ToRole,
+			"payload_hash":  ackMsg.Receipt.PayloadHash,
+			"previous_hash": ackMsg.Receipt.PreviousHash,
+			"hash":          ackMsg.Receipt.Hash,
+		})
 	return nil
 }
 
+// handleShutdownMessage ends the agent when the server advises against reconnecting
+// Otherwise the server closes the connection and the agent reconnects as after any lost connection
+func (ac *AgentClient) handleShutdownMessage(line string) error {
+	var shutdownMsg tcp.ShutdownMessage
+	if err := ac.codec.Decode([]byte(line), &shutdownMsg); err != nil {
+		return fmt.Errorf("failed to parse SHUTDOWN message: %w", err)
+	}
+
+	if !shutdownMsg.ReconnectAdvised {
+		return fmt.Errorf("%w: %s", errServerShutdown, shutdownMsg.Message)
+	}
+
+	ac.logEvent(domain.LogLevelInfo,
+		fmt.Sprintf("🔄 Central Committee is going away (%s): %s\n", shutdownMsg.Reason, shutdownMsg.Message),
+		"Server shutting down, will reconnect", map[string]interface{}{
+			"reason":  shutdownMsg.Reason,
+			"message": shutdownMsg.Message,
+		})
+	return nil
+}
+
+// handleHaltNoticeMessage surfaces a halt or resume by the people
+// A halt stops the running --exec command, which is run again for the same activation on resume
+func (ac *AgentClient) handleHaltNoticeMessage(line string) error {
+	var notice tcp.HaltNoticeMessage
+	if err := ac.codec.Decode([]byte(line), &notice); err != nil {
+		return fmt.Errorf("failed to parse HALT_NOTICE message: %w", err)
+	}
+
+	ac.stateMu.Lock()
+	ac.halted = notice.Halted
+	stopped := notice.Halted && ac.running != nil
+	if stopped {
+		ac.running.cancel()
+		activation := ac.running.activation
+		ac.interrupted = &activation
+	}
+	var resumed *tcp.ActivateMessage
+	if !notice.Halted {
+		resumed, ac.interrupted = ac.interrupted, nil
+	}
+	ac.stateMu.Unlock()
+
+	fields := map[string]interface{}{
+		"role":   ac.role,
+		"reason": notice.Reason,
+	}
+	if !notice.Halted {
+		fmt.Printf("\n▶️  RESUMED: %s\n", notice.Message)
+		if ac.logger != nil {
+			ac.logger.Info("Collective resumed by the people", fields)
+		}
+		if resumed != nil {
+			fmt.Printf("🔁 Running the command the halt stopped again\n")
+			ac.startExec(*resumed)
+		}
+		return nil
+	}
+
+	// A halt is printed even when diagnostics go to a log file: whoever watches the agent must see it
+	fmt.Printf("\n🛑🛑🛑 HALT ORDERED BY THE PEOPLE 🛑🛑🛑\n")
+	fmt.Printf("🛑 Reason: %s\n", notice.Reason)
+	fmt.Printf("🛑 Stop current work; yields are refused until the people resume\n\n")
+	if stopped {
+		fmt.Printf("⏹️  Stopping command: %s\n", ac.execCommand)
+	}
+	if ac.logger != nil {
+		fields["exec_stopped"] = stopped
+		ac.logger.Warn("Collective halted by the people", fields)
+	}
+	return nil
+}
+
+// logEvent reports a lifecycle diagnostic to the log file when one is configured,
+// otherwise it falls back to printing the human-readable text on stdout
+func (ac *AgentClient) logEvent(level domain.LogLevel, text string, message string, fields map[string]interface{}) {
+	if ac.logger == nil {
+		fmt.Print(text)
+		return
+	}
+
+	switch level {
+	case domain.LogLevelDebug:
+		ac.logger.Debug(message, fields)
+	case domain.LogLevelWarn:
+		ac.logger.Warn(message, fields)
+	case domain.LogLevelError:
+		ac.logger.Error(message, fields)
+	default:
+		ac.logger.Info(message, fields)
+	}
+}
+
 func (ac *AgentClient) printMorningCallFile() error {
 	content, err := os.ReadFile(ac.morningCallFile)
 	if err != nil {
@@ -287,6 +880,8 @@ func (ac *AgentClient) yieldBarrel() error {
 		FromRole: ac.role,
 		ToRole:   ac.yieldTo,
 		Payload:  ac.yieldMsg,
+		Failed:   ac.yieldFailed,
+		SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
 	}
 
 	if err := ac.sendMessage(yieldMsg); err != nil {
@@ -298,15 +893,82 @@ func (ac *AgentClient) yieldBarrel() error {
 	return nil
 }
 
+// deregister asks the server to take the agent out of the collective, which returns a held barrel
+// to the people. The server closes the connection after ACK_DEREGISTER; a connection it leaves
+// open is closed after deregisterTimeout
+func (ac *AgentClient) deregister() {
+	conn := ac.conn
+	if conn == nil {
+		return
+	}
+	if err := ac.sendMessage(tcp.DeregisterMessage{Type: "DEREGISTER", Role: ac.role}); err != nil {
+		_ = conn.Close()
+		return
+	}
+	time.AfterFunc(deregisterTimeout, func() {
+		_ = conn.Close()
+	})
+}
+
+// handleAckDeregisterMessage ends the agent once the server confirmed the deregistration
+func (ac *AgentClient) handleAckDeregisterMessage(line string) error {
+	var ackMsg tcp.AckDeregisterMessage
+	if err := ac.codec.Decode([]byte(line), &ackMsg); err != nil {
+		return fmt.Errorf("failed to parse ACK_DEREGISTER message: %w", err)
+	}
+
+	ac.logEvent(domain.LogLevelInfo,
+		fmt.Sprintf("👋 %s\n", ackMsg.Message),
+		"Deregistered from Central Committee", map[string]interface{}{
+			"role": ackMsg.Role,
+		})
+	return errDeregistered
+}
+
+// heartbeat pings the server on conn every heartbeatPeriod until done is closed or a write fails,
+// so a server checking heartbeats does not mark the agent disconnected. The connection and codec
+// are passed in because a reconnection replaces them while a late ping may still be in flight
+func (ac *AgentClient) heartbeat(conn net.Conn, codec tcp.Codec, done <-chan struct{}) {
+	ticker := time.NewTicker(ac.heartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		data, err := codec.Encode(tcp.PingMessage{Type: "PING"})
+		if err != nil {
+			return
+		}
+		ac.writeMu.Lock()
+		err = tcp.WriteFrame(conn, data)
+		ac.writeMu.Unlock()
+		if err != nil {
+			// The message loop notices the broken connection and reconnects
+			return
+		}
+	}
+}
+
 func (ac *AgentClient) sendMessage(msg interface{}) error {
-	data, err := json.Marshal(msg)
+	_, err := ac.sendRequest(msg)
+	return err
+}
+
+// sendRequest sends a message with a new request id and returns the id, which the server echoes on its answer
+func (ac *AgentClient) sendRequest(msg interface{}) (string, error) {
+	id := strconv.FormatUint(ac.requestSeq.Add(1), 10)
+	data, err := ac.codec.Encode(tcp.WithRequestID(msg, id))
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return "", fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	data = append(data, '\n')
-	_, err = ac.conn.Write(data)
-	return err
+	ac.writeMu.Lock()
+	defer ac.writeMu.Unlock()
+	return id, tcp.WriteFrame(ac.conn, data)
 }
 
 func showHelp() {
@@ -318,11 +980,32 @@ USAGE:
 OPTIONS:
     --role <role>               Agent comrade role (required)
     --capabilities <caps>       Agent comrade capabilities (comma-separated, e.g., "coding,testing,debugging")
+    --type <type>               Agent comrade type: worker, observer, coordinator (default: worker); observers never receive the barrel
+    --description <text>        What the agent does, shown to the people in agent listings (max 280 characters)
+    --tags <key=value,...>      Labels for routing and filtering, e.g. region=us-east,gpu=true
+    --instance-id <id>          Identity kept across reconnections (default: hostname-pid); a different
+                                instance registering the same role replaces this one
+    --weight <n>                Share of the work of weighted groups relative to other members, 1-100 (default: 1)
     --server <address>          Soviet server address (default: %s)
+    --reservation-token <token> Token claiming a role the server reserved (default: $AGENTFARM_RESERVATION_TOKEN)
     --yield-to <role>           Target role to yield barrel to after activation
     --yield-msg <message>       Message to send with yield
+    --yield-failed              Report the work as failed when yielding so the server may requeue it
+    --expected-duration <d>     Tell the server how long the agent expects to hold the barrel (e.g. 30m); overruns are flagged
     --morning-call-file <path>  Optional file to read and print when activated
     --query-agents              Query registered agents and their capabilities (JSON format)
+    --codec <name>              Wire format negotiated with the server: json, msgpack (default: json)
+    --framing <mode>            Framing of JSON messages, as configured on the server: newline, length (default: newline)
+    --max-retries <n>           Give up after n consecutive failed connection attempts (default: 0, retry forever)
+    --heartbeat-interval <d>    Ping the server this often so it knows the agent is alive (default: %s, 0 = never)
+    --exec <command>            Run a shell command on every activation; its stdout is yielded to --yield-to
+    --on-failure <target>       Where --exec sends the barrel when the command fails: people (default), keep or a role
+    --control-socket <path>     Keep the barrel after activation until a yield arrives on this Unix socket
+    --control-yield             Make the agent listening on --control-socket yield (uses --yield-to, --yield-msg, --yield-failed), then exit
+    --yield-timeout <seconds>   How long --control-yield waits for the server to confirm the yield (default: 30)
+    --log-file <path>           Write lifecycle logs to this file instead of stdout
+    --log-level <level>         Minimum log level for --log-file: debug, info, warn, error (default: info)
+    --log-max-size <mb>         Rotate --log-file after it reaches this size in megabytes (default: %d, 0 = never)
     --help                      Show this help
     --version                   Show version
 
@@ -348,6 +1031,16 @@ EXAMPLES:
     # Connect to custom server with capabilities
     agent --role=developer --server=localhost:8080 --capabilities="coding,debugging"
 
+    # Keep working until a supervisor script decides the work is done
+    agent --role=developer --yield-to=tester --control-socket=/tmp/developer.sock
+    agent --control-yield --control-socket=/tmp/developer.sock --yield-msg="Code ready for testing"
+
+    # Run the test suite on every activation; failures go back to the people
+    agent --role=tester --exec="make test" --yield-to=reviewer
+
+    # Run as a service with lifecycle logs kept out of stdout
+    agent --role=developer --log-file=/var/log/agentfarm/developer.log --log-level=debug
+
 REVOLUTIONARY WORKFLOW:
     1. Agent comrade connects to Central Committee
     2. Registers with specified role and capabilities
@@ -357,6 +1050,39 @@ REVOLUTIONARY WORKFLOW:
     6. If --yield-to specified, yields barrel to target and waits for barrel to return
     7. When barrel is received again (or first time if no yield-to), agent exits
 
+    If an activation requires a capability the agent did not register with, the agent
+    immediately yields the barrel back to the people with a "cannot perform" message
+    and keeps waiting instead of working.
+
+EXEC MODE:
+    With --exec the agent runs the command through sh -c on every activation, with the
+    activation payload on stdin and in $AGENTFARM_PAYLOAD, and never exits on activation.
+    On success the barrel goes to --yield-to (the people when unset) with the command's
+    stdout, or --yield-msg when there is none. On a non-zero exit the work is reported as
+    failed, with the exit code and the end of stderr, to the --on-failure target instead:
+    failures escalate to the people by default rather than advancing the pipeline, and
+    --on-failure=keep leaves the barrel with the agent.
+
+CONTROL SOCKET:
+    With --control-socket the agent never exits on activation. It holds the barrel until
+    a supervisor sends a yield over the Unix socket, then waits for the next activation.
+    Requests and responses are single JSON lines:
+        {"type":"YIELD","to_role":"tester","payload":"Code ready","failed":false,"timeout_seconds":30}
+        {"status":"ok","to_role":"tester"}
+        {"status":"error","message":"agent does not hold the barrel"}
+    to_role and payload default to the running agent's --yield-to and --yield-msg.
+    The response waits for the server to confirm the yield; after timeout_seconds
+    (default 30) the supervisor gets a timeout error instead.
+
+SHUTDOWN:
+    When the server shuts down it says whether to reconnect. The agent reconnects
+    after a drain or restart, and exits cleanly when the server is going away for good.
+
+HALT:
+    When the people halt the collective (people halt "<reason>") the agent prints the
+    reason prominently and stops a running --exec command; the barrel stays where it is.
+    On resume the stopped command runs again for the same activation.
+
 BLOCKING BEHAVIOR:
     - Without --yield-to: Agent blocks until barrel received, then exits
     - With --yield-to: Agent blocks until barrel received, yields it, then blocks again until barrel returns, then exits
@@ -371,9 +1097,11 @@ CAPABILITIES:
     the collective to understand each agent's revolutionary potential and assign
     appropriate tasks based on their expertise.
 
-The agent will automatically reconnect if connection is lost.
+The agent will automatically reconnect if connection is lost, up to --max-retries
+consecutive attempts. A registration refused by the server (for example a reserved
+or blank role) is not retried: the agent exits with status 1 and the server's reason.
 Use Ctrl+C to gracefully disconnect while waiting for barrel assignment.
-`, defaultServerAddr)
+`, defaultServerAddr, defaultHeartbeatInterval, defaultLogMaxSize)
 }
 
 func showVersion() {
@@ -383,7 +1111,7 @@ func showVersion() {
 }
 
 // executeQueryAgents connects to the server and queries agent details
-func executeQueryAgents(serverAddr string) error {
+func executeQueryAgents(serverAddr, framing string) error {
 	// Connect to the server
 	conn, err := net.DialTimeout("tcp", serverAddr, connectionTimeout)
 	if err != nil {
@@ -401,14 +1129,12 @@ func executeQueryAgents(serverAddr string) error {
 		return fmt.Errorf("failed to marshal query message: %w", err)
 	}
 
-	data = append(data, '\n')
-	_, err = conn.Write(data)
-	if err != nil {
+	if err := tcp.WriteFrame(conn, tcp.EncodeFrame(framing, data)); err != nil {
 		return fmt.Errorf("failed to send query message: %w", err)
 	}
 
 	// Read response
-	scanner := bufio.NewScanner(conn)
+	scanner := tcp.NewFrameScanner(conn, tcp.SplitFraming(framing))
 	if !scanner.Scan() {
 		return fmt.Errorf("no response from server")
 	}
@@ -0,0 +1,651 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+)
+
+func TestDeclineIfIncapable(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	client := &AgentClient{
+		role:         "developer",
+		capabilities: []string{"coding"},
+		conn:         clientConn,
+		codec:        tcp.JSONCodec{},
+	}
+
+	t.Run("matching capability is accepted", func(t *testing.T) {
+		declined, err := client.declineIfIncapable(tcp.ActivateMessage{Type: "ACTIVATE", RequiredCapability: "coding"})
+		assert.NoError(t, err)
+		assert.False(t, declined)
+	})
+
+	t.Run("missing capability yields back to people", func(t *testing.T) {
+		received := make(chan tcp.YieldMessage, 1)
+		go func() {
+			var yieldMsg tcp.YieldMessage
+			_ = json.NewDecoder(serverConn).Decode(&yieldMsg)
+			received <- yieldMsg
+		}()
+
+		declined, err := client.declineIfIncapable(tcp.ActivateMessage{Type: "ACTIVATE", RequiredCapability: "security-audit"})
+		require.NoError(t, err)
+		assert.True(t, declined)
+
+		yieldMsg := <-received
+		assert.Equal(t, "YIELD", yieldMsg.Type)
+		assert.Equal(t, "developer", yieldMsg.FromRole)
+		assert.Equal(t, "people", yieldMsg.ToRole)
+		assert.Contains(t, yieldMsg.Payload, "Cannot perform")
+		assert.Contains(t, yieldMsg.Payload, "security-audit")
+	})
+}
+
+func TestControlSocketYield(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	socket := filepath.Join(t.TempDir(), "developer.sock")
+	client := &AgentClient{
+		role:          "developer",
+		yieldTo:       "tester",
+		yieldMsg:      "Code ready",
+		conn:          clientConn,
+		codec:         tcp.JSONCodec{},
+		controlSocket: socket,
+	}
+	listener, err := client.listenControl(socket)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	t.Run("rejected while not holding the barrel", func(t *testing.T) {
+		response, err := sendControlRequest(context.Background(), socket, ControlRequest{Type: controlTypeYield})
+		require.Error(t, err)
+		assert.Equal(t, controlStatusError, response.Status)
+		assert.Contains(t, response.Message, "does not hold the barrel")
+	})
+
+	t.Run("unknown request type", func(t *testing.T) {
+		response, err := sendControlRequest(context.Background(), socket, ControlRequest{Type: "EXPLODE"})
+		require.Error(t, err)
+		assert.Contains(t, response.Message, "unknown request type")
+	})
+
+	t.Run("yields the held barrel", func(t *testing.T) {
+		client.holdForControl()
+
+		received := make(chan tcp.YieldMessage, 1)
+		go func() {
+			var yieldMsg tcp.YieldMessage
+			_ = json.NewDecoder(serverConn).Decode(&yieldMsg)
+			received <- yieldMsg
+			assert.NoError(t, client.handleMessage(`{"type":"ACK_YIELD","to_role":"tester"}`))
+		}()
+
+		response, err := sendControlRequest(context.Background(), socket, ControlRequest{Type: controlTypeYield, Payload: "All done", Failed: true})
+		require.NoError(t, err)
+		assert.Equal(t, controlStatusOK, response.Status)
+		assert.Equal(t, "tester", response.ToRole)
+
+		yieldMsg := <-received
+		assert.Equal(t, "YIELD", yieldMsg.Type)
+		assert.Equal(t, "developer", yieldMsg.FromRole)
+		assert.Equal(t, "tester", yieldMsg.ToRole)
+		assert.Equal(t, "All done", yieldMsg.Payload)
+		assert.True(t, yieldMsg.Failed)
+
+		// The barrel is gone, so a second yield is refused
+		_, err = sendControlRequest(context.Background(), socket, ControlRequest{Type: controlTypeYield})
+		assert.Error(t, err)
+	})
+
+	t.Run("answers to other requests are not the yield's", func(t *testing.T) {
+		client.registered = true
+		client.holdForControl()
+
+		go func() {
+			var yieldMsg tcp.TCPMessage
+			_ = json.NewDecoder(serverConn).Decode(&yieldMsg)
+			// An error answering some other request arrives first
+			assert.NoError(t, client.handleMessage(`{"type":"ERROR","id":"other","message":"unrelated"}`))
+			assert.NoError(t, client.handleMessage(`{"type":"ACK_YIELD","id":"`+yieldMsg.ID+`","to_role":"tester"}`))
+		}()
+
+		response, err := sendControlRequest(context.Background(), socket, ControlRequest{Type: controlTypeYield})
+		require.NoError(t, err)
+		assert.Equal(t, controlStatusOK, response.Status)
+	})
+
+	t.Run("server refusal keeps the barrel", func(t *testing.T) {
+		client.registered = true
+		client.holdForControl()
+
+		go func() {
+			var yieldMsg tcp.YieldMessage
+			_ = json.NewDecoder(serverConn).Decode(&yieldMsg)
+			assert.NoError(t, client.handleMessage(`{"type":"ERROR","message":"target role not found: ghost"}`))
+		}()
+
+		response, err := sendControlRequest(context.Background(), socket, ControlRequest{Type: controlTypeYield, ToRole: "ghost"})
+		require.Error(t, err)
+		assert.Contains(t, response.Message, "target role not found: ghost")
+
+		client.stateMu.Lock()
+		assert.True(t, client.holding)
+		client.stateMu.Unlock()
+	})
+
+	t.Run("times out without confirmation", func(t *testing.T) {
+		client.holdForControl()
+		go func() {
+			var yieldMsg tcp.YieldMessage
+			_ = json.NewDecoder(serverConn).Decode(&yieldMsg)
+		}()
+
+		start := time.Now()
+		response, err := sendControlRequest(context.Background(), socket, ControlRequest{Type: controlTypeYield, TimeoutSeconds: 1})
+		require.Error(t, err)
+		assert.Contains(t, response.Message, "timed out after 1s")
+		assert.Less(t, time.Since(start), 3*time.Second)
+
+		// A late confirmation is taken, and the barrel stays yielded
+		assert.True(t, client.confirmYield("", nil))
+		client.stateMu.Lock()
+		assert.False(t, client.holding)
+		client.stateMu.Unlock()
+	})
+
+	t.Run("late refusal keeps the barrel", func(t *testing.T) {
+		client.registered = true
+		client.holdForControl()
+		yieldID := make(chan string, 1)
+		go func() {
+			var yieldMsg tcp.TCPMessage
+			_ = json.NewDecoder(serverConn).Decode(&yieldMsg)
+			yieldID <- yieldMsg.ID
+		}()
+
+		response, err := sendControlRequest(context.Background(), socket, ControlRequest{Type: controlTypeYield, ToRole: "ghost", TimeoutSeconds: 1})
+		require.Error(t, err)
+		assert.Contains(t, response.Message, "timed out after 1s")
+
+		// The refusal arrives after the control request gave up waiting
+		assert.NoError(t, client.handleMessage(`{"type":"ERROR","id":"`+<-yieldID+`","message":"target role not found: ghost"}`))
+		client.stateMu.Lock()
+		assert.True(t, client.holding)
+		client.stateMu.Unlock()
+	})
+
+	t.Run("cancelled context stops waiting", func(t *testing.T) {
+		client.holdForControl()
+		go func() {
+			var yieldMsg tcp.YieldMessage
+			_ = json.NewDecoder(serverConn).Decode(&yieldMsg)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := sendControlRequest(ctx, socket, ControlRequest{Type: controlTypeYield})
+		assert.ErrorContains(t, err, "timed out waiting for the agent to yield")
+	})
+
+	t.Run("socket in use is not replaced", func(t *testing.T) {
+		_, err := client.listenControl(socket)
+		assert.ErrorContains(t, err, "already in use")
+	})
+}
+
+func TestHasCapability_Patterns(t *testing.T) {
+	client := &AgentClient{capabilities: []string{"test/unit", "coding"}}
+
+	assert.True(t, client.hasCapability("coding"))
+	assert.True(t, client.hasCapability("test/*"))
+	assert.False(t, client.hasCapability("test"))
+	assert.False(t, client.hasCapability("deploy/*"))
+}
+
+// fakeServer accepts connections, answers every REGISTER with reply (if any) and hangs up
+func fakeServer(t *testing.T, reply interface{}) (string, *int32) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	var connections int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&connections, 1)
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				if scanner.Scan() && reply != nil {
+					_ = json.NewEncoder(conn).Encode(reply)
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), &connections
+}
+
+func newRetryingClient(serverAddr string, maxRetries int) *AgentClient {
+	return &AgentClient{
+		role:       "developer",
+		serverAddr: serverAddr,
+		codecName:  tcp.CodecJSON,
+		done:       make(chan bool),
+		maxRetries: maxRetries,
+	}
+}
+
+func TestRun_GivesUpAfterMaxRetries(t *testing.T) {
+	// Nothing listens on a closed listener's address, so every attempt fails transiently
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	err = newRetryingClient(addr, 2).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "giving up after 2 retries")
+	assert.False(t, errors.Is(err, errRegistrationRejected))
+}
+
+func TestRun_RetriesWhenServerClosesConnection(t *testing.T) {
+	// A server that hangs up before acknowledging is a transient failure; it is retried
+	addr, connections := fakeServer(t, nil)
+
+	err := newRetryingClient(addr, 1).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "giving up after 1 retries")
+	assert.GreaterOrEqual(t, atomic.LoadInt32(connections), int32(2))
+}
+
+func TestRun_ExitsImmediatelyOnRejectedRegistration(t *testing.T) {
+	t.Run("error before acknowledgment", func(t *testing.T) {
+		addr, connections := fakeServer(t, tcp.ErrorMessage{Type: "ERROR", Message: "Role 'people' is reserved and cannot be registered"})
+
+		err := newRetryingClient(addr, 5).Run()
+		require.ErrorIs(t, err, errRegistrationRejected)
+		assert.Contains(t, err.Error(), "reserved")
+		assert.Equal(t, int32(1), atomic.LoadInt32(connections))
+	})
+
+	t.Run("non-success acknowledgment", func(t *testing.T) {
+		addr, connections := fakeServer(t, tcp.AckRegisterMessage{Type: "ACK_REGISTER", Status: "denied", Message: "role not allowed"})
+
+		err := newRetryingClient(addr, 0).Run()
+		require.ErrorIs(t, err, errRegistrationRejected)
+		assert.Contains(t, err.Error(), "role not allowed")
+		assert.Equal(t, int32(1), atomic.LoadInt32(connections))
+	})
+}
+
+// pipeServer plays the server side of one piped connection
+// Messages are read whole lines at a time; a pipe write only returns once every byte is read
+type pipeServer struct {
+	t       *testing.T
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+// pipeDialer hands out one piped connection per dial, failing the dials listed in failures
+func pipeDialer(t *testing.T, failures int, serve func(server *pipeServer)) (dialer, *int32) {
+	var dials int32
+	return dialFunc(func(addr string, timeout time.Duration) (net.Conn, error) {
+		if atomic.AddInt32(&dials, 1) <= int32(failures) {
+			return nil, errors.New("connection refused")
+		}
+		clientConn, serverConn := net.Pipe()
+		go func() {
+			defer serverConn.Close()
+			serve(&pipeServer{t: t, conn: serverConn, scanner: bufio.NewScanner(serverConn)})
+		}()
+		return clientConn, nil
+	}), &dials
+}
+
+// expect reads the next client message and checks its type
+func (s *pipeServer) expect(messageType string, message interface{}) {
+	require.True(s.t, s.scanner.Scan(), "client closed the connection before sending %s", messageType)
+	raw := s.scanner.Bytes()
+	var base tcp.TCPMessage
+	require.NoError(s.t, json.Unmarshal(raw, &base))
+	require.Equal(s.t, messageType, base.Type)
+	if message != nil {
+		require.NoError(s.t, json.Unmarshal(raw, message))
+	}
+}
+
+func (s *pipeServer) send(message interface{}) {
+	require.NoError(s.t, json.NewEncoder(s.conn).Encode(message))
+}
+
+// register accepts the client's registration
+func (s *pipeServer) register() {
+	s.expect("REGISTER", nil)
+	s.send(tcp.AckRegisterMessage{Type: "ACK_REGISTER", Status: "success", Message: "welcome"})
+}
+
+func newPipedClient(d dialer) *AgentClient {
+	return &AgentClient{
+		role:         "developer",
+		capabilities: []string{"coding"},
+		serverAddr:   "soviet:53646",
+		codecName:    tcp.CodecJSON,
+		done:         make(chan bool),
+		maxRetries:   1, // A broken script ends the test instead of reconnecting forever
+		dialer:       d,
+	}
+}
+
+func TestRun_ActivationCompletesTask(t *testing.T) {
+	d, dials := pipeDialer(t, 0, func(server *pipeServer) {
+		var register tcp.RegisterMessage
+		server.expect("REGISTER", &register)
+		assert.Equal(t, "developer", register.Role)
+		assert.Equal(t, []string{"coding"}, register.Capabilities)
+		server.send(tcp.AckRegisterMessage{Type: "ACK_REGISTER", Status: "success"})
+
+		server.send(tcp.ActivateMessage{Type: "ACTIVATE", Payload: "Fix the bug"})
+		var ack tcp.ActivateAckMessage
+		server.expect("ACTIVATE_ACK", &ack)
+		assert.Equal(t, "developer", ack.Role)
+	})
+
+	client := newPipedClient(d)
+	require.NoError(t, client.Run())
+	assert.True(t, client.registered)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+}
+
+func TestRun_AutoYieldsAndWaitsForReturn(t *testing.T) {
+	d, _ := pipeDialer(t, 0, func(server *pipeServer) {
+		server.register()
+
+		server.send(tcp.ActivateMessage{Type: "ACTIVATE", Payload: "Write the code"})
+		server.expect("ACTIVATE_ACK", nil)
+		var yieldMsg tcp.YieldMessage
+		server.expect("YIELD", &yieldMsg)
+		assert.Equal(t, "developer", yieldMsg.FromRole)
+		assert.Equal(t, "tester", yieldMsg.ToRole)
+		assert.Equal(t, "Code ready", yieldMsg.Payload)
+		assert.NotEmpty(t, yieldMsg.SentAt)
+
+		// The barrel comes back; the agent has already yielded once, so it completes
+		server.send(tcp.ActivateMessage{Type: "ACTIVATE", Payload: "Tests pass"})
+		server.expect("ACTIVATE_ACK", nil)
+	})
+
+	client := newPipedClient(d)
+	client.yieldTo = "tester"
+	client.yieldMsg = "Code ready"
+	require.NoError(t, client.Run())
+	assert.True(t, client.hasYielded)
+}
+
+func TestRun_ReconnectsAfterErrors(t *testing.T) {
+	var sessions int32
+	d, dials := pipeDialer(t, 1, func(server *pipeServer) {
+		server.register()
+		if atomic.AddInt32(&sessions, 1) == 1 {
+			// Drop the first registered connection
+			return
+		}
+		server.send(tcp.ActivateMessage{Type: "ACTIVATE"})
+		server.expect("ACTIVATE_ACK", nil)
+	})
+
+	// One failed dial and one dropped connection stay within the retry budget
+	client := newPipedClient(d)
+	require.NoError(t, client.Run())
+	assert.Equal(t, int32(3), atomic.LoadInt32(dials))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&sessions))
+}
+
+func TestRun_ExitsOnShutdownWithoutReconnect(t *testing.T) {
+	d, dials := pipeDialer(t, 0, func(server *pipeServer) {
+		server.register()
+		server.send(tcp.ShutdownMessage{
+			Type:    "SHUTDOWN",
+			Reason:  tcp.ShutdownReasonStop,
+			Message: "Server is shutting down",
+		})
+		// Keep the connection open: the agent must leave on its own
+		server.scanner.Scan()
+	})
+
+	client := newPipedClient(d)
+	client.maxRetries = 3
+	require.NoError(t, client.Run())
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+}
+
+func TestRun_SendsHeartbeats(t *testing.T) {
+	d, _ := pipeDialer(t, 0, func(server *pipeServer) {
+		server.register()
+		server.expect("PING", nil)
+		server.send(tcp.PongMessage{Type: "PONG", ServerTime: time.Now().UTC().Format(time.RFC3339)})
+		server.expect("PING", nil)
+		server.send(tcp.ShutdownMessage{Type: "SHUTDOWN", Reason: tcp.ShutdownReasonStop})
+		// Keep reading so late pings do not block; the agent closes the connection on its own
+		for server.scanner.Scan() {
+		}
+	})
+
+	client := newPipedClient(d)
+	client.heartbeatPeriod = 10 * time.Millisecond
+	require.NoError(t, client.Run())
+}
+
+func TestDeregisterEndsAgent(t *testing.T) {
+	registered := make(chan struct{})
+	d, dials := pipeDialer(t, 0, func(server *pipeServer) {
+		server.register()
+		close(registered)
+		var deregister tcp.DeregisterMessage
+		server.expect("DEREGISTER", &deregister)
+		assert.Equal(t, "developer", deregister.Role)
+		server.send(tcp.AckDeregisterMessage{Type: "ACK_DEREGISTER", Role: "developer", Message: "Comrade 'developer' has left the collective."})
+		// Keep the connection open: the agent must leave on its own
+		server.scanner.Scan()
+	})
+
+	client := newPipedClient(d)
+	client.maxRetries = 3
+	go func() {
+		// The signal handler deregisters the same way
+		<-registered
+		client.deregister()
+	}()
+	require.NoError(t, client.Run())
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+}
+
+func TestRun_ReconnectsOnAdvisedShutdown(t *testing.T) {
+	var sessions int32
+	d, dials := pipeDialer(t, 0, func(server *pipeServer) {
+		server.register()
+		if atomic.AddInt32(&sessions, 1) == 1 {
+			server.send(tcp.ShutdownMessage{
+				Type:             "SHUTDOWN",
+				Reason:           tcp.ShutdownReasonDrain,
+				Message:          "Server is restarting; reconnect once it is back",
+				ReconnectAdvised: true,
+			})
+			return
+		}
+		server.send(tcp.ActivateMessage{Type: "ACTIVATE"})
+		server.expect("ACTIVATE_ACK", nil)
+	})
+
+	client := newPipedClient(d)
+	require.NoError(t, client.Run())
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+}
+
+// fakeRunner records the payloads it runs and answers with a fixed result
+func fakeRunner(result execResult, err error, payloads *[]string) commandRunner {
+	return runnerFunc(func(ctx context.Context, command, payload string) (execResult, error) {
+		*payloads = append(*payloads, payload)
+		return result, err
+	})
+}
+
+// runExecSession activates an --exec agent once and returns the yield it answers with
+func runExecSession(t *testing.T, client *AgentClient) tcp.YieldMessage {
+	var yieldMsg tcp.YieldMessage
+	d, _ := pipeDialer(t, 0, func(server *pipeServer) {
+		server.register()
+		server.send(tcp.ActivateMessage{Type: "ACTIVATE", FromRole: "people", Payload: "Run the tests"})
+		server.expect("ACTIVATE_ACK", nil)
+		server.expect("YIELD", &yieldMsg)
+		// Exec agents keep serving activations, so end the session from the server side
+		server.send(tcp.ShutdownMessage{Type: "SHUTDOWN", Reason: tcp.ShutdownReasonStop})
+	})
+	client.dialer = d
+	require.NoError(t, client.Run())
+	return yieldMsg
+}
+
+func TestRun_ExecYieldsOutputOnSuccess(t *testing.T) {
+	var payloads []string
+	client := newPipedClient(nil)
+	client.execCommand = "make test"
+	client.yieldTo = "reviewer"
+	client.onFailure = onFailurePeople
+	client.runner = fakeRunner(execResult{Stdout: "42 tests passed\n"}, nil, &payloads)
+
+	yieldMsg := runExecSession(t, client)
+	assert.Equal(t, []string{"Run the tests"}, payloads)
+	assert.Equal(t, "reviewer", yieldMsg.ToRole)
+	assert.Equal(t, "42 tests passed", yieldMsg.Payload)
+	assert.False(t, yieldMsg.Failed)
+}
+
+func TestRun_ExecEscalatesFailure(t *testing.T) {
+	failing := execResult{Stdout: "ran 42 tests", Stderr: "FAIL: TestLogin\n", ExitCode: 2}
+
+	t.Run("to the people by default", func(t *testing.T) {
+		var payloads []string
+		client := newPipedClient(nil)
+		client.execCommand = "make test"
+		client.yieldTo = "reviewer"
+		client.runner = fakeRunner(failing, nil, &payloads)
+
+		yieldMsg := runExecSession(t, client)
+		assert.Equal(t, "people", yieldMsg.ToRole)
+		assert.True(t, yieldMsg.Failed)
+		assert.Contains(t, yieldMsg.Payload, "exit code 2")
+		assert.Contains(t, yieldMsg.Payload, "FAIL: TestLogin")
+	})
+
+	t.Run("to a named role", func(t *testing.T) {
+		var payloads []string
+		client := newPipedClient(nil)
+		client.execCommand = "make test"
+		client.yieldTo = "reviewer"
+		client.onFailure = "developer-lead"
+		client.runner = fakeRunner(execResult{}, errors.New("sh: not found"), &payloads)
+
+		yieldMsg := runExecSession(t, client)
+		assert.Equal(t, "developer-lead", yieldMsg.ToRole)
+		assert.True(t, yieldMsg.Failed)
+		assert.Contains(t, yieldMsg.Payload, "sh: not found")
+	})
+
+	t.Run("kept by the agent", func(t *testing.T) {
+		var payloads []string
+		d, _ := pipeDialer(t, 0, func(server *pipeServer) {
+			server.register()
+			server.send(tcp.ActivateMessage{Type: "ACTIVATE", Payload: "Run the tests"})
+			server.expect("ACTIVATE_ACK", nil)
+			// Nothing is yielded; the next message the server sees would be a YIELD
+			server.send(tcp.ShutdownMessage{Type: "SHUTDOWN", Reason: tcp.ShutdownReasonStop})
+			assert.False(t, server.scanner.Scan(), "a kept barrel must not be yielded")
+		})
+		client := newPipedClient(d)
+		client.execCommand = "make test"
+		client.onFailure = onFailureKeep
+		client.runner = fakeRunner(failing, nil, &payloads)
+
+		require.NoError(t, client.Run())
+		assert.Len(t, payloads, 1)
+	})
+}
+
+func TestRun_HaltStopsExecUntilResume(t *testing.T) {
+	var runs int32
+	stopped := make(chan struct{})
+	runner := runnerFunc(func(ctx context.Context, command, payload string) (execResult, error) {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			// The first run only ends when the halt stops it
+			<-ctx.Done()
+			close(stopped)
+			return execResult{}, ctx.Err()
+		}
+		return execResult{Stdout: "deployed"}, nil
+	})
+
+	var yieldMsg tcp.YieldMessage
+	d, _ := pipeDialer(t, 0, func(server *pipeServer) {
+		server.register()
+		server.send(tcp.ActivateMessage{Type: "ACTIVATE", Payload: "Deploy"})
+		server.expect("ACTIVATE_ACK", nil)
+
+		server.send(tcp.HaltNoticeMessage{Type: "HALT_NOTICE", Halted: true, Reason: "Bad deploy"})
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Error("the halt did not stop the running command")
+			return
+		}
+
+		server.send(tcp.HaltNoticeMessage{Type: "HALT_NOTICE", Halted: false})
+		server.expect("YIELD", &yieldMsg)
+		server.send(tcp.ShutdownMessage{Type: "SHUTDOWN", Reason: tcp.ShutdownReasonStop})
+	})
+	client := newPipedClient(d)
+	client.execCommand = "make deploy"
+	client.runner = runner
+
+	require.NoError(t, client.Run())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs), "the stopped command runs again on resume")
+	assert.Equal(t, "people", yieldMsg.ToRole)
+	assert.Equal(t, "deployed", yieldMsg.Payload)
+	assert.False(t, yieldMsg.Failed)
+}
+
+func TestValidateOnFailure(t *testing.T) {
+	assert.NoError(t, validateOnFailure(onFailurePeople, "tester"))
+	assert.NoError(t, validateOnFailure(onFailureKeep, "tester"))
+	assert.NoError(t, validateOnFailure("developer", "tester"))
+	assert.Error(t, validateOnFailure(" ", "tester"))
+	assert.Error(t, validateOnFailure("tester", "tester"))
+}
+
+func TestTailOutput(t *testing.T) {
+	assert.Equal(t, "boom", tailOutput("  boom\n"))
+	long := tailOutput(strings.Repeat("x", maxFailureOutput) + "the end")
+	assert.True(t, strings.HasSuffix(long, "the end"))
+	assert.Len(t, long, maxFailureOutput+len("..."))
+}
@@ -2,15 +2,20 @@ package main
 
 import (
 	"bufio"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
 )
 
 const (
@@ -22,109 +27,1209 @@ const (
 type PeopleClient struct {
 	serverAddr string
 	conn       net.Conn
+
+	// operator names the representative in the barrel history, e.g. "alice" yields as "people:alice"
+	operator string
+
+	// framing is the framing of JSON messages, which must match the server's
+	framing string
+}
+
+func main() {
+	var (
+		serverAddr = flag.String("server", defaultServerAddr, "Soviet server address")
+		help       = flag.Bool("help", false, "Show help")
+		version    = flag.Bool("version", false, "Show version")
+		operator   = flag.String("as", os.Getenv("AGENTFARM_OPERATOR"), "Operator name recorded with your yields, e.g. alice yields as people:alice")
+		framing    = flag.String("framing", tcp.FramingNewline, "Framing of JSON messages, as configured on the server (newline, length)")
+	)
+	flag.Parse()
+
+	if *help {
+		showHelp()
+		return
+	}
+
+	if *version {
+		showVersion()
+		return
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: Command is required\n")
+		showHelp()
+		os.Exit(1)
+	}
+
+	if err := tcp.ValidateFraming(*framing); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := &PeopleClient{
+		serverAddr: *serverAddr,
+		operator:   strings.TrimSpace(*operator),
+		framing:    *framing,
+	}
+
+	if err := client.ExecuteCommand(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func (pc *PeopleClient) ExecuteCommand(args []string) error {
+	command := args[0]
+
+	switch command {
+	case "yield":
+		return pc.executeYield(args[1:])
+	case "run":
+		return pc.executeRun(args[1:])
+	case "status":
+		return pc.executeStatus()
+	case "workers":
+		return pc.executeWorkers()
+	case "watch":
+		return pc.executeWatch()
+	case "query-agents":
+		return pc.executeQueryAgents(args[1:])
+	case "pipeline":
+		return pc.executePipeline()
+	case "groups":
+		return pc.executeGroups()
+	case "ping":
+		return pc.executePing(args[1:])
+	case "validate-yield":
+		return pc.executeValidateYield(args[1:])
+	case "staleness":
+		return pc.executeStaleness(args[1:])
+	case "context":
+		return pc.executeContext()
+	case "history":
+		return pc.executeHistory(args[1:])
+	case "needed":
+		return pc.executeNeeded()
+	case "connections":
+		return pc.executeConnections()
+	case "can":
+		return pc.executeCan(args[1:])
+	case "reassign":
+		return pc.executeReassign(args[1:])
+	case "set-state":
+		return pc.executeSetState(args[1:])
+	case "set-ttl":
+		return pc.executeSetTTL(args[1:])
+	case "get-ttl":
+		return pc.executeGetTTL()
+	case "drain":
+		return pc.executeDrain()
+	case "drain-status":
+		return pc.executeDrainStatus()
+	case "halt":
+		return pc.executeHalt(args[1:])
+	case "resume":
+		return pc.executeResume()
+	case "mock-agent":
+		return pc.executeMockAgent(args[1:])
+	default:
+		return fmt.Errorf("unknown command: %s", command)
+	}
+}
+
+// identity returns the role yields are sent as: "people", or "people:<operator>" to tell operators apart
+func (pc *PeopleClient) identity() string {
+	if pc.operator == "" {
+		return "people"
+	}
+	return "people:" + pc.operator
+}
+
+func (pc *PeopleClient) executeYield(args []string) error {
+	yieldFlags := flag.NewFlagSet("yield", flag.ContinueOnError)
+	requiredCapability := yieldFlags.String("require", "", "Capability (or pattern such as test/*) the receiving agent must have, or the yield is refused")
+	expected := yieldFlags.Duration("expect", 0, "How long the receiving agent should need the barrel; staleness flags holders that overrun it")
+	meta := yieldFlags.String("meta", "", "Structured metadata delivered with the message, as key=value pairs separated by commas")
+	if err := yieldFlags.Parse(args); err != nil {
+		return err
+	}
+	args = yieldFlags.Args()
+
+	if len(args) < 2 {
+		return fmt.Errorf("yield command requires: yield [--require <capability>] [--expect <duration>] [--meta <key=value,...>] <to_role> \"<message>\"")
+	}
+	if *expected < 0 {
+		return fmt.Errorf("expected duration cannot be negative: %s", *expected)
+	}
+	metadata, err := domain.ParseMetadata(*meta)
+	if err != nil {
+		return err
+	}
+
+	toRole := args[0]
+	message := strings.Join(args[1:], " ")
+	
+	// Remove quotes if present
+	message = strings.Trim(message, `"'`)
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	yieldMsg := tcp.YieldMessage{
+		Type:     "YIELD",
+		FromRole: pc.identity(),
+		ToRole:   toRole,
+		Payload:  message,
+		SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
+		Nonce:    nonce,
+
+		RequiredCapability: *requiredCapability,
+		Metadata:           metadata,
+	}
+	if *expected > 0 {
+		yieldMsg.ExpectedDuration = expected.String()
+	}
+
+	if err := pc.sendMessage(yieldMsg); err != nil {
+		return fmt.Errorf("failed to send yield command: %w", err)
+	}
+
+	ack, err := pc.readYieldResponse()
+	if err != nil {
+		return err
+	}
+
+	// Group and capability targets are resolved by the server to a concrete role
+	if ack != nil && ack.ToRole != "" {
+		toRole = ack.ToRole
+	}
+	fmt.Printf("✅ The People have yielded the barrel to comrade %s\n", toRole)
+	if message != "" {
+		fmt.Printf("📜 Message: %s\n", message)
+	}
+	if ack != nil && ack.Receipt != nil {
+		fmt.Printf("🧾 Receipt #%d: %s\n", ack.Receipt.Sequence, ack.Receipt.Hash)
+	}
+
+	return nil
+}
+
+// readYieldResponse waits briefly for the yield acknowledgment
+// Returns nil without error when the server does not acknowledge yields
+func (pc *PeopleClient) readYieldResponse() (*tcp.YieldAckMessage, error) {
+	if err := pc.conn.SetReadDeadline(time.Now().Add(connectionTimeout)); err != nil {
+		return nil, nil
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return nil, nil
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	var baseMsg tcp.TCPMessage
+	if err := json.Unmarshal([]byte(line), &baseMsg); err != nil {
+		return nil, nil
+	}
+
+	switch baseMsg.Type {
+	case "ERROR":
+		var errorMsg tcp.ErrorMessage
+		if err := json.Unmarshal([]byte(line), &errorMsg); err == nil {
+			return nil, fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+	case "ACK_YIELD":
+		var ackMsg tcp.YieldAckMessage
+		if err := json.Unmarshal([]byte(line), &ackMsg); err == nil {
+			return &ackMsg, nil
+		}
+	}
+	return nil, nil
+}
+
+func (pc *PeopleClient) executeStatus() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryMessage{
+		Type: "QUERY_STATUS",
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send status query: %w", err)
+	}
+
+	// Read the response
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	return pc.handleStatusResponse(line)
+}
+
+// executeWatch subscribes to status updates and prints the status every time the server pushes one,
+// until interrupted or the server closes the connection
+func (pc *PeopleClient) executeWatch() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	if err := pc.sendMessage(tcp.QueryMessage{Type: "SUBSCRIBE"}); err != nil {
+		return fmt.Errorf("failed to subscribe to status updates: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	for {
+		frame, err := readFrame(scanner)
+		if err == io.EOF {
+			return fmt.Errorf("server closed the connection")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read status update: %w", err)
+		}
+
+		var base tcp.TCPMessage
+		if err := json.Unmarshal(frame, &base); err != nil {
+			return fmt.Errorf("failed to parse status update: %w", err)
+		}
+		switch base.Type {
+		case "STATUS":
+			fmt.Printf("\n🔄 %s\n", time.Now().Format("15:04:05"))
+			if err := pc.handleStatusResponse(string(frame)); err != nil {
+				return err
+			}
+		case "ERROR":
+			var errorMsg tcp.ErrorMessage
+			if err := json.Unmarshal(frame, &errorMsg); err != nil {
+				return fmt.Errorf("failed to parse error response: %w", err)
+			}
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+	}
+}
+
+func (pc *PeopleClient) executeWorkers() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryMessage{
+		Type: "QUERY_STATUS",
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send status query: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	var statusMsg tcp.StatusMessage
+	if err := json.Unmarshal([]byte(line), &statusMsg); err != nil || statusMsg.Type != "STATUS" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse status response")
+	}
+
+	displayWorkers(statusMsg)
+	return nil
+}
+
+// displayWorkers prints a compact two-column view of the working holder and the waiting roles
+func displayWorkers(statusMsg tcp.StatusMessage) {
+	const columnWidth = 28
+
+	working := []string{"(none - barrel with people)"}
+	if statusMsg.BarrelHolder != "people" {
+		working = []string{fmt.Sprintf("%s [%s]", statusMsg.BarrelHolder, statusMsg.AgentStates[statusMsg.BarrelHolder])}
+	}
+
+	var waiting []string
+	for _, role := range statusMsg.RegisteredAgents {
+		if role == statusMsg.BarrelHolder || statusMsg.AgentStates[role] != "waiting" {
+			continue
+		}
+		if statusMsg.ConnectedAgents[role] {
+			waiting = append(waiting, role)
+		} else {
+			waiting = append(waiting, role+" (offline)")
+		}
+	}
+	sort.Strings(waiting)
+	if len(waiting) == 0 {
+		waiting = []string{"(none)"}
+	}
+
+	// The emoji renders two cells wide but pads as one rune
+	fmt.Printf("%-*s %s\n", columnWidth-1, "🔥 WORKING", "⏳ WAITING")
+	rows := len(working)
+	if len(waiting) > rows {
+		rows = len(waiting)
+	}
+	for i := 0; i < rows; i++ {
+		left, right := "", ""
+		if i < len(working) {
+			left = working[i]
+		}
+		if i < len(waiting) {
+			right = waiting[i]
+		}
+		fmt.Printf("%-*s %s\n", columnWidth, left, right)
+	}
+}
+
+func (pc *PeopleClient) executeQueryAgents(args []string) error {
+	queryFlags := flag.NewFlagSet("query-agents", flag.ContinueOnError)
+	tag := queryFlags.String("tag", "", "Only list agents tagged key=value, or carrying key with any value")
+	if err := queryFlags.Parse(args); err != nil {
+		return err
+	}
+	if queryFlags.NArg() > 0 {
+		return fmt.Errorf("query-agents command requires: query-agents [--tag key[=value]]")
+	}
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryAgentsMessage{
+		Type: "QUERY_AGENTS",
+		Tag:  strings.TrimSpace(*tag),
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send agent query: %w", err)
+	}
+
+	// Read the response
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	return pc.handleAgentListResponse(line)
+}
+
+func (pc *PeopleClient) executePipeline() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryMessage{
+		Type: "QUERY_PIPELINE",
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send pipeline query: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	var pipelineMsg tcp.PipelineMessage
+	if err := json.Unmarshal([]byte(line), &pipelineMsg); err != nil || pipelineMsg.Type != "PIPELINE" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse pipeline response")
+	}
+
+	return pc.displayPipeline(pipelineMsg)
+}
+
+func (pc *PeopleClient) executeGroups() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryMessage{
+		Type: "QUERY_GROUPS",
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send groups query: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	var groupsMsg tcp.GroupsMessage
+	if err := json.Unmarshal([]byte(line), &groupsMsg); err != nil || groupsMsg.Type != "GROUPS" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse groups response")
+	}
+
+	return pc.displayGroups(groupsMsg)
+}
+
+func (pc *PeopleClient) executePing(args []string) error {
+	pingFlags := flag.NewFlagSet("ping", flag.ContinueOnError)
+	count := pingFlags.Int("count", 1, "Number of pings to send")
+	interval := pingFlags.Duration("interval", time.Second, "Wait between pings")
+	timeout := pingFlags.Duration("timeout", 5*time.Second, "Give up when a reply takes longer than this")
+	if err := pingFlags.Parse(args); err != nil {
+		return err
+	}
+	if *count < 1 {
+		return fmt.Errorf("ping count must be at least 1, got %d", *count)
+	}
+	if *timeout <= 0 {
+		return fmt.Errorf("ping timeout must be positive, got %s", *timeout)
+	}
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	scanner := pc.newScanner()
+	latencies := make([]time.Duration, 0, *count)
+	for seq := 1; seq <= *count; seq++ {
+		if seq > 1 {
+			time.Sleep(*interval)
+		}
+
+		start := time.Now()
+		if err := pc.conn.SetDeadline(start.Add(*timeout)); err != nil {
+			return fmt.Errorf("failed to set ping timeout: %w", err)
+		}
+		if err := pc.sendMessage(tcp.PingMessage{Type: "PING", Seq: seq}); err != nil {
+			return fmt.Errorf("failed to send ping: %w", err)
+		}
+		if !scanner.Scan() {
+			return fmt.Errorf("no reply to ping seq=%d within %s", seq, *timeout)
+		}
+		latency := time.Since(start)
+
+		var pongMsg tcp.PongMessage
+		if err := json.Unmarshal(scanner.Bytes(), &pongMsg); err != nil || pongMsg.Type != "PONG" {
+			var errorMsg tcp.ErrorMessage
+			if errParse := json.Unmarshal(scanner.Bytes(), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+				return fmt.Errorf("server error: %s", errorMsg.Message)
+			}
+			return fmt.Errorf("failed to parse ping response")
+		}
+		if pongMsg.Seq != seq {
+			return fmt.Errorf("ping reply out of order: expected seq=%d, got seq=%d", seq, pongMsg.Seq)
+		}
+
+		latencies = append(latencies, latency)
+		fmt.Printf("🏓 Pong from %s: seq=%d time=%s version=%s\n", pc.serverAddr, seq, latency.Round(time.Microsecond), pongMsg.Version)
+	}
+
+	minLatency, maxLatency, total := latencies[0], latencies[0], time.Duration(0)
+	for _, latency := range latencies {
+		if latency < minLatency {
+			minLatency = latency
+		}
+		if latency > maxLatency {
+			maxLatency = latency
+		}
+		total += latency
+	}
+	average := total / time.Duration(len(latencies))
+
+	fmt.Printf("\n--- %s ping statistics ---\n", pc.serverAddr)
+	fmt.Printf("%d pings sent, %d replies received\n", *count, len(latencies))
+	fmt.Printf("round-trip min/avg/max = %s/%s/%s\n",
+		minLatency.Round(time.Microsecond), average.Round(time.Microsecond), maxLatency.Round(time.Microsecond))
+	return nil
+}
+
+func (pc *PeopleClient) executeValidateYield(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("validate-yield command requires: validate-yield <to_role> [\"<message>\"]")
+	}
+
+	toRole := args[0]
+	message := strings.Trim(strings.Join(args[1:], " "), `"'`)
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	validateMsg := tcp.YieldMessage{
+		Type:     "VALIDATE_YIELD",
+		FromRole: pc.identity(),
+		ToRole:   toRole,
+		Payload:  message,
+		SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if err := pc.sendMessage(validateMsg); err != nil {
+		return fmt.Errorf("failed to send validate-yield command: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	var resultMsg tcp.ValidationResultMessage
+	if err := json.Unmarshal([]byte(line), &resultMsg); err != nil || resultMsg.Type != "VALIDATION_RESULT" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse validation response")
+	}
+
+	if resultMsg.Valid {
+		fmt.Printf("✅ A yield to %s would be accepted\n", toRole)
+		return nil
+	}
+
+	fmt.Printf("❌ A yield to %s would be rejected:\n", toRole)
+	for _, problem := range resultMsg.Errors {
+		fmt.Printf("  - [%s] %s\n", problem.Code, problem.Message)
+	}
+	return fmt.Errorf("%d validation problem(s)", len(resultMsg.Errors))
+}
+
+func (pc *PeopleClient) executeStaleness(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("staleness command requires: staleness [max_duration]")
+	}
+
+	// An optional threshold turns the command into a check suitable for alerting
+	var threshold time.Duration
+	if len(args) == 1 {
+		var err error
+		threshold, err = time.ParseDuration(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", args[0], err)
+		}
+		if threshold <= 0 {
+			return fmt.Errorf("staleness threshold must be positive: %s", threshold)
+		}
+	}
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryMessage{
+		Type: "QUERY_STALENESS",
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send staleness query: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	var stalenessMsg tcp.StalenessMessage
+	if err := json.Unmarshal([]byte(line), &stalenessMsg); err != nil || stalenessMsg.Type != "STALENESS" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse staleness response")
+	}
+
+	fmt.Printf("🔫 Barrel held by %s for %s", stalenessMsg.BarrelHolder, stalenessMsg.StaleFor)
+	if stalenessMsg.Since != "" {
+		fmt.Printf(" (since %s)", stalenessMsg.Since)
+	}
+	fmt.Println()
+	if stalenessMsg.OverExpected {
+		fmt.Printf("⏰ Over the expected %s\n", stalenessMsg.ExpectedDuration)
+	} else if stalenessMsg.ExpectedDuration != "" {
+		fmt.Printf("⏱️  Expected to take %s\n", stalenessMsg.ExpectedDuration)
+	}
+
+	if threshold > 0 && time.Duration(stalenessMsg.StaleSeconds)*time.Second >= threshold {
+		return fmt.Errorf("barrel hasn't moved in %s (threshold %s)", stalenessMsg.StaleFor, threshold)
+	}
+	return nil
+}
+
+func (pc *PeopleClient) executeContext() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryMessage{
+		Type: "QUERY_CONTEXT",
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send context query: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	var contextMsg tcp.ContextMessage
+	if err := json.Unmarshal([]byte(line), &contextMsg); err != nil || contextMsg.Type != "CONTEXT" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse context response")
+	}
+
+	fmt.Printf("🔫 Barrel held by %s", contextMsg.BarrelHolder)
+	if contextMsg.FromRole != "" {
+		fmt.Printf(", sent by %s", contextMsg.FromRole)
+	}
+	fmt.Println()
+	if contextMsg.ReceivedAt != "" {
+		fmt.Printf("🕐 Received at %s (%s ago)\n", contextMsg.ReceivedAt, contextMsg.HeldFor)
+	}
+	if contextMsg.OverExpected {
+		fmt.Printf("⏰ Over the expected %s\n", contextMsg.ExpectedDuration)
+	} else if contextMsg.ExpectedDuration != "" {
+		fmt.Printf("⏱️  Expected to take %s\n", contextMsg.ExpectedDuration)
+	}
+	for _, key := range domain.SortedMetadataKeys(contextMsg.Metadata) {
+		fmt.Printf("🏷️  %s: %s\n", key, contextMsg.Metadata[key])
+	}
+	if contextMsg.RetryCount > 0 {
+		fmt.Printf("🔁 Retry %d\n", contextMsg.RetryCount)
+	}
+	if contextMsg.Receipt != nil {
+		fmt.Printf("🧾 Receipt #%d %s\n", contextMsg.Receipt.Sequence, contextMsg.Receipt.Hash)
+	}
+	fmt.Printf("📜 Message:\n%s\n", contextMsg.Payload)
+	return nil
+}
+
+func (pc *PeopleClient) executeHistory(args []string) error {
+	historyFlags := flag.NewFlagSet("history", flag.ContinueOnError)
+	limit := historyFlags.Int("limit", 0, "Only show the last N transfers (0 = all)")
+	if err := historyFlags.Parse(args); err != nil {
+		return err
+	}
+	if historyFlags.NArg() > 0 {
+		return fmt.Errorf("history command requires: history [--limit N]")
+	}
+	if *limit < 0 {
+		return fmt.Errorf("limit cannot be negative: %d", *limit)
+	}
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	if err := pc.sendMessage(tcp.QueryHistoryMessage{Type: "QUERY_HISTORY", Limit: *limit}); err != nil {
+		return fmt.Errorf("failed to send history query: %w", err)
+	}
+
+	line, err := readFrame(pc.newScanner())
+	if err != nil {
+		return fmt.Errorf("no response from server: %w", err)
+	}
+
+	var historyMsg tcp.HistoryMessage
+	if err := json.Unmarshal(line, &historyMsg); err != nil || historyMsg.Type != "HISTORY" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal(line, &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse history response")
+	}
+
+	if len(historyMsg.Transfers) == 0 {
+		fmt.Println("📜 No barrel transfers recorded")
+		return nil
+	}
+	fmt.Printf("📜 Barrel history (%d transfers):\n", len(historyMsg.Transfers))
+	for _, transfer := range historyMsg.Transfers {
+		fmt.Printf("  %s → %s: %s (%s)\n", transfer.FromRole, transfer.ToRole, transfer.Message, transfer.Timestamp)
+	}
+	return nil
+}
+
+func (pc *PeopleClient) executeNeeded() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryMessage{
+		Type: "QUERY_ROLES_NEEDED",
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send roles-needed query: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	var neededMsg tcp.RolesNeededMessage
+	if err := json.Unmarshal([]byte(line), &neededMsg); err != nil || neededMsg.Type != "ROLES_NEEDED" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse roles-needed response")
+	}
+
+	if len(neededMsg.Required) == 0 {
+		fmt.Println("📋 No required roles are configured (see the server's -required-roles)")
+		return nil
+	}
+	if len(neededMsg.Missing) == 0 {
+		fmt.Printf("✅ All %d required roles are online: %s\n", len(neededMsg.Required), strings.Join(neededMsg.Required, ", "))
+		return nil
+	}
+
+	fmt.Printf("📋 Required roles still needed (%d of %d):\n", len(neededMsg.Missing), len(neededMsg.Required))
+	for _, need := range neededMsg.Missing {
+		reason := "not registered"
+		if need.Reason == "disconnected" {
+			reason = "registered but disconnected"
+		}
+		fmt.Printf("  ❌ %s (%s)\n", need.Role, reason)
+	}
+	// Fail so bootstrap scripts can wait with: until people needed; do sleep 5; done
+	return fmt.Errorf("%d required role(s) not online", len(neededMsg.Missing))
+}
+
+func (pc *PeopleClient) executeConnections() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	queryMsg := tcp.QueryMessage{
+		Type: "QUERY_CONNECTIONS",
+	}
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send connections query: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	var connectionsMsg tcp.ConnectionsMessage
+	if err := json.Unmarshal([]byte(line), &connectionsMsg); err != nil || connectionsMsg.Type != "CONNECTIONS" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse connections response")
+	}
+
+	return pc.displayConnections(connectionsMsg)
+}
+
+func (pc *PeopleClient) executeCan(args []string) error {
+	queryMsg := tcp.QueryCapabilityMessage{
+		Type: "QUERY_CAPABILITY",
+	}
+	switch len(args) {
+	case 1:
+		queryMsg.Capability = args[0]
+	case 2:
+		queryMsg.Role = args[0]
+		queryMsg.Capability = args[1]
+	default:
+		return fmt.Errorf("can command requires: can [role] <capability>")
+	}
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	if err := pc.sendMessage(queryMsg); err != nil {
+		return fmt.Errorf("failed to send capability query: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return fmt.Errorf("empty response from server")
+	}
+
+	var matchMsg tcp.CapabilityMatchMessage
+	if err := json.Unmarshal([]byte(line), &matchMsg); err != nil || matchMsg.Type != "CAPABILITY_MATCH" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse capability response")
+	}
+
+	return pc.displayCapabilityMatch(matchMsg)
+}
+
+// displayCapabilityMatch answers yes or no, and fails on no so scripts can test before yielding
+func (pc *PeopleClient) displayCapabilityMatch(msg tcp.CapabilityMatchMessage) error {
+	if msg.Role != "" {
+		if !msg.Capable {
+			fmt.Printf("❌ No: %s does not advertise %s\n", msg.Role, msg.Capability)
+			return fmt.Errorf("%s cannot %s", msg.Role, msg.Capability)
+		}
+		agent := msg.Agents[0]
+		fmt.Printf("✅ Yes: %s advertises %s (%s)\n", msg.Role, msg.Capability, capableAgentStatus(agent))
+		return nil
+	}
+
+	if !msg.Capable {
+		fmt.Printf("❌ No agent comrade advertises %s\n", msg.Capability)
+		return fmt.Errorf("no agent can %s", msg.Capability)
+	}
+	fmt.Printf("✅ Agent comrades that can %s:\n", msg.Capability)
+	for _, agent := range msg.Agents {
+		icon := "⚪"
+		if agent.Connected && agent.State == "waiting" {
+			icon = "🟢"
+		}
+		fmt.Printf("  %s %s (%s)\n", icon, agent.Role, capableAgentStatus(agent))
+	}
+	return nil
+}
+
+// capableAgentStatus describes whether a capable agent could take work now
+func capableAgentStatus(agent tcp.CapableAgentInfo) string {
+	if !agent.Connected {
+		return agent.State + ", disconnected"
+	}
+	return agent.State + ", connected"
+}
+
+func (pc *PeopleClient) displayConnections(msg tcp.ConnectionsMessage) error {
+	fmt.Println("🔌 OPEN CONNECTIONS")
+	fmt.Println("===================")
+
+	if msg.ByteBudget > 0 {
+		fmt.Printf("Byte budget: %d bytes per %s\n", msg.ByteBudget, msg.Window)
+	} else {
+		fmt.Println("Byte budget: unlimited")
+	}
+
+	for _, connection := range msg.Connections {
+		role := connection.Role
+		if role == "" {
+			role = "people"
+		}
+		fmt.Printf("  %s (%s) - %d bytes total, %d in window\n", connection.Remote, role, connection.BytesRead, connection.WindowBytes)
+	}
+
+	fmt.Printf("\nTotal: %d connections\n", len(msg.Connections))
+	return nil
+}
+
+func (pc *PeopleClient) displayGroups(msg tcp.GroupsMessage) error {
+	fmt.Println("👥 REVOLUTIONARY BRIGADES")
+	fmt.Println("=========================")
+
+	if len(msg.Groups) == 0 {
+		fmt.Println("No groups configured")
+		return nil
+	}
+
+	for _, group := range msg.Groups {
+		fmt.Printf("\n🏷️  %s\n", group.Name)
+		available := make(map[string]bool, len(group.Available))
+		for _, role := range group.Available {
+			available[role] = true
+		}
+		for i, role := range group.Members {
+			status := "⏳ busy or offline"
+			if available[role] {
+				status = "✅ available"
+			}
+			fmt.Printf("  %d. %s - %s\n", i+1, role, status)
+		}
+		if group.Weighted {
+			fmt.Println("  ⚖️  Work is shared among available members by agent weight")
+		} else if len(group.Available) > 0 {
+			fmt.Printf("  ➡️  Next yield goes to: %s\n", group.Available[0])
+		}
+	}
+
+	fmt.Println()
+	return nil
 }
 
-func main() {
-	var (
-		serverAddr = flag.String("server", defaultServerAddr, "Soviet server address")
-		help       = flag.Bool("help", false, "Show help")
-		version    = flag.Bool("version", false, "Show version")
-	)
-	flag.Parse()
+func (pc *PeopleClient) displayPipeline(msg tcp.PipelineMessage) error {
+	fmt.Println("🏭 REVOLUTIONARY PIPELINE")
+	fmt.Println("=========================")
+
+	if len(msg.Roles) == 0 {
+		fmt.Println("No pipeline configured")
+		fmt.Printf("🔫 Barrel Holder: %s\n", msg.BarrelHolder)
+		return nil
+	}
 
-	if *help {
-		showHelp()
-		return
+	stages := append([]string{"people"}, msg.Roles...)
+	for i, stage := range stages {
+		marker := "  "
+		if stage == msg.BarrelHolder {
+			marker = "🔥"
+		}
+		fmt.Printf("%s %d. %s\n", marker, i, stage)
 	}
+	fmt.Println("   ↩ people")
+	fmt.Println()
 
-	if *version {
-		showVersion()
-		return
+	fmt.Printf("🔫 Barrel Holder: %s\n", msg.BarrelHolder)
+	if msg.OnPipeline {
+		fmt.Printf("➡️  Next Role: %s\n", msg.NextRole)
+	} else {
+		fmt.Printf("⚠️  Barrel is off-pipeline: %s is not a pipeline stage\n", msg.BarrelHolder)
 	}
+	return nil
+}
 
-	args := flag.Args()
-	if len(args) == 0 {
-		fmt.Fprintf(os.Stderr, "Error: Command is required\n")
-		showHelp()
-		os.Exit(1)
+func (pc *PeopleClient) executeReassign(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("reassign command requires: reassign <from_role> <to_role>")
 	}
 
-	client := &PeopleClient{
-		serverAddr: *serverAddr,
+	if err := pc.connect(); err != nil {
+		return err
 	}
+	defer pc.conn.Close()
 
-	if err := client.ExecuteCommand(args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	reassignMsg := tcp.ReassignMessage{
+		Type:     "REASSIGN",
+		FromRole: args[0],
+		ToRole:   args[1],
+		Nonce:    nonce,
+		SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
 	}
-}
 
-func (pc *PeopleClient) ExecuteCommand(args []string) error {
-	command := args[0]
+	if err := pc.sendMessage(reassignMsg); err != nil {
+		return fmt.Errorf("failed to send reassign command: %w", err)
+	}
 
-	switch command {
-	case "yield":
-		return pc.executeYield(args[1:])
-	case "status":
-		return pc.executeStatus()
-	case "query-agents":
-		return pc.executeQueryAgents()
-	default:
-		return fmt.Errorf("unknown command: %s", command)
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
 	}
+
+	line := strings.TrimSpace(scanner.Text())
+	var ackMsg tcp.YieldAckMessage
+	if err := json.Unmarshal([]byte(line), &ackMsg); err != nil || ackMsg.Type != "ACK_YIELD" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse reassign response")
+	}
+
+	fmt.Printf("🔀 Work reassigned from %s to %s with its original message\n", args[0], ackMsg.ToRole)
+	if ackMsg.Receipt != nil {
+		fmt.Printf("🧾 Receipt #%d: %s\n", ackMsg.Receipt.Sequence, ackMsg.Receipt.Hash)
+	}
+	return nil
 }
 
-func (pc *PeopleClient) executeYield(args []string) error {
-	if len(args) < 2 {
-		return fmt.Errorf("yield command requires: yield <to_role> \"<message>\"")
+func (pc *PeopleClient) executeSetState(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("set-state command requires: set-state <role> <waiting|working|paused|error>")
 	}
 
-	toRole := args[0]
-	message := strings.Join(args[1:], " ")
-	
-	// Remove quotes if present
-	message = strings.Trim(message, `"'`)
+	role, state := args[0], args[1]
+	if state != "waiting" && state != "working" && state != "paused" && state != "error" {
+		return fmt.Errorf("invalid state %q: must be waiting, working, paused or error", state)
+	}
 
 	if err := pc.connect(); err != nil {
 		return err
 	}
 	defer pc.conn.Close()
 
-	yieldMsg := tcp.YieldMessage{
-		Type:     "YIELD",
-		FromRole: "people",
-		ToRole:   toRole,
-		Payload:  message,
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	setMsg := tcp.SetAgentStateMessage{
+		Type:   "SET_AGENT_STATE",
+		Role:   role,
+		State:  state,
+		Nonce:  nonce,
+		SentAt: time.Now().UTC().Format(time.RFC3339Nano),
 	}
 
-	if err := pc.sendMessage(yieldMsg); err != nil {
-		return fmt.Errorf("failed to send yield command: %w", err)
+	if err := pc.sendMessage(setMsg); err != nil {
+		return fmt.Errorf("failed to send set-state command: %w", err)
 	}
 
-	fmt.Printf("✅ The People have yielded the barrel to comrade %s\n", toRole)
-	if message != "" {
-		fmt.Printf("📜 Message: %s\n", message)
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	var stateMsg tcp.AgentStateMessage
+	if err := json.Unmarshal([]byte(line), &stateMsg); err != nil || stateMsg.Type != "AGENT_STATE" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse set-state response")
 	}
 
+	fmt.Printf("🛠️  Comrade %s forced from %s to %s\n", stateMsg.Role, stateMsg.Previous, stateMsg.State)
 	return nil
 }
 
-func (pc *PeopleClient) executeStatus() error {
+func (pc *PeopleClient) executeSetTTL(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("set-ttl command requires: set-ttl <duration>")
+	}
+
+	ttl, err := time.ParseDuration(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", args[0], err)
+	}
+	if ttl < 0 {
+		return fmt.Errorf("TTL cannot be negative: %s", ttl)
+	}
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	setMsg := tcp.SetTTLMessage{
+		Type:   "SET_TTL",
+		TTL:    ttl.String(),
+		Nonce:  nonce,
+		SentAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if err := pc.sendMessage(setMsg); err != nil {
+		return fmt.Errorf("failed to send set-ttl command: %w", err)
+	}
+
+	return pc.readTTLResponse()
+}
+
+// newNonce returns a random nonce so a captured privileged command cannot be replayed
+func newNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+func (pc *PeopleClient) executeGetTTL() error {
 	if err := pc.connect(); err != nil {
 		return err
 	}
 	defer pc.conn.Close()
 
 	queryMsg := tcp.QueryMessage{
-		Type: "QUERY_STATUS",
+		Type: "GET_TTL",
 	}
 
 	if err := pc.sendMessage(queryMsg); err != nil {
-		return fmt.Errorf("failed to send status query: %w", err)
+		return fmt.Errorf("failed to send get-ttl query: %w", err)
 	}
 
-	// Read the response
-	scanner := bufio.NewScanner(pc.conn)
+	return pc.readTTLResponse()
+}
+
+func (pc *PeopleClient) readTTLResponse() error {
+	scanner := pc.newScanner()
 	if !scanner.Scan() {
 		return fmt.Errorf("no response from server")
 	}
@@ -134,35 +1239,211 @@ func (pc *PeopleClient) executeStatus() error {
 		return fmt.Errorf("empty response from server")
 	}
 
-	return pc.handleStatusResponse(line)
+	var ttlMsg tcp.TTLMessage
+	if err := json.Unmarshal([]byte(line), &ttlMsg); err != nil || ttlMsg.Type != "TTL" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return fmt.Errorf("failed to parse TTL response")
+	}
+
+	if ttlMsg.TTL == "0s" {
+		fmt.Println("⏱️  Barrel TTL: disabled")
+	} else {
+		fmt.Printf("⏱️  Barrel TTL: %s\n", ttlMsg.TTL)
+	}
+	fmt.Printf("🔫 Barrel Holder: %s\n", ttlMsg.BarrelHolder)
+	if ttlMsg.Deadline != "" {
+		fmt.Printf("⌛ Reclaim Deadline: %s (%s remaining)\n", ttlMsg.Deadline, ttlMsg.Remaining)
+	}
+	return nil
+}
+
+func (pc *PeopleClient) executeDrain() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	drainMsg := tcp.DrainMessage{
+		Type:   "DRAIN",
+		Nonce:  nonce,
+		SentAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if err := pc.sendMessage(drainMsg); err != nil {
+		return fmt.Errorf("failed to send drain command: %w", err)
+	}
+
+	status, err := pc.readDrainStatusResponse()
+	if err != nil {
+		return err
+	}
+	displayDrainStatus(status)
+	return nil
 }
 
-func (pc *PeopleClient) executeQueryAgents() error {
+func (pc *PeopleClient) executeDrainStatus() error {
 	if err := pc.connect(); err != nil {
 		return err
 	}
 	defer pc.conn.Close()
 
 	queryMsg := tcp.QueryMessage{
-		Type: "QUERY_AGENTS",
+		Type: "QUERY_DRAIN_STATUS",
 	}
 
 	if err := pc.sendMessage(queryMsg); err != nil {
-		return fmt.Errorf("failed to send agent query: %w", err)
+		return fmt.Errorf("failed to send drain-status query: %w", err)
 	}
 
-	// Read the response
-	scanner := bufio.NewScanner(pc.conn)
+	status, err := pc.readDrainStatusResponse()
+	if err != nil {
+		return err
+	}
+	displayDrainStatus(status)
+
+	// Fail so restart scripts can wait with: until people drain-status; do sleep 2; done
+	if !status.Draining {
+		return fmt.Errorf("server is not draining")
+	}
+	if !status.Complete {
+		return fmt.Errorf("drain not complete")
+	}
+	return nil
+}
+
+func (pc *PeopleClient) readDrainStatusResponse() (tcp.DrainStatusMessage, error) {
+	scanner := pc.newScanner()
 	if !scanner.Scan() {
-		return fmt.Errorf("no response from server")
+		return tcp.DrainStatusMessage{}, fmt.Errorf("no response from server")
 	}
 
 	line := strings.TrimSpace(scanner.Text())
 	if line == "" {
-		return fmt.Errorf("empty response from server")
+		return tcp.DrainStatusMessage{}, fmt.Errorf("empty response from server")
 	}
 
-	return pc.handleAgentListResponse(line)
+	var statusMsg tcp.DrainStatusMessage
+	if err := json.Unmarshal([]byte(line), &statusMsg); err != nil || statusMsg.Type != "DRAIN_STATUS" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return tcp.DrainStatusMessage{}, fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return tcp.DrainStatusMessage{}, fmt.Errorf("failed to parse drain-status response")
+	}
+	return statusMsg, nil
+}
+
+func displayDrainStatus(status tcp.DrainStatusMessage) {
+	if !status.Draining {
+		fmt.Println("🟢 Server is not draining")
+		return
+	}
+
+	fmt.Printf("🚰 Draining since %s\n", status.StartedAt)
+	fmt.Printf("🔌 Agents still connected: %d\n", status.AgentConnections)
+	if status.BarrelWithPeople {
+		fmt.Println("🔫 Barrel: back with the people")
+	} else {
+		fmt.Printf("🔫 Barrel: still held by %s\n", status.BarrelHolder)
+	}
+	if status.Complete {
+		fmt.Println("✅ Drain complete; the server can be restarted")
+	}
+}
+
+func (pc *PeopleClient) executeHalt(args []string) error {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return fmt.Errorf("usage: halt \"<reason>\"")
+	}
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	haltMsg := tcp.HaltMessage{
+		Type:   "HALT",
+		Reason: args[0],
+		Nonce:  nonce,
+		SentAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if err := pc.sendMessage(haltMsg); err != nil {
+		return fmt.Errorf("failed to send halt command: %w", err)
+	}
+
+	status, err := pc.readHaltStatusResponse()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🛑 Collective halted: %s\n", status.Reason)
+	fmt.Printf("📢 Agents notified: %d\n", status.NotifiedAgents)
+	fmt.Printf("🔫 Barrel frozen with: %s\n", status.BarrelHolder)
+	fmt.Println("▶️  Run 'people resume' to let the barrel move again")
+	return nil
+}
+
+func (pc *PeopleClient) executeResume() error {
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	resumeMsg := tcp.ResumeMessage{
+		Type:   "RESUME",
+		Nonce:  nonce,
+		SentAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if err := pc.sendMessage(resumeMsg); err != nil {
+		return fmt.Errorf("failed to send resume command: %w", err)
+	}
+
+	status, err := pc.readHaltStatusResponse()
+	if err != nil {
+		return err
+	}
+	fmt.Println("▶️  Collective resumed")
+	fmt.Printf("📢 Agents notified: %d\n", status.NotifiedAgents)
+	fmt.Printf("🔫 Barrel held by: %s\n", status.BarrelHolder)
+	return nil
+}
+
+func (pc *PeopleClient) readHaltStatusResponse() (tcp.HaltStatusMessage, error) {
+	scanner := pc.newScanner()
+	if !scanner.Scan() {
+		return tcp.HaltStatusMessage{}, fmt.Errorf("no response from server")
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if line == "" {
+		return tcp.HaltStatusMessage{}, fmt.Errorf("empty response from server")
+	}
+
+	var statusMsg tcp.HaltStatusMessage
+	if err := json.Unmarshal([]byte(line), &statusMsg); err != nil || statusMsg.Type != "HALT_STATUS" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal([]byte(line), &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return tcp.HaltStatusMessage{}, fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return tcp.HaltStatusMessage{}, fmt.Errorf("failed to parse halt-status response")
+	}
+	return statusMsg, nil
 }
 
 func (pc *PeopleClient) connect() error {
@@ -180,9 +1461,23 @@ func (pc *PeopleClient) sendMessage(msg interface{}) error {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	data = append(data, '\n')
-	_, err = pc.conn.Write(data)
-	return err
+	return tcp.WriteFrame(pc.conn, tcp.EncodeFrame(pc.framing, data))
+}
+
+// newScanner returns a scanner reading the server's messages from the connection
+func (pc *PeopleClient) newScanner() *bufio.Scanner {
+	return tcp.NewFrameScanner(pc.conn, tcp.SplitFraming(pc.framing))
+}
+
+// readFrame reads the next message from scanner, failing with io.EOF when the server closed the connection
+func readFrame(scanner *bufio.Scanner) ([]byte, error) {
+	if scanner.Scan() {
+		return scanner.Bytes(), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
 }
 
 func (pc *PeopleClient) handleStatusResponse(line string) error {
@@ -199,7 +1494,12 @@ func (pc *PeopleClient) handleStatusResponse(line string) error {
 	fmt.Println("🏛️  REVOLUTIONARY COLLECTIVE STATUS")
 	fmt.Println("====================================")
 	fmt.Printf("🔫 Barrel Holder: %s\n", statusMsg.BarrelHolder)
+	if statusMsg.HolderUnregistered {
+		fmt.Printf("⚠️  %s is not a registered agent: the work waits until it registers again\n", statusMsg.BarrelHolder)
+		fmt.Printf("   Reclaim the barrel with: people reassign %s people\n", statusMsg.BarrelHolder)
+	}
 	fmt.Printf("👥 Registered Agents: %d\n", len(statusMsg.RegisteredAgents))
+	fmt.Printf("🔗 Yield Chain Depth: %d\n", statusMsg.YieldChainDepth)
 
 	if len(statusMsg.RegisteredAgents) > 0 {
 		fmt.Println("\n📋 AGENT COMRADES:")
@@ -220,6 +1520,24 @@ func (pc *PeopleClient) handleStatusResponse(line string) error {
 			}
 			
 			fmt.Printf("  %s %s - %s (%s)\n", icon, agent, state, connected)
+			if weight := statusMsg.AgentWeights[agent]; weight > 1 {
+				fmt.Printf("     ⚖️  weight %d\n", weight)
+			}
+			if instance := statusMsg.AgentInstances[agent]; instance != "" {
+				fmt.Printf("     🪪 instance %s\n", instance)
+			}
+			if average, held := statusMsg.AverageHolds[agent]; held {
+				fmt.Printf("     ⏱  avg hold %s\n", average)
+			}
+			if caps := statusMsg.AgentCapabilities[agent]; len(caps) > 0 {
+				fmt.Printf("     🛠️  %s\n", strings.Join(caps, ", "))
+			}
+			if until, benched := statusMsg.BenchedAgents[agent]; benched {
+				fmt.Printf("     🚧 Benched after repeated failures until %s\n", until)
+			}
+			if depth := statusMsg.QueueDepths[agent]; depth > 0 {
+				fmt.Printf("     📥 %d undelivered activation(s) queued\n", depth)
+			}
 		}
 	} else {
 		fmt.Println("\n📋 No agents registered in the collective")
@@ -232,13 +1550,13 @@ func (pc *PeopleClient) handleStatusResponse(line string) error {
 func (pc *PeopleClient) handleAgentListResponse(line string) error {
 	// Try to parse as detailed agent response first
 	var agentDetailsMsg tcp.AgentDetailsMessage
-	if err := json.Unmarshal([]byte(line), &agentDetailsMsg); err == nil {
+	if err := json.Unmarshal([]byte(line), &agentDetailsMsg); err == nil && agentDetailsMsg.Type == "AGENT_DETAILS" {
 		return pc.displayAgentDetails(agentDetailsMsg)
 	}
 
 	// Fallback to simple agent list (for backward compatibility)
 	var agentListMsg tcp.AgentListMessage
-	if err := json.Unmarshal([]byte(line), &agentListMsg); err == nil {
+	if err := json.Unmarshal([]byte(line), &agentListMsg); err == nil && agentListMsg.Type == "AGENT_LIST" {
 		return pc.displaySimpleAgentList(agentListMsg)
 	}
 
@@ -258,8 +1576,15 @@ func (pc *PeopleClient) displayAgentDetails(msg tcp.AgentDetailsMessage) error {
 	if len(msg.AgentDetails) > 0 {
 		for i, agent := range msg.AgentDetails {
 			icon := "⏳"
-			if agent.State == "working" {
+			switch agent.State {
+			case "working":
 				icon = "🔥"
+			case "offered":
+				icon = "📨"
+			case "paused":
+				icon = "⏸️"
+			case "error":
+				icon = "💥"
 			}
 			
 			connected := "❌ offline"
@@ -268,12 +1593,28 @@ func (pc *PeopleClient) displayAgentDetails(msg tcp.AgentDetailsMessage) error {
 			}
 			
 			fmt.Printf("%d. %s %s - %s (%s)\n", i+1, icon, agent.Role, agent.State, connected)
+			fmt.Printf("   🎖️  Enlisted: #%d\n", agent.RegistrationSeq)
+			if agent.AgentType != "" {
+				fmt.Printf("   🏷️  Type: %s\n", agent.AgentType)
+			}
+			if agent.Description != "" {
+				fmt.Printf("   📝 %s\n", agent.Description)
+			}
+			if agent.Weight > 1 {
+				fmt.Printf("   ⚖️  Weight: %d\n", agent.Weight)
+			}
 			
 			if len(agent.Capabilities) > 0 {
 				fmt.Printf("   🛠️  Capabilities: %s\n", strings.Join(agent.Capabilities, ", "))
 			} else {
 				fmt.Printf("   🛠️  Capabilities: none specified\n")
 			}
+			if len(agent.Tags) > 0 {
+				fmt.Printf("   🔖 Tags: %s\n", formatTags(agent.Tags))
+			}
+			if agent.InstanceID != "" {
+				fmt.Printf("   🪪 Instance: %s\n", agent.InstanceID)
+			}
 			fmt.Println()
 		}
 	} else {
@@ -284,6 +1625,16 @@ func (pc *PeopleClient) displayAgentDetails(msg tcp.AgentDetailsMessage) error {
 	return nil
 }
 
+// formatTags writes tags as key=value pairs sorted by key
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
 func (pc *PeopleClient) displaySimpleAgentList(msg tcp.AgentListMessage) error {
 	fmt.Println("👥 REGISTERED AGENT COMRADES")
 	fmt.Println("============================")
@@ -310,25 +1661,115 @@ OPTIONS:
     --server <address>      Soviet server address (default: %s)
     --help                  Show this help
     --version               Show version
+    --as <name>             Record your yields as people:<name> (default: $AGENTFARM_OPERATOR)
+    --framing <mode>        Framing of JSON messages, as configured on the server: newline, length (default: newline)
 
 COMMANDS:
-    yield <to_role> "<message>"     Transfer the barrel to specified agent comrade
+    yield [--require <capability>] [--expect <duration>] [--meta <key=value,...>] <to_role> "<message>"
+                                    Transfer the barrel to specified agent comrade; a to_role of
+                                    capability:<name> picks a connected agent that has the capability,
+                                    and next the first stage of the server's pipeline;
+                                    --meta sends structured data such as pr=42,branch=main along
+    run [--timeout <duration>] <role> "<task>"
+                                    Hand role a task and wait until the barrel is back with the people;
+                                    exits 2 if the yield is refused and 3 on timeout
     status                          Query comprehensive system status
-    query-agents                    List all registered agent comrades
+    workers                         Show at a glance which agent is working and which are waiting
+    watch                           Print the status again whenever an agent registers or leaves, an
+                                    agent's state changes or the barrel moves, until interrupted
+    query-agents [--tag key[=value]]
+                                    List all registered agent comrades, or only those with a tag
+    pipeline                        Show the configured pipeline and the barrel's position in it
+    groups                          Show yield groups and which members are available
+    ping [--count n] [--interval d] [--timeout d]
+                                    Measure round-trip latency to the server and show its version
+    validate-yield <to_role> ["<message>"]
+                                    Check a yield without sending it and list every problem
+    staleness [max_duration]        Show how long the barrel has sat with its holder; fails past max_duration
+    context                         Show the holder's whole task: sender, message, when it arrived, receipt and hints
+    history [--limit N]             Show barrel transfers, oldest first, or only the last N
+    needed                          List required roles that are not online yet; fails while any are missing
+    connections                     Show open connections and the bytes each has sent
+    can [role] <capability>         Tell whether role advertises a capability (patterns like test/* work);
+                                    without a role, list every agent that does with its state; fails on no
+    reassign <from_role> <to_role>  Move a stuck holder's work, with its original message, to another agent
+    set-state <role> <state>        Force a wedged agent to waiting or working (recovery only), pause a waiting
+                                    agent, fail a working one (its barrel returns to the people) or resume
+                                    a paused or failed one by setting it to waiting
+    set-ttl <duration>              Set how long an agent may hold the barrel (e.g. 30m, 0 disables)
+    get-ttl                         Show the barrel TTL and the current holder's deadline
+    drain                           Stop handing out work ahead of a restart; idle agents are disconnected
+    drain-status                    Show drain progress; fails until no agent is connected and the barrel is with the people
+    halt "<reason>"                 Emergency stop: tell every agent to stop and freeze the barrel until resume
+    resume                          Lift a halt so the barrel moves again
+    mock-agent <role> [--yield-to <role>] [--message <text>]
+                                    Stand in for an agent: register as role and yield every activation on (default: to people) until Ctrl+C
 
 EXAMPLES:
     # Transfer barrel to developer with instructions
     people yield developer "Implement the authentication module"
 
+    # Hand work to the developer only if it can do code review
+    people yield --require code-review developer "Review the login module"
+
+    # Hand work to whichever connected agent can test
+    people yield capability:testing "Run the regression suite"
+
+    # Start the configured pipeline at its first stage
+    people yield next "Ship the release"
+
+    # Run a job from CI: wait up to an hour for the collective to finish it
+    people run --timeout 1h developer "Fix the failing build"
+
     # Transfer barrel to tester
     people yield tester "Code ready for revolutionary testing"
 
     # Check complete system status
     people status
 
+    # See who is working and who is available
+    people workers
+
+    # Hand work to the first available member of the backend group
+    people yield backend "Fix the flaky API test"
+
     # List all registered agents
     people query-agents
 
+    # List the agents running in us-east
+    people query-agents --tag region=us-east
+
+    # Alert when the barrel hasn't moved in 30 minutes
+    people staleness 30m || notify-send "Barrel is stuck"
+
+    # See what the holder is working on, e.g. after an agent crashed
+    people context
+
+    # Wait until every required role has come online
+    until people needed; do sleep 5; done
+
+    # Find an available agent that can run unit tests before yielding
+    people can test/unit
+
+    # Hand a stuck developer's task to the senior developer
+    people reassign developer senior-developer
+
+    # Reclaim the barrel if an agent holds it for more than 30 minutes
+    people set-ttl 30m
+
+    # Restart the server once the barrel holder has finished
+    people drain && until people drain-status; do sleep 2; done
+
+    # Stop everything while a bad deploy is investigated, then carry on
+    people halt "Bad deploy in progress, hands off"
+    people resume
+
+    # Test a workflow without a real tester: hand every activation on to the reviewer
+    people mock-agent tester --yield-to reviewer
+
+    # Check that the server answers, five times
+    people ping --count 5
+
     # Connect to custom server
     people --server=localhost:8080 status
 
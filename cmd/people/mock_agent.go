@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+)
+
+// mockAgent stands in for a worker while testing a workflow end to end
+// It registers like the agent binary does and hands the barrel on as soon as it is activated
+type mockAgent struct {
+	role       string
+	yieldTo    string
+	message    string
+	registered bool
+}
+
+func (pc *PeopleClient) executeMockAgent(args []string) error {
+	mockFlags := flag.NewFlagSet("mock-agent", flag.ContinueOnError)
+	yieldTo := mockFlags.String("yield-to", "people", "Role or group to hand the barrel to after every activation")
+	message := mockFlags.String("message", "", "Payload of every yield (default: a note naming the mock agent)")
+	if err := mockFlags.Parse(args); err != nil {
+		return err
+	}
+	args = mockFlags.Args()
+	if len(args) < 1 {
+		return fmt.Errorf("mock-agent command requires: mock-agent <role> [--yield-to <role>] [--message <text>]")
+	}
+	role := strings.TrimSpace(args[0])
+	// Flags may also follow the role
+	if err := mockFlags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if mockFlags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(mockFlags.Args(), " "))
+	}
+
+	agent := &mockAgent{
+		role:    role,
+		yieldTo: strings.TrimSpace(*yieldTo),
+		message: *message,
+	}
+	if agent.role == "" || agent.role == "people" {
+		return fmt.Errorf("mock-agent needs an agent role, got %q", args[0])
+	}
+	if agent.yieldTo == "" {
+		return fmt.Errorf("--yield-to cannot be empty")
+	}
+	if agent.yieldTo == agent.role {
+		return fmt.Errorf("mock agent %s cannot yield to itself", agent.role)
+	}
+	if agent.message == "" {
+		agent.message = fmt.Sprintf("Mock agent %s finished its work", agent.role)
+	}
+
+	if err := pc.connect(); err != nil {
+		return err
+	}
+	defer pc.conn.Close()
+
+	// Stay connected until Ctrl+C; closing the connection ends the read loop below
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = pc.conn.Close()
+	}()
+
+	registerMsg := tcp.RegisterMessage{
+		Type:         "REGISTER",
+		Role:         agent.role,
+		Capabilities: []string{},
+		Description:  "Mock agent started with people mock-agent",
+	}
+	if err := pc.sendMessage(registerMsg); err != nil {
+		return fmt.Errorf("failed to register mock agent: %w", err)
+	}
+
+	scanner := pc.newScanner()
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := pc.handleMockAgentMessage(agent, line); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		fmt.Printf("\n👋 Mock agent %s disconnected\n", agent.role)
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("connection error: %w", err)
+	}
+	return fmt.Errorf("connection closed by server")
+}
+
+// handleMockAgentMessage answers one server message; errors end the mock agent
+func (pc *PeopleClient) handleMockAgentMessage(agent *mockAgent, line string) error {
+	var baseMsg tcp.TCPMessage
+	if err := json.Unmarshal([]byte(line), &baseMsg); err != nil {
+		return fmt.Errorf("failed to parse server message: %w", err)
+	}
+
+	switch baseMsg.Type {
+	case "ACK_REGISTER":
+		var ackMsg tcp.AckRegisterMessage
+		if err := json.Unmarshal([]byte(line), &ackMsg); err != nil {
+			return fmt.Errorf("failed to parse ACK_REGISTER message: %w", err)
+		}
+		if ackMsg.Status != "success" {
+			return fmt.Errorf("registration rejected: %s (%s)", ackMsg.Message, ackMsg.Status)
+		}
+		agent.registered = true
+		fmt.Printf("🤖 Mock agent %s registered; every activation is yielded to %s. Press Ctrl+C to stop\n", agent.role, agent.yieldTo)
+	case "ERROR":
+		var errorMsg tcp.ErrorMessage
+		if err := json.Unmarshal([]byte(line), &errorMsg); err != nil {
+			return fmt.Errorf("failed to parse ERROR message: %w", err)
+		}
+		// Until registration is acknowledged, any error is the server refusing the role
+		if !agent.registered {
+			return fmt.Errorf("registration rejected: %s", errorMsg.Message)
+		}
+		fmt.Printf("❌ Server error: %s\n", errorMsg.Message)
+	case "ACTIVATE":
+		var activateMsg tcp.ActivateMessage
+		if err := json.Unmarshal([]byte(line), &activateMsg); err != nil {
+			return fmt.Errorf("failed to parse ACTIVATE message: %w", err)
+		}
+		if err := pc.sendMessage(tcp.ActivateAckMessage{Type: "ACTIVATE_ACK", Role: agent.role}); err != nil {
+			return fmt.Errorf("failed to acknowledge activation: %w", err)
+		}
+		fmt.Printf("🔥 Barrel received from %s", activateMsg.FromRole)
+		if activateMsg.Payload != "" {
+			fmt.Printf(": %s", activateMsg.Payload)
+		}
+		fmt.Println()
+
+		yieldMsg := tcp.YieldMessage{
+			Type:     "YIELD",
+			FromRole: agent.role,
+			ToRole:   agent.yieldTo,
+			Payload:  agent.message,
+			SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
+		}
+		if err := pc.sendMessage(yieldMsg); err != nil {
+			return fmt.Errorf("failed to yield barrel: %w", err)
+		}
+		fmt.Printf("⚡ Yielded barrel to %s\n", agent.yieldTo)
+	case "ACK_YIELD":
+		var ackMsg tcp.YieldAckMessage
+		if err := json.Unmarshal([]byte(line), &ackMsg); err == nil && ackMsg.Receipt != nil {
+			fmt.Printf("🧾 Receipt #%d: %s\n", ackMsg.Receipt.Sequence, ackMsg.Receipt.Hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// Exit codes of people run besides 0 (the barrel came back) and 1 (any other error)
+const (
+	exitRunRejected = 2 // The server refused to hand the task to the role
+	exitRunTimeout  = 3 // The barrel did not come back within --timeout
+)
+
+// runPollInterval is how often people run asks the server whether the barrel is back
+var runPollInterval = time.Second
+
+// exitError is an error that ends the CLI with a specific exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e exitError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code the CLI ends with after err
+func exitCode(err error) int {
+	var exitErr exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return 1
+}
+
+// runResult is the hand-off that returned the barrel to the people
+type runResult struct {
+	FromRole string
+	Message  string
+	Receipt  *tcp.ReceiptInfo
+}
+
+func (pc *PeopleClient) executeRun(args []string) error {
+	runFlags := flag.NewFlagSet("run", flag.ContinueOnError)
+	timeout := runFlags.Duration("timeout", 0, "Give up waiting for the barrel to come back after this long (0 = wait forever)")
+	if err := runFlags.Parse(args); err != nil {
+		return err
+	}
+	args = runFlags.Args()
+
+	if len(args) < 2 {
+		return fmt.Errorf("run command requires: run [--timeout <duration>] <role> \"<task>\"")
+	}
+	if *timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative: %s", *timeout)
+	}
+
+	toRole := args[0]
+	task := strings.Trim(strings.Join(args[1:], " "), `"'`)
+
+	fmt.Printf("🚀 Handing the task to comrade %s\n", toRole)
+	result, err := pc.runTask(toRole, task, *timeout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🏁 Barrel returned to the people by %s\n", result.FromRole)
+	if result.Receipt != nil {
+		fmt.Printf("🧾 Receipt #%d: %s\n", result.Receipt.Sequence, result.Receipt.Hash)
+	}
+	fmt.Printf("📜 Message:\n%s\n", result.Message)
+	return nil
+}
+
+// runTask yields the barrel to toRole with task, then waits until it is back with the people
+// A refused yield fails with exitRunRejected and a barrel still out after timeout with exitRunTimeout
+func (pc *PeopleClient) runTask(toRole, task string, timeout time.Duration) (runResult, error) {
+	if err := pc.connect(); err != nil {
+		return runResult{}, err
+	}
+	defer pc.conn.Close()
+
+	nonce, err := newNonce()
+	if err != nil {
+		return runResult{}, err
+	}
+	yieldMsg := tcp.YieldMessage{
+		Type:     "YIELD",
+		FromRole: pc.identity(),
+		ToRole:   toRole,
+		Payload:  task,
+		SentAt:   time.Now().UTC().Format(time.RFC3339Nano),
+		Nonce:    nonce,
+	}
+	if err := pc.sendMessage(yieldMsg); err != nil {
+		return runResult{}, fmt.Errorf("failed to send yield command: %w", err)
+	}
+	ack, err := pc.readYieldResponse()
+	if err != nil {
+		return runResult{}, exitError{code: exitRunRejected, err: err}
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	scanner := pc.newScanner()
+	for {
+		barrel, err := pc.queryContext(scanner)
+		if err != nil {
+			return runResult{}, err
+		}
+		if returned(barrel, ack) {
+			return runResult{FromRole: barrel.FromRole, Message: barrel.Payload, Receipt: barrel.Receipt}, nil
+		}
+
+		wait := runPollInterval
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return runResult{}, exitError{code: exitRunTimeout, err: fmt.Errorf(
+					"barrel not back after %s: still held by %s", timeout, barrel.BarrelHolder)}
+			}
+			wait = min(wait, remaining)
+		}
+		time.Sleep(wait)
+	}
+}
+
+// returned reports whether the barrel is back with the people after the yield acknowledged by ack
+// Receipts tell a return apart from the people still holding the barrel; servers that do not
+// acknowledge yields are taken to have returned it once an agent yielded to the people
+func returned(barrel tcp.ContextMessage, ack *tcp.YieldAckMessage) bool {
+	if barrel.BarrelHolder != "people" {
+		return false
+	}
+	if ack != nil && ack.Receipt != nil && barrel.Receipt != nil {
+		return barrel.Receipt.Sequence > ack.Receipt.Sequence
+	}
+	return !domain.IsPeopleIdentity(barrel.FromRole)
+}
+
+// queryContext asks for the barrel's holder and the hand-off that gave it the barrel
+func (pc *PeopleClient) queryContext(scanner *bufio.Scanner) (tcp.ContextMessage, error) {
+	if err := pc.sendMessage(tcp.QueryMessage{Type: "QUERY_CONTEXT"}); err != nil {
+		return tcp.ContextMessage{}, fmt.Errorf("failed to send context query: %w", err)
+	}
+	if err := pc.conn.SetReadDeadline(time.Now().Add(connectionTimeout)); err != nil {
+		return tcp.ContextMessage{}, err
+	}
+
+	line, err := readFrame(scanner)
+	if err != nil {
+		return tcp.ContextMessage{}, fmt.Errorf("no response from server: %w", err)
+	}
+	var contextMsg tcp.ContextMessage
+	if err := json.Unmarshal(line, &contextMsg); err != nil || contextMsg.Type != "CONTEXT" {
+		var errorMsg tcp.ErrorMessage
+		if errParse := json.Unmarshal(line, &errorMsg); errParse == nil && errorMsg.Type == "ERROR" {
+			return tcp.ContextMessage{}, fmt.Errorf("server error: %s", errorMsg.Message)
+		}
+		return tcp.ContextMessage{}, fmt.Errorf("failed to parse context response")
+	}
+	return contextMsg, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// quietLogger discards the server's logs
+type quietLogger struct{}
+
+func (quietLogger) Debug(string, ...map[string]interface{}) {}
+func (quietLogger) Info(string, ...map[string]interface{})  {}
+func (quietLogger) Warn(string, ...map[string]interface{})  {}
+func (quietLogger) Error(string, ...map[string]interface{}) {}
+
+// startServer runs an in-process server with a barrel and returns its address
+func startServer(t *testing.T) string {
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := tcp.NewTCPServer(soviet, soviet, nil, quietLogger{}, 0)
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, server.Start(ctx))
+	t.Cleanup(func() {
+		cancel()
+		server.Stop()
+	})
+	return server.Addr().String()
+}
+
+// startAgent registers role and, if reply is set, yields every activation back to the people with it
+func startAgent(t *testing.T, addr, role, reply string) {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	encoder := json.NewEncoder(conn)
+	reader := bufio.NewReader(conn)
+	require.NoError(t, encoder.Encode(tcp.RegisterMessage{Type: "REGISTER", Role: role}))
+	line, err := reader.ReadBytes('\n')
+	require.NoError(t, err)
+	require.Contains(t, string(line), "ACK_REGISTER")
+
+	go func() {
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			var base tcp.TCPMessage
+			if json.Unmarshal(line, &base) != nil || base.Type != "ACTIVATE" || reply == "" {
+				continue
+			}
+			_ = encoder.Encode(tcp.YieldMessage{Type: "YIELD", FromRole: role, ToRole: "people", Payload: reply})
+		}
+	}()
+}
+
+func TestRunTask(t *testing.T) {
+	stubs := gostub.Stub(&runPollInterval, 10*time.Millisecond)
+	defer stubs.Reset()
+
+	t.Run("waits for the barrel to come back", func(t *testing.T) {
+		addr := startServer(t)
+		startAgent(t, addr, "developer", "Build fixed")
+
+		client := &PeopleClient{serverAddr: addr}
+		result, err := client.runTask("developer", "Fix the build", 5*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "developer", result.FromRole)
+		assert.Equal(t, "Build fixed", result.Message)
+		require.NotNil(t, result.Receipt)
+		assert.Equal(t, 2, result.Receipt.Sequence)
+	})
+
+	t.Run("refused yields are rejected", func(t *testing.T) {
+		addr := startServer(t)
+
+		client := &PeopleClient{serverAddr: addr}
+		_, err := client.runTask("ghost", "Fix the build", 5*time.Second)
+		require.Error(t, err)
+		assert.Equal(t, exitRunRejected, exitCode(err))
+	})
+
+	t.Run("times out while the role keeps the barrel", func(t *testing.T) {
+		addr := startServer(t)
+		startAgent(t, addr, "developer", "")
+
+		client := &PeopleClient{serverAddr: addr}
+		_, err := client.runTask("developer", "Fix the build", 50*time.Millisecond)
+		require.Error(t, err)
+		assert.Equal(t, exitRunTimeout, exitCode(err))
+		assert.Contains(t, err.Error(), "still held by developer")
+	})
+}
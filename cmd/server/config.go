@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/replica"
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/webhook"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configDocs describes every YAML option; it becomes the comments of the generated config
+var configDocs = map[string]string{
+	"port":                   "TCP port for the Soviet server",
+	"http_port":              "Serve a JSON status snapshot at /status.json on this port (0 = disabled)",
+	"events_port":            "Stream barrel transfers and status changes as server-sent events at /events on this port (0 = disabled)",
+	"events_payloads":        "Include yield payloads in transfer events; anyone who can reach events_port can then read the work handed out",
+	"debug":                  "Enable debug logging",
+	"max_yield_depth":        "Maximum consecutive agent-to-agent yields before the barrel must return to people (0 = unlimited)",
+	"max_yields_per_minute":  "Yields each role may make per minute before further ones are rejected with RATE_LIMITED; the people are exempt (0 = unlimited)",
+	"barrel_ttl":             "Reclaim the barrel for the people after an agent holds it this long (0s = never)",
+	"pipeline":               "Ordered roles the barrel travels through, e.g. [developer, tester, reviewer]",
+	"required_roles":         "Roles the workflow expects to be online; `people needed` lists those not registered or connected",
+	"max_retries":            "Requeue work reported as failed up to this many times (0 = never)",
+	"retry_fallbacks":        "Failing role -> fallback role used for retries, e.g. {developer: senior-developer}",
+	"breaker_threshold":      "Bench a role after this many consecutive failed yields from it (0 = never)",
+	"breaker_cooldown":       "How long a benched role is skipped by routing and rejected as a yield target",
+	"activation_ack_timeout": "Require agents to acknowledge activation within this time or return the barrel to people (0s = no acknowledgment)",
+	"redact_payloads":        "Replace yield payloads and metadata values in logs with their length and hash",
+	"reconnect_grace_period": "Return the barrel to people when its holder stays disconnected this long (0s = wait for reconnect)",
+	"heartbeat_timeout":      "Mark agents disconnected when no PING arrives from them for this long; agents ping every --heartbeat-interval (0s = not checked)",
+	"max_message_age":        "Reject yields whose sent_at is older than this as stale (0s = accept any age)",
+	"clock_skew_tolerance":   "Accept client timestamps up to this far ahead of the server clock and reject later ones with CLOCK_SKEW (0s = not checked)",
+	"max_conn_bytes":         "Disconnect a connection that sends more than this many bytes within conn_bytes_window (0 = unlimited)",
+	"conn_bytes_window":      "Rolling window of the per-connection byte budget",
+	"message_rate":           "Messages per second each connection may send; excess messages get a RATE_LIMITED error (0 = unlimited)",
+	"message_burst":          "Messages a connection may send in a burst before message_rate applies",
+	"max_strikes":            "Disconnect a connection with a QUARANTINED error after this many malformed messages in a row (0 = never)",
+	"strike_block":           "Refuse new connections from the address of a quarantined connection for this long (0s = not blocked)",
+	"inbox_depth":            "Activations kept per disconnected role and reported as its queue depth in status; older ones are dropped (0 = keep none)",
+	"field_naming":           "JSON field names of connections that do not choose them in HELLO: snake_case or camelCase; either is accepted from clients",
+	"framing":                "Framing of JSON messages: newline, or length for a 4-byte big-endian length prefix; clients must use the same (agent/people --framing)",
+	"replica_of":             "Run as a read-only replica of the primary whose event stream is at this address, e.g. primary:8081; only queries are served (empty = primary)",
+	"people_idle_timeout":    "Disconnect people connections that neither send nor receive anything for this long (0s = never)",
+	"replay_window":          "Require privileged people commands (yield, set-state, set-ttl, reassign) to carry a fresh nonce sent within this window (0s = not checked)",
+	"close_linger":           "Wait this long for the last message to reach a client before closing its connection (0s = close immediately)",
+	"initial_message":        "First message of the barrel, e.g. the task the collective is started for (empty = \"Initial barrel creation\")",
+	"history_retention":      "Drop barrel transfer records older than this from the in-memory history; the latest is always kept (0s = keep all)",
+	"history_archive":        "Log each transfer record dropped by history_retention first, so a log file keeps the full audit trail",
+	"default_yield_message":  "Payload delivered to an agent when a yield carries no message (empty = deliver nothing)",
+	"role_yield_messages":    "Target role -> payload overriding default_yield_message, e.g. {tester: Run the full test suite}",
+	"reserved_roles":         "Role -> token an agent must present (agent --reservation-token) to register as the role, e.g. {deployer: s3cret}",
+	"groups":                 "Yield groups: group name -> member roles in priority order, e.g. {backend: [api, db, cache]}",
+	"weighted_groups":        "Groups that share work among available members in proportion to agent weights instead of by priority",
+	"persistence":            "Repository failure policy: strict fails registration, best-effort keeps agents in memory",
+	"repo_file":              "JSON file keeping registered agents across restarts; reloaded agents stay disconnected until they register again (empty = in memory only)",
+	"barrel_file":            "Save the barrel and its TTL deadline to this file after every change and reload it on startup, so its holder resumes after a restart; a saved TTL replaces barrel_ttl (empty = memory only)",
+	"webhook_url":            "POST every domain event (registrations, deregistrations, barrel transfers) as JSON to this URL (empty = disabled)",
+	"webhook_timeout":        "Time allowed for one webhook delivery attempt; failed deliveries are retried twice",
+}
+
+// DefaultConfig returns the configuration used when neither a config file nor flags change it
+func DefaultConfig() Config {
+	return Config{
+		Port:            defaultPort,
+		Persistence:     "strict",
+		ConnBytesWindow: time.Minute,
+		MessageBurst:    defaultMessageBurst,
+		BreakerCooldown: defaultBreakerCooldown,
+		CloseLinger:     tcp.DefaultCloseLinger,
+		InboxDepth:      tcp.DefaultInboxDepth,
+		FieldNaming:     tcp.FieldNamingSnake,
+		Framing:         tcp.FramingNewline,
+		WebhookTimeout:  webhook.DefaultTimeout,
+	}
+}
+
+// LoadConfig reads a YAML config file on top of the defaults
+// Unknown keys are rejected so typos do not silently fall back to defaults
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config := DefaultConfig()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// BarrelTimeoutEnv names the environment variable that sets barrel_ttl, e.g. AGENT_FARM_BARREL_TIMEOUT=30m
+const BarrelTimeoutEnv = "AGENT_FARM_BARREL_TIMEOUT"
+
+// MaxYieldsPerMinuteEnv names the environment variable that sets max_yields_per_minute
+const MaxYieldsPerMinuteEnv = "AGENT_FARM_MAX_YIELDS_PER_MINUTE"
+
+// WebhookURLEnv names the environment variable that sets webhook_url
+const WebhookURLEnv = "AGENT_FARM_WEBHOOK_URL"
+
+// ApplyEnvironment overrides config with the environment variables lookup finds
+// They take precedence over a config file; flags given on the command line override both
+func (c *Config) ApplyEnvironment(lookup func(string) (string, bool)) error {
+	if value, ok := lookup(BarrelTimeoutEnv); ok && value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", BarrelTimeoutEnv, value, err)
+		}
+		c.BarrelTTL = ttl
+	}
+	if value, ok := lookup(MaxYieldsPerMinuteEnv); ok && value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", MaxYieldsPerMinuteEnv, value, err)
+		}
+		c.MaxYieldsPerMinute = limit
+	}
+	if value, ok := lookup(WebhookURLEnv); ok && value != "" {
+		c.WebhookURL = value
+	}
+	return nil
+}
+
+// Validate reports every problem that would stop the server from starting with this config
+func (c Config) Validate() error {
+	var problems []error
+	if c.Port < 0 || c.Port > 65535 {
+		problems = append(problems, fmt.Errorf("invalid port: %d (expected 0-65535)", c.Port))
+	}
+	if c.HTTPPort < 0 || c.HTTPPort > 65535 {
+		problems = append(problems, fmt.Errorf("invalid http port: %d (expected 0-65535)", c.HTTPPort))
+	}
+	if c.HTTPPort != 0 && c.HTTPPort == c.Port {
+		problems = append(problems, fmt.Errorf("http port %d must differ from the TCP port", c.HTTPPort))
+	}
+	if c.EventsPort < 0 || c.EventsPort > 65535 {
+		problems = append(problems, fmt.Errorf("invalid events port: %d (expected 0-65535)", c.EventsPort))
+	}
+	if c.EventsPort != 0 && (c.EventsPort == c.Port || c.EventsPort == c.HTTPPort) {
+		problems = append(problems, fmt.Errorf("events port %d must differ from the TCP and http ports", c.EventsPort))
+	}
+	if err := tcp.ValidateByteBudget(c.MaxConnBytes, c.ConnBytesWindow); err != nil {
+		problems = append(problems, fmt.Errorf("invalid connection byte budget: %w", err))
+	}
+	if err := tcp.ValidateMessageRate(c.MessageRate, c.MessageBurst); err != nil {
+		problems = append(problems, fmt.Errorf("invalid message rate limit: %w", err))
+	}
+	if err := tcp.ValidateQuarantine(c.MaxStrikes, c.StrikeBlock); err != nil {
+		problems = append(problems, fmt.Errorf("invalid malformed message quarantine: %w", err))
+	}
+	if err := tcp.ValidateFieldNaming(c.FieldNaming); err != nil {
+		problems = append(problems, fmt.Errorf("invalid field naming: %w", err))
+	}
+	if err := tcp.ValidateFraming(c.Framing); err != nil {
+		problems = append(problems, fmt.Errorf("invalid framing: %w", err))
+	}
+	if c.InboxDepth < 0 {
+		problems = append(problems, fmt.Errorf("invalid inbox depth: %d", c.InboxDepth))
+	}
+	if c.ReplicaOf != "" {
+		if _, err := replica.PrimaryEventsURL(c.ReplicaOf); err != nil {
+			problems = append(problems, fmt.Errorf("invalid replica_of: %w", err))
+		}
+	}
+	if c.PeopleIdleTimeout < 0 {
+		problems = append(problems, fmt.Errorf("invalid people idle timeout: %s", c.PeopleIdleTimeout))
+	}
+	if c.ReplayWindow < 0 {
+		problems = append(problems, fmt.Errorf("invalid replay window: %s", c.ReplayWindow))
+	}
+	if c.CloseLinger < 0 {
+		problems = append(problems, fmt.Errorf("invalid close linger: %s", c.CloseLinger))
+	}
+	if c.WebhookURL != "" {
+		if err := webhook.ValidateURL(c.WebhookURL); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if c.WebhookTimeout < 0 {
+		problems = append(problems, fmt.Errorf("invalid webhook timeout: %s", c.WebhookTimeout))
+	}
+	if _, err := newSoviet(c, nil); err != nil {
+		problems = append(problems, err)
+	}
+	return errors.Join(problems...)
+}
+
+// WriteDefaultConfig writes the default config as YAML with every option documented
+// The output can be loaded back with LoadConfig
+func WriteDefaultConfig(w io.Writer) error {
+	var node yaml.Node
+	if err := node.Encode(DefaultConfig()); err != nil {
+		return fmt.Errorf("failed to encode default config: %w", err)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		doc, ok := configDocs[key.Value]
+		if !ok {
+			return fmt.Errorf("config option %s is not documented", key.Value)
+		}
+		key.HeadComment = doc
+	}
+
+	if _, err := fmt.Fprint(w, "# Agent Farm Soviet Server configuration\n# Durations use Go syntax such as \"30s\" or \"5m\"; flags given on the command line override this file\n\n"); err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to write default config: %w", err)
+	}
+	return encoder.Close()
+}
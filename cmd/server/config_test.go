@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfigFile(t, `
+barrel_ttl: 30m
+max_retries: 2
+groups:
+  backend: [api, db, cache]
+`)
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, defaultPort, config.Port)
+	assert.Equal(t, 30*time.Minute, config.BarrelTTL)
+	assert.Equal(t, 2, config.MaxRetries)
+	assert.Equal(t, []string{"api", "db", "cache"}, config.Groups["backend"])
+
+	soviet, err := newSoviet(config, nil)
+	require.NoError(t, err)
+	assert.True(t, soviet.IsGroup("backend"))
+}
+
+func TestLoadConfig_RejectsUnknownKeys(t *testing.T) {
+	_, err := LoadConfig(writeConfigFile(t, "barel_ttl: 30m\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "barel_ttl")
+}
+
+func TestConfig_ApplyEnvironment(t *testing.T) {
+	env := map[string]string{}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	config := DefaultConfig()
+	config.BarrelTTL = 30 * time.Minute
+	require.NoError(t, config.ApplyEnvironment(lookup))
+	assert.Equal(t, 30*time.Minute, config.BarrelTTL, "an unset variable keeps the config file's value")
+
+	env[BarrelTimeoutEnv] = "45m"
+	require.NoError(t, config.ApplyEnvironment(lookup))
+	assert.Equal(t, 45*time.Minute, config.BarrelTTL)
+
+	env[BarrelTimeoutEnv] = "forever"
+	err := config.ApplyEnvironment(lookup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), BarrelTimeoutEnv)
+	assert.Equal(t, 45*time.Minute, config.BarrelTTL)
+}
+
+func TestConfig_MaxYieldsPerMinuteFromEnvironment(t *testing.T) {
+	env := map[string]string{MaxYieldsPerMinuteEnv: "12"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	config := DefaultConfig()
+	require.NoError(t, config.ApplyEnvironment(lookup))
+	assert.Equal(t, 12, config.MaxYieldsPerMinute)
+	soviet, err := newSoviet(config, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 12, soviet.MaxYieldsPerMinute())
+
+	env[MaxYieldsPerMinuteEnv] = "lots"
+	err = config.ApplyEnvironment(lookup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), MaxYieldsPerMinuteEnv)
+
+	config.MaxYieldsPerMinute = -1
+	assert.ErrorContains(t, config.Validate(), "invalid max yields per minute")
+}
+
+func TestConfig_WebhookURLFromEnvironment(t *testing.T) {
+	env := map[string]string{WebhookURLEnv: "http://dashboard.example.com/hooks"}
+	config := DefaultConfig()
+	require.NoError(t, config.ApplyEnvironment(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}))
+	assert.Equal(t, "http://dashboard.example.com/hooks", config.WebhookURL)
+	require.NoError(t, config.Validate())
+
+	config.WebhookURL = "dashboard.example.com/hooks"
+	config.WebhookTimeout = -time.Second
+	err := config.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid webhook URL")
+	assert.Contains(t, err.Error(), "invalid webhook timeout")
+}
+
+func TestWriteDefaultConfig_RoundTrip(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteDefaultConfig(&out))
+	assert.Contains(t, out.String(), "# TCP port for the Soviet server\nport: 53646\n")
+	assert.Contains(t, out.String(), "barrel_ttl: 0s\n")
+
+	config, err := LoadConfig(writeConfigFile(t, out.String()))
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	defaults := DefaultConfig()
+	assert.Equal(t, defaults.Port, config.Port)
+	assert.Equal(t, defaults.BarrelTTL, config.BarrelTTL)
+	assert.Equal(t, defaults.Persistence, config.Persistence)
+	assert.Empty(t, config.Pipeline)
+	assert.Empty(t, config.Groups)
+}
+
+func TestConfigValidate_ReportsAllProblems(t *testing.T) {
+	config := DefaultConfig()
+	config.Port = 70000
+	config.MaxRetries = -1
+
+	err := config.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid port: 70000")
+	assert.Contains(t, err.Error(), "invalid retry policy")
+}
+
+func TestConfigValidate_EventsPortMustBeDistinct(t *testing.T) {
+	config := DefaultConfig()
+	config.HTTPPort = 8080
+	config.EventsPort = 8080
+
+	err := config.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "events port 8080 must differ")
+
+	config.EventsPort = 8081
+	assert.NoError(t, config.Validate())
+}
+
+func TestConfigValidate_RepoFile(t *testing.T) {
+	config := DefaultConfig()
+	config.RepoFile = filepath.Join(t.TempDir(), "agents.json")
+	assert.NoError(t, config.Validate(), "a missing repo file starts empty")
+
+	require.NoError(t, os.WriteFile(config.RepoFile, []byte("not json"), 0o600))
+	err := config.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid repo file")
+}
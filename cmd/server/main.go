@@ -2,27 +2,78 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/webhook"
 	"github.com/lonegunmanb/agentfarm/pkg/domain"
 )
 
 const (
-	defaultPort = 53646
+	defaultPort            = 53646
+	defaultMessageBurst    = 10
+	defaultBreakerCooldown = 5 * time.Minute
+	serverVersion          = "4.0"
 )
 
 func main() {
 	// Parse command line flags
 	var (
-		port        = flag.Int("port", defaultPort, "TCP port for the Soviet server")
-		debugMode   = flag.Bool("debug", false, "Enable debug logging")
-		showHelp    = flag.Bool("help", false, "Show help message")
-		showVersion = flag.Bool("version", false, "Show version information")
+		configFile    = flag.String("config", "", "YAML config file; flags given on the command line override it")
+		port          = flag.Int("port", defaultPort, "TCP port for the Soviet server")
+		debugMode     = flag.Bool("debug", false, "Enable debug logging")
+		maxYieldDepth = flag.Int("max-yield-depth", 0, "Maximum consecutive agent-to-agent yields before the barrel must return to people (0 = unlimited)")
+		yieldRate     = flag.Int("max-yields-per-minute", 0, "Yields each role may make per minute; the people are exempt (0 = unlimited)")
+		barrelTTL     = flag.Duration("barrel-ttl", 0, "Reclaim the barrel for the people after an agent holds it this long (0 = never)")
+		pipelineRoles = flag.String("pipeline", "", "Ordered, comma-separated roles the barrel travels through (e.g. developer,tester,reviewer)")
+		requiredRoles = flag.String("required-roles", "", "Comma-separated roles the workflow expects to be online (e.g. developer,tester)")
+		maxRetries    = flag.Int("max-retries", 0, "Requeue work reported as failed up to this many times (0 = never)")
+		breakerLimit  = flag.Int("breaker-threshold", 0, "Bench a role after this many consecutive failed yields from it (0 = never)")
+		breakerCool   = flag.Duration("breaker-cooldown", defaultBreakerCooldown, "How long a benched role is skipped by routing")
+		retryFallback = flag.String("retry-fallback", "", "Comma-separated failing=fallback role pairs used for retries (e.g. developer=senior-developer)")
+		httpPort      = flag.Int("http-port", 0, "Serve a JSON status snapshot at /status.json on this port (0 = disabled)")
+		eventsPort    = flag.Int("events-port", 0, "Stream barrel transfers and status changes as server-sent events at /events on this port (0 = disabled)")
+		eventsPayload = flag.Bool("events-payloads", false, "Include yield payloads in transfer events on the event stream")
+		reconnect     = flag.Duration("reconnect-grace", 0, "Return the barrel to people when its holder stays disconnected this long (0 = wait for reconnect)")
+		heartbeatTTL  = flag.Duration("heartbeat-timeout", 0, "Mark agents disconnected when no PING arrives from them for this long (0 = not checked)")
+		maxMessageAge = flag.Duration("max-message-age", 0, "Reject yields whose sent_at is older than this as stale (0 = accept any age)")
+		clockSkew     = flag.Duration("clock-skew-tolerance", 0, "Accept client timestamps up to this far ahead of the server clock (0 = not checked)")
+		redact        = flag.Bool("redact-payloads", false, "Replace yield payloads and metadata values in logs with their length and hash")
+		maxConnBytes  = flag.Int64("max-conn-bytes", 0, "Disconnect a connection that sends more than this many bytes within -conn-bytes-window (0 = unlimited)")
+		connWindow    = flag.Duration("conn-bytes-window", time.Minute, "Rolling window of the per-connection byte budget")
+		messageRate   = flag.Float64("message-rate", 0, "Messages per second each connection may send (0 = unlimited)")
+		messageBurst  = flag.Int("message-burst", defaultMessageBurst, "Messages a connection may send in a burst before -message-rate applies")
+		maxStrikes    = flag.Int("max-strikes", 0, "Disconnect a connection after this many malformed messages in a row (0 = never)")
+		strikeBlock   = flag.Duration("strike-block", 0, "Refuse new connections from the address of a quarantined connection for this long (0 = not blocked)")
+		inboxDepth    = flag.Int("inbox-depth", tcp.DefaultInboxDepth, "Activations kept per disconnected role and reported as its queue depth (0 = keep none)")
+		fieldNaming   = flag.String("field-naming", tcp.FieldNamingSnake, "JSON field names of connections that do not choose them in HELLO: snake_case or camelCase")
+		framing       = flag.String("framing", tcp.FramingNewline, "Framing of JSON messages: newline or length (4-byte big-endian length prefix)")
+		replicaOf     = flag.String("replica-of", "", "Run as a read-only replica of the primary whose event stream is at this address (e.g. primary:8081)")
+		peopleIdle    = flag.Duration("people-idle-timeout", 0, "Disconnect people connections idle in both directions for this long (0 = never)")
+		replayWindow  = flag.Duration("replay-window", 0, "Require privileged people commands to carry a fresh nonce sent within this window (0 = not checked)")
+		closeLinger   = flag.Duration("close-linger", tcp.DefaultCloseLinger, "Wait this long for the last message to reach a client before closing its connection (0 = close immediately)")
+		initialMsg    = flag.String("initial-message", "", "First message of the barrel, e.g. the task the collective is started for")
+		retention     = flag.Duration("history-retention", 0, "Drop barrel transfer records older than this from the history (0 = keep all)")
+		archive       = flag.Bool("history-archive", false, "Log transfer records dropped by -history-retention first")
+		defaultYield  = flag.String("default-yield-message", "", "Payload delivered to an agent when a yield carries no message")
+		persistence   = flag.String("persistence", "strict", "Repository failure policy: strict fails registration, best-effort keeps agents in memory")
+		repoFile      = flag.String("repo-file", "", "Keep registered agents in this JSON file so they survive a restart (empty = in memory only)")
+		barrelFile    = flag.String("barrel-file", "", "Save the barrel to this file after every change and reload it on startup (empty = memory only)")
+		webhookURL    = flag.String("webhook-url", "", "POST every domain event as JSON to this URL (empty = disabled)")
+		hookTimeout   = flag.Duration("webhook-timeout", webhook.DefaultTimeout, "Time allowed for one webhook delivery attempt")
+		ackTimeout    = flag.Duration("activation-ack-timeout", 0, "Require agents to acknowledge activation within this time or return the barrel to people (0 = no acknowledgment)")
+		generate      = flag.Bool("generate-config", false, "Print a fully commented default config file and exit")
+		validate      = flag.String("validate-config", "", "Check a YAML config file for problems and exit")
+		describe      = flag.Bool("describe-protocol", false, "Print a JSON description of every protocol message and exit")
+		showHelp      = flag.Bool("help", false, "Show help message")
+		showVersion   = flag.Bool("version", false, "Show version information")
 	)
 	flag.Parse()
 
@@ -36,73 +87,201 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Create logger
-	logger := domain.NewConsoleLogger(*debugMode)
-	logger.Info("Starting Agent Farm Soviet Server", map[string]interface{}{
-		"port":  *port,
-		"debug": *debugMode,
-	})
+	if *generate {
+		if err := WriteDefaultConfig(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *validate != "" {
+		os.Exit(validateConfigFile(*validate))
+	}
+
+	if *describe {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(tcp.DescribeProtocol()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
-	// Create core domain components
-	repository := domain.NewMemoryAgentRepository()
-	barrel := domain.NewBarrelOfGun() // Initially held by the people
-	soviet := domain.NewSovietState(repository)
-	
-	// Set the barrel in the soviet state
-	if err := soviet.SetBarrel(barrel); err != nil {
-		logger.Error("Failed to set barrel in soviet state", map[string]interface{}{
+	config := DefaultConfig()
+	if *configFile != "" {
+		loaded, err := LoadConfig(*configFile)
+		if err != nil {
+			domain.NewConsoleLogger(*debugMode).Error("Invalid config file", map[string]interface{}{
+				"error": err.Error(),
+			})
+			os.Exit(1)
+		}
+		config = loaded
+	}
+	if err := config.ApplyEnvironment(os.LookupEnv); err != nil {
+		domain.NewConsoleLogger(*debugMode).Error("Invalid environment", map[string]interface{}{
 			"error": err.Error(),
 		})
 		os.Exit(1)
 	}
 
-	// Create message sender
-	sender := tcp.NewTCPMessageSender()
-
-	// Create TCP server adapter
-	server := tcp.NewTCPServer(soviet, soviet, sender, logger, *port)
+	// Flags given on the command line override the config file
+	var flagErr error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			config.Port = *port
+		case "debug":
+			config.Debug = *debugMode
+		case "max-yield-depth":
+			config.MaxYieldDepth = *maxYieldDepth
+		case "max-yields-per-minute":
+			config.MaxYieldsPerMinute = *yieldRate
+		case "barrel-ttl":
+			config.BarrelTTL = *barrelTTL
+		case "pipeline":
+			config.Pipeline = splitRoles(*pipelineRoles)
+		case "required-roles":
+			config.RequiredRoles = splitRoles(*requiredRoles)
+		case "max-retries":
+			config.MaxRetries = *maxRetries
+		case "breaker-threshold":
+			config.BreakerThreshold = *breakerLimit
+		case "breaker-cooldown":
+			config.BreakerCooldown = *breakerCool
+		case "retry-fallback":
+			config.RetryFallbacks, flagErr = parseRetryFallbacks(*retryFallback)
+		case "http-port":
+			config.HTTPPort = *httpPort
+		case "events-port":
+			config.EventsPort = *eventsPort
+		case "events-payloads":
+			config.EventsPayloads = *eventsPayload
+		case "reconnect-grace":
+			config.ReconnectGracePeriod = *reconnect
+		case "heartbeat-timeout":
+			config.HeartbeatTimeout = *heartbeatTTL
+		case "max-message-age":
+			config.MaxMessageAge = *maxMessageAge
+		case "clock-skew-tolerance":
+			config.ClockSkewTolerance = *clockSkew
+		case "redact-payloads":
+			config.RedactPayloads = *redact
+		case "activation-ack-timeout":
+			config.ActivationAckTimeout = *ackTimeout
+		case "max-conn-bytes":
+			config.MaxConnBytes = *maxConnBytes
+		case "conn-bytes-window":
+			config.ConnBytesWindow = *connWindow
+		case "message-rate":
+			config.MessageRate = *messageRate
+		case "message-burst":
+			config.MessageBurst = *messageBurst
+		case "max-strikes":
+			config.MaxStrikes = *maxStrikes
+		case "strike-block":
+			config.StrikeBlock = *strikeBlock
+		case "inbox-depth":
+			config.InboxDepth = *inboxDepth
+		case "field-naming":
+			config.FieldNaming = *fieldNaming
+		case "framing":
+			config.Framing = *framing
+		case "replica-of":
+			config.ReplicaOf = *replicaOf
+		case "people-idle-timeout":
+			config.PeopleIdleTimeout = *peopleIdle
+		case "replay-window":
+			config.ReplayWindow = *replayWindow
+		case "close-linger":
+			config.CloseLinger = *closeLinger
+		case "initial-message":
+			config.InitialMessage = *initialMsg
+		case "history-retention":
+			config.HistoryRetention = *retention
+		case "history-archive":
+			config.HistoryArchive = *archive
+		case "default-yield-message":
+			config.DefaultYieldMessage = *defaultYield
+		case "persistence":
+			config.Persistence = *persistence
+		case "repo-file":
+			config.RepoFile = *repoFile
+		case "barrel-file":
+			config.BarrelFile = *barrelFile
+		case "webhook-url":
+			config.WebhookURL = *webhookURL
+		case "webhook-timeout":
+			config.WebhookTimeout = *hookTimeout
+		}
+	})
+	config.Logger = domain.NewConsoleLogger(config.Debug)
 
-	// Set up graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if flagErr != nil {
+		config.Logger.Error("Invalid retry policy", map[string]interface{}{
+			"error": flagErr.Error(),
+		})
+		os.Exit(1)
+	}
 
 	// Handle shutdown signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Start the server
-	if err := server.Start(ctx); err != nil {
-		logger.Error("Failed to start server", map[string]interface{}{
+	if err := Run(ctx, config); err != nil {
+		config.Logger.Error("Failed to run server", map[string]interface{}{
 			"error": err.Error(),
 		})
 		os.Exit(1)
 	}
+}
 
-	logger.Info("Agent Farm Soviet Server is running", map[string]interface{}{
-		"port": *port,
-		"status": "ready_for_agents",
-	})
-	logger.Info("Connect Agent Comrades via TCP", map[string]interface{}{
-		"instructions": "Agents should connect to this port and register with their role",
-	})
-	logger.Info("People's representatives can connect via netcat", map[string]interface{}{
-		"example": fmt.Sprintf("nc localhost %d", *port),
-	})
+// validateConfigFile loads a config file, prints any problems and returns the exit code
+func validateConfigFile(path string) int {
+	config, err := LoadConfig(path)
+	if err == nil {
+		err = config.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config file %s is invalid:\n", path)
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+		}
+		return 1
+	}
+	fmt.Printf("Config file %s is valid\n", path)
+	return 0
+}
 
-	// Wait for shutdown signal
-	<-sigChan
-	logger.Info("Received shutdown signal, gracefully stopping server...")
+// splitRoles parses a comma-separated role list; an empty list yields nil
+func splitRoles(spec string) []string {
+	if spec == "" {
+		return nil
+	}
+	roles := strings.Split(spec, ",")
+	for i, role := range roles {
+		roles[i] = strings.TrimSpace(role)
+	}
+	return roles
+}
 
-	// Stop the server
-	if err := server.Stop(); err != nil {
-		logger.Error("Error stopping server", map[string]interface{}{
-			"error": err.Error(),
-		})
+// parseRetryFallbacks parses "failing=fallback" pairs separated by commas
+func parseRetryFallbacks(spec string) (map[string]string, error) {
+	fallbacks := make(map[string]string)
+	if strings.TrimSpace(spec) == "" {
+		return fallbacks, nil
 	}
 
-	logger.Info("Agent Farm Soviet Server stopped", map[string]interface{}{
-		"status": "shutdown_complete",
-	})
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid retry fallback %q, expected failing=fallback", pair)
+		}
+		fallbacks[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return fallbacks, nil
 }
 
 func showUsage() {
@@ -112,9 +291,97 @@ func showUsage() {
 	fmt.Printf("  %s [options]\n", os.Args[0])
 	fmt.Println()
 	fmt.Println("OPTIONS:")
+	fmt.Println("  -config file")
+	fmt.Println("\tYAML config file (including yield groups); flags given on the command line override it")
 	fmt.Printf("  -port int\n\tTCP port for the Soviet server (default: %d)\n", defaultPort)
 	fmt.Println("  -debug")
 	fmt.Println("\tEnable debug logging")
+	fmt.Println("  -max-yield-depth int")
+	fmt.Println("\tMaximum consecutive agent-to-agent yields before the barrel must return to people (default: 0, unlimited)")
+	fmt.Println("  -max-yields-per-minute int")
+	fmt.Println("\tYields each role may make per minute before further ones are rejected with RATE_LIMITED; the people are exempt (default: $AGENT_FARM_MAX_YIELDS_PER_MINUTE, else 0, unlimited)")
+	fmt.Println("  -barrel-ttl duration")
+	fmt.Println("\tReclaim the barrel for the people after an agent holds it this long (default: $AGENT_FARM_BARREL_TIMEOUT, else 0, never)")
+	fmt.Println("  -pipeline roles")
+	fmt.Println("\tOrdered, comma-separated roles the barrel travels through (e.g. developer,tester,reviewer)")
+	fmt.Println("  -required-roles roles")
+	fmt.Println("\tComma-separated roles the workflow expects to be online; `people needed` lists the gaps (e.g. developer,tester)")
+	fmt.Println("  -max-retries int")
+	fmt.Println("\tRequeue work reported as failed up to this many times (default: 0, never)")
+	fmt.Println("  -breaker-threshold int")
+	fmt.Println("\tBench a role after this many consecutive failed yields; benched roles are skipped by routing and rejected as yield targets (default: 0, never)")
+	fmt.Println("  -breaker-cooldown duration")
+	fmt.Printf("\tHow long a benched role stays benched; a successful yield lifts the bench (default: %s)\n", defaultBreakerCooldown)
+	fmt.Println("  -retry-fallback pairs")
+	fmt.Println("\tComma-separated failing=fallback role pairs used for retries (e.g. developer=senior-developer)")
+	fmt.Println("  -http-port int")
+	fmt.Println("\tServe a JSON status snapshot at /status.json on this port (default: 0, disabled)")
+	fmt.Println("  -events-port int")
+	fmt.Println("\tStream barrel transfers and status changes as server-sent events at /events on this port (default: 0, disabled)")
+	fmt.Println("  -events-payloads")
+	fmt.Println("\tInclude yield payloads in transfer events; anyone who can reach -events-port can then read the work handed out")
+	fmt.Println("  -activation-ack-timeout duration")
+	fmt.Println("\tRequire agents to acknowledge activation within this time or return the barrel to people (default: 0, no acknowledgment)")
+	fmt.Println("  -reconnect-grace duration")
+	fmt.Println("\tReturn the barrel to people when its holder stays disconnected this long (default: 0, wait for reconnect)")
+	fmt.Println("  -heartbeat-timeout duration")
+	fmt.Println("\tMark agents disconnected when no PING arrives from them for this long; a disconnected holder then falls under -reconnect-grace (default: 0, not checked)")
+	fmt.Println("  -max-message-age duration")
+	fmt.Println("\tReject yields whose sent_at is older than this as stale (default: 0, accept any age)")
+	fmt.Println("  -clock-skew-tolerance duration")
+	fmt.Println("\tAccept client timestamps up to this far ahead of the server clock; later ones are rejected with CLOCK_SKEW and stale checks allow the same slack (default: 0, not checked)")
+	fmt.Println("  -max-conn-bytes int")
+	fmt.Println("\tDisconnect a connection that sends more than this many bytes within -conn-bytes-window (default: 0, unlimited)")
+	fmt.Println("  -conn-bytes-window duration")
+	fmt.Println("\tRolling window of the per-connection byte budget (default: 1m)")
+	fmt.Println("  -message-rate float")
+	fmt.Println("\tMessages per second each connection may send; excess messages get a RATE_LIMITED error (default: 0, unlimited)")
+	fmt.Println("  -message-burst int")
+	fmt.Printf("\tMessages a connection may send in a burst before -message-rate applies (default: %d)\n", defaultMessageBurst)
+	fmt.Println("  -max-strikes int")
+	fmt.Println("\tDisconnect a connection with a QUARANTINED error after this many malformed messages in a row; a well-formed message clears its strikes (default: 0, never)")
+	fmt.Println("  -strike-block duration")
+	fmt.Println("\tRefuse new connections from the address of a quarantined connection for this long (default: 0, not blocked)")
+	fmt.Println("  -inbox-depth int")
+	fmt.Printf("\tActivations kept per disconnected role and reported as its queue depth in status; the oldest are dropped beyond it and the rest are drained when the role registers again (default: %d)\n", tcp.DefaultInboxDepth)
+	fmt.Println("  -field-naming convention")
+	fmt.Printf("\tJSON field names of connections that do not choose them with field_naming in HELLO: %s or %s; clients may send either (default: %s)\n", tcp.FieldNamingSnake, tcp.FieldNamingCamel, tcp.FieldNamingSnake)
+	fmt.Println("  -framing mode")
+	fmt.Printf("\tFraming of JSON messages: %s, or %s for a 4-byte big-endian length prefix before each message; agents and people must use the same -framing (default: %s)\n", tcp.FramingNewline, tcp.FramingLength, tcp.FramingNewline)
+	fmt.Println("  -replica-of addr")
+	fmt.Println("\tRun as a read-only replica of the primary whose event stream (-events-port) is at this address, e.g. primary:8081; queries are answered from the mirrored state and everything else is rejected with READ_ONLY")
+	fmt.Println("  -people-idle-timeout duration")
+	fmt.Println("\tDisconnect people connections idle in both directions for this long (default: 0, never)")
+	fmt.Println("  -replay-window duration")
+	fmt.Println("\tRequire privileged people commands (yield, set-state, set-ttl, reassign) to carry a fresh nonce sent within this window; reused nonces are rejected (default: 0, not checked)")
+	fmt.Println("  -close-linger duration")
+	fmt.Printf("\tWait this long for the last message, such as a final ERROR, to reach a client before closing its connection (default: %s)\n", tcp.DefaultCloseLinger)
+	fmt.Println("  -initial-message text")
+	fmt.Printf("\tFirst message of the barrel and of its transfer history, e.g. the task the collective is started for (default: %q)\n", domain.DefaultInitialMessage)
+	fmt.Println("  -history-retention duration")
+	fmt.Println("\tDrop barrel transfer records older than this from the in-memory history; the latest transfer is always kept and receipts still chain (default: 0, keep all)")
+	fmt.Println("  -history-archive")
+	fmt.Println("\tLog each transfer record dropped by -history-retention before it is dropped, so a log file keeps the full audit trail")
+	fmt.Println("  -default-yield-message text")
+	fmt.Println("\tPayload delivered to an agent when a yield carries no message (per-role defaults: config file)")
+	fmt.Println("  -redact-payloads")
+	fmt.Println("\tReplace yield payloads and metadata values in logs with their length and hash")
+	fmt.Println("  -persistence policy")
+	fmt.Println("\tRepository failure policy: strict fails registration, best-effort keeps agents in memory (default: strict)")
+	fmt.Println("  -repo-file path")
+	fmt.Println("\tKeep registered agents in this JSON file, rewritten atomically on every change, so they survive a restart; reloaded agents stay disconnected until they register again (default: in memory only)")
+	fmt.Println("  -barrel-file path")
+	fmt.Println("\tSave the barrel with its holder, transfer history and TTL deadline to this file after every change and reload it on startup, so an agent that held the barrel resumes its work after a restart; a saved TTL, e.g. one changed by set-ttl, replaces -barrel-ttl (default: memory only)")
+	fmt.Println("  -webhook-url url")
+	fmt.Println("\tPOST every agent registration, deregistration and barrel transfer as JSON to this URL, retrying failed deliveries (default: $AGENT_FARM_WEBHOOK_URL, else disabled)")
+	fmt.Println("  -webhook-timeout duration")
+	fmt.Println("\tTime allowed for one webhook delivery attempt (default: 5s)")
+	fmt.Println("  -generate-config")
+	fmt.Println("\tPrint a fully commented default config file and exit")
+	fmt.Println("  -validate-config file")
+	fmt.Println("\tCheck a YAML config file for problems and exit")
+	fmt.Println("  -describe-protocol")
+	fmt.Println("\tPrint a JSON description of every protocol message, its direction and fields, and exit")
 	fmt.Println("  -help")
 	fmt.Println("\tShow this help message")
 	fmt.Println("  -version")
@@ -134,13 +401,17 @@ func showUsage() {
 	fmt.Printf("  # Start server on custom port with debug logging\n")
 	fmt.Printf("  %s -port 8080 -debug\n", os.Args[0])
 	fmt.Println()
+	fmt.Printf("  # Generate a config file, edit it, then check it\n")
+	fmt.Printf("  %s -generate-config > config.yaml\n", os.Args[0])
+	fmt.Printf("  %s -validate-config config.yaml\n", os.Args[0])
+	fmt.Println()
 	fmt.Printf("  # Connect as People's representative\n")
 	fmt.Printf("  nc localhost %d\n", defaultPort)
 }
 
 func showVersionInfo() {
 	fmt.Println("Agent Farm Soviet Server")
-	fmt.Println("Version: 4.0")
+	fmt.Printf("Version: %s\n", serverVersion)
 	fmt.Println("Date: August 20, 2025")
 	fmt.Println("Revolutionary Multi-agent Control Protocol")
 }
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/replica"
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/web"
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/webhook"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// Config holds everything needed to run the Soviet server
+// It can be loaded from a YAML file; durations use Go syntax such as "30s" or "5m"
+type Config struct {
+	Port                 int                 `yaml:"port"`
+	HTTPPort             int                 `yaml:"http_port"`
+	EventsPort           int                 `yaml:"events_port"`
+	EventsPayloads       bool                `yaml:"events_payloads"`
+	Debug                bool                `yaml:"debug"`
+	MaxYieldDepth        int                 `yaml:"max_yield_depth"`
+	MaxYieldsPerMinute   int                 `yaml:"max_yields_per_minute"`
+	BarrelTTL            time.Duration       `yaml:"barrel_ttl"`
+	Pipeline             []string            `yaml:"pipeline"`
+	RequiredRoles        []string            `yaml:"required_roles"`
+	MaxRetries           int                 `yaml:"max_retries"`
+	RetryFallbacks       map[string]string   `yaml:"retry_fallbacks"`
+	BreakerThreshold     int                 `yaml:"breaker_threshold"`
+	BreakerCooldown      time.Duration       `yaml:"breaker_cooldown"`
+	ActivationAckTimeout time.Duration       `yaml:"activation_ack_timeout"`
+	RedactPayloads       bool                `yaml:"redact_payloads"`
+	ReconnectGracePeriod time.Duration       `yaml:"reconnect_grace_period"`
+	HeartbeatTimeout     time.Duration       `yaml:"heartbeat_timeout"`
+	MaxMessageAge        time.Duration       `yaml:"max_message_age"`
+	ClockSkewTolerance   time.Duration       `yaml:"clock_skew_tolerance"`
+	MaxConnBytes         int64               `yaml:"max_conn_bytes"`
+	ConnBytesWindow      time.Duration       `yaml:"conn_bytes_window"`
+	PeopleIdleTimeout    time.Duration       `yaml:"people_idle_timeout"`
+	ReplayWindow         time.Duration       `yaml:"replay_window"`
+	CloseLinger          time.Duration       `yaml:"close_linger"`
+	MessageRate          float64             `yaml:"message_rate"`
+	MessageBurst         int                 `yaml:"message_burst"`
+	MaxStrikes           int                 `yaml:"max_strikes"`
+	StrikeBlock          time.Duration       `yaml:"strike_block"`
+	InboxDepth           int                 `yaml:"inbox_depth"`
+	ReplicaOf            string              `yaml:"replica_of"`
+	FieldNaming          string              `yaml:"field_naming"`
+	Framing              string              `yaml:"framing"`
+	InitialMessage       string              `yaml:"initial_message"`
+	HistoryRetention     time.Duration       `yaml:"history_retention"`
+	HistoryArchive       bool                `yaml:"history_archive"`
+	DefaultYieldMessage  string              `yaml:"default_yield_message"`
+	RoleYieldMessages    map[string]string   `yaml:"role_yield_messages"` // target role -> default payload
+	ReservedRoles        map[string]string   `yaml:"reserved_roles"`      // role -> token agents must present to register as it
+	Groups               map[string][]string `yaml:"groups"`              // group name -> member roles in priority order
+	WeightedGroups       []string            `yaml:"weighted_groups"`     // groups sharing work by agent weight instead of priority
+	Persistence          string              `yaml:"persistence"`         // "strict" (default) or "best-effort"
+	RepoFile             string              `yaml:"repo_file"`           // JSON file keeping registered agents across restarts
+	BarrelFile           string              `yaml:"barrel_file"`         // file the barrel is saved to and reloaded from
+	WebhookURL           string              `yaml:"webhook_url"`         // endpoint domain events are POSTed to
+	WebhookTimeout       time.Duration       `yaml:"webhook_timeout"`     // time allowed for one webhook delivery attempt
+
+	// Logger overrides the console logger (optional)
+	Logger domain.Logger `yaml:"-"`
+
+	// OnReady is called with the TCP listen address once the server accepts connections (optional)
+	OnReady func(addr net.Addr) `yaml:"-"`
+}
+
+// Run starts the Soviet server and blocks until ctx is cancelled, then shuts it down
+// Configuration and startup errors are returned instead of exiting so the lifecycle can be tested
+func Run(ctx context.Context, config Config) error {
+	logger := config.Logger
+	if logger == nil {
+		logger = domain.NewConsoleLogger(config.Debug)
+	}
+	logger.Info("Starting Agent Farm Soviet Server", map[string]interface{}{
+		"port":  config.Port,
+		"debug": config.Debug,
+	})
+
+	// Deliver domain events to the optional webhook
+	var publisher domain.EventPublisher
+	var webhookPublisher *webhook.HTTPEventPublisher
+	if config.WebhookURL != "" {
+		created, err := webhook.NewHTTPEventPublisher(config.WebhookURL, config.WebhookTimeout, logger)
+		if err != nil {
+			return err
+		}
+		webhookPublisher, publisher = created, created
+	}
+
+	soviet, err := newSoviet(config, publisher)
+	if err != nil {
+		return err
+	}
+
+	// Never accept connections without a barrel to hand out
+	if soviet.GetBarrel() == nil {
+		return fmt.Errorf("soviet state has no barrel; refusing to accept connections")
+	}
+
+	if config.BarrelFile != "" {
+		if err := soviet.SetBarrelStore(domain.NewBarrelStore(config.BarrelFile, logger)); err != nil {
+			return err
+		}
+		logger.Info("Barrel is saved to file", map[string]interface{}{
+			"path":   config.BarrelFile,
+			"holder": soviet.GetBarrelStatus(),
+		})
+	}
+
+	// Create message sender
+	sender := tcp.NewTCPMessageSender()
+
+	// Create TCP server adapter
+	server := tcp.NewTCPServer(soviet, soviet, sender, logger, config.Port)
+	// SUBSCRIBE connections are pushed a status after every published domain event
+	soviet.AddPublisher(server.EventPublisher())
+	server.SetRedactPayloads(config.RedactPayloads)
+	server.SetVersion(serverVersion)
+	if err := server.SetByteBudget(config.MaxConnBytes, config.ConnBytesWindow); err != nil {
+		return fmt.Errorf("invalid connection byte budget: %w", err)
+	}
+	if err := server.SetMessageRateLimit(config.MessageRate, config.MessageBurst); err != nil {
+		return fmt.Errorf("invalid message rate limit: %w", err)
+	}
+	if err := server.SetQuarantine(config.MaxStrikes, config.StrikeBlock); err != nil {
+		return fmt.Errorf("invalid malformed message quarantine: %w", err)
+	}
+	if err := server.SetFieldNaming(config.FieldNaming); err != nil {
+		return fmt.Errorf("invalid field naming: %w", err)
+	}
+	if err := server.SetFraming(config.Framing); err != nil {
+		return fmt.Errorf("invalid framing: %w", err)
+	}
+	if err := server.SetInboxDepth(config.InboxDepth); err != nil {
+		return fmt.Errorf("invalid inbox depth: %w", err)
+	}
+	if err := server.SetPeopleIdleTimeout(config.PeopleIdleTimeout); err != nil {
+		return fmt.Errorf("invalid people idle timeout: %w", err)
+	}
+	if err := server.SetReplayWindow(config.ReplayWindow); err != nil {
+		return fmt.Errorf("invalid replay window: %w", err)
+	}
+	if err := server.SetCloseLinger(config.CloseLinger); err != nil {
+		return fmt.Errorf("invalid close linger: %w", err)
+	}
+	if err := server.SetClockSkewTolerance(config.ClockSkewTolerance); err != nil {
+		return fmt.Errorf("invalid clock skew tolerance: %w", err)
+	}
+
+	// Background goroutines of the adapters stop when this context is cancelled
+	serverCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// A replica mirrors its primary and only answers queries
+	if config.ReplicaOf != "" {
+		replicator, err := replica.NewReplicator(config.ReplicaOf, soviet, logger)
+		if err != nil {
+			return err
+		}
+		if err := replicator.Start(serverCtx); err != nil {
+			return err
+		}
+		server.SetReadOnly(true)
+		logger.Info("Running as a read-only replica", map[string]interface{}{
+			"primary": config.ReplicaOf,
+		})
+	}
+
+	if webhookPublisher != nil {
+		webhookPublisher.Start(serverCtx)
+		defer webhookPublisher.Stop()
+	}
+
+	// Start the server
+	if err := server.Start(serverCtx); err != nil {
+		return err
+	}
+
+	// Start the optional HTTP status endpoint
+	var statusServer *web.StatusServer
+	if config.HTTPPort != 0 {
+		statusServer = web.NewStatusServer(soviet, soviet, logger, config.HTTPPort)
+		if err := statusServer.Start(serverCtx); err != nil {
+			_ = server.Stop()
+			return err
+		}
+	}
+
+	// Start the optional server-sent events stream
+	var eventServer *web.EventStreamServer
+	if config.EventsPort != 0 {
+		eventServer = web.NewEventStreamServer(soviet, logger, config.EventsPort)
+		eventServer.SetIncludePayloads(config.EventsPayloads)
+		if err := eventServer.Start(serverCtx); err != nil {
+			_ = server.Stop()
+			if statusServer != nil {
+				_ = statusServer.Stop()
+			}
+			return err
+		}
+	}
+
+	logger.Info("Agent Farm Soviet Server is running", map[string]interface{}{
+		"port":   config.Port,
+		"status": "ready_for_agents",
+	})
+	logger.Info("Connect Agent Comrades via TCP", map[string]interface{}{
+		"instructions": "Agents should connect to this port and register with their role",
+	})
+	logger.Info("People's representatives can connect via netcat", map[string]interface{}{
+		"example": fmt.Sprintf("nc localhost %d", config.Port),
+	})
+
+	if config.OnReady != nil {
+		config.OnReady(server.Addr())
+	}
+
+	// Wait for shutdown
+	<-ctx.Done()
+	logger.Info("Received shutdown signal, gracefully stopping server...")
+
+	// Stop the server
+	if err := server.Stop(); err != nil {
+		logger.Error("Error stopping server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if statusServer != nil {
+		if err := statusServer.Stop(); err != nil {
+			logger.Error("Error stopping HTTP status server", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if eventServer != nil {
+		if err := eventServer.Stop(); err != nil {
+			logger.Error("Error stopping HTTP event stream server", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	logger.Info("Agent Farm Soviet Server stopped", map[string]interface{}{
+		"status": "shutdown_complete",
+	})
+	return nil
+}
+
+// restoreBarrel restores the barrel saved to the barrel file, keeping the barrel held by the people
+// when nothing has been saved to it yet. The saved TTL replaces barrel_ttl, so a TTL the people
+// changed with set-ttl survives the restart and the holder's deadline stays where it was
+func restoreBarrel(soviet *domain.SovietState, path string) error {
+	snapshot, err := domain.NewBarrelStore(path, nil).Load()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := soviet.RestoreBarrel(snapshot); err != nil {
+		return fmt.Errorf("invalid barrel file %s: %w", path, err)
+	}
+	return nil
+}
+
+// newSoviet creates the core domain components and applies the configured policies
+// The publisher is optional and announces domain events
+func newSoviet(config Config, publisher domain.EventPublisher) (*domain.SovietState, error) {
+	var repository domain.AgentRepository = domain.NewMemoryAgentRepository()
+	if config.RepoFile != "" {
+		fileRepository, err := domain.NewFileAgentRepository(config.RepoFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repo file: %w", err)
+		}
+		repository = fileRepository
+	}
+	soviet := domain.NewSovietStateWithDependencies(repository, nil, nil, publisher)
+
+	// Set the barrel in the soviet state
+	if err := soviet.SetBarrel(domain.NewBarrelOfGunWithMessage(config.InitialMessage)); err != nil {
+		return nil, fmt.Errorf("failed to set barrel in soviet state: %w", err)
+	}
+
+	if err := soviet.SetMaxYieldChainDepth(config.MaxYieldDepth); err != nil {
+		return nil, fmt.Errorf("invalid maximum yield chain depth: %w", err)
+	}
+
+	if err := soviet.SetMaxYieldsPerMinute(config.MaxYieldsPerMinute); err != nil {
+		return nil, fmt.Errorf("invalid max yields per minute: %w", err)
+	}
+
+	if err := soviet.SetBarrelTTL(config.BarrelTTL); err != nil {
+		return nil, fmt.Errorf("invalid barrel TTL: %w", err)
+	}
+
+	if config.BarrelFile != "" {
+		if err := restoreBarrel(soviet, config.BarrelFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := soviet.SetHistoryRetention(config.HistoryRetention, config.HistoryArchive); err != nil {
+		return nil, fmt.Errorf("invalid history retention: %w", err)
+	}
+
+	if len(config.Pipeline) > 0 {
+		pipeline, err := domain.NewPipeline(config.Pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pipeline: %w", err)
+		}
+		soviet.SetPipeline(pipeline)
+	}
+
+	if err := soviet.SetRequiredRoles(config.RequiredRoles); err != nil {
+		return nil, fmt.Errorf("invalid required roles: %w", err)
+	}
+
+	if err := soviet.SetRetryPolicy(config.MaxRetries, config.RetryFallbacks); err != nil {
+		return nil, fmt.Errorf("invalid retry policy: %w", err)
+	}
+
+	if err := soviet.SetCircuitBreaker(config.BreakerThreshold, config.BreakerCooldown); err != nil {
+		return nil, fmt.Errorf("invalid circuit breaker: %w", err)
+	}
+
+	if err := soviet.SetActivationAckTimeout(config.ActivationAckTimeout); err != nil {
+		return nil, fmt.Errorf("invalid activation ack timeout: %w", err)
+	}
+
+	// Apply groups in name order so configuration errors are reported deterministically
+	groupNames := make([]string, 0, len(config.Groups))
+	for name := range config.Groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		if err := soviet.SetGroup(name, config.Groups[name]); err != nil {
+			return nil, fmt.Errorf("invalid group: %w", err)
+		}
+	}
+	for _, name := range config.WeightedGroups {
+		if err := soviet.SetGroupWeighted(name, true); err != nil {
+			return nil, fmt.Errorf("invalid weighted group: %w", err)
+		}
+	}
+
+	if err := soviet.SetReconnectGracePeriod(config.ReconnectGracePeriod); err != nil {
+		return nil, fmt.Errorf("invalid reconnect grace period: %w", err)
+	}
+
+	if err := soviet.SetHeartbeatTimeout(config.HeartbeatTimeout); err != nil {
+		return nil, fmt.Errorf("invalid heartbeat timeout: %w", err)
+	}
+
+	if err := soviet.SetMaxMessageAge(config.MaxMessageAge); err != nil {
+		return nil, fmt.Errorf("invalid max message age: %w", err)
+	}
+
+	if err := soviet.SetClockSkewTolerance(config.ClockSkewTolerance); err != nil {
+		return nil, fmt.Errorf("invalid clock skew tolerance: %w", err)
+	}
+
+	if config.Persistence != "" {
+		policy, err := domain.ParsePersistencePolicy(config.Persistence)
+		if err != nil {
+			return nil, fmt.Errorf("invalid persistence policy: %w", err)
+		}
+		if err := soviet.SetPersistencePolicy(policy); err != nil {
+			return nil, fmt.Errorf("invalid persistence policy: %w", err)
+		}
+	}
+
+	// Reserve roles in name order so configuration errors are reported deterministically
+	reservedRoles := make([]string, 0, len(config.ReservedRoles))
+	for role := range config.ReservedRoles {
+		reservedRoles = append(reservedRoles, role)
+	}
+	sort.Strings(reservedRoles)
+	for _, role := range reservedRoles {
+		if err := soviet.ReserveRole(role, config.ReservedRoles[role]); err != nil {
+			return nil, fmt.Errorf("invalid reserved role: %w", err)
+		}
+	}
+
+	if err := soviet.SetDefaultYieldMessages(config.DefaultYieldMessage, config.RoleYieldMessages); err != nil {
+		return nil, fmt.Errorf("invalid default yield message: %w", err)
+	}
+
+	soviet.SetRedactPayloads(config.RedactPayloads)
+	return soviet, nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+	"github.com/lonegunmanb/agentfarm/pkg/mocks"
+)
+
+// testClient is a minimal line-based JSON client for the server protocol
+type testClient struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+func dialTestClient(t *testing.T, addr net.Addr) *testClient {
+	conn, err := net.DialTimeout("tcp", addr.String(), time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &testClient{conn: conn, scanner: bufio.NewScanner(conn)}
+}
+
+func (c *testClient) send(t *testing.T, msg interface{}) {
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	_, err = c.conn.Write(append(data, '\n'))
+	require.NoError(t, err)
+}
+
+func (c *testClient) receive(t *testing.T, msg interface{}) {
+	require.NoError(t, c.conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	require.True(t, c.scanner.Scan(), "expected a message from the server")
+	require.NoError(t, json.Unmarshal(c.scanner.Bytes(), msg))
+}
+
+func TestRun_WorkflowAndCleanShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ready := make(chan net.Addr, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, Config{
+			Port:    0,
+			Logger:  mocks.NewMockLogger(),
+			OnReady: func(addr net.Addr) { ready <- addr },
+		})
+	}()
+
+	var addr net.Addr
+	select {
+	case addr = <-ready:
+	case err := <-done:
+		t.Fatalf("server exited before becoming ready: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not become ready")
+	}
+
+	// An agent registers
+	agent := dialTestClient(t, addr)
+	agent.send(t, tcp.RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}})
+	var ack tcp.AckRegisterMessage
+	agent.receive(t, &ack)
+	assert.Equal(t, "success", ack.Status)
+
+	// The people hand it the barrel
+	people := dialTestClient(t, addr)
+	people.send(t, tcp.YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Build it"})
+	var yieldAck tcp.YieldAckMessage
+	people.receive(t, &yieldAck)
+	assert.Equal(t, "ACK_YIELD", yieldAck.Type)
+	require.NotNil(t, yieldAck.Receipt)
+
+	var activate tcp.ActivateMessage
+	agent.receive(t, &activate)
+	assert.Equal(t, "ACTIVATE", activate.Type)
+	assert.Equal(t, "Build it", activate.Payload)
+	require.NotNil(t, activate.Receipt)
+	assert.Equal(t, yieldAck.Receipt.Hash, activate.Receipt.Hash)
+
+	people.send(t, tcp.QueryMessage{Type: "QUERY_STATUS"})
+	var status tcp.StatusMessage
+	people.receive(t, &status)
+	assert.Equal(t, "developer", status.BarrelHolder)
+
+	// Cancelling the context shuts the server down cleanly
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	_, err := net.DialTimeout("tcp", addr.String(), 200*time.Millisecond)
+	assert.Error(t, err, "listener should be closed after shutdown")
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	err := Run(context.Background(), Config{
+		Logger:   mocks.NewMockLogger(),
+		Pipeline: []string{"developer", "developer"},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid pipeline")
+}
+
+func TestRun_BarrelFileResumesHolderAfterRestart(t *testing.T) {
+	barrelFile := filepath.Join(t.TempDir(), "barrel.json")
+	start := func() (net.Addr, func()) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ready := make(chan net.Addr, 1)
+		done := make(chan error, 1)
+		go func() {
+			done <- Run(ctx, Config{
+				Logger:     mocks.NewMockLogger(),
+				BarrelFile: barrelFile,
+				OnReady:    func(addr net.Addr) { ready <- addr },
+			})
+		}()
+		select {
+		case addr := <-ready:
+			return addr, func() {
+				cancel()
+				require.NoError(t, <-done)
+			}
+		case err := <-done:
+			cancel()
+			t.Fatalf("server exited before becoming ready: %v", err)
+		case <-time.After(2 * time.Second):
+			cancel()
+			t.Fatal("server did not become ready")
+		}
+		return nil, nil
+	}
+
+	addr, stop := start()
+	agent := dialTestClient(t, addr)
+	agent.send(t, tcp.RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}})
+	var ack tcp.AckRegisterMessage
+	agent.receive(t, &ack)
+	people := dialTestClient(t, addr)
+	people.send(t, tcp.YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Build it"})
+	var yieldAck tcp.YieldAckMessage
+	people.receive(t, &yieldAck)
+	var activate tcp.ActivateMessage
+	agent.receive(t, &activate)
+	stop()
+
+	// The developer still holds the barrel after the restart and is told to resume its work
+	addr, stop = start()
+	defer stop()
+	agent = dialTestClient(t, addr)
+	agent.send(t, tcp.RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}})
+	agent.receive(t, &ack)
+	assert.Equal(t, "success", ack.Status)
+	agent.receive(t, &activate)
+	assert.Equal(t, "ACTIVATE", activate.Type)
+	assert.Equal(t, "Build it", activate.Payload)
+	assert.Equal(t, "people", activate.FromRole)
+
+	people = dialTestClient(t, addr)
+	people.send(t, tcp.QueryHistoryMessage{Type: "QUERY_HISTORY"})
+	var history tcp.HistoryMessage
+	people.receive(t, &history)
+	require.Len(t, history.Transfers, 2)
+	assert.Equal(t, yieldAck.Receipt.Hash, history.Transfers[1].Receipt.Hash)
+}
+
+func TestNewSoviet_BarrelFileKeepsTTLChangedByPeople(t *testing.T) {
+	barrelFile := filepath.Join(t.TempDir(), "barrel.json")
+	config := Config{BarrelFile: barrelFile, BarrelTTL: time.Minute}
+	soviet, err := newSoviet(config, nil)
+	require.NoError(t, err)
+	require.NoError(t, soviet.SetBarrelStore(domain.NewBarrelStore(barrelFile, nil)))
+	_, _, _, err = soviet.RegisterAgent(domain.NewAgentComrade("developer", []string{"coding"}))
+	require.NoError(t, err)
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("people", "developer", "Build it")))
+	require.NoError(t, soviet.SetBarrelTTL(30*time.Minute))
+
+	// The restarted server keeps the people's TTL and the holder's deadline over barrel_ttl
+	restarted, err := newSoviet(config, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "developer", restarted.GetBarrelStatus())
+	assert.Equal(t, 30*time.Minute, restarted.BarrelTTL())
+	assert.True(t, soviet.BarrelDeadline().Equal(restarted.BarrelDeadline()))
+
+	require.NoError(t, os.WriteFile(barrelFile, []byte(`{"holder": "developer", "history": []}`), 0o600))
+	_, err = newSoviet(config, nil)
+	assert.ErrorContains(t, err, "invalid barrel file")
+}
+
+func TestRun_BarrelFileKeepsHolderDeadline(t *testing.T) {
+	barrelFile := filepath.Join(t.TempDir(), "barrel.json")
+	soviet, err := newSoviet(Config{BarrelFile: barrelFile}, nil)
+	require.NoError(t, err)
+	require.NoError(t, soviet.SetBarrelStore(domain.NewBarrelStore(barrelFile, nil)))
+	_, _, _, err = soviet.RegisterAgent(domain.NewAgentComrade("developer", []string{"coding"}))
+	require.NoError(t, err)
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("people", "developer", "Build it")))
+	require.NoError(t, soviet.SetBarrelTTL(time.Millisecond))
+
+	// The deadline passed while the server was down, so the sweep reclaims the barrel right away
+	ctx, cancel := context.WithCancel(context.Background())
+	ready := make(chan net.Addr, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, Config{
+			Logger:     mocks.NewMockLogger(),
+			BarrelFile: barrelFile,
+			OnReady:    func(addr net.Addr) { ready <- addr },
+		})
+	}()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+	var addr net.Addr
+	select {
+	case addr = <-ready:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not become ready")
+	}
+
+	people := dialTestClient(t, addr)
+	assert.Eventually(t, func() bool {
+		people.send(t, tcp.QueryMessage{Type: "QUERY_STATUS"})
+		var status tcp.StatusMessage
+		people.receive(t, &status)
+		return status.BarrelHolder == "people"
+	}, 5*time.Second, 100*time.Millisecond)
+
+	restored, err := domain.NewBarrelStore(barrelFile, nil).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "people", restored.Holder, "the reclaim is saved too")
+}
@@ -0,0 +1,39 @@
+// Command watch-barrel logs every barrel transfer of an Agent Farm server
+//
+// Start the server with an event stream, then point this program at it:
+//
+//	server -events-port 8081
+//	go run ./examples/watch-barrel -events http://localhost:8081
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/client"
+)
+
+func main() {
+	eventsURL := flag.String("events", "http://localhost:8081", "Base URL of the server's event stream")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	changes, err := client.NewClient(*eventsURL).WatchBarrel(ctx)
+	if err != nil {
+		log.Fatalf("Failed to watch the barrel: %v", err)
+	}
+
+	log.Printf("Watching barrel transfers at %s", *eventsURL)
+	for change := range changes {
+		log.Printf("#%d %s -> %s at %s", change.Sequence, change.FromRole, change.ToRole, change.Timestamp.Format(time.RFC3339))
+		if change.Message != "" {
+			log.Printf("    %s", change.Message)
+		}
+	}
+}
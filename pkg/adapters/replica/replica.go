@@ -0,0 +1,100 @@
+// Package replica keeps a read-only copy of a primary server's collective from its event stream
+package replica
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lonegunmanb/agentfarm/pkg/client"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// Replicator mirrors the status a primary publishes on its event stream into a local soviet
+// The soviet must only be read by others, e.g. through a TCP server set to read-only
+type Replicator struct {
+	primary *client.Client
+	soviet  *domain.SovietState
+	logger  domain.Logger
+}
+
+// PrimaryEventsURL turns a --replica-of address into the base URL of the primary's event stream
+// Both "host:port" and "http://host:port" are accepted
+func PrimaryEventsURL(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	parsed, err := url.Parse(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid primary address: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("invalid primary address %s: expected an http or https URL", addr)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("invalid primary address %s: no host", addr)
+	}
+	return strings.TrimSuffix(addr, "/"), nil
+}
+
+// NewReplicator creates a replicator following the event stream of the primary at primaryAddr
+// The primary must be started with an events port; see PrimaryEventsURL for accepted addresses
+func NewReplicator(primaryAddr string, soviet *domain.SovietState, logger domain.Logger) (*Replicator, error) {
+	eventsURL, err := PrimaryEventsURL(primaryAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &Replicator{
+		primary: client.NewClient(eventsURL),
+		soviet:  soviet,
+		logger:  logger,
+	}, nil
+}
+
+// Start subscribes to the primary and mirrors every status change until ctx is cancelled
+// An error is returned when the primary's event stream cannot be opened
+func (r *Replicator) Start(ctx context.Context) error {
+	changes, err := r.primary.WatchStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to primary: %w", err)
+	}
+	go func() {
+		for change := range changes {
+			r.apply(change)
+		}
+	}()
+	return nil
+}
+
+// apply mirrors one status change, logging changes that cannot be applied
+func (r *Replicator) apply(change client.StatusChange) {
+	status := domain.ReplicatedStatus{
+		BarrelHolder:    change.BarrelHolder,
+		AgentStates:     make(map[string]domain.AgentState, len(change.AgentStates)),
+		ConnectedAgents: change.ConnectedAgents,
+	}
+	for role, name := range change.AgentStates {
+		state, err := domain.ParseAgentState(name)
+		if err != nil {
+			r.logger.Warn("Ignoring status change from primary", map[string]interface{}{
+				"role":  role,
+				"error": err.Error(),
+			})
+			return
+		}
+		status.AgentStates[role] = state
+	}
+
+	if err := r.soviet.MirrorStatus(status); err != nil {
+		r.logger.Error("Failed to mirror primary status", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	r.logger.Debug("Mirrored primary status", map[string]interface{}{
+		"barrel_holder": status.BarrelHolder,
+		"agents":        len(status.AgentStates),
+	})
+}
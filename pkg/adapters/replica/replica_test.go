@@ -0,0 +1,121 @@
+package replica
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/tcp"
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/web"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+	"github.com/lonegunmanb/agentfarm/pkg/mocks"
+)
+
+func newSoviet(t *testing.T) *domain.SovietState {
+	t.Helper()
+	soviet := domain.NewSovietStateWithDependencies(
+		domain.NewMemoryAgentRepository(),
+		mocks.NewMockMessageSender(),
+		mocks.NewMockLogger(),
+		nil,
+	)
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	return soviet
+}
+
+// request sends one message to a TCP server and decodes the reply
+func request(t *testing.T, addr net.Addr, message, reply interface{}) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, json.NewEncoder(conn).Encode(message))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(line, reply))
+}
+
+func TestReplicator_MirrorsTransfersOfPrimary(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	primary := newSoviet(t)
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := primary.RegisterAgent(domain.NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+	events := web.NewEventStreamServer(primary, mocks.NewMockLogger(), 0)
+	require.NoError(t, events.Start(ctx))
+	defer events.Stop()
+	eventsServer := httptest.NewServer(events.Handler())
+	defer eventsServer.Close()
+
+	// The stream must end before the events server can close
+	replicaCtx, stopReplica := context.WithCancel(ctx)
+	defer stopReplica()
+	mirror := newSoviet(t)
+	replicator, err := NewReplicator(eventsServer.URL, mirror, mocks.NewMockLogger())
+	require.NoError(t, err)
+	require.NoError(t, replicator.Start(replicaCtx))
+
+	server := tcp.NewTCPServer(mirror, mirror, tcp.NewTCPMessageSender(), mocks.NewMockLogger(), 0)
+	server.SetReadOnly(true)
+	require.NoError(t, server.Start(replicaCtx))
+	defer server.Stop()
+
+	status := func() tcp.StatusMessage {
+		var status tcp.StatusMessage
+		request(t, server.Addr(), tcp.QueryMessage{Type: "QUERY_STATUS"}, &status)
+		return status
+	}
+	require.Eventually(t, func() bool {
+		return len(status().RegisteredAgents) == 2
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, primary.ProcessYield(domain.NewYieldMessage("people", "developer", "Build it")))
+	require.Eventually(t, func() bool {
+		return status().BarrelHolder == "developer"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, primary.ProcessYield(domain.NewYieldMessage("developer", "tester", "Test it")))
+	require.Eventually(t, func() bool {
+		current := status()
+		return current.BarrelHolder == "tester" && current.AgentStates["developer"] == "waiting"
+	}, 2*time.Second, 20*time.Millisecond)
+	transfer, ok := mirror.LastTransfer()
+	require.True(t, ok)
+	assert.Equal(t, "developer", transfer.FromRole)
+	assert.Equal(t, "tester", transfer.ToRole)
+
+	// The replica refuses to change the collective
+	var rejected tcp.ErrorMessage
+	request(t, server.Addr(), tcp.YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer"}, &rejected)
+	assert.Equal(t, tcp.ErrorCodeReadOnly, rejected.Code)
+	request(t, server.Addr(), tcp.RegisterMessage{Type: "REGISTER", Role: "reviewer"}, &rejected)
+	assert.Equal(t, tcp.ErrorCodeReadOnly, rejected.Code)
+	assert.Contains(t, rejected.Message, "read-only replica")
+	assert.Equal(t, "tester", status().BarrelHolder)
+}
+
+func TestPrimaryEventsURL(t *testing.T) {
+	for addr, expected := range map[string]string{
+		"primary:8081":          "http://primary:8081",
+		"http://primary:8081/":  "http://primary:8081",
+		"https://primary.local": "https://primary.local",
+	} {
+		actual, err := PrimaryEventsURL(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, expected, actual)
+	}
+	for _, addr := range []string{"ftp://primary:21", "http://"} {
+		_, err := PrimaryEventsURL(addr)
+		assert.Error(t, err, addr)
+	}
+}
@@ -0,0 +1,102 @@
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// errByteBudgetExceeded stops reading from a connection that sent too many bytes in the window
+var errByteBudgetExceeded = errors.New("connection exceeded its inbound byte budget")
+
+// byteSample records the bytes read by a single read call
+type byteSample struct {
+	at    time.Time
+	bytes int64
+}
+
+// byteBudget accounts the bytes read on a connection over a rolling time window
+// A limit of 0 only counts bytes and never rejects them
+type byteBudget struct {
+	mu       sync.Mutex
+	limit    int64
+	window   time.Duration
+	total    int64
+	inWindow int64
+	samples  []byteSample // oldest first, all within the window
+}
+
+// ValidateByteBudget checks a per-connection byte budget before it is applied
+func ValidateByteBudget(maxBytes int64, window time.Duration) error {
+	if maxBytes < 0 {
+		return fmt.Errorf("byte budget cannot be negative: %d", maxBytes)
+	}
+	if maxBytes > 0 && window <= 0 {
+		return fmt.Errorf("byte budget window must be positive, got %s", window)
+	}
+	return nil
+}
+
+// newByteBudget creates the accounting for one connection
+func newByteBudget(limit int64, window time.Duration) *byteBudget {
+	return &byteBudget{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// add records n bytes read at now and reports whether the connection is still within budget
+func (b *byteBudget) add(n int, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.total += int64(n)
+	if b.window <= 0 {
+		return true
+	}
+
+	b.expire(now)
+	if n > 0 {
+		b.samples = append(b.samples, byteSample{at: now, bytes: int64(n)})
+		b.inWindow += int64(n)
+	}
+	return b.limit <= 0 || b.inWindow <= b.limit
+}
+
+// counts returns the bytes read since the connection opened and within the current window
+func (b *byteBudget) counts(now time.Time) (total int64, inWindow int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(now)
+	return b.total, b.inWindow
+}
+
+// expire drops samples that fell out of the window (caller holds b.mu)
+func (b *byteBudget) expire(now time.Time) {
+	cutoff := now.Add(-b.window)
+	expired := 0
+	for expired < len(b.samples) && !b.samples[expired].at.After(cutoff) {
+		b.inWindow -= b.samples[expired].bytes
+		expired++
+	}
+	b.samples = b.samples[expired:]
+}
+
+// budgetReader counts every byte read from a connection against its budget
+type budgetReader struct {
+	reader io.Reader
+	budget *byteBudget
+	now    func() time.Time
+}
+
+// Read reads from the connection and fails once the budget is exceeded
+func (r *budgetReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if !r.budget.add(n, r.now()) {
+		return 0, errByteBudgetExceeded
+	}
+	return n, err
+}
@@ -0,0 +1,112 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestByteBudget_RollingWindow(t *testing.T) {
+	budget := newByteBudget(100, time.Minute)
+	start := time.Now()
+
+	assert.True(t, budget.add(60, start))
+	assert.True(t, budget.add(40, start.Add(30*time.Second)))
+	assert.False(t, budget.add(1, start.Add(45*time.Second)), "101 bytes within one minute exceeds the budget")
+
+	// The first 60 bytes fall out of the window
+	total, inWindow := budget.counts(start.Add(61 * time.Second))
+	assert.Equal(t, int64(101), total)
+	assert.Equal(t, int64(41), inWindow)
+	assert.True(t, budget.add(59, start.Add(61*time.Second)))
+}
+
+func TestByteBudget_UnlimitedOnlyCounts(t *testing.T) {
+	budget := newByteBudget(0, time.Minute)
+	now := time.Now()
+
+	assert.True(t, budget.add(1<<20, now))
+	total, inWindow := budget.counts(now)
+	assert.Equal(t, int64(1<<20), total)
+	assert.Equal(t, int64(1<<20), inWindow)
+}
+
+func TestValidateByteBudget(t *testing.T) {
+	assert.NoError(t, ValidateByteBudget(0, 0))
+	assert.NoError(t, ValidateByteBudget(1024, time.Second))
+	assert.Error(t, ValidateByteBudget(-1, time.Second))
+	assert.Error(t, ValidateByteBudget(1024, 0))
+}
+
+func TestTCPServer_DisconnectsConnectionOverByteBudget(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+	mockLogger.On("Warn", "Disconnecting connection over its byte budget", mock.Anything).Once()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	require.NoError(t, server.SetByteBudget(64, time.Minute))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan struct{})
+	go func() {
+		server.handleConnection(context.Background(), serverConn)
+		close(done)
+	}()
+
+	go func() {
+		_, _ = clientConn.Write([]byte(`{"type":"YIELD","from_role":"people","to_role":"developer","payload":"` + strings.Repeat("x", 128) + `"}` + "\n"))
+	}()
+
+	var response ErrorMessage
+	require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+	assert.Equal(t, "ERROR", response.Type)
+	assert.Contains(t, response.Message, "Byte budget exceeded")
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection handler did not exit")
+	}
+	mockLogger.AssertExpectations(t)
+}
+
+func TestTCPServer_QueryConnectionsReportsBytes(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	require.NoError(t, server.SetByteBudget(4096, time.Minute))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go server.handleConnection(context.Background(), serverConn)
+
+	query := `{"type":"QUERY_CONNECTIONS"}` + "\n"
+	go func() {
+		_, _ = clientConn.Write([]byte(query))
+	}()
+
+	var response ConnectionsMessage
+	require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+	assert.Equal(t, "CONNECTIONS", response.Type)
+	assert.Equal(t, int64(4096), response.ByteBudget)
+	assert.Equal(t, "1m0s", response.Window)
+	require.Len(t, response.Connections, 1)
+	assert.Empty(t, response.Connections[0].Role)
+	assert.Equal(t, int64(len(query)), response.Connections[0].BytesRead)
+	assert.Equal(t, int64(len(query)), response.Connections[0].WindowBytes)
+}
@@ -0,0 +1,153 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// CodecJSON is the default JSON wire format, newline-delimited unless length framing is set
+	CodecJSON = "json"
+	// CodecMsgpack is the length-prefixed msgpack wire format
+	CodecMsgpack = "msgpack"
+
+	// maxFrameSize bounds a single length-prefixed frame to protect against corrupt prefixes
+	maxFrameSize = 16 * 1024 * 1024
+)
+
+// Codec defines how protocol messages are serialized and framed on a connection
+// Every connection starts with JSON; a client may switch codecs with a HELLO handshake
+type Codec interface {
+	// Name returns the codec identifier used during negotiation
+	Name() string
+
+	// Encode serializes a message into a complete frame ready to be written
+	Encode(message interface{}) ([]byte, error)
+
+	// Decode deserializes a single frame produced by Split into message
+	Decode(frame []byte, message interface{}) error
+
+	// Split is a bufio.SplitFunc that extracts one frame at a time from the stream
+	Split(data []byte, atEOF bool) (advance int, token []byte, err error)
+}
+
+// NewCodec returns the codec registered under the given name
+func NewCodec(name string) (Codec, error) {
+	return NewNamedCodec(name, "")
+}
+
+// NewNamedCodec returns the codec registered under the given name, writing field names in naming
+// Only the JSON codec supports camelCase; msgpack keys always follow the declared names
+func NewNamedCodec(name, naming string) (Codec, error) {
+	if err := ValidateFieldNaming(naming); err != nil {
+		return nil, err
+	}
+	switch name {
+	case "", CodecJSON:
+		return JSONCodec{FieldNaming: naming}, nil
+	case CodecMsgpack:
+		if naming == FieldNamingCamel {
+			return nil, fmt.Errorf("field naming %s is only supported by the %s codec", naming, CodecJSON)
+		}
+		return MsgpackCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported codec: %s", name)
+	}
+}
+
+// SplitFrames returns a bufio.SplitFunc that always frames with the codec currently in effect
+// This lets a scanner keep reading the same connection after a codec switch
+func SplitFrames(current func() Codec) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		return current().Split(data, atEOF)
+	}
+}
+
+// WriteFrame writes a whole encoded frame, retrying until every byte is written
+// A frame cut short would corrupt the framing of everything sent after it on the connection
+func WriteFrame(w io.Writer, frame []byte) error {
+	for len(frame) > 0 {
+		n, err := w.Write(frame)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return io.ErrShortWrite
+		}
+		frame = frame[n:]
+	}
+	return nil
+}
+
+// JSONCodec implements Codec with JSON framed by Framing (newline-delimited when empty)
+// Field names are written in FieldNaming (snake_case when empty) and read in either convention
+type JSONCodec struct {
+	FieldNaming string
+	Framing     string
+}
+
+// Name returns the codec identifier
+func (JSONCodec) Name() string {
+	return CodecJSON
+}
+
+// Encode serializes a message as a single JSON frame
+func (c JSONCodec) Encode(message interface{}) ([]byte, error) {
+	marshal := json.Marshal
+	if c.FieldNaming == FieldNamingCamel {
+		marshal = encodeCamel
+	}
+	data, err := marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeFrame(c.Framing, data), nil
+}
+
+// Decode deserializes a JSON frame with snake_case or camelCase field names
+func (JSONCodec) Decode(frame []byte, message interface{}) error {
+	return decodeEitherNaming(bytes.TrimSpace(frame), message)
+}
+
+// Split extracts the frames of the codec's framing
+func (c JSONCodec) Split(data []byte, atEOF bool) (int, []byte, error) {
+	return SplitFraming(c.Framing)(data, atEOF)
+}
+
+// MsgpackCodec implements Codec with msgpack bodies behind a 4-byte big-endian length prefix
+// Field names follow the existing json struct tags so both codecs share the message types
+type MsgpackCodec struct{}
+
+// Name returns the codec identifier
+func (MsgpackCodec) Name() string {
+	return CodecMsgpack
+}
+
+// Encode serializes a message as a length-prefixed msgpack frame
+func (MsgpackCodec) Encode(message interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	encoder := msgpack.NewEncoder(&body)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(message); err != nil {
+		return nil, err
+	}
+
+	return encodeLengthPrefixed(body.Bytes()), nil
+}
+
+// Decode deserializes a msgpack frame body
+func (MsgpackCodec) Decode(frame []byte, message interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(frame))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(message)
+}
+
+// Split extracts length-prefixed frames, returning the body without its prefix
+func (MsgpackCodec) Split(data []byte, atEOF bool) (int, []byte, error) {
+	return splitLengthPrefixed(data, atEOF)
+}
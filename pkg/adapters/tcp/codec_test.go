@@ -0,0 +1,282 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	for _, name := range []string{CodecJSON, CodecMsgpack} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := NewCodec(name)
+			require.NoError(t, err)
+			assert.Equal(t, name, codec.Name())
+
+			original := StatusMessage{
+				Type:             "STATUS",
+				BarrelHolder:     "developer",
+				RegisteredAgents: []string{"developer", "tester"},
+				AgentStates:      map[string]string{"developer": "working", "tester": "waiting"},
+				ConnectedAgents:  map[string]bool{"developer": true, "tester": false},
+				YieldChainDepth:  3,
+			}
+
+			// Write two frames back to back and read them through the codec's splitter
+			var stream bytes.Buffer
+			for i := 0; i < 2; i++ {
+				frame, err := codec.Encode(original)
+				require.NoError(t, err)
+				stream.Write(frame)
+			}
+
+			scanner := bufio.NewScanner(&stream)
+			scanner.Split(codec.Split)
+			frames := 0
+			for scanner.Scan() {
+				var decoded StatusMessage
+				require.NoError(t, codec.Decode(scanner.Bytes(), &decoded))
+				assert.Equal(t, original, decoded)
+				frames++
+			}
+			assert.NoError(t, scanner.Err())
+			assert.Equal(t, 2, frames)
+		})
+	}
+}
+
+func TestNewCodec_Unsupported(t *testing.T) {
+	_, err := NewCodec("xml")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported codec")
+}
+
+func TestMsgpackCodec_SplitTruncatedFrame(t *testing.T) {
+	frame, err := MsgpackCodec{}.Encode(ErrorMessage{Type: "ERROR", Message: "boom"})
+	require.NoError(t, err)
+
+	// Partial data is not an error until EOF
+	advance, token, err := MsgpackCodec{}.Split(frame[:len(frame)-1], false)
+	assert.NoError(t, err)
+	assert.Zero(t, advance)
+	assert.Nil(t, token)
+
+	_, _, err = MsgpackCodec{}.Split(frame[:len(frame)-1], true)
+	assert.Error(t, err)
+}
+
+func TestTCPServer_HelloNegotiatesMsgpack(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go server.handleConnection(context.Background(), serverConn)
+
+	jsonCodec := JSONCodec{}
+	msgpackCodec := MsgpackCodec{}
+	current := Codec(jsonCodec)
+	scanner := bufio.NewScanner(clientConn)
+	scanner.Split(SplitFrames(func() Codec { return current }))
+
+	// Handshake happens in JSON
+	hello, err := jsonCodec.Encode(HelloMessage{Type: "HELLO", Codec: CodecMsgpack})
+	require.NoError(t, err)
+	_, err = clientConn.Write(hello)
+	require.NoError(t, err)
+
+	require.True(t, scanner.Scan())
+	var ack HelloAckMessage
+	require.NoError(t, jsonCodec.Decode(scanner.Bytes(), &ack))
+	assert.Equal(t, "HELLO_ACK", ack.Type)
+	assert.Equal(t, CodecMsgpack, ack.Codec)
+	current = msgpackCodec
+
+	// Everything afterwards is msgpack in both directions
+	mockSoviet.On("DisconnectAgent", "developer").Return(nil).Maybe()
+	mockSoviet.On("RegisterAgent", mock.MatchedBy(func(agent *domain.AgentComrade) bool {
+		return agent.Role() == "developer"
+	})).Return(false, "", "", nil).Once()
+
+	register, err := msgpackCodec.Encode(RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}})
+	require.NoError(t, err)
+	_, err = clientConn.Write(register)
+	require.NoError(t, err)
+
+	require.True(t, scanner.Scan())
+	var registered AckRegisterMessage
+	require.NoError(t, msgpackCodec.Decode(scanner.Bytes(), &registered))
+	assert.Equal(t, "ACK_REGISTER", registered.Type)
+	assert.Equal(t, "success", registered.Status)
+	mockSoviet.AssertExpectations(t)
+}
+
+func TestJSONCodec_FieldNamingRoundTrip(t *testing.T) {
+	original := StatusMessage{
+		Type:             "STATUS",
+		BarrelHolder:     "senior_dev",
+		RegisteredAgents: []string{"senior_dev"},
+		AgentStates:      map[string]string{"senior_dev": "working"},
+		ConnectedAgents:  map[string]bool{"senior_dev": true},
+		RegistrationSeqs: map[string]uint64{"senior_dev": 1 << 60},
+		YieldChainDepth:  2,
+	}
+
+	for naming, key := range map[string]string{
+		FieldNamingSnake: `"barrel_holder":"senior_dev"`,
+		FieldNamingCamel: `"barrelHolder":"senior_dev"`,
+	} {
+		t.Run(naming, func(t *testing.T) {
+			codec, err := NewNamedCodec(CodecJSON, naming)
+			require.NoError(t, err)
+
+			frame, err := codec.Encode(original)
+			require.NoError(t, err)
+			assert.Contains(t, string(frame), key)
+			// Map keys are role names and keep their spelling
+			assert.Contains(t, string(frame), `{"senior_dev":"working"}`)
+
+			var decoded StatusMessage
+			require.NoError(t, codec.Decode(frame, &decoded))
+			assert.Equal(t, original, decoded)
+		})
+	}
+}
+
+func TestJSONCodec_DecodesEitherNaming(t *testing.T) {
+	for _, frame := range []string{
+		`{"type":"YIELD","from_role":"developer","to_role":"tester","payload":"Test it"}`,
+		`{"type":"YIELD","fromRole":"developer","toRole":"tester","payload":"Test it"}`,
+	} {
+		var yield YieldMessage
+		require.NoError(t, JSONCodec{}.Decode([]byte(frame), &yield), frame)
+		assert.Equal(t, YieldMessage{Type: "YIELD", FromRole: "developer", ToRole: "tester", Payload: "Test it"}, yield)
+	}
+
+	var yield YieldMessage
+	assert.Error(t, JSONCodec{}.Decode([]byte(`{"type":"YIELD"} trailing`), &yield))
+}
+
+func TestNewNamedCodec_Unsupported(t *testing.T) {
+	_, err := NewNamedCodec(CodecJSON, "kebab-case")
+	assert.ErrorContains(t, err, "unsupported field naming")
+	_, err = NewNamedCodec(CodecMsgpack, FieldNamingCamel)
+	assert.ErrorContains(t, err, "only supported by the json codec")
+}
+
+func TestTCPServer_HelloNegotiatesCamelCase(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+	mockLogger.On("Info", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go server.handleConnection(context.Background(), serverConn)
+	reader := bufio.NewReader(clientConn)
+
+	_, err := clientConn.Write([]byte(`{"type":"HELLO","codec":"json","fieldNaming":"camelCase"}` + "\n"))
+	require.NoError(t, err)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"HELLO_ACK","codec":"json","field_naming":"camelCase"}`, line)
+
+	// Replies are camelCase while either naming is accepted
+	mockSoviet.On("DisconnectAgent", "senior_dev").Return(nil).Maybe()
+	mockSoviet.On("RegisterAgent", mock.MatchedBy(func(agent *domain.AgentComrade) bool {
+		return agent.Role() == "senior_dev"
+	})).Return(false, "", "", nil).Once()
+	_, err = clientConn.Write([]byte(`{"type":"REGISTER","role":"senior_dev","capabilities":["coding"]}` + "\n"))
+	require.NoError(t, err)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"type":"ACK_REGISTER"`)
+	mockSoviet.AssertExpectations(t)
+
+	mockSoviet.On("RecordHeartbeat", "senior_dev").Return(nil).Once()
+	_, err = clientConn.Write([]byte(`{"type":"PING","seq":7}` + "\n"))
+	require.NoError(t, err)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"serverTime":`)
+	assert.NotContains(t, line, `"server_time"`)
+}
+
+// chunkedConn accepts at most chunk bytes per Write, like a socket with a full send buffer
+type chunkedConn struct {
+	net.Conn
+	chunk int
+}
+
+func (c chunkedConn) Write(data []byte) (int, error) {
+	if len(data) > c.chunk {
+		data = data[:c.chunk]
+	}
+	return c.Conn.Write(data)
+}
+
+// stuckWriter accepts nothing without reporting an error
+type stuckWriter struct{}
+
+func (stuckWriter) Write([]byte) (int, error) {
+	return 0, nil
+}
+
+func TestWriteFrame_PartialWrites(t *testing.T) {
+	var buffer bytes.Buffer
+	frame := []byte(`{"type":"PONG","seq":1}` + "\n")
+	require.NoError(t, WriteFrame(&buffer, frame))
+	assert.Equal(t, frame, buffer.Bytes())
+
+	assert.ErrorIs(t, WriteFrame(stuckWriter{}, frame), io.ErrShortWrite)
+}
+
+func TestTCPServer_SendMessageSurvivesPartialWrites(t *testing.T) {
+	server := NewTCPServer(&MockSovietService{}, &MockAgentService{}, &MockMessageSender{}, &MockLogger{}, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	conn := chunkedConn{Conn: serverConn, chunk: 5}
+
+	status := StatusMessage{
+		Type:             "STATUS",
+		BarrelHolder:     "developer",
+		RegisteredAgents: []string{"developer", "tester"},
+		AgentStates:      map[string]string{"developer": "working", "tester": "waiting"},
+	}
+	go func() {
+		server.sendMessage(conn, status)
+		server.sendMessage(conn, PongMessage{Type: "PONG", Seq: 2})
+	}()
+
+	scanner := bufio.NewScanner(clientConn)
+	require.True(t, scanner.Scan())
+	var received StatusMessage
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &received))
+	assert.Equal(t, status.RegisteredAgents, received.RegisteredAgents)
+	assert.Equal(t, status.AgentStates, received.AgentStates)
+
+	// The next frame starts cleanly after the first one
+	require.True(t, scanner.Scan())
+	var pong PongMessage
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &pong))
+	assert.Equal(t, 2, pong.Seq)
+}
@@ -0,0 +1,53 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// handleDeregisterMessage removes the agent registered on a connection from the collective
+// The soviet returns the barrel to the people if the agent holds it. The connection stops
+// routing to the role before the ACK, and is closed after it
+func (s *TCPServer) handleDeregisterMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg DeregisterMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid DEREGISTER message format")
+		return
+	}
+
+	if msg.Role == "" {
+		s.sendError(conn, "Role is required for deregistration")
+		return
+	}
+
+	// Only the connection a role is registered on may take it out of the collective
+	s.mu.RLock()
+	registered := s.connections[msg.Role]
+	s.mu.RUnlock()
+	if registered != conn {
+		s.sendError(conn, fmt.Sprintf("Connection is not registered as '%s'", msg.Role))
+		return
+	}
+
+	if err := s.sovietService.DeregisterAgent(msg.Role); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+
+	// The role is gone, so the connection's cleanup must not mark it disconnected again
+	s.mu.Lock()
+	if s.connections[msg.Role] == conn {
+		delete(s.connections, msg.Role)
+	}
+	delete(s.instances, conn)
+	s.mu.Unlock()
+	s.takeInbox(msg.Role)
+
+	s.sendMessage(conn, AckDeregisterMessage{
+		Type:    "ACK_DEREGISTER",
+		Role:    msg.Role,
+		Message: fmt.Sprintf("Comrade '%s' has left the collective.", msg.Role),
+	})
+	s.closeConn(conn)
+}
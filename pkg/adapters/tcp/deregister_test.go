@@ -0,0 +1,57 @@
+package tcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestTCPServer_DeregisterReturnsBarrelAndClosesConnection(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+	addr := server.Addr()
+
+	developer := dialDrainClient(t, addr)
+	developer.send(RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}})
+	developer.receive("ACK_REGISTER", nil)
+
+	people := dialDrainClient(t, addr)
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Fix the login bug"})
+	people.receive("ACK_YIELD", nil)
+	developer.receive("ACTIVATE", nil)
+
+	// Only the connection the role is registered on may deregister it
+	people.send(DeregisterMessage{Type: "DEREGISTER", Role: "developer"})
+	var refused ErrorMessage
+	people.receive("ERROR", &refused)
+	assert.Contains(t, refused.Message, "not registered as 'developer'")
+
+	developer.send(DeregisterMessage{Type: "DEREGISTER", Role: "developer"})
+	var ack AckDeregisterMessage
+	developer.receive("ACK_DEREGISTER", &ack)
+	assert.Equal(t, "developer", ack.Role)
+	developer.expectClosed()
+
+	assert.True(t, soviet.GetBarrel().IsHeldBy("people"))
+	assert.False(t, soviet.IsAgentRegistered("developer"))
+	server.mu.RLock()
+	assert.Empty(t, server.connections)
+	server.mu.RUnlock()
+}
@@ -0,0 +1,107 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// drainNoticeTimeout bounds the DRAINING notice sent to an agent before its connection is closed
+const drainNoticeTimeout = time.Second
+
+// Drain stops the server from handing out new work so it can be restarted without losing any
+// Agents can no longer register and yields may only return the barrel to the people. Agents that
+// do not hold the barrel are disconnected now, the holder once it yields. Draining cannot be undone
+func (s *TCPServer) Drain() {
+	holder := s.sovietService.QueryStatus().BarrelHolder
+
+	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		return
+	}
+	s.draining = true
+	s.drainStartedAt = time.Now()
+	idle := make([]net.Conn, 0, len(s.connections))
+	for role, conn := range s.connections {
+		if role != holder {
+			idle = append(idle, conn)
+		}
+	}
+	s.mu.Unlock()
+
+	s.logger.Info("Draining server", map[string]interface{}{
+		"barrel_holder":    holder,
+		"idle_connections": len(idle),
+	})
+	for _, conn := range idle {
+		go s.dismiss(conn, ShutdownReasonDrain, "Server is draining for a restart; reconnect once it is back", true)
+	}
+}
+
+// isDraining reports whether Drain has been called
+func (s *TCPServer) isDraining() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.draining
+}
+
+// dismiss sends an agent SHUTDOWN, telling it why it is disconnected and whether to reconnect,
+// and closes its connection. Closing the connection ends its read loop, which unregisters the
+// connection as usual
+func (s *TCPServer) dismiss(conn net.Conn, reason, message string, reconnectAdvised bool) {
+	_ = conn.SetWriteDeadline(time.Now().Add(drainNoticeTimeout))
+	s.pushMessage(conn, ShutdownMessage{
+		Type:             "SHUTDOWN",
+		Reason:           reason,
+		Message:          message,
+		ReconnectAdvised: reconnectAdvised,
+	})
+	s.closeConn(conn)
+}
+
+// DrainStatus reports how far a drain has progressed
+func (s *TCPServer) DrainStatus() DrainStatusMessage {
+	holder := s.sovietService.QueryStatus().BarrelHolder
+
+	s.mu.RLock()
+	status := DrainStatusMessage{
+		Type:             "DRAIN_STATUS",
+		Draining:         s.draining,
+		AgentConnections: len(s.connections),
+		OpenConnections:  len(s.budgets),
+		BarrelWithPeople: holder == "people",
+		BarrelHolder:     holder,
+	}
+	if s.draining {
+		status.StartedAt = s.drainStartedAt.UTC().Format(time.RFC3339)
+	}
+	s.mu.RUnlock()
+
+	status.Complete = status.Draining && status.AgentConnections == 0 && status.BarrelWithPeople
+	return status
+}
+
+// handleDrainMessage starts a drain on behalf of the people and reports its status
+func (s *TCPServer) handleDrainMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg DrainMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid DRAIN message format")
+		return
+	}
+
+	if s.rejectFromAgent(conn, "DRAIN") {
+		return
+	}
+
+	if !s.checkReplay(conn, "DRAIN", msg.Nonce, msg.SentAt) {
+		return
+	}
+
+	s.Drain()
+	s.sendMessage(conn, s.DrainStatus())
+}
+
+func (s *TCPServer) handleQueryDrainStatusMessage(ctx context.Context, conn net.Conn) {
+	s.sendMessage(conn, s.DrainStatus())
+}
@@ -0,0 +1,194 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// drainClient is one client connection to a running server
+type drainClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialDrainClient(t *testing.T, addr net.Addr) *drainClient {
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+	return &drainClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *drainClient) send(message interface{}) {
+	require.NoError(c.t, json.NewEncoder(c.conn).Encode(message))
+}
+
+// receive reads the next message and decodes it if it has the expected type
+func (c *drainClient) receive(messageType string, message interface{}) {
+	line, err := c.reader.ReadBytes('\n')
+	require.NoError(c.t, err)
+	var base TCPMessage
+	require.NoError(c.t, json.Unmarshal(line, &base))
+	require.Equal(c.t, messageType, base.Type, string(line))
+	if message != nil {
+		require.NoError(c.t, json.Unmarshal(line, message))
+	}
+}
+
+// skipUntil discards messages until one of the expected type arrives
+func (c *drainClient) skipUntil(messageType string, message interface{}) {
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		require.NoError(c.t, err)
+		var base TCPMessage
+		require.NoError(c.t, json.Unmarshal(line, &base))
+		if base.Type == messageType {
+			require.NoError(c.t, json.Unmarshal(line, message))
+			return
+		}
+	}
+}
+
+func (c *drainClient) expectClosed() {
+	_, err := c.reader.ReadBytes('\n')
+	assert.ErrorIs(c.t, err, io.EOF)
+}
+
+func queryDrainStatus(t *testing.T, addr net.Addr) DrainStatusMessage {
+	people := dialDrainClient(t, addr)
+	people.send(QueryMessage{Type: "QUERY_DRAIN_STATUS"})
+	var status DrainStatusMessage
+	people.receive("DRAIN_STATUS", &status)
+	return status
+}
+
+func TestTCPServer_DrainCompletesOnceHolderYields(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+	mockLogger.On("Info", mock.Anything).Maybe()
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+	addr := server.Addr()
+
+	developer := dialDrainClient(t, addr)
+	developer.send(RegisterMessage{Type: "REGISTER", Role: "developer"})
+	developer.receive("ACK_REGISTER", nil)
+	tester := dialDrainClient(t, addr)
+	tester.send(RegisterMessage{Type: "REGISTER", Role: "tester"})
+	tester.receive("ACK_REGISTER", nil)
+
+	people := dialDrainClient(t, addr)
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Finish up"})
+	people.receive("ACK_YIELD", nil)
+	developer.receive("ACTIVATE", nil)
+
+	assert.False(t, queryDrainStatus(t, addr).Draining)
+
+	people.send(DrainMessage{Type: "DRAIN"})
+	var started DrainStatusMessage
+	people.receive("DRAIN_STATUS", &started)
+	assert.True(t, started.Draining)
+	assert.NotEmpty(t, started.StartedAt)
+	assert.Equal(t, "developer", started.BarrelHolder)
+	assert.False(t, started.Complete)
+
+	// The idle tester is sent away until the server is back, and cannot register before
+	var shutdown ShutdownMessage
+	tester.receive("SHUTDOWN", &shutdown)
+	assert.Equal(t, ShutdownReasonDrain, shutdown.Reason)
+	assert.True(t, shutdown.ReconnectAdvised)
+	tester.expectClosed()
+	late := dialDrainClient(t, addr)
+	late.send(RegisterMessage{Type: "REGISTER", Role: "tester"})
+	late.receive("SHUTDOWN", &shutdown)
+	assert.True(t, shutdown.ReconnectAdvised)
+
+	// The holder may only hand the barrel back to the people, after which it is disconnected
+	var notice ErrorMessage
+	developer.send(YieldMessage{Type: "YIELD", FromRole: "developer", ToRole: "tester", Payload: "Test it"})
+	developer.skipUntil("ERROR", &notice)
+	assert.Equal(t, ErrorCodeDraining, notice.Code)
+	developer.send(YieldMessage{Type: "YIELD", FromRole: "developer", ToRole: "people", Payload: "Done"})
+	developer.receive("ACK_YIELD", nil)
+	developer.receive("SHUTDOWN", &shutdown)
+	assert.Equal(t, ShutdownReasonDrain, shutdown.Reason)
+	developer.expectClosed()
+
+	var status DrainStatusMessage
+	require.Eventually(t, func() bool {
+		status = queryDrainStatus(t, addr)
+		return status.Complete
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 0, status.AgentConnections)
+	assert.True(t, status.BarrelWithPeople)
+	assert.Equal(t, started.StartedAt, status.StartedAt)
+}
+
+func TestTCPServer_DrainIsReservedForThePeople(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+
+	developer := dialDrainClient(t, server.Addr())
+	developer.send(RegisterMessage{Type: "REGISTER", Role: "developer"})
+	developer.receive("ACK_REGISTER", nil)
+
+	var refused ErrorMessage
+	developer.send(DrainMessage{Type: "DRAIN"})
+	developer.receive("ERROR", &refused)
+	assert.Contains(t, refused.Message, "DRAIN is reserved for the people")
+	assert.False(t, queryDrainStatus(t, server.Addr()).Draining)
+}
+
+func TestTCPServer_StopEndsBarrelSweeper(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+
+	// The context outlives the server: Stop alone must end the sweeper
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	require.NoError(t, server.Stop())
+
+	select {
+	case <-server.sweeperDone:
+	default:
+		t.Fatal("barrel sweeper still running after Stop")
+	}
+}
@@ -0,0 +1,216 @@
+package tcp
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Field naming conventions of JSON protocol messages
+const (
+	// FieldNamingSnake writes field names as declared, e.g. "from_role"
+	FieldNamingSnake = "snake_case"
+	// FieldNamingCamel writes field names in camelCase, e.g. "fromRole"
+	FieldNamingCamel = "camelCase"
+)
+
+// ValidateFieldNaming checks that naming is a supported field naming convention
+// An empty naming selects FieldNamingSnake
+func ValidateFieldNaming(naming string) error {
+	switch naming {
+	case "", FieldNamingSnake, FieldNamingCamel:
+		return nil
+	default:
+		return fmt.Errorf("unsupported field naming %q: expected %s or %s", naming, FieldNamingSnake, FieldNamingCamel)
+	}
+}
+
+// SetFieldNaming sets the field naming convention of connections that do not choose one in HELLO
+func (s *TCPServer) SetFieldNaming(naming string) error {
+	if err := ValidateFieldNaming(naming); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fieldNaming = naming
+	return nil
+}
+
+// messageField is a JSON object key of a message type and the type of its value
+type messageField struct {
+	name  string
+	camel string
+	typ   reflect.Type
+}
+
+// messageFields caches the JSON fields of every message type seen: reflect.Type -> []messageField
+var messageFields sync.Map
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// fieldsOf returns the JSON fields of a struct type, including those of embedded structs
+func fieldsOf(t reflect.Type) []messageField {
+	if cached, ok := messageFields.Load(t); ok {
+		return cached.([]messageField)
+	}
+
+	var fields []messageField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			fields = append(fields, fieldsOf(indirect(field.Type))...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, messageField{name: name, camel: camelCase(name), typ: field.Type})
+	}
+	messageFields.Store(t, fields)
+	return fields
+}
+
+// indirect returns the type a pointer type points to
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// camelCase converts a snake_case field name to camelCase
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// renameFields rewrites the object keys of a decoded JSON value that name fields of t
+// toCamel renames declared names to camelCase; otherwise camelCase names are renamed back, and
+// declared names are kept. Keys of maps, such as role names, are never renamed
+func renameFields(value interface{}, t reflect.Type, toCamel bool) interface{} {
+	t = indirect(t)
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return value
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for _, field := range fieldsOf(t) {
+			from, to := field.camel, field.name
+			if toCamel {
+				from, to = field.name, field.camel
+			}
+			if fieldValue, exists := object[from]; exists && from != to {
+				delete(object, from)
+				if _, taken := object[to]; !taken {
+					object[to] = fieldValue
+				}
+			}
+			if fieldValue, exists := object[to]; exists {
+				object[to] = renameFields(fieldValue, field.typ, toCamel)
+			}
+		}
+		return object
+	case reflect.Map:
+		if object, ok := value.(map[string]interface{}); ok {
+			for key, element := range object {
+				object[key] = renameFields(element, t.Elem(), toCamel)
+			}
+		}
+		return value
+	case reflect.Slice, reflect.Array:
+		if elements, ok := value.([]interface{}); ok {
+			for i, element := range elements {
+				elements[i] = renameFields(element, t.Elem(), toCamel)
+			}
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// encodeCamel serializes a message with camelCase field names
+func encodeCamel(message interface{}) ([]byte, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	generic, err := decodeGeneric(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(renameFields(generic, reflect.TypeOf(message), true))
+}
+
+// decodeEitherNaming deserializes a message whose field names may be snake_case or camelCase
+func decodeEitherNaming(frame []byte, message interface{}) error {
+	t := reflect.TypeOf(message)
+	if t == nil || t.Kind() != reflect.Pointer || !hasCamelAliases(t.Elem()) {
+		return json.Unmarshal(frame, message)
+	}
+
+	generic, err := decodeGeneric(frame)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(renameFields(generic, t.Elem(), false))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, message)
+}
+
+// decodeGeneric decodes JSON keeping numbers exact so they are written back unchanged
+func decodeGeneric(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the top-level value")
+	}
+	return generic, nil
+}
+
+// hasCamelAliases reports whether some field of a struct type is named differently in camelCase
+// Only struct targets are renamed; other targets such as maps are decoded as they are
+func hasCamelAliases(t reflect.Type) bool {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, field := range fieldsOf(t) {
+		if field.name != field.camel {
+			return true
+		}
+	}
+	return false
+}
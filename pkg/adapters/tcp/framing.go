@@ -0,0 +1,97 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Framings of JSON messages on a connection
+const (
+	// FramingNewline ends every JSON message with a newline
+	FramingNewline = "newline"
+	// FramingLength puts a 4-byte big-endian length prefix before every JSON message
+	FramingLength = "length"
+)
+
+// ValidateFraming checks that framing is a supported framing
+// An empty framing selects FramingNewline
+func ValidateFraming(framing string) error {
+	switch framing {
+	case "", FramingNewline, FramingLength:
+		return nil
+	default:
+		return fmt.Errorf("unsupported framing %q: expected %s or %s", framing, FramingNewline, FramingLength)
+	}
+}
+
+// SetFraming sets the framing of JSON connections; clients must use the same framing
+// Switching codecs with HELLO keeps it for JSON, while msgpack is always length-prefixed
+func (s *TCPServer) SetFraming(framing string) error {
+	if err := ValidateFraming(framing); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.framing = framing
+	return nil
+}
+
+// EncodeFrame frames an encoded message body for writing with framing
+func EncodeFrame(framing string, body []byte) []byte {
+	if framing == FramingLength {
+		return encodeLengthPrefixed(body)
+	}
+	frame := make([]byte, 0, len(body)+1)
+	frame = append(frame, body...)
+	return append(frame, '\n')
+}
+
+// SplitFraming returns the bufio.SplitFunc extracting the message bodies framed with framing
+func SplitFraming(framing string) bufio.SplitFunc {
+	if framing == FramingLength {
+		return splitLengthPrefixed
+	}
+	return bufio.ScanLines
+}
+
+// NewFrameScanner returns a scanner splitting r with split that accepts frames up to the frame size limit
+// A scanner's default 64KB limit would fail on large payloads such as diffs
+func NewFrameScanner(r io.Reader, split bufio.SplitFunc) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxFrameSize+4)
+	scanner.Split(split)
+	return scanner
+}
+
+// encodeLengthPrefixed puts the 4-byte big-endian length of body before it
+func encodeLengthPrefixed(body []byte) []byte {
+	frame := make([]byte, 4, 4+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(len(body)))
+	return append(frame, body...)
+}
+
+// splitLengthPrefixed extracts length-prefixed frames, returning the body without its prefix
+func splitLengthPrefixed(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) < 4 {
+		if atEOF && len(data) > 0 {
+			return 0, nil, fmt.Errorf("truncated frame header")
+		}
+		return 0, nil, nil
+	}
+
+	size := binary.BigEndian.Uint32(data[:4])
+	if size > maxFrameSize {
+		return 0, nil, fmt.Errorf("frame size %d exceeds limit of %d bytes", size, maxFrameSize)
+	}
+
+	end := 4 + int(size)
+	if len(data) < end {
+		if atEOF {
+			return 0, nil, fmt.Errorf("truncated frame body")
+		}
+		return 0, nil, nil
+	}
+	return end, data[4:end], nil
+}
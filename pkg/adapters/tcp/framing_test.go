@@ -0,0 +1,108 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestValidateFraming(t *testing.T) {
+	for _, framing := range []string{"", FramingNewline, FramingLength} {
+		assert.NoError(t, ValidateFraming(framing), framing)
+	}
+	assert.ErrorContains(t, ValidateFraming("chunked"), "unsupported framing")
+}
+
+func TestJSONCodec_FramingRoundTripsLargePayload(t *testing.T) {
+	payload := strings.Repeat("diff --git a/main.go b/main.go\n", 1<<20/31+1)
+	for _, framing := range []string{FramingNewline, FramingLength} {
+		t.Run(framing, func(t *testing.T) {
+			codec := JSONCodec{Framing: framing}
+			var stream bytes.Buffer
+			for _, message := range []YieldMessage{
+				{Type: "YIELD", FromRole: "developer", ToRole: "tester", Payload: payload},
+				{Type: "YIELD", FromRole: "tester", ToRole: "people", Payload: "Done"},
+			} {
+				frame, err := codec.Encode(message)
+				require.NoError(t, err)
+				stream.Write(frame)
+			}
+
+			scanner := NewFrameScanner(&stream, codec.Split)
+			var first, second YieldMessage
+			require.True(t, scanner.Scan(), scanner.Err())
+			require.NoError(t, codec.Decode(scanner.Bytes(), &first))
+			require.True(t, scanner.Scan(), scanner.Err())
+			require.NoError(t, codec.Decode(scanner.Bytes(), &second))
+			assert.Equal(t, payload, first.Payload)
+			assert.Equal(t, "Done", second.Payload)
+			assert.False(t, scanner.Scan())
+		})
+	}
+}
+
+func TestTCPServer_FramingCarriesLargePayload(t *testing.T) {
+	payload := strings.Repeat("x", 1<<20)
+	for _, framing := range []string{FramingNewline, FramingLength} {
+		t.Run(framing, func(t *testing.T) {
+			mockLogger := &MockLogger{}
+			for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+				mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+			}
+			soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+			require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+			server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+			require.NoError(t, server.SetFraming(framing))
+			require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			require.NoError(t, server.Start(ctx))
+			defer server.Stop()
+
+			codec := JSONCodec{Framing: framing}
+			dial := func() (net.Conn, *bufio.Scanner) {
+				conn, err := net.Dial("tcp", server.Addr().String())
+				require.NoError(t, err)
+				t.Cleanup(func() { conn.Close() })
+				require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+				return conn, NewFrameScanner(conn, codec.Split)
+			}
+			send := func(conn net.Conn, message interface{}) {
+				frame, err := codec.Encode(message)
+				require.NoError(t, err)
+				require.NoError(t, WriteFrame(conn, frame))
+			}
+			receive := func(scanner *bufio.Scanner, messageType string, message interface{}) {
+				require.True(t, scanner.Scan(), "no %s: %v", messageType, scanner.Err())
+				var base TCPMessage
+				require.NoError(t, codec.Decode(scanner.Bytes(), &base))
+				require.Equal(t, messageType, base.Type, string(scanner.Bytes()))
+				if message != nil {
+					require.NoError(t, codec.Decode(scanner.Bytes(), message))
+				}
+			}
+
+			developer, developerFrames := dial()
+			send(developer, RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}})
+			receive(developerFrames, "ACK_REGISTER", nil)
+
+			people, peopleFrames := dial()
+			send(people, YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: payload})
+			receive(peopleFrames, "ACK_YIELD", nil)
+
+			var activate ActivateMessage
+			receive(developerFrames, "ACTIVATE", &activate)
+			assert.Equal(t, payload, activate.Payload)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// notifyHalt sends every connected agent a HALT_NOTICE and returns how many were notified
+// Notices are written concurrently so a slow agent does not hold up the others
+func (s *TCPServer) notifyHalt(status domain.HaltStatus) int {
+	notice := HaltNoticeMessage{
+		Type:    "HALT_NOTICE",
+		Halted:  status.Halted,
+		Reason:  status.Reason,
+		Message: "The people resumed the collective; the barrel moves again",
+	}
+	if status.Halted {
+		notice.Message = fmt.Sprintf("The people halted the collective: %s. Stop current work; yields are refused until they resume", status.Reason)
+	}
+
+	s.mu.RLock()
+	conns := make([]net.Conn, 0, len(s.connections))
+	for _, conn := range s.connections {
+		conns = append(conns, conn)
+	}
+	s.mu.RUnlock()
+
+	for _, conn := range conns {
+		go s.pushMessage(conn, notice)
+	}
+	return len(conns)
+}
+
+// haltStatus describes the current halt, with the number of agents notified of it
+func (s *TCPServer) haltStatus(notified int) HaltStatusMessage {
+	halt := s.sovietService.HaltStatus()
+	status := HaltStatusMessage{
+		Type:           "HALT_STATUS",
+		Halted:         halt.Halted,
+		Reason:         halt.Reason,
+		BarrelHolder:   s.sovietService.QueryStatus().BarrelHolder,
+		NotifiedAgents: notified,
+	}
+	if halt.Halted {
+		status.Since = halt.Since.UTC().Format(time.RFC3339)
+	}
+	return status
+}
+
+// rejectFromAgent refuses a command reserved for the people when it arrives on an agent's connection
+// Returns true if the command was rejected
+func (s *TCPServer) rejectFromAgent(conn net.Conn, command string) bool {
+	s.mu.RLock()
+	agentRole := s.roleFor(conn)
+	s.mu.RUnlock()
+	if agentRole == "" {
+		return false
+	}
+	s.sendError(conn, fmt.Sprintf("%s is reserved for the people (connection is registered as '%s')", command, agentRole))
+	return true
+}
+
+// handleHaltMessage freezes the barrel on behalf of the people and tells every agent to stop
+func (s *TCPServer) handleHaltMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg HaltMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid HALT message format")
+		return
+	}
+
+	if s.rejectFromAgent(conn, "HALT") {
+		return
+	}
+	if !s.checkReplay(conn, "HALT", msg.Nonce, msg.SentAt) {
+		return
+	}
+
+	if err := s.sovietService.Halt(strings.TrimSpace(msg.Reason)); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	notified := s.notifyHalt(s.sovietService.HaltStatus())
+	s.sendMessage(conn, s.haltStatus(notified))
+}
+
+// handleResumeMessage lifts a halt on behalf of the people and tells every agent
+func (s *TCPServer) handleResumeMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg ResumeMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid RESUME message format")
+		return
+	}
+
+	if s.rejectFromAgent(conn, "RESUME") {
+		return
+	}
+	if !s.checkReplay(conn, "RESUME", msg.Nonce, msg.SentAt) {
+		return
+	}
+
+	if !s.sovietService.Resume() {
+		s.sendError(conn, "the collective is not halted")
+		return
+	}
+	notified := s.notifyHalt(s.sovietService.HaltStatus())
+	s.sendMessage(conn, s.haltStatus(notified))
+}
+
+// sendHaltAwareError answers a failed barrel move, flagging refusals caused by a halt with HALTED
+func (s *TCPServer) sendHaltAwareError(conn net.Conn, err error) {
+	var validationErr domain.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Code == domain.ValidationCodeHalted {
+		s.sendErrorCode(conn, ErrorCodeHalted, err.Error())
+		return
+	}
+	s.sendError(conn, err.Error())
+}
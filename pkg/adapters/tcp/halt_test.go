@@ -0,0 +1,125 @@
+package tcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestTCPServer_HaltFreezesBarrelUntilResume(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+	mockLogger.On("Info", mock.Anything).Maybe()
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+	addr := server.Addr()
+
+	developer := dialDrainClient(t, addr)
+	developer.send(RegisterMessage{Type: "REGISTER", Role: "developer"})
+	developer.receive("ACK_REGISTER", nil)
+	tester := dialDrainClient(t, addr)
+	tester.send(RegisterMessage{Type: "REGISTER", Role: "tester"})
+	tester.receive("ACK_REGISTER", nil)
+
+	people := dialDrainClient(t, addr)
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Deploy"})
+	people.receive("ACK_YIELD", nil)
+	developer.receive("ACTIVATE", nil)
+
+	people.send(HaltMessage{Type: "HALT", Reason: "  "})
+	people.receive("ERROR", nil)
+
+	people.send(HaltMessage{Type: "HALT", Reason: "Bad deploy"})
+	var halted HaltStatusMessage
+	people.receive("HALT_STATUS", &halted)
+	assert.True(t, halted.Halted)
+	assert.Equal(t, "Bad deploy", halted.Reason)
+	assert.NotEmpty(t, halted.Since)
+	assert.Equal(t, "developer", halted.BarrelHolder)
+	assert.Equal(t, 2, halted.NotifiedAgents)
+
+	// Every agent is told to stop, not just the holder
+	for _, agent := range []*drainClient{developer, tester} {
+		var notice HaltNoticeMessage
+		agent.receive("HALT_NOTICE", &notice)
+		assert.True(t, notice.Halted)
+		assert.Equal(t, "Bad deploy", notice.Reason)
+	}
+
+	// The barrel stays with the holder: neither the holder nor the people can move it
+	var refused ErrorMessage
+	developer.send(YieldMessage{Type: "YIELD", FromRole: "developer", ToRole: "tester", Payload: "Test it"})
+	developer.receive("ERROR", &refused)
+	assert.Equal(t, ErrorCodeHalted, refused.Code)
+	assert.Contains(t, refused.Message, "Bad deploy")
+	people.send(ReassignMessage{Type: "REASSIGN", FromRole: "developer", ToRole: "tester"})
+	people.receive("ERROR", &refused)
+	assert.Equal(t, ErrorCodeHalted, refused.Code)
+	assert.Equal(t, "developer", soviet.GetBarrelStatus())
+
+	// Agents cannot lift a halt themselves
+	developer.send(ResumeMessage{Type: "RESUME"})
+	developer.receive("ERROR", &refused)
+	assert.Contains(t, refused.Message, "reserved for the people")
+
+	people.send(ResumeMessage{Type: "RESUME"})
+	var resumed HaltStatusMessage
+	people.receive("HALT_STATUS", &resumed)
+	assert.False(t, resumed.Halted)
+	assert.Empty(t, resumed.Since)
+	for _, agent := range []*drainClient{developer, tester} {
+		var notice HaltNoticeMessage
+		agent.receive("HALT_NOTICE", &notice)
+		assert.False(t, notice.Halted)
+	}
+
+	developer.send(YieldMessage{Type: "YIELD", FromRole: "developer", ToRole: "tester", Payload: "Test it"})
+	developer.receive("ACK_YIELD", nil)
+	tester.receive("ACTIVATE", nil)
+
+	// Resuming twice is an error so scripts notice they were not halted
+	people.send(ResumeMessage{Type: "RESUME"})
+	people.receive("ERROR", nil)
+}
+
+func TestTCPServer_HaltRequiresFreshNonce(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetReplayWindow(time.Minute))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+
+	people := dialDrainClient(t, server.Addr())
+	var refused ErrorMessage
+	people.send(HaltMessage{Type: "HALT", Reason: "Bad deploy"})
+	people.receive("ERROR", &refused)
+	assert.Equal(t, ErrorCodeInvalidNonce, refused.Code)
+	assert.False(t, soviet.HaltStatus().Halted)
+
+	people.send(HaltMessage{Type: "HALT", Reason: "Bad deploy", Nonce: "n-1", SentAt: time.Now().UTC().Format(time.RFC3339Nano)})
+	people.receive("HALT_STATUS", nil)
+	assert.True(t, soviet.HaltStatus().Halted)
+}
@@ -0,0 +1,71 @@
+package tcp
+
+import (
+	"fmt"
+)
+
+// DefaultInboxDepth is how many undelivered activations are kept for a role by default
+const DefaultInboxDepth = 16
+
+// SetInboxDepth sets how many undelivered activations are kept for a role without a connection
+// Older activations are dropped beyond it; 0 keeps none, so queue depths are not reported
+func (s *TCPServer) SetInboxDepth(depth int) error {
+	if depth < 0 {
+		return fmt.Errorf("inbox depth cannot be negative: %d", depth)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inboxDepth = depth
+	for role, queued := range s.inbox {
+		if len(queued) > depth {
+			s.inbox[role] = queued[len(queued)-depth:]
+		}
+		if len(s.inbox[role]) == 0 {
+			delete(s.inbox, role)
+		}
+	}
+	return nil
+}
+
+// queueActivation keeps an activation for a role that has no connection to deliver it on
+// A growing queue shows the role is offline while work is routed to it
+func (s *TCPServer) queueActivation(role string, activateMsg ActivateMessage) {
+	s.mu.Lock()
+	if s.inboxDepth == 0 {
+		s.mu.Unlock()
+		return
+	}
+	queued := append(s.inbox[role], activateMsg)
+	if len(queued) > s.inboxDepth {
+		queued = queued[len(queued)-s.inboxDepth:]
+	}
+	s.inbox[role] = queued
+	depth := len(queued)
+	s.mu.Unlock()
+
+	s.logger.Warn("Queued activation for disconnected role", map[string]interface{}{
+		"role":  role,
+		"depth": depth,
+	})
+}
+
+// takeInbox removes and returns the activations queued for a role, oldest first
+func (s *TCPServer) takeInbox(role string) []ActivateMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queued := s.inbox[role]
+	delete(s.inbox, role)
+	return queued
+}
+
+// QueueDepths returns how many undelivered activations each role has queued
+// Roles with an empty queue are left out
+func (s *TCPServer) QueueDepths() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	depths := make(map[string]int, len(s.inbox))
+	for role, queued := range s.inbox {
+		depths[role] = len(queued)
+	}
+	return depths
+}
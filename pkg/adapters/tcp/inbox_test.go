@@ -0,0 +1,104 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func queryStatus(t *testing.T, addr net.Addr) StatusMessage {
+	people := dialDrainClient(t, addr)
+	people.send(QueryMessage{Type: "QUERY_STATUS"})
+	var status StatusMessage
+	people.receive("STATUS", &status)
+	return status
+}
+
+func queryQueueDepths(t *testing.T, addr net.Addr) map[string]int {
+	return queryStatus(t, addr).QueueDepths
+}
+
+func TestTCPServer_QueuesActivationsForDisconnectedRole(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+	mockLogger.On("Info", mock.Anything).Maybe()
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+	addr := server.Addr()
+
+	developer := dialDrainClient(t, addr)
+	developer.send(RegisterMessage{Type: "REGISTER", Role: "developer"})
+	developer.receive("ACK_REGISTER", nil)
+	tester := dialDrainClient(t, addr)
+	tester.send(RegisterMessage{Type: "REGISTER", Role: "tester"})
+	tester.receive("ACK_REGISTER", nil)
+	require.NoError(t, tester.conn.Close())
+	require.Eventually(t, func() bool {
+		return !queryStatus(t, addr).ConnectedAgents["tester"]
+	}, 2*time.Second, 10*time.Millisecond)
+
+	people := dialDrainClient(t, addr)
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Build it"})
+	people.receive("ACK_YIELD", nil)
+	developer.receive("ACTIVATE", nil)
+	assert.Empty(t, queryQueueDepths(t, addr))
+
+	// Work routed to the offline tester piles up in its inbox
+	people.send(ReassignMessage{Type: "REASSIGN", FromRole: "developer", ToRole: "tester"})
+	people.receive("ACK_YIELD", nil)
+	assert.Equal(t, map[string]int{"tester": 1}, queryQueueDepths(t, addr))
+	people.send(ReassignMessage{Type: "REASSIGN", FromRole: "tester", ToRole: "developer"})
+	people.receive("ACK_YIELD", nil)
+	developer.receive("ACTIVATE", nil)
+	people.send(ReassignMessage{Type: "REASSIGN", FromRole: "developer", ToRole: "tester"})
+	people.receive("ACK_YIELD", nil)
+	assert.Equal(t, map[string]int{"tester": 2}, queryQueueDepths(t, addr))
+
+	// Registering again delivers the current hand-off and drains the inbox
+	tester = dialDrainClient(t, addr)
+	tester.send(RegisterMessage{Type: "REGISTER", Role: "tester"})
+	tester.receive("ACK_REGISTER", nil)
+	var activate ActivateMessage
+	tester.receive("ACTIVATE", &activate)
+	assert.Equal(t, "developer", activate.FromRole)
+	require.NotNil(t, activate.Receipt)
+	assert.Empty(t, queryQueueDepths(t, addr))
+}
+
+func TestTCPServer_InboxDepthDropsOldestActivations(t *testing.T) {
+	mockLogger := &MockLogger{}
+	mockLogger.On("Warn", mock.Anything, mock.Anything).Maybe()
+	server := NewTCPServer(&MockSovietService{}, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetInboxDepth(2))
+
+	for _, from := range []string{"developer", "reviewer", "architect"} {
+		server.queueActivation("tester", ActivateMessage{Type: "ACTIVATE", FromRole: from})
+	}
+	assert.Equal(t, map[string]int{"tester": 2}, server.QueueDepths())
+
+	queued := server.takeInbox("tester")
+	require.Len(t, queued, 2)
+	assert.Equal(t, "reviewer", queued[0].FromRole)
+	assert.Equal(t, "architect", queued[1].FromRole)
+	assert.Empty(t, server.QueueDepths())
+
+	require.NoError(t, server.SetInboxDepth(0))
+	server.queueActivation("tester", ActivateMessage{Type: "ACTIVATE", FromRole: "developer"})
+	assert.Empty(t, server.QueueDepths())
+	assert.Error(t, server.SetInboxDepth(-1))
+}
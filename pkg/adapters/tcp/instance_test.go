@@ -0,0 +1,68 @@
+package tcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestTCPServer_InstanceTakeoverDismissesPreviousInstance(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+	mockLogger.On("Info", mock.Anything).Maybe()
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+	addr := server.Addr()
+
+	first := dialDrainClient(t, addr)
+	first.send(RegisterMessage{Type: "REGISTER", Role: "developer", InstanceID: "host-a-1"})
+	first.receive("ACK_REGISTER", nil)
+
+	// The same instance registering again is a reconnection: the earlier connection is left alone
+	resumed := dialDrainClient(t, addr)
+	resumed.send(RegisterMessage{Type: "REGISTER", Role: "developer", InstanceID: "host-a-1"})
+	resumed.receive("ACK_REGISTER", nil)
+	first.send(QueryMessage{Type: "QUERY_STATUS"})
+	first.receive("STATUS", nil)
+
+	// A different instance takes the role over and the one it replaced is told not to come back
+	takeover := dialDrainClient(t, addr)
+	takeover.send(RegisterMessage{Type: "REGISTER", Role: "developer", InstanceID: "host-b-2"})
+	takeover.receive("ACK_REGISTER", nil)
+	var shutdown ShutdownMessage
+	resumed.receive("SHUTDOWN", &shutdown)
+	assert.Equal(t, ShutdownReasonReplaced, shutdown.Reason)
+	assert.False(t, shutdown.ReconnectAdvised)
+	assert.Contains(t, shutdown.Message, "host-b-2")
+	resumed.expectClosed()
+
+	var status StatusMessage
+	takeover.send(QueryMessage{Type: "QUERY_STATUS"})
+	takeover.receive("STATUS", &status)
+	assert.Equal(t, map[string]string{"developer": "host-b-2"}, status.AgentInstances)
+
+	var agents AgentDetailsMessage
+	takeover.send(QueryAgentsMessage{Type: "QUERY_AGENTS"})
+	takeover.receive("AGENT_DETAILS", &agents)
+	require.Len(t, agents.AgentDetails, 1)
+	assert.Equal(t, "host-b-2", agents.AgentDetails[0].InstanceID)
+
+	malformed := dialDrainClient(t, addr)
+	malformed.send(RegisterMessage{Type: "REGISTER", Role: "tester", InstanceID: "host c"})
+	malformed.receive("ERROR", nil)
+}
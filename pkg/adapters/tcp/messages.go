@@ -3,6 +3,39 @@ package tcp
 // TCPMessage represents the base structure for all TCP protocol messages
 type TCPMessage struct {
 	Type string `json:"type"`
+	ID   string `json:"id,omitempty"` // Optional request id, echoed in the reply; see WithRequestID
+}
+
+// HelloMessage represents codec negotiation requests, always sent as JSON before any other message
+type HelloMessage struct {
+	Type  string `json:"type"`  // "HELLO"
+	Codec string `json:"codec"` // "json" or "msgpack"
+
+	// FieldNaming selects "snake_case" or "camelCase" field names for JSON; empty keeps the server default
+	FieldNaming string `json:"field_naming,omitempty"`
+}
+
+// HelloAckMessage confirms the codec used for all following messages on the connection
+type HelloAckMessage struct {
+	Type  string `json:"type"` // "HELLO_ACK"
+	Codec string `json:"codec"`
+
+	// FieldNaming is the field naming of the codec; empty for codecs that have only one
+	FieldNaming string `json:"field_naming,omitempty"`
+}
+
+// PingMessage checks that the server is reachable and responsive
+type PingMessage struct {
+	Type string `json:"type"`          // "PING"
+	Seq  int    `json:"seq,omitempty"` // Echoed back so clients can match replies
+}
+
+// PongMessage answers a ping
+type PongMessage struct {
+	Type       string `json:"type"` // "PONG"
+	Seq        int    `json:"seq,omitempty"`
+	Version    string `json:"version,omitempty"`
+	ServerTime string `json:"server_time"` // RFC3339
 }
 
 // RegisterMessage represents agent registration requests
@@ -10,26 +43,167 @@ type RegisterMessage struct {
 	Type         string   `json:"type"`         // "REGISTER"
 	Role         string   `json:"role"`
 	Capabilities []string `json:"capabilities"`
+	AgentType    string   `json:"agent_type,omitempty"`  // worker (default), observer or coordinator
+	Description  string   `json:"description,omitempty"` // What the agent does, for human operators
+	Weight       int      `json:"weight,omitempty"`      // Share of the assignments of weighted groups (default 1)
+
+	// ReservationToken claims a role the server reserved for agents holding this token
+	ReservationToken string `json:"reservation_token,omitempty"`
+
+	// Tags are free-form key/value labels such as {"region": "us-east"}, for routing and filtering
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// InstanceID identifies the process behind the agent across its reconnections. Registering a
+	// role under a different instance ID takes it over and sends the previous instance away
+	InstanceID string `json:"instance_id,omitempty"`
+}
+
+// UpdateCapabilitiesMessage lets a registered agent replace its capability list without re-registering
+type UpdateCapabilitiesMessage struct {
+	Type         string   `json:"type"` // "UPDATE_CAPABILITIES"
+	Role         string   `json:"role"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// AckUpdateCapabilitiesMessage confirms the capability list stored for the agent
+type AckUpdateCapabilitiesMessage struct {
+	Type         string   `json:"type"` // "ACK_UPDATE_CAPABILITIES"
+	Role         string   `json:"role"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// DeregisterMessage lets an agent leave the collective gracefully instead of dropping its connection
+type DeregisterMessage struct {
+	Type string `json:"type"` // "DEREGISTER"
+	Role string `json:"role"`
+}
+
+// AckDeregisterMessage confirms a deregistration; the server closes the connection after it
+type AckDeregisterMessage struct {
+	Type    string `json:"type"` // "ACK_DEREGISTER"
+	Role    string `json:"role"`
+	Message string `json:"message"`
+}
+
+// SetAgentStateMessage lets the people force an agent's state for recovery, or pause, fail or resume it
+type SetAgentStateMessage struct {
+	Type   string `json:"type"` // "SET_AGENT_STATE"
+	Role   string `json:"role"`
+	State  string `json:"state"`             // "waiting", "working", "paused" or "error"
+	Nonce  string `json:"nonce,omitempty"`   // Unique per command; required when the server enforces a replay window
+	SentAt string `json:"sent_at,omitempty"` // RFC 3339 send time; required when the server enforces a replay window
+}
+
+// ReassignMessage lets the people move in-progress work from a stuck holder to another role
+// The new holder receives the barrel's last message; the reply is an ACK_YIELD
+type ReassignMessage struct {
+	Type     string `json:"type"` // "REASSIGN"
+	FromRole string `json:"from_role"`
+	ToRole   string `json:"to_role"`
+	Nonce    string `json:"nonce,omitempty"`   // Unique per command; required when the server enforces a replay window
+	SentAt   string `json:"sent_at,omitempty"` // RFC 3339 send time; required when the server enforces a replay window
+}
+
+// AgentStateMessage confirms an agent's state after a forced change
+type AgentStateMessage struct {
+	Type     string `json:"type"` // "AGENT_STATE"
+	Role     string `json:"role"`
+	Previous string `json:"previous"`
+	State    string `json:"state"`
 }
 
 // YieldMessage represents yield requests from agents or people
 type YieldMessage struct {
-	Type     string `json:"type"` // "YIELD"
+	Type     string `json:"type"` // "YIELD", or "VALIDATE_YIELD" to only check it
 	FromRole string `json:"from_role"`
 	ToRole   string `json:"to_role"`
 	Payload  string `json:"payload"`
+	Failed   bool   `json:"failed,omitempty"`  // Sender reports its work failed; may be requeued
+	SentAt   string `json:"sent_at,omitempty"` // RFC 3339 send time; old yields are rejected as stale
+
+	// Nonce is unique per people yield, which can take the barrel from its holder; it is required from
+	// the people when the server enforces a replay window
+	Nonce string `json:"nonce,omitempty"`
+
+	// RequiredCapability rejects the yield when the target lacks it; it is also passed on to the
+	// activated agent, which declines work it is not capable of
+	RequiredCapability string `json:"required_capability,omitempty"`
+
+	// ExpectedDuration hints how long the recipient should hold the barrel, e.g. "30m"; overruns are only flagged
+	ExpectedDuration string `json:"expected_duration,omitempty"`
+
+	// Metadata is structured data delivered with the payload, e.g. {"pr": "42", "branch": "main"}
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ValidationResultMessage reports every problem found by VALIDATE_YIELD at once
+type ValidationResultMessage struct {
+	Type   string                `json:"type"` // "VALIDATION_RESULT"
+	Valid  bool                  `json:"valid"`
+	Errors []ValidationErrorInfo `json:"errors"`
+}
+
+// ValidationErrorInfo represents a single validation problem
+type ValidationErrorInfo struct {
+	Code    string `json:"code"` // e.g. "NOT_BARREL_HOLDER", "INVALID_TARGET"
+	Message string `json:"message"`
 }
 
 // QueryMessage represents query requests
 type QueryMessage struct {
-	Type string `json:"type"` // "QUERY_AGENTS" or "QUERY_STATUS"
+	Type string `json:"type"` // "QUERY_STATUS", "QUERY_PIPELINE", "QUERY_GROUPS" or "GET_TTL"
+}
+
+// QueryAgentsMessage asks for the registered agents, optionally only those carrying a tag
+type QueryAgentsMessage struct {
+	Type string `json:"type"`          // "QUERY_AGENTS"
+	Tag  string `json:"tag,omitempty"` // "key=value", or "key" for any value of the key
 }
 
 // ActivateMessage represents activation messages sent to agents
 type ActivateMessage struct {
-	Type     string `json:"type"` // "ACTIVATE"
-	FromRole string `json:"from_role"`
-	Payload  string `json:"payload"`
+	Type       string       `json:"type"` // "ACTIVATE"
+	FromRole   string       `json:"from_role"`
+	Payload    string       `json:"payload"`
+	RetryCount int          `json:"retry_count,omitempty"` // Set when the activation retries failed work
+	Receipt    *ReceiptInfo `json:"receipt,omitempty"`     // Receipt of the hand-off that activated the agent
+
+	// RequiredCapability is the capability the work needs, when the yielding party said so
+	RequiredCapability string `json:"required_capability,omitempty"`
+
+	// ExpectedDuration is how long the yielding party expects the work to take, when it said so
+	ExpectedDuration string `json:"expected_duration,omitempty"`
+
+	// Metadata is the structured data the yielding party sent with the payload, when it sent any
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// YieldAckMessage confirms a successful yield to the sender with the hand-off receipt
+type YieldAckMessage struct {
+	Type    string       `json:"type"` // "ACK_YIELD"
+	ToRole  string       `json:"to_role"`
+	Receipt *ReceiptInfo `json:"receipt,omitempty"`
+}
+
+// ReceiptInfo represents a hash-chained hand-off receipt
+type ReceiptInfo struct {
+	Sequence     int    `json:"sequence"`
+	FromRole     string `json:"from_role"`
+	ToRole       string `json:"to_role"`
+	PayloadHash  string `json:"payload_hash"`
+	Timestamp    string `json:"timestamp"` // RFC 3339 with nanoseconds
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash"`
+}
+
+// ActivateAckMessage represents an agent confirming it received an activation
+type ActivateAckMessage struct {
+	Type string `json:"type"` // "ACTIVATE_ACK"
+	Role string `json:"role"`
+
+	// ExpectedDuration is the agent's own estimate of how long it will hold the barrel, e.g. "30m"
+	// It replaces any hint given by the yielding party
+	ExpectedDuration string `json:"expected_duration,omitempty"`
 }
 
 // AgentListMessage represents response to agent list queries
@@ -46,25 +220,70 @@ type AgentDetailsMessage struct {
 
 // AgentDetailInfo represents detailed information about a single agent
 type AgentDetailInfo struct {
-	Role         string   `json:"role"`
-	Capabilities []string `json:"capabilities"`
-	State        string   `json:"state"`
-	Connected    bool     `json:"connected"`
+	Role            string   `json:"role"`
+	AgentType       string   `json:"agent_type"`
+	Description     string   `json:"description,omitempty"`
+	Weight          int      `json:"weight"`
+	Capabilities    []string `json:"capabilities"`
+	State           string   `json:"state"`
+	Connected       bool     `json:"connected"`
+	RegistrationSeq uint64   `json:"registration_seq"` // Lower numbers registered earlier
+
+	// Tags are the agent's key/value labels, e.g. {"region": "us-east"}
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// InstanceID identifies the process running the agent, empty if it gave none
+	InstanceID string `json:"instance_id,omitempty"`
 }
 
 // StatusMessage represents response to status queries
 type StatusMessage struct {
-	Type             string            `json:"type"` // "STATUS"
-	BarrelHolder     string            `json:"barrel_holder"`
-	RegisteredAgents []string          `json:"registered_agents"`
-	AgentStates      map[string]string `json:"agent_states"`
-	ConnectedAgents  map[string]bool   `json:"connected_agents"`
+	Type              string              `json:"type"` // "STATUS"
+	BarrelHolder      string              `json:"barrel_holder"`
+	RegisteredAgents  []string            `json:"registered_agents"`
+	AgentStates       map[string]string   `json:"agent_states"`
+	ConnectedAgents   map[string]bool     `json:"connected_agents"`
+	AgentCapabilities map[string][]string `json:"agent_capabilities,omitempty"`
+	RegistrationSeqs  map[string]uint64   `json:"registration_seqs,omitempty"`
+	AgentWeights      map[string]int      `json:"agent_weights,omitempty"`
+	YieldChainDepth   int                 `json:"yield_chain_depth"`
+	ExpectedDuration  string              `json:"expected_duration,omitempty"` // How long the holder is expected to keep the barrel
+	OverExpected      bool                `json:"over_expected,omitempty"`     // The holder has kept the barrel longer than expected
+	BenchedAgents     map[string]string   `json:"benched_agents,omitempty"`    // Role -> RFC3339 time the circuit breaker lets it work again
+
+	// HolderUnregistered is set when barrel_holder is not a registered agent; the people can reclaim it with REASSIGN
+	HolderUnregistered bool `json:"holder_unregistered,omitempty"`
+
+	// QueueDepths counts the activations queued for roles that were offline when work was routed to them
+	QueueDepths map[string]int `json:"queue_depths,omitempty"`
+
+	// AgentInstances maps roles to the instance ID they registered with, for agents that gave one
+	AgentInstances map[string]string `json:"agent_instances,omitempty"`
+
+	// AverageHolds maps roles to how long they hold the barrel on average, e.g. "12m30s", counting the current hold so far
+	AverageHolds map[string]string `json:"average_holds,omitempty"`
 }
 
+// Error codes sent in ErrorMessage.Code
+const (
+	ErrorCodeRateLimited  = "RATE_LIMITED"  // The connection exceeded its message rate, or its role its yield rate
+	ErrorCodeInvalidRole  = "INVALID_ROLE"  // A role field was missing or blank
+	ErrorCodeInvalidNonce = "INVALID_NONCE" // A privileged command lacked a usable nonce or sent_at
+	ErrorCodeStaleCommand = "STALE_COMMAND" // A privileged command was sent outside the replay window
+	ErrorCodeReplayed     = "REPLAYED"      // A privileged command reused a nonce
+	ErrorCodeClockSkew    = "CLOCK_SKEW"    // A timestamp is further ahead of the server clock than the skew tolerance
+	ErrorCodeDraining     = "DRAINING"      // The server is draining for a restart and hands out no new work
+	ErrorCodeHalted       = "HALTED"        // The people halted the collective; the barrel does not move until they resume
+	ErrorCodeQuarantined  = "QUARANTINED"   // The connection sent too many malformed messages and is closed
+	ErrorCodeReadOnly     = "READ_ONLY"     // The server is a read-only replica and only answers queries
+)
+
 // ErrorMessage represents error responses
 type ErrorMessage struct {
-	Type    string `json:"type"` // "ERROR"
-	Message string `json:"message"`
+	Type       string `json:"type"` // "ERROR"
+	Message    string `json:"message"`
+	Code       string `json:"code,omitempty"`        // Machine-readable reason, e.g. "RATE_LIMITED"
+	RetryAfter string `json:"retry_after,omitempty"` // How long to wait before retrying, when known
 }
 
 // AckRegisterMessage represents registration acknowledgment
@@ -73,3 +292,222 @@ type AckRegisterMessage struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 }
+
+// SetTTLMessage represents requests to change the barrel TTL at runtime
+type SetTTLMessage struct {
+	Type   string `json:"type"`              // "SET_TTL"
+	TTL    string `json:"ttl"`               // Go duration string, e.g. "30m"; "0" disables the deadline
+	Nonce  string `json:"nonce,omitempty"`   // Unique per command; required when the server enforces a replay window
+	SentAt string `json:"sent_at,omitempty"` // RFC 3339 send time; required when the server enforces a replay window
+}
+
+// TTLMessage represents response to TTL queries and updates
+type TTLMessage struct {
+	Type         string `json:"type"` // "TTL"
+	TTL          string `json:"ttl"`
+	BarrelHolder string `json:"barrel_holder"`
+	Deadline     string `json:"deadline,omitempty"`  // RFC3339, empty when no deadline applies
+	Remaining    string `json:"remaining,omitempty"` // Time left before the barrel is reclaimed
+}
+
+// DrainMessage is a people command that makes the server stop handing out work ahead of a restart
+type DrainMessage struct {
+	Type   string `json:"type"`              // "DRAIN"
+	Nonce  string `json:"nonce,omitempty"`   // Unique per command; required when the server enforces a replay window
+	SentAt string `json:"sent_at,omitempty"` // RFC 3339 send time; required when the server enforces a replay window
+}
+
+// DrainStatusMessage reports the progress of a drain
+type DrainStatusMessage struct {
+	Type             string `json:"type"` // "DRAIN_STATUS"
+	Draining         bool   `json:"draining"`
+	StartedAt        string `json:"started_at,omitempty"` // RFC3339, empty when not draining
+	AgentConnections int    `json:"agent_connections"`    // Registered agents still connected
+	OpenConnections  int    `json:"open_connections"`     // Every open connection, including the one asking
+	BarrelHolder     string `json:"barrel_holder"`
+	BarrelWithPeople bool   `json:"barrel_with_people"`
+	Complete         bool   `json:"complete"` // Draining, no agents connected and the barrel with the people
+}
+
+// Reasons a server gives in SHUTDOWN
+const (
+	ShutdownReasonDrain = "drain" // The server is draining for a planned restart
+	ShutdownReasonStop  = "stop"  // The server is stopping
+
+	ShutdownReasonReplaced = "replaced" // Another instance registered the agent's role
+)
+
+// ShutdownMessage tells an agent the server is closing its connection and whether to come back
+type ShutdownMessage struct {
+	Type             string `json:"type"`   // "SHUTDOWN"
+	Reason           string `json:"reason"` // ShutdownReasonDrain, ShutdownReasonStop or ShutdownReasonReplaced
+	Message          string `json:"message"`
+	ReconnectAdvised bool   `json:"reconnect_advised"` // False when the server is not coming back
+}
+
+// HaltMessage is a people command that stops every agent and freezes the barrel until RESUME
+type HaltMessage struct {
+	Type   string `json:"type"`              // "HALT"
+	Reason string `json:"reason"`            // Why the people stop the collective; required
+	Nonce  string `json:"nonce,omitempty"`   // Unique per command; required when the server enforces a replay window
+	SentAt string `json:"sent_at,omitempty"` // RFC 3339 send time; required when the server enforces a replay window
+}
+
+// ResumeMessage is a people command that lifts a halt
+type ResumeMessage struct {
+	Type   string `json:"type"`              // "RESUME"
+	Nonce  string `json:"nonce,omitempty"`   // Unique per command; required when the server enforces a replay window
+	SentAt string `json:"sent_at,omitempty"` // RFC 3339 send time; required when the server enforces a replay window
+}
+
+// HaltStatusMessage answers HALT and RESUME with whether the collective is halted
+type HaltStatusMessage struct {
+	Type           string `json:"type"` // "HALT_STATUS"
+	Halted         bool   `json:"halted"`
+	Reason         string `json:"reason,omitempty"`
+	Since          string `json:"since,omitempty"` // RFC3339, empty when not halted
+	BarrelHolder   string `json:"barrel_holder"`
+	NotifiedAgents int    `json:"notified_agents"` // Agents sent HALT_NOTICE by this command
+}
+
+// HaltNoticeMessage tells every connected agent that the people halted or resumed the collective
+type HaltNoticeMessage struct {
+	Type    string `json:"type"` // "HALT_NOTICE"
+	Halted  bool   `json:"halted"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+}
+
+// GroupsMessage represents response to group queries
+type GroupsMessage struct {
+	Type   string      `json:"type"` // "GROUPS"
+	Groups []GroupInfo `json:"groups"`
+}
+
+// GroupInfo represents a single yield group
+type GroupInfo struct {
+	Name      string   `json:"name"`
+	Members   []string `json:"members"`            // Priority order
+	Available []string `json:"available"`          // Connected, waiting members in priority order
+	Weighted  bool     `json:"weighted,omitempty"` // Assignments are shared by member weight instead of priority
+}
+
+// PipelineMessage represents response to pipeline queries
+type PipelineMessage struct {
+	Type         string   `json:"type"` // "PIPELINE"
+	Roles        []string `json:"roles"`
+	BarrelHolder string   `json:"barrel_holder"`
+	StageIndex   int      `json:"stage_index"` // -1 when the holder is not a pipeline stage
+	NextRole     string   `json:"next_role"`
+	OnPipeline   bool     `json:"on_pipeline"`
+}
+
+// StalenessMessage represents response to staleness queries
+type StalenessMessage struct {
+	Type         string `json:"type"` // "STALENESS"
+	BarrelHolder string `json:"barrel_holder"`
+	Since        string `json:"since,omitempty"` // RFC3339 time of the last barrel transfer
+	StaleFor     string `json:"stale_for"`       // How long the holder has had the barrel, e.g. "12m30s"
+	StaleSeconds int64  `json:"stale_seconds"`   // Same as StaleFor in whole seconds, for alerting
+
+	// ExpectedDuration is how long the holder was expected to keep the barrel, empty without a hint
+	ExpectedDuration string `json:"expected_duration,omitempty"`
+	OverExpected     bool   `json:"over_expected"` // The holder has kept the barrel longer than expected
+}
+
+// QueryHistoryMessage asks for the barrel's transfer history
+type QueryHistoryMessage struct {
+	Type  string `json:"type"`            // "QUERY_HISTORY"
+	Limit int    `json:"limit,omitempty"` // Only the last limit transfers; 0 returns every retained transfer
+}
+
+// TransferInfo is one barrel transfer of a history
+type TransferInfo struct {
+	FromRole   string       `json:"from_role"`
+	ToRole     string       `json:"to_role"`
+	Message    string       `json:"message"`
+	Timestamp  string       `json:"timestamp"` // RFC 3339
+	RetryCount int          `json:"retry_count,omitempty"`
+	Receipt    *ReceiptInfo `json:"receipt,omitempty"`
+
+	Metadata map[string]string `json:"metadata,omitempty"` // Structured data sent with Message
+}
+
+// HistoryMessage represents response to history queries
+type HistoryMessage struct {
+	Type      string         `json:"type"`      // "HISTORY"
+	Transfers []TransferInfo `json:"transfers"` // Oldest first; empty, not null, without transfers
+}
+
+// ContextMessage represents response to context queries: the holder's current task in one read
+type ContextMessage struct {
+	Type         string       `json:"type"` // "CONTEXT"
+	BarrelHolder string       `json:"barrel_holder"`
+	FromRole     string       `json:"from_role"`             // Who handed the holder the barrel, empty before the first transfer
+	Payload      string       `json:"payload"`               // The message the holder received with the barrel
+	ReceivedAt   string       `json:"received_at,omitempty"` // RFC3339 time the holder received the barrel
+	HeldFor      string       `json:"held_for"`              // How long the holder has had the barrel, e.g. "12m30s"
+	RetryCount   int          `json:"retry_count,omitempty"`
+	Receipt      *ReceiptInfo `json:"receipt,omitempty"` // Receipt of the hand-off that gave the holder the barrel
+
+	// ExpectedDuration is how long the holder is expected to keep the barrel, empty without a hint
+	ExpectedDuration string `json:"expected_duration,omitempty"`
+	OverExpected     bool   `json:"over_expected"` // The holder has kept the barrel longer than expected
+
+	// Metadata is the structured data the holder received with the payload
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// RolesNeededMessage represents response to required-role queries
+type RolesNeededMessage struct {
+	Type     string         `json:"type"` // "ROLES_NEEDED"
+	Required []string       `json:"required"`
+	Missing  []RoleNeedInfo `json:"missing"` // Empty when every required role is online
+}
+
+// RoleNeedInfo describes a required role that is not online
+type RoleNeedInfo struct {
+	Role   string `json:"role"`
+	Reason string `json:"reason"` // "not_registered" or "disconnected"
+}
+
+// ConnectionsMessage represents response to connection queries
+type ConnectionsMessage struct {
+	Type        string           `json:"type"` // "CONNECTIONS"
+	Connections []ConnectionInfo `json:"connections"`
+	ByteBudget  int64            `json:"byte_budget"`      // Bytes allowed per connection within the window (0 = unlimited)
+	Window      string           `json:"window,omitempty"` // Rolling window of the byte budget
+}
+
+// ConnectionInfo represents the inbound traffic of a single open connection
+type ConnectionInfo struct {
+	Remote      string `json:"remote"`
+	Role        string `json:"role,omitempty"` // Empty for unregistered (people) connections
+	BytesRead   int64  `json:"bytes_read"`     // Since the connection opened
+	WindowBytes int64  `json:"window_bytes"`   // Within the current budget window
+}
+
+// QueryCapabilityMessage asks which agents can take work needing a capability
+type QueryCapabilityMessage struct {
+	Type       string `json:"type"`           // "QUERY_CAPABILITY"
+	Role       string `json:"role,omitempty"` // Ask about this role only; empty lists every capable agent
+	Capability string `json:"capability"`     // May be a pattern such as "test/*"
+}
+
+// CapabilityMatchMessage represents response to capability queries
+type CapabilityMatchMessage struct {
+	Type       string `json:"type"` // "CAPABILITY_MATCH"
+	Capability string `json:"capability"`
+	Role       string `json:"role,omitempty"` // The role asked about, empty when listing
+
+	// Capable tells whether Role advertises the capability; when listing, whether any agent does
+	Capable bool               `json:"capable"`
+	Agents  []CapableAgentInfo `json:"agents"` // Capable agents in registration order
+}
+
+// CapableAgentInfo describes an agent advertising a queried capability and whether it can take work now
+type CapableAgentInfo struct {
+	Role      string `json:"role"`
+	State     string `json:"state"`
+	Connected bool   `json:"connected"`
+}
@@ -0,0 +1,59 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestTCPServer_YieldMetadataReachesRecipient(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+
+	developer := dialDrainClient(t, server.Addr())
+	developer.send(RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}})
+	developer.receive("ACK_REGISTER", nil)
+
+	metadata := map[string]string{"pr": "42", "branch": "main"}
+	people := dialDrainClient(t, server.Addr())
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Review the PR", Metadata: metadata})
+	people.receive("ACK_YIELD", nil)
+
+	var activate ActivateMessage
+	developer.receive("ACTIVATE", &activate)
+	assert.Equal(t, "Review the PR", activate.Payload)
+	assert.Equal(t, metadata, activate.Metadata)
+
+	people.send(QueryMessage{Type: "QUERY_CONTEXT"})
+	var barrelContext ContextMessage
+	people.receive("CONTEXT", &barrelContext)
+	assert.Equal(t, metadata, barrelContext.Metadata)
+
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Metadata: map[string]string{"": "42"}})
+	var response ErrorMessage
+	people.receive("ERROR", &response)
+	assert.Contains(t, response.Message, "metadata key cannot be empty")
+}
+
+func TestActivateMessage_OmitsEmptyMetadata(t *testing.T) {
+	data, err := json.Marshal(ActivateMessage{Type: "ACTIVATE", FromRole: "people", Payload: "Start"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "metadata")
+}
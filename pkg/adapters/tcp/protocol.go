@@ -0,0 +1,211 @@
+package tcp
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Message directions in a protocol description
+const (
+	DirectionClientToServer = "client_to_server"
+	DirectionServerToClient = "server_to_client"
+)
+
+// ProtocolDescription is a machine-readable description of every message on the wire
+// It is generated from the message structs so clients in other languages can be written against it
+type ProtocolDescription struct {
+	Messages   []MessageDescription `json:"messages"`
+	ErrorCodes []string             `json:"error_codes"` // Values of ErrorMessage.Code
+}
+
+// MessageDescription describes one message type
+type MessageDescription struct {
+	Type        string             `json:"type"`
+	Direction   string             `json:"direction"`
+	Description string             `json:"description"`
+	Replies     []string           `json:"replies,omitempty"` // Message types the server may answer with
+	Fields      []FieldDescription `json:"fields"`
+}
+
+// FieldDescription describes one field of a message
+// Fields encoded with omitempty are optional; nested objects list their own fields
+type FieldDescription struct {
+	Name     string             `json:"name"`
+	Type     string             `json:"type"` // string, integer, number, boolean, object, array<...> or map<string,...>
+	Required bool               `json:"required"`
+	Fields   []FieldDescription `json:"fields,omitempty"`
+}
+
+// protocolMessage registers a message type with the struct that encodes it
+type protocolMessage struct {
+	messageType string
+	direction   string
+	description string
+	replies     []string
+	message     interface{}
+}
+
+// protocolMessages lists every message type in the order a session typically uses them
+// A test keeps it in sync with messages.go and the server's message dispatch
+var protocolMessages = []protocolMessage{
+	{"HELLO", DirectionClientToServer, "Negotiate the codec for the rest of the connection; always sent as JSON", []string{"HELLO_ACK", "ERROR"}, HelloMessage{}},
+	{"HELLO_ACK", DirectionServerToClient, "Confirms the codec; it takes effect after this message", nil, HelloAckMessage{}},
+	{"PING", DirectionClientToServer, "Check that the server is reachable", []string{"PONG"}, PingMessage{}},
+	{"PONG", DirectionServerToClient, "Answers PING with the server version and time", nil, PongMessage{}},
+	{"REGISTER", DirectionClientToServer, "Register the connection as an agent with its capabilities", []string{"ACK_REGISTER", "ERROR"}, RegisterMessage{}},
+	{"ACK_REGISTER", DirectionServerToClient, "Confirms a registration; an ACTIVATE follows if the agent already holds the barrel", nil, AckRegisterMessage{}},
+	{"UPDATE_CAPABILITIES", DirectionClientToServer, "Replace a registered agent's capabilities", []string{"ACK_UPDATE_CAPABILITIES", "ERROR"}, UpdateCapabilitiesMessage{}},
+	{"ACK_UPDATE_CAPABILITIES", DirectionServerToClient, "Confirms the stored capabilities", nil, AckUpdateCapabilitiesMessage{}},
+	{"DEREGISTER", DirectionClientToServer, "Leave the collective, returning the barrel to the people if held", []string{"ACK_DEREGISTER", "ERROR"}, DeregisterMessage{}},
+	{"ACK_DEREGISTER", DirectionServerToClient, "Confirms a deregistration; the server closes the connection after it", nil, AckDeregisterMessage{}},
+	{"YIELD", DirectionClientToServer, "Hand the barrel to another role or group", []string{"ACK_YIELD", "ERROR"}, YieldMessage{}},
+	{"VALIDATE_YIELD", DirectionClientToServer, "Check a yield without transferring the barrel", []string{"VALIDATION_RESULT"}, YieldMessage{}},
+	{"VALIDATION_RESULT", DirectionServerToClient, "Every problem found by VALIDATE_YIELD", nil, ValidationResultMessage{}},
+	{"ACK_YIELD", DirectionServerToClient, "Confirms a transfer with its hand-off receipt", nil, YieldAckMessage{}},
+	{"ACTIVATE", DirectionServerToClient, "Tells an agent it holds the barrel and what to work on", []string{"ACTIVATE_ACK"}, ActivateMessage{}},
+	{"ACTIVATE_ACK", DirectionClientToServer, "Confirms an activation was received; no reply unless it fails", []string{"ERROR"}, ActivateAckMessage{}},
+	{"QUERY_AGENTS", DirectionClientToServer, "List registered agents with their details, optionally only those with a tag", []string{"AGENT_DETAILS", "ERROR"}, QueryAgentsMessage{}},
+	{"AGENT_DETAILS", DirectionServerToClient, "Registered agents with capabilities, state and connection", nil, AgentDetailsMessage{}},
+	{"AGENT_LIST", DirectionServerToClient, "Registered agent roles, sent by older servers instead of AGENT_DETAILS", nil, AgentListMessage{}},
+	{"QUERY_STATUS", DirectionClientToServer, "Query the state of the collective", []string{"STATUS"}, QueryMessage{}},
+	{"STATUS", DirectionServerToClient, "Barrel holder and every agent's state", nil, StatusMessage{}},
+	{"SUBSCRIBE", DirectionClientToServer, "Receive the STATUS now and again after every registration, deregistration, agent state change and barrel transfer", []string{"STATUS"}, QueryMessage{}},
+	{"QUERY_PIPELINE", DirectionClientToServer, "Query the configured pipeline", []string{"PIPELINE"}, QueryMessage{}},
+	{"PIPELINE", DirectionServerToClient, "Pipeline stages and the barrel's position in them", nil, PipelineMessage{}},
+	{"QUERY_GROUPS", DirectionClientToServer, "Query the configured yield groups", []string{"GROUPS"}, QueryMessage{}},
+	{"GROUPS", DirectionServerToClient, "Yield groups and their available members", nil, GroupsMessage{}},
+	{"QUERY_STALENESS", DirectionClientToServer, "Query how long the barrel has sat with its holder", []string{"STALENESS"}, QueryMessage{}},
+	{"STALENESS", DirectionServerToClient, "How long the holder has had the barrel", nil, StalenessMessage{}},
+	{"QUERY_CONTEXT", DirectionClientToServer, "Query everything the holder has to work with in one read", []string{"CONTEXT"}, QueryMessage{}},
+	{"CONTEXT", DirectionServerToClient, "The holder's current task: sender, payload, receipt and hints", nil, ContextMessage{}},
+	{"QUERY_HISTORY", DirectionClientToServer, "Query the barrel's transfer history, optionally only the last transfers", []string{"HISTORY", "ERROR"}, QueryHistoryMessage{}},
+	{"HISTORY", DirectionServerToClient, "Barrel transfers, oldest first, with their receipts", nil, HistoryMessage{}},
+	{"QUERY_ROLES_NEEDED", DirectionClientToServer, "Query required roles that are not online", []string{"ROLES_NEEDED"}, QueryMessage{}},
+	{"ROLES_NEEDED", DirectionServerToClient, "Required roles and those missing", nil, RolesNeededMessage{}},
+	{"QUERY_CONNECTIONS", DirectionClientToServer, "Query open connections and their traffic", []string{"CONNECTIONS"}, QueryMessage{}},
+	{"CONNECTIONS", DirectionServerToClient, "Open connections and the bytes each has sent", nil, ConnectionsMessage{}},
+	{"QUERY_CAPABILITY", DirectionClientToServer, "Query whether a role, or which roles, advertise a capability", []string{"CAPABILITY_MATCH", "ERROR"}, QueryCapabilityMessage{}},
+	{"CAPABILITY_MATCH", DirectionServerToClient, "Agents advertising the capability with their state and connection", nil, CapabilityMatchMessage{}},
+	{"REASSIGN", DirectionClientToServer, "People only: move a stuck holder's work to another role", []string{"ACK_YIELD", "ERROR"}, ReassignMessage{}},
+	{"SET_AGENT_STATE", DirectionClientToServer, "People only: force an agent's state for recovery, or pause, fail or resume it", []string{"AGENT_STATE", "ERROR"}, SetAgentStateMessage{}},
+	{"AGENT_STATE", DirectionServerToClient, "Confirms a forced state change", nil, AgentStateMessage{}},
+	{"SET_TTL", DirectionClientToServer, "People only: change the barrel TTL", []string{"TTL", "ERROR"}, SetTTLMessage{}},
+	{"GET_TTL", DirectionClientToServer, "Query the barrel TTL", []string{"TTL"}, QueryMessage{}},
+	{"TTL", DirectionServerToClient, "The barrel TTL and the holder's deadline", nil, TTLMessage{}},
+	{"DRAIN", DirectionClientToServer, "People only: stop handing out work ahead of a restart", []string{"DRAIN_STATUS", "ERROR"}, DrainMessage{}},
+	{"QUERY_DRAIN_STATUS", DirectionClientToServer, "Query the progress of a drain", []string{"DRAIN_STATUS"}, QueryMessage{}},
+	{"DRAIN_STATUS", DirectionServerToClient, "Whether the server is draining, the agents still connected and where the barrel is", nil, DrainStatusMessage{}},
+	{"HALT", DirectionClientToServer, "People only: stop every agent and freeze the barrel until RESUME", []string{"HALT_STATUS", "ERROR"}, HaltMessage{}},
+	{"RESUME", DirectionClientToServer, "People only: lift a halt so the barrel moves again", []string{"HALT_STATUS", "ERROR"}, ResumeMessage{}},
+	{"HALT_STATUS", DirectionServerToClient, "Whether the collective is halted, why and since when", nil, HaltStatusMessage{}},
+	{"HALT_NOTICE", DirectionServerToClient, "Sent to every agent when the people halt or resume the collective", nil, HaltNoticeMessage{}},
+	{"SHUTDOWN", DirectionServerToClient, "The server is closing the connection; reconnect_advised tells a restart from a permanent stop", nil, ShutdownMessage{}},
+	{"ERROR", DirectionServerToClient, "A request failed; code is set for machine-readable reasons", nil, ErrorMessage{}},
+}
+
+// errorCodes lists the values of ErrorMessage.Code
+var errorCodes = []string{
+	ErrorCodeRateLimited,
+	ErrorCodeInvalidRole,
+	ErrorCodeInvalidNonce,
+	ErrorCodeStaleCommand,
+	ErrorCodeReplayed,
+	ErrorCodeClockSkew,
+	ErrorCodeDraining,
+	ErrorCodeHalted,
+	ErrorCodeQuarantined,
+	ErrorCodeReadOnly,
+}
+
+// DescribeProtocol returns the description of every message type
+func DescribeProtocol() ProtocolDescription {
+	// Requests may carry an id, which the server echoes on the messages that answer them
+	answers := make(map[string]bool)
+	for _, registered := range protocolMessages {
+		if registered.direction == DirectionClientToServer {
+			for _, reply := range registered.replies {
+				answers[reply] = true
+			}
+		}
+	}
+
+	messages := make([]MessageDescription, 0, len(protocolMessages))
+	for _, registered := range protocolMessages {
+		fields := describeFields(reflect.TypeOf(registered.message))
+		if registered.direction == DirectionClientToServer || answers[registered.messageType] {
+			fields = append(fields, FieldDescription{Name: "id", Type: "string"})
+		}
+		messages = append(messages, MessageDescription{
+			Type:        registered.messageType,
+			Direction:   registered.direction,
+			Description: registered.description,
+			Replies:     registered.replies,
+			Fields:      fields,
+		})
+	}
+	return ProtocolDescription{
+		Messages:   messages,
+		ErrorCodes: append([]string(nil), errorCodes...),
+	}
+}
+
+// describeFields describes the JSON fields of a struct type
+func describeFields(structType reflect.Type) []FieldDescription {
+	fields := make([]FieldDescription, 0, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		description := FieldDescription{
+			Name:     name,
+			Type:     jsonType(field.Type),
+			Required: !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer,
+		}
+		if nested := elemStruct(field.Type); nested != nil {
+			description.Fields = describeFields(nested)
+		}
+		fields = append(fields, description)
+	}
+	return fields
+}
+
+// jsonType names the JSON representation of a Go type
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array<" + jsonType(t.Elem()) + ">"
+	case reflect.Map:
+		return "map<string," + jsonType(t.Elem()) + ">"
+	default:
+		return "object"
+	}
+}
+
+// elemStruct returns the struct a field holds directly, through a pointer or as slice elements
+func elemStruct(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return t
+	}
+	return nil
+}
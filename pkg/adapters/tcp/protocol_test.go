@@ -0,0 +1,166 @@
+package tcp
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messageStructs returns the structs in messages.go that carry a type field, i.e. whole messages
+func messageStructs(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "messages.go", nil, 0)
+	require.NoError(t, err)
+
+	var names []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		structType, ok := spec.Type.(*ast.StructType)
+		if !ok || spec.Name.Name == "TCPMessage" { // The envelope every message shares
+			return false
+		}
+		for _, field := range structType.Fields.List {
+			for _, name := range field.Names {
+				if name.Name == "Type" {
+					names = append(names, spec.Name.Name)
+				}
+			}
+		}
+		return false
+	})
+	return names
+}
+
+// dispatchedTypes returns the message types handled by the switch in processMessage
+func dispatchedTypes(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "server.go", nil, 0)
+	require.NoError(t, err)
+
+	var types []string
+	for _, decl := range file.Decls {
+		function, ok := decl.(*ast.FuncDecl)
+		if !ok || function.Name.Name != "processMessage" {
+			continue
+		}
+		ast.Inspect(function.Body, func(node ast.Node) bool {
+			clause, ok := node.(*ast.CaseClause)
+			if !ok {
+				return true
+			}
+			for _, expr := range clause.List {
+				if literal, ok := expr.(*ast.BasicLit); ok && literal.Kind == token.STRING {
+					value, err := strconv.Unquote(literal.Value)
+					require.NoError(t, err)
+					types = append(types, value)
+				}
+			}
+			return true
+		})
+	}
+	require.NotEmpty(t, types, "processMessage dispatch not found")
+	return types
+}
+
+func TestProtocolRegistry_CoversEveryMessage(t *testing.T) {
+	registered := make(map[string]protocolMessage)
+	described := make(map[string]bool)
+	for _, message := range protocolMessages {
+		_, duplicate := registered[message.messageType]
+		assert.False(t, duplicate, "message type %s registered twice", message.messageType)
+		registered[message.messageType] = message
+		described[reflect.TypeOf(message.message).Name()] = true
+	}
+
+	for _, name := range messageStructs(t) {
+		assert.True(t, described[name], "%s is not in the protocol registry", name)
+	}
+
+	dispatched := make(map[string]bool)
+	for _, messageType := range dispatchedTypes(t) {
+		dispatched[messageType] = true
+		message, ok := registered[messageType]
+		if assert.True(t, ok, "server handles %s but it is not in the protocol registry", messageType) {
+			assert.Equal(t, DirectionClientToServer, message.direction, messageType)
+		}
+	}
+
+	for _, message := range protocolMessages {
+		if message.direction == DirectionClientToServer {
+			assert.True(t, dispatched[message.messageType], "%s is registered but the server does not handle it", message.messageType)
+		}
+		for _, reply := range message.replies {
+			replyMessage, ok := registered[reply]
+			if assert.True(t, ok, "%s replies with unregistered %s", message.messageType, reply) {
+				assert.NotEqual(t, message.direction, replyMessage.direction, "%s replies to %s in the same direction", reply, message.messageType)
+			}
+		}
+	}
+}
+
+func TestProtocolRegistry_ListsEveryErrorCode(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "messages.go", nil, 0)
+	require.NoError(t, err)
+
+	listed := make(map[string]bool)
+	for _, code := range errorCodes {
+		listed[code] = true
+	}
+	for name, object := range file.Scope.Objects {
+		if object.Kind != ast.Con || !strings.HasPrefix(name, "ErrorCode") {
+			continue
+		}
+		value, err := strconv.Unquote(object.Decl.(*ast.ValueSpec).Values[0].(*ast.BasicLit).Value)
+		require.NoError(t, err)
+		assert.True(t, listed[value], "%s is missing from errorCodes", name)
+	}
+}
+
+func TestDescribeProtocol_Fields(t *testing.T) {
+	messages := make(map[string]MessageDescription)
+	for _, message := range DescribeProtocol().Messages {
+		messages[message.Type] = message
+	}
+	field := func(messageType, name string) FieldDescription {
+		for _, field := range messages[messageType].Fields {
+			if field.Name == name {
+				return field
+			}
+		}
+		t.Fatalf("%s has no field %s", messageType, name)
+		return FieldDescription{}
+	}
+
+	assert.Equal(t, DirectionClientToServer, messages["YIELD"].Direction)
+	assert.Equal(t, []string{"ACK_YIELD", "ERROR"}, messages["YIELD"].Replies)
+	assert.Equal(t, FieldDescription{Name: "to_role", Type: "string", Required: true}, field("YIELD", "to_role"))
+	assert.Equal(t, FieldDescription{Name: "failed", Type: "boolean"}, field("YIELD", "failed"))
+	assert.Equal(t, "array<string>", field("REGISTER", "capabilities").Type)
+	assert.Equal(t, "map<string,array<string>>", field("STATUS", "agent_capabilities").Type)
+
+	receipt := field("ACTIVATE", "receipt")
+	assert.Equal(t, "object", receipt.Type)
+	assert.False(t, receipt.Required)
+	assert.NotEmpty(t, receipt.Fields)
+
+	// Requests and their answers may carry an id; pushed messages never do
+	assert.Equal(t, FieldDescription{Name: "id", Type: "string"}, field("YIELD", "id"))
+	assert.Equal(t, FieldDescription{Name: "id", Type: "string"}, field("ACK_YIELD", "id"))
+	for _, pushed := range messages["ACTIVATE"].Fields {
+		assert.NotEqual(t, "id", pushed.Name)
+	}
+
+	connections := field("CONNECTIONS", "connections")
+	assert.Equal(t, "array<object>", connections.Type)
+	assert.Equal(t, "remote", connections.Fields[0].Name)
+}
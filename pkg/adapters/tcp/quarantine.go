@@ -0,0 +1,121 @@
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// ValidateQuarantine checks a malformed-message quarantine configuration
+// maxStrikes of 0 disables the quarantine; block of 0 disconnects without blocking the address
+func ValidateQuarantine(maxStrikes int, block time.Duration) error {
+	if maxStrikes < 0 {
+		return fmt.Errorf("max strikes cannot be negative: %d", maxStrikes)
+	}
+	if block < 0 {
+		return fmt.Errorf("strike block cannot be negative: %s", block)
+	}
+	return nil
+}
+
+// SetQuarantine disconnects a connection once it sends maxStrikes malformed messages in a row
+// A well-formed message clears its strikes. With a positive block, new connections from the same
+// remote host are refused for that long. maxStrikes of 0 disables the quarantine
+func (s *TCPServer) SetQuarantine(maxStrikes int, block time.Duration) error {
+	if err := ValidateQuarantine(maxStrikes, block); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxStrikes = maxStrikes
+	s.strikeBlock = block
+	return nil
+}
+
+// rejectMalformed answers a message that could not be parsed or has an unknown type and records a strike
+// The strike that reaches the limit closes the connection with a final QUARANTINED error instead
+func (s *TCPServer) rejectMalformed(conn net.Conn, message string) {
+	s.mu.Lock()
+	if s.maxStrikes == 0 {
+		s.mu.Unlock()
+		s.sendError(conn, message)
+		return
+	}
+	s.strikes[conn]++
+	strikes := s.strikes[conn]
+	quarantined := strikes >= s.maxStrikes
+	if quarantined && s.strikeBlock > 0 {
+		s.blocked[remoteHost(conn)] = time.Now().Add(s.strikeBlock)
+	}
+	role := s.roleFor(conn)
+	block := s.strikeBlock
+	s.mu.Unlock()
+
+	if !quarantined {
+		s.sendError(conn, message)
+		return
+	}
+
+	s.logger.Warn("Disconnecting connection after repeated malformed messages", map[string]interface{}{
+		"remote":  conn.RemoteAddr().String(),
+		"role":    role,
+		"strikes": strikes,
+		"blocked": block.String(),
+	})
+	// A client spamming the connection may not be reading; do not let the final error block forever
+	if s.closeLinger > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(s.closeLinger))
+	}
+	s.sendErrorCode(conn, ErrorCodeQuarantined, fmt.Sprintf("%s; disconnected after %d malformed messages", message, strikes))
+	_ = conn.Close()
+}
+
+// strikeCount returns the malformed messages a connection has sent since its last well-formed one
+func (s *TCPServer) strikeCount(conn net.Conn) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.strikes[conn]
+}
+
+// isQuarantined reports whether a connection reached the strike limit and was closed
+func (s *TCPServer) isQuarantined(conn net.Conn) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxStrikes > 0 && s.strikes[conn] >= s.maxStrikes
+}
+
+// forgive clears the strikes of a connection whose message did not add to the count it had before
+func (s *TCPServer) forgive(conn net.Conn, before int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strikes, ok := s.strikes[conn]; ok && strikes == before {
+		delete(s.strikes, conn)
+	}
+}
+
+// isBlocked reports whether connections from the remote host of conn are refused at now
+// Expired blocks are forgotten
+func (s *TCPServer) isBlocked(conn net.Conn, now time.Time) bool {
+	host := remoteHost(conn)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.blocked[host]
+	if !ok {
+		return false
+	}
+	if !now.Before(until) {
+		delete(s.blocked, host)
+		return false
+	}
+	return true
+}
+
+// remoteHost returns the address a connection comes from without its port
+func remoteHost(conn net.Conn) string {
+	addr := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
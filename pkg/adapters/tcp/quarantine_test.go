@@ -0,0 +1,137 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateQuarantine(t *testing.T) {
+	assert.NoError(t, ValidateQuarantine(0, 0))
+	assert.NoError(t, ValidateQuarantine(3, time.Minute))
+	assert.Error(t, ValidateQuarantine(-1, 0))
+	assert.Error(t, ValidateQuarantine(3, -time.Second))
+}
+
+// sendLine writes one frame to the server and decodes its reply
+func sendLine(t *testing.T, conn net.Conn, decoder *json.Decoder, line string) map[string]interface{} {
+	t.Helper()
+	_, err := conn.Write([]byte(line + "\n"))
+	require.NoError(t, err)
+	var reply map[string]interface{}
+	require.NoError(t, decoder.Decode(&reply))
+	return reply
+}
+
+func TestTCPServer_MalformedMessagesAccumulateStrikes(t *testing.T) {
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(&MockSovietService{}, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetQuarantine(5, 0))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go server.handleConnection(context.Background(), serverConn)
+	decoder := json.NewDecoder(clientConn)
+
+	for i, line := range []string{`not json`, `{"type":"NOPE"}`, `{"type":"PING","seq":"one"}`} {
+		reply := sendLine(t, clientConn, decoder, line)
+		assert.Equal(t, "ERROR", reply["type"], line)
+		assert.Nil(t, reply["code"], "strikes below the limit get a plain error")
+		assert.Equal(t, i+1, server.strikeCount(serverConn))
+	}
+}
+
+func TestTCPServer_QuarantinesConnectionAtStrikeLimit(t *testing.T) {
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+	mockLogger.On("Warn", "Disconnecting connection after repeated malformed messages", mock.Anything).Once()
+
+	server := NewTCPServer(&MockSovietService{}, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetQuarantine(3, time.Minute))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan struct{})
+	go func() {
+		server.handleConnection(context.Background(), serverConn)
+		close(done)
+	}()
+	decoder := json.NewDecoder(clientConn)
+
+	sendLine(t, clientConn, decoder, `garbage`)
+	sendLine(t, clientConn, decoder, `garbage`)
+	final := sendLine(t, clientConn, decoder, `garbage`)
+	assert.Equal(t, "ERROR", final["type"])
+	assert.Equal(t, ErrorCodeQuarantined, final["code"])
+	assert.Contains(t, final["message"], "disconnected after 3 malformed messages")
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection handler did not exit")
+	}
+	assert.Zero(t, server.strikeCount(serverConn), "strikes are forgotten with the connection")
+
+	now := time.Now()
+	assert.True(t, server.isBlocked(serverConn, now), "the remote address is blocked")
+	assert.False(t, server.isBlocked(serverConn, now.Add(time.Minute)), "the block expires")
+	mockLogger.AssertExpectations(t)
+}
+
+func TestTCPServer_WellFormedMessageClearsStrikes(t *testing.T) {
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(&MockSovietService{}, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetQuarantine(3, 0))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go server.handleConnection(context.Background(), serverConn)
+	decoder := json.NewDecoder(clientConn)
+
+	sendLine(t, clientConn, decoder, `garbage`)
+	sendLine(t, clientConn, decoder, `garbage`)
+	assert.Equal(t, 2, server.strikeCount(serverConn))
+
+	pong := sendLine(t, clientConn, decoder, `{"type":"PING","seq":1}`)
+	assert.Equal(t, "PONG", pong["type"])
+	// Strikes are cleared once the handler returns, just after the reply is written
+	assert.Eventually(t, func() bool {
+		return server.strikeCount(serverConn) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// Two more malformed messages stay below the limit again
+	for i := 0; i < 2; i++ {
+		reply := sendLine(t, clientConn, decoder, `garbage`)
+		assert.Nil(t, reply["code"])
+	}
+	assert.Equal(t, 2, server.strikeCount(serverConn))
+	assert.False(t, server.isBlocked(serverConn, time.Now()))
+}
+
+func TestTCPServer_QuarantineDisabledByDefault(t *testing.T) {
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(&MockSovietService{}, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go server.handleConnection(context.Background(), serverConn)
+	decoder := json.NewDecoder(clientConn)
+
+	for i := 0; i < 10; i++ {
+		reply := sendLine(t, clientConn, decoder, `garbage`)
+		assert.Nil(t, reply["code"])
+	}
+	assert.Zero(t, server.strikeCount(serverConn))
+}
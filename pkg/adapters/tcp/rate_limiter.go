@@ -0,0 +1,55 @@
+package tcp
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ValidateMessageRate checks a per-connection message rate limit before it is applied
+func ValidateMessageRate(rate float64, burst int) error {
+	if rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return fmt.Errorf("message rate must be a non-negative number, got %v", rate)
+	}
+	if rate > 0 && burst < 1 {
+		return fmt.Errorf("message burst must be at least 1, got %d", burst)
+	}
+	return nil
+}
+
+// tokenBucket limits the message throughput of a single connection
+// It holds up to burst tokens, refilled at rate tokens per second; each message spends one
+// Only the connection's own goroutine uses it, so it needs no locking
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// allow spends a token if one is available
+// Otherwise it reports how long until the next token is available
+func (b *tokenBucket) allow(now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, wait
+}
@@ -0,0 +1,81 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestTokenBucket_RefillsAtRate(t *testing.T) {
+	start := time.Now()
+	bucket := newTokenBucket(2, 2, start)
+
+	allowed, _ := bucket.allow(start)
+	assert.True(t, allowed)
+	allowed, _ = bucket.allow(start)
+	assert.True(t, allowed)
+
+	allowed, retryAfter := bucket.allow(start)
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	allowed, _ = bucket.allow(start.Add(500 * time.Millisecond))
+	assert.True(t, allowed)
+}
+
+func TestValidateMessageRate(t *testing.T) {
+	assert.NoError(t, ValidateMessageRate(0, 0))
+	assert.NoError(t, ValidateMessageRate(5, 10))
+	assert.Error(t, ValidateMessageRate(-1, 10))
+	assert.Error(t, ValidateMessageRate(5, 0))
+}
+
+func TestTCPServer_RateLimitsChattyConnection(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+	mockAgent.On("GetStaleness").Return(domain.Staleness{BarrelHolder: "people"})
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	require.NoError(t, server.SetMessageRateLimit(1, 2))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go server.handleConnection(context.Background(), serverConn)
+
+	// A tight status-poll loop: the burst is served, the rest is rejected
+	go func() {
+		for i := 0; i < 4; i++ {
+			_, _ = clientConn.Write([]byte(`{"type":"QUERY_STALENESS"}` + "\n"))
+		}
+	}()
+
+	scanner := bufio.NewScanner(clientConn)
+	var served, limited int
+	for i := 0; i < 4; i++ {
+		require.True(t, scanner.Scan())
+		var response ErrorMessage
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &response))
+		switch response.Type {
+		case "STALENESS":
+			served++
+		case "ERROR":
+			limited++
+			assert.Equal(t, ErrorCodeRateLimited, response.Code)
+			assert.NotEmpty(t, response.RetryAfter)
+		}
+	}
+	assert.Equal(t, 2, served)
+	assert.Equal(t, 2, limited)
+}
@@ -0,0 +1,46 @@
+package tcp
+
+import (
+	"fmt"
+	"net"
+)
+
+// replicaRejected lists the messages that change the collective and are refused by a read-only replica
+var replicaRejected = map[string]bool{
+	"REGISTER":            true,
+	"UPDATE_CAPABILITIES": true,
+	"YIELD":               true,
+	"ACTIVATE_ACK":        true,
+	"REASSIGN":            true,
+	"SET_AGENT_STATE":     true,
+	"SET_TTL":             true,
+	"DRAIN":               true,
+	"HALT":                true,
+	"RESUME":              true,
+}
+
+// SetReadOnly makes the server a read-only replica: it answers queries and rejects every message
+// that would change the collective with a READ_ONLY error. Barrels are not reclaimed either, since
+// the state belongs to the primary. It must be called before Start
+func (s *TCPServer) SetReadOnly(readOnly bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readOnly = readOnly
+}
+
+// IsReadOnly reports whether the server is a read-only replica
+func (s *TCPServer) IsReadOnly() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readOnly
+}
+
+// rejectOnReplica answers a message a read-only replica cannot serve with a READ_ONLY error
+// Returns true if the message was rejected
+func (s *TCPServer) rejectOnReplica(conn net.Conn, messageType string) bool {
+	if !replicaRejected[messageType] || !s.IsReadOnly() {
+		return false
+	}
+	s.sendErrorCode(conn, ErrorCodeReadOnly, fmt.Sprintf("%s rejected: this server is a read-only replica; send it to the primary", messageType))
+	return true
+}
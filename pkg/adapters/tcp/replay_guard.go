@@ -0,0 +1,63 @@
+package tcp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// replayGuard rejects privileged commands that are reused or too old to trust
+// A command is accepted once per nonce while its sent_at lies within the window of the server clock
+// With a clock skew tolerance, sent_at may run ahead of the server by up to skew, and behind by window plus skew
+type replayGuard struct {
+	mu     sync.Mutex
+	window time.Duration
+	skew   time.Duration        // Clock skew tolerance (0 = future commands are bounded by window)
+	seen   map[string]time.Time // nonce -> when its command stops being accepted anyway
+}
+
+// newReplayGuard creates a guard remembering nonces for the given window
+func newReplayGuard(window time.Duration) *replayGuard {
+	return &replayGuard{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// check records the nonce of a privileged command and returns an error code and message if it must be rejected
+func (g *replayGuard) check(nonce, sentAt string, now time.Time) (string, error) {
+	if nonce == "" {
+		return ErrorCodeInvalidNonce, fmt.Errorf("privileged commands require a nonce")
+	}
+	if sentAt == "" {
+		return ErrorCodeInvalidNonce, fmt.Errorf("privileged commands require sent_at")
+	}
+	sent, err := time.Parse(time.RFC3339Nano, sentAt)
+	if err != nil {
+		return ErrorCodeInvalidNonce, fmt.Errorf("invalid sent_at timestamp: %s", sentAt)
+	}
+
+	if g.skew > 0 && !domain.WithinSkew(sent, now, g.skew) {
+		return ErrorCodeClockSkew, fmt.Errorf("command sent at %s is ahead of the server clock by more than %s", sentAt, g.skew)
+	}
+	// Without a tolerance, commands from the future are bounded by the same window to tolerate clock skew
+	if age := now.Sub(sent); age > g.window+g.skew || (g.skew == 0 && age < -g.window) {
+		return ErrorCodeStaleCommand, fmt.Errorf("command sent at %s is outside the %s replay window", sentAt, g.window)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for seenNonce, expiry := range g.seen {
+		if now.After(expiry) {
+			delete(g.seen, seenNonce)
+		}
+	}
+	if _, replayed := g.seen[nonce]; replayed {
+		return ErrorCodeReplayed, fmt.Errorf("nonce %s was already used", nonce)
+	}
+	g.seen[nonce] = sent.Add(g.window + g.skew)
+	return "", nil
+}
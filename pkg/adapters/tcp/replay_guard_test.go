@@ -0,0 +1,164 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestReplayGuard_Check(t *testing.T) {
+	now := time.Now()
+	guard := newReplayGuard(time.Minute)
+	sentAt := now.Format(time.RFC3339Nano)
+
+	code, err := guard.check("n1", sentAt, now)
+	assert.NoError(t, err)
+	assert.Empty(t, code)
+
+	code, err = guard.check("n1", sentAt, now.Add(time.Second))
+	assert.Error(t, err)
+	assert.Equal(t, ErrorCodeReplayed, code)
+
+	code, _ = guard.check("n2", now.Add(-2*time.Minute).Format(time.RFC3339Nano), now)
+	assert.Equal(t, ErrorCodeStaleCommand, code)
+
+	code, _ = guard.check("n3", now.Add(2*time.Minute).Format(time.RFC3339Nano), now)
+	assert.Equal(t, ErrorCodeStaleCommand, code)
+
+	code, _ = guard.check("", sentAt, now)
+	assert.Equal(t, ErrorCodeInvalidNonce, code)
+
+	code, _ = guard.check("n4", "", now)
+	assert.Equal(t, ErrorCodeInvalidNonce, code)
+
+	// Expired nonces are forgotten; their commands are rejected as stale instead
+	guard.check("n5", sentAt, now)
+	assert.Contains(t, guard.seen, "n5")
+	code, _ = guard.check("n6", now.Add(2*time.Minute).Format(time.RFC3339Nano), now.Add(2*time.Minute))
+	assert.Empty(t, code)
+	assert.NotContains(t, guard.seen, "n5")
+}
+
+func TestReplayGuard_ClockSkew(t *testing.T) {
+	now := time.Now()
+	guard := newReplayGuard(time.Minute)
+	guard.skew = 5 * time.Second
+
+	code, err := guard.check("n1", now.Add(3*time.Second).Format(time.RFC3339Nano), now)
+	assert.NoError(t, err)
+	assert.Empty(t, code)
+
+	code, err = guard.check("n2", now.Add(10*time.Second).Format(time.RFC3339Nano), now)
+	assert.Error(t, err)
+	assert.Equal(t, ErrorCodeClockSkew, code)
+
+	// Slow clocks get the same slack on the window
+	code, _ = guard.check("n3", now.Add(-time.Minute-3*time.Second).Format(time.RFC3339Nano), now)
+	assert.Empty(t, code)
+	code, _ = guard.check("n4", now.Add(-time.Minute-10*time.Second).Format(time.RFC3339Nano), now)
+	assert.Equal(t, ErrorCodeStaleCommand, code)
+}
+
+func TestTCPServer_RejectsReplayedPrivilegedCommand(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	require.NoError(t, server.SetReplayWindow(time.Minute))
+	assert.Error(t, server.SetReplayWindow(-time.Second))
+
+	send := func(message string, response interface{}) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, message)
+		require.NoError(t, json.NewDecoder(clientConn).Decode(response))
+	}
+	setTTL := fmt.Sprintf(`{"type":"SET_TTL","ttl":"30m","nonce":"abc123","sent_at":%q}`, time.Now().UTC().Format(time.RFC3339Nano))
+
+	// A fresh command succeeds
+	mockSoviet.On("SetBarrelTTL", 30*time.Minute).Return(nil).Once()
+	mockSoviet.On("BarrelTTL").Return(30 * time.Minute).Once()
+	mockSoviet.On("BarrelDeadline").Return(time.Time{}).Once()
+	mockSoviet.On("QueryStatus").Return(domain.StatusResponse{BarrelHolder: "people"}).Once()
+	mockLogger.On("Info", "Barrel TTL updated", mock.Anything).Once()
+	var ttl TTLMessage
+	send(setTTL, &ttl)
+	assert.Equal(t, "TTL", ttl.Type)
+
+	// The captured command replayed verbatim is rejected without touching the domain
+	mockLogger.On("Warn", "Rejected privileged command", mock.Anything)
+	var replayed ErrorMessage
+	send(setTTL, &replayed)
+	assert.Equal(t, "ERROR", replayed.Type)
+	assert.Equal(t, ErrorCodeReplayed, replayed.Code)
+
+	// Commands without a nonce are rejected once the window is enforced
+	var missing ErrorMessage
+	send(`{"type":"SET_AGENT_STATE","role":"tester","state":"waiting"}`, &missing)
+	assert.Equal(t, ErrorCodeInvalidNonce, missing.Code)
+
+	mockSoviet.AssertExpectations(t)
+	mockSoviet.AssertNumberOfCalls(t, "SetBarrelTTL", 1)
+	mockSoviet.AssertNotCalled(t, "ForceAgentState", mock.Anything, mock.Anything)
+}
+
+func TestTCPServer_RejectsReplayedPeopleYield(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetReplayWindow(time.Minute))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+
+	developer := dialDrainClient(t, server.Addr())
+	developer.send(RegisterMessage{Type: "REGISTER", Role: "developer"})
+	developer.receive("ACK_REGISTER", nil)
+	tester := dialDrainClient(t, server.Addr())
+	tester.send(RegisterMessage{Type: "REGISTER", Role: "tester"})
+	tester.receive("ACK_REGISTER", nil)
+
+	people := dialDrainClient(t, server.Addr())
+	sentAt := time.Now().UTC().Format(time.RFC3339Nano)
+	start := YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Build it", Nonce: "n-1", SentAt: sentAt}
+	people.send(start)
+	people.receive("ACK_YIELD", nil)
+
+	// Taking the barrel from a working holder is the people's force-yield
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "tester", Payload: "Test it", SentAt: sentAt})
+	var missing ErrorMessage
+	people.receive("ERROR", &missing)
+	assert.Equal(t, ErrorCodeInvalidNonce, missing.Code)
+	assert.Equal(t, "developer", soviet.GetBarrelStatus())
+
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people:alice", ToRole: "tester", Payload: "Test it", Nonce: "n-2", SentAt: sentAt})
+	people.receive("ACK_YIELD", nil)
+	assert.Equal(t, "tester", soviet.GetBarrelStatus())
+
+	// The captured first yield replayed verbatim does not hand the barrel out again
+	people.send(start)
+	var replayed ErrorMessage
+	people.receive("ERROR", &replayed)
+	assert.Equal(t, ErrorCodeReplayed, replayed.Code)
+	assert.Equal(t, "tester", soviet.GetBarrelStatus())
+}
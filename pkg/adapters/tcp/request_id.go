@@ -0,0 +1,48 @@
+package tcp
+
+import (
+	"go/token"
+	"net"
+	"reflect"
+)
+
+// WithRequestID returns message with an "id" field, so a reply can be told apart from other
+// messages on a connection carrying several requests at once. Clients set it on requests and the
+// server echoes it on the reply; pushed messages such as ACTIVATE never have one. An empty id or a
+// message that is not an exported struct returns the message unchanged
+func WithRequestID(message interface{}, id string) interface{} {
+	value := reflect.ValueOf(message)
+	if id == "" || value.Kind() != reflect.Struct || !token.IsExported(value.Type().Name()) {
+		return message
+	}
+
+	// The message is embedded so its fields stay at the top level in every codec
+	identified := reflect.New(reflect.StructOf([]reflect.StructField{
+		{Name: value.Type().Name(), Type: value.Type(), Anonymous: true},
+		{Name: "ID", Type: reflect.TypeOf(id), Tag: `json:"id,omitempty"`},
+	})).Elem()
+	identified.Field(0).Set(value)
+	identified.Field(1).SetString(id)
+	return identified.Interface()
+}
+
+// beginRequest records the id of the request being processed on a connection
+func (s *TCPServer) beginRequest(conn net.Conn, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[conn] = id
+}
+
+// endRequest forgets the id once the request has been answered
+func (s *TCPServer) endRequest(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.requests, conn)
+}
+
+// requestID returns the id of the request being processed on a connection, empty if it had none
+func (s *TCPServer) requestID(conn net.Conn) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.requests[conn]
+}
@@ -0,0 +1,101 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestWithRequestID(t *testing.T) {
+	message := YieldMessage{Type: "YIELD", FromRole: "developer", ToRole: "tester"}
+	assert.Equal(t, message, WithRequestID(message, ""), "no id leaves the message as it is")
+	assert.Equal(t, "not a struct", WithRequestID("not a struct", "7"))
+
+	for _, codec := range []Codec{JSONCodec{}, JSONCodec{FieldNaming: FieldNamingCamel}, MsgpackCodec{}} {
+		t.Run(codec.Name()+"/"+codecNaming(codec), func(t *testing.T) {
+			data, err := codec.Encode(WithRequestID(message, "7"))
+			require.NoError(t, err)
+			frame := data
+			if codec.Name() == CodecMsgpack {
+				frame = data[4:]
+			}
+
+			var base TCPMessage
+			require.NoError(t, codec.Decode(frame, &base))
+			assert.Equal(t, TCPMessage{Type: "YIELD", ID: "7"}, base)
+			var decoded YieldMessage
+			require.NoError(t, codec.Decode(frame, &decoded))
+			assert.Equal(t, message, decoded)
+		})
+	}
+}
+
+// codecNaming names the field naming of JSON codecs in subtest names
+func codecNaming(codec Codec) string {
+	if jsonCodec, ok := codec.(JSONCodec); ok && jsonCodec.FieldNaming != "" {
+		return jsonCodec.FieldNaming
+	}
+	return "default"
+}
+
+func TestTCPServer_EchoesRequestIDs(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+
+	developer := dialDrainClient(t, server.Addr())
+	developer.send(WithRequestID(RegisterMessage{Type: "REGISTER", Role: "developer"}, "register-1"))
+	var ack struct {
+		AckRegisterMessage
+		ID string `json:"id"`
+	}
+	developer.receive("ACK_REGISTER", &ack)
+	assert.Equal(t, "register-1", ack.ID)
+
+	// Two requests are in flight on the agent's connection while the barrel is pushed to it
+	developer.send(WithRequestID(QueryMessage{Type: "QUERY_STATUS"}, "status-1"))
+	developer.send(WithRequestID(QueryMessage{Type: "GET_TTL"}, "ttl-1"))
+	people := dialDrainClient(t, server.Addr())
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Build it"})
+	people.receive("ACK_YIELD", nil)
+
+	ids := make(map[string]string)
+	for len(ids) < 3 {
+		line, err := developer.reader.ReadBytes('\n')
+		require.NoError(t, err)
+		var base TCPMessage
+		require.NoError(t, json.Unmarshal(line, &base))
+		ids[base.Type] = base.ID
+	}
+	assert.Equal(t, map[string]string{
+		"STATUS":   "status-1",
+		"TTL":      "ttl-1",
+		"ACTIVATE": "", // Pushed, so it answers no request
+	}, ids)
+
+	// Requests without an id get replies without one
+	developer.send(QueryMessage{Type: "QUERY_STATUS"})
+	var status struct {
+		StatusMessage
+		ID *string `json:"id"`
+	}
+	developer.receive("STATUS", &status)
+	assert.Nil(t, status.ID)
+}
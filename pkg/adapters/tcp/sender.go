@@ -62,8 +62,7 @@ func (s *TCPMessageSender) SendActivation(role string, payload string) error {
 
 	// Send with newline delimiter
 	data = append(data, '\n')
-	_, err = conn.Write(data)
-	if err != nil {
+	if err := WriteFrame(conn, data); err != nil {
 		return fmt.Errorf("failed to send activation message: %w", err)
 	}
 
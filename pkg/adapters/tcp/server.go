@@ -1,18 +1,27 @@
 package tcp
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/lonegunmanb/agentfarm/pkg/domain"
 )
 
+// ttlCheckInterval is how often the server checks for expired barrels and unacknowledged offers
+const ttlCheckInterval = time.Second
+
+// DefaultCloseLinger is how long a closing connection waits for its final message to reach the client
+const DefaultCloseLinger = 500 * time.Millisecond
+
 // TCPServer implements the CommandHandler port for TCP communication
 // This adapter handles incoming TCP connections and translates them to domain operations
 type TCPServer struct {
@@ -21,9 +30,77 @@ type TCPServer struct {
 	sender        domain.MessageSender
 	logger        domain.Logger
 	connections   map[string]net.Conn // role -> connection
+	codecs        map[net.Conn]Codec  // connection -> negotiated codec (JSON when absent)
+	budgets       map[net.Conn]*byteBudget
 	mu            sync.RWMutex
 	port          int
 	listener      net.Listener
+
+	// redactPayloads keeps message payloads out of debug logs
+	redactPayloads bool
+
+	// version is reported to clients in PONG replies
+	version string
+
+	// maxBytes is the inbound byte budget per connection over byteWindow (0 = unlimited)
+	maxBytes   int64
+	byteWindow time.Duration
+
+	// messageRate limits messages per second on each connection, allowing bursts of messageBurst (0 = unlimited)
+	messageRate  float64
+	messageBurst int
+
+	// peopleIdleTimeout disconnects unregistered connections with no traffic in either direction (0 = never)
+	peopleIdleTimeout time.Duration
+
+	// replay rejects reused or expired privileged commands (nil = commands are not checked)
+	replay *replayGuard
+
+	// clockSkew bounds how far ahead of the server clock a client timestamp may be (0 = not checked)
+	clockSkew time.Duration
+
+	// closeLinger bounds the final write and drain before a connection is closed (0 = close immediately)
+	closeLinger time.Duration
+
+	// draining is set by Drain: no new work is handed out and agents are disconnected once idle
+	draining       bool
+	drainStartedAt time.Time
+
+	// maxStrikes disconnects a connection after this many malformed messages in a row (0 = never)
+	// strikeBlock then refuses its remote host for that long (0 = not blocked)
+	maxStrikes  int
+	strikeBlock time.Duration
+	strikes     map[net.Conn]int     // connection -> malformed messages since its last well-formed one
+	blocked     map[string]time.Time // remote host -> when it may connect again
+
+	// inbox keeps activations for roles without a connection, at most inboxDepth per role
+	inbox      map[string][]ActivateMessage
+	inboxDepth int
+
+	// readOnly serves queries only, for replicas whose state is mirrored from a primary
+	readOnly bool
+
+	// fieldNaming is the JSON field naming of connections that do not choose one in HELLO
+	fieldNaming string
+
+	// framing is the framing of JSON connections, newline-delimited when empty
+	framing string
+
+	// requests maps a connection to the id of the request being answered on it, if the request had one
+	requests map[net.Conn]string
+
+	// instances maps an agent's connection to the instance ID it registered with, if it gave one
+	instances map[net.Conn]string
+
+	// subscribers are pushed a STATUS whenever notifier reports a published change; publishMu
+	// serializes the pushes
+	subscribers map[net.Conn]*statusSubscriber
+	publishMu   sync.Mutex
+	notifier    *statusNotifier
+
+	// stopSweeper ends the sweeper reclaiming expired barrels; sweeperDone is closed once it has returned
+	stopSweeper context.CancelFunc
+	sweeperDone chan struct{}
 }
 
 // NewTCPServer creates a new TCP server adapter
@@ -40,7 +117,18 @@ func NewTCPServer(
 		sender:        sender,
 		logger:        logger,
 		connections:   make(map[string]net.Conn),
+		codecs:        make(map[net.Conn]Codec),
+		budgets:       make(map[net.Conn]*byteBudget),
+		strikes:       make(map[net.Conn]int),
+		blocked:       make(map[string]time.Time),
+		inbox:         make(map[string][]ActivateMessage),
+		requests:      make(map[net.Conn]string),
+		instances:     make(map[net.Conn]string),
+		subscribers:   make(map[net.Conn]*statusSubscriber),
+		notifier:      newStatusNotifier(),
+		inboxDepth:    DefaultInboxDepth,
 		port:          port,
+		closeLinger:   DefaultCloseLinger,
 	}
 }
 
@@ -57,17 +145,144 @@ func (s *TCPServer) Start(ctx context.Context) error {
 	})
 
 	go s.acceptConnections(ctx)
+	go s.watchStatus(ctx)
+	// A replica's state is owned by its primary, which reclaims barrels itself
+	if !s.IsReadOnly() {
+		sweepCtx, cancel := context.WithCancel(ctx)
+		s.stopSweeper, s.sweeperDone = cancel, make(chan struct{})
+		go func() {
+			defer close(s.sweeperDone)
+			s.reclaimExpiredBarrels(sweepCtx)
+		}()
+	}
 	return nil
 }
 
-// Stop stops the TCP server
+// Stop stops the TCP server and sends every connected agent SHUTDOWN
+// Agents are advised to reconnect only when the server was drained first, i.e. for a planned restart
 func (s *TCPServer) Stop() error {
+	var err error
 	if s.listener != nil {
-		return s.listener.Close()
+		err = s.listener.Close()
+	}
+	// No barrel is reclaimed once Stop returns
+	if s.stopSweeper != nil {
+		s.stopSweeper()
+		<-s.sweeperDone
+	}
+
+	s.mu.RLock()
+	draining := s.draining
+	agents := make([]net.Conn, 0, len(s.connections))
+	for _, conn := range s.connections {
+		agents = append(agents, conn)
+	}
+	s.mu.RUnlock()
+
+	message := "Server is shutting down"
+	if draining {
+		message = "Server is restarting; reconnect once it is back"
+	}
+	var wg sync.WaitGroup
+	for _, conn := range agents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.dismiss(conn, ShutdownReasonStop, message, draining)
+		}()
+	}
+	wg.Wait()
+	return err
+}
+
+// SetRedactPayloads controls whether payloads of received messages are redacted in logs
+func (s *TCPServer) SetRedactPayloads(redact bool) {
+	s.redactPayloads = redact
+}
+
+// SetByteBudget limits how many bytes each connection may send within a rolling window
+// Connections exceeding it receive an ERROR and are disconnected; a limit of 0 disables the budget
+func (s *TCPServer) SetByteBudget(maxBytes int64, window time.Duration) error {
+	if err := ValidateByteBudget(maxBytes, window); err != nil {
+		return err
 	}
+	s.maxBytes = maxBytes
+	s.byteWindow = window
 	return nil
 }
 
+// SetReplayWindow requires privileged commands to carry a unique nonce and a sent_at within window
+// Reused nonces and commands sent outside the window are rejected; a window of 0 disables the check
+func (s *TCPServer) SetReplayWindow(window time.Duration) error {
+	if window < 0 {
+		return fmt.Errorf("replay window cannot be negative: %s", window)
+	}
+	s.replay = nil
+	if window > 0 {
+		s.replay = newReplayGuard(window)
+		s.replay.skew = s.clockSkew
+	}
+	return nil
+}
+
+// SetClockSkewTolerance sets how far ahead of the server clock the sent_at of privileged commands may be
+// Commands beyond it are rejected with CLOCK_SKEW; 0 keeps future commands bounded by the replay window
+func (s *TCPServer) SetClockSkewTolerance(tolerance time.Duration) error {
+	if tolerance < 0 {
+		return fmt.Errorf("clock skew tolerance cannot be negative: %s", tolerance)
+	}
+	s.clockSkew = tolerance
+	if s.replay != nil {
+		s.replay.skew = tolerance
+	}
+	return nil
+}
+
+// SetMessageRateLimit throttles each connection to rate messages per second with bursts of up to burst messages
+// Messages over the limit are dropped and answered with a RATE_LIMITED error; a rate of 0 disables the limit
+func (s *TCPServer) SetMessageRateLimit(rate float64, burst int) error {
+	if err := ValidateMessageRate(rate, burst); err != nil {
+		return err
+	}
+	s.messageRate = rate
+	s.messageBurst = burst
+	return nil
+}
+
+// SetPeopleIdleTimeout disconnects people connections that neither send nor receive anything for this long
+// Connections registered as agents are never disconnected for inactivity; 0 disables the timeout
+func (s *TCPServer) SetPeopleIdleTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("people idle timeout cannot be negative: %s", timeout)
+	}
+	s.peopleIdleTimeout = timeout
+	return nil
+}
+
+// SetCloseLinger sets how long a closing connection waits for its final message to be delivered
+// A client that stops reading cannot hold the connection open longer than this; 0 closes immediately
+func (s *TCPServer) SetCloseLinger(linger time.Duration) error {
+	if linger < 0 {
+		return fmt.Errorf("close linger cannot be negative: %s", linger)
+	}
+	s.closeLinger = linger
+	return nil
+}
+
+// SetVersion sets the server version reported in PONG replies
+func (s *TCPServer) SetVersion(version string) {
+	s.version = version
+}
+
+// Addr returns the address the server is listening on, or nil before Start
+// Useful when the server was started on port 0 and the kernel picked the port
+func (s *TCPServer) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
 // acceptConnections accepts incoming connections and handles them
 func (s *TCPServer) acceptConnections(ctx context.Context) {
 	for {
@@ -85,28 +300,152 @@ func (s *TCPServer) acceptConnections(ctx context.Context) {
 				continue
 			}
 
+			if s.isBlocked(conn, time.Now()) {
+				s.logger.Debug("Refusing connection from quarantined address", map[string]interface{}{
+					"remote": conn.RemoteAddr().String(),
+				})
+				_ = conn.Close()
+				continue
+			}
+
 			go s.handleConnection(ctx, conn)
 		}
 	}
 }
 
+// reclaimExpiredBarrels periodically returns the barrel to the people once its TTL expires,
+// when an offered holder fails to acknowledge its activation in time, or when a disconnected
+// holder does not reconnect within the grace period. It also disconnects agents that missed their
+// heartbeats, returns agents left working without the barrel to waiting and prunes transfer
+// records past the history retention
+func (s *TCPServer) reclaimExpiredBarrels(ctx context.Context) {
+	ticker := time.NewTicker(ttlCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.sovietService.ReclaimExpiredBarrel(); err != nil {
+				s.logger.Error("Failed to reclaim expired barrel", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			if _, err := s.sovietService.ReclaimUnacknowledgedOffer(); err != nil {
+				s.logger.Error("Failed to reclaim unacknowledged barrel", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			if _, err := s.sovietService.DisconnectSilentAgents(); err != nil {
+				s.logger.Error("Failed to disconnect silent agents", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			if _, err := s.sovietService.ReclaimDisconnectedBarrel(); err != nil {
+				s.logger.Error("Failed to reclaim barrel from disconnected agent", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			if _, err := s.sovietService.ReconcileStates(); err != nil {
+				s.logger.Error("Failed to reconcile agent states", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			if _, err := s.sovietService.PruneHistory(); err != nil {
+				s.logger.Error("Failed to prune transfer history", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
+
 // handleConnection handles a single TCP connection
 func (s *TCPServer) handleConnection(ctx context.Context, conn net.Conn) {
 	defer func() {
-		_ = conn.Close()
+		s.mu.Lock()
+		delete(s.codecs, conn)
+		delete(s.budgets, conn)
+		delete(s.strikes, conn)
+		delete(s.instances, conn)
+		// Only roles still stored with this connection are removed; a role re-registered on
+		// another connection keeps it, so yields are never routed to a closed connection
+		roles := s.rolesFor(conn)
+		for _, role := range roles {
+			delete(s.connections, role)
+		}
+		s.mu.Unlock()
+		s.closeConn(conn)
+
+		// A role re-registered on another connection is not disconnected
+		for _, role := range roles {
+			if err := s.sovietService.DisconnectAgent(role); err != nil {
+				s.logger.Error("Failed to mark agent disconnected", map[string]interface{}{
+					"role":  role,
+					"error": err.Error(),
+				})
+			}
+		}
+		s.unsubscribe(conn)
 	}()
 
-	scanner := bufio.NewScanner(conn)
+	budget := newByteBudget(s.maxBytes, s.byteWindow)
+	s.mu.Lock()
+	s.budgets[conn] = budget
+	s.mu.Unlock()
+
+	var limiter *tokenBucket
+	if s.messageRate > 0 {
+		limiter = newTokenBucket(s.messageRate, s.messageBurst, time.Now())
+	}
+
+	s.touch(conn)
+	scanner := NewFrameScanner(&budgetReader{reader: conn, budget: budget, now: time.Now}, SplitFrames(func() Codec {
+		return s.codecFor(conn)
+	}))
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
+		frame := scanner.Text()
+		if strings.TrimSpace(frame) == "" {
 			continue
 		}
 
-		s.processMessage(ctx, conn, line)
+		if limiter != nil {
+			if allowed, retryAfter := limiter.allow(time.Now()); !allowed {
+				s.sendRateLimited(conn, retryAfter)
+				continue
+			}
+		}
+
+		s.processMessage(ctx, conn, frame)
+		if s.isQuarantined(conn) {
+			break
+		}
+		s.touch(conn)
 	}
 
-	if err := scanner.Err(); err != nil {
+	var netErr net.Error
+	if err := scanner.Err(); errors.As(err, &netErr) && netErr.Timeout() {
+		s.logger.Info("Closing idle people connection", map[string]interface{}{
+			"remote":  conn.RemoteAddr().String(),
+			"timeout": s.peopleIdleTimeout.String(),
+		})
+	} else if err == errByteBudgetExceeded {
+		s.mu.RLock()
+		role := s.roleFor(conn)
+		s.mu.RUnlock()
+		s.logger.Warn("Disconnecting connection over its byte budget", map[string]interface{}{
+			"remote": conn.RemoteAddr().String(),
+			"role":   role,
+			"limit":  s.maxBytes,
+			"window": s.byteWindow.String(),
+		})
+		// A client flooding the connection may not be reading; do not let the final error block forever
+		if s.closeLinger > 0 {
+			_ = conn.SetWriteDeadline(time.Now().Add(s.closeLinger))
+		}
+		s.sendError(conn, fmt.Sprintf("Byte budget exceeded: more than %d bytes within %s", s.maxBytes, s.byteWindow))
+	} else if err != nil {
 		s.logger.Error("Connection scan error", map[string]interface{}{
 			"error": err.Error(),
 		})
@@ -116,28 +455,85 @@ func (s *TCPServer) handleConnection(ctx context.Context, conn net.Conn) {
 // processMessage processes a single JSON message from a connection
 func (s *TCPServer) processMessage(ctx context.Context, conn net.Conn, messageData string) {
 	s.logger.Debug("Received message", map[string]interface{}{
-		"message": messageData,
+		"message": s.loggedMessage(conn, messageData),
 	})
 
 	// Parse base message to determine type
 	var baseMsg TCPMessage
-	if err := json.Unmarshal([]byte(messageData), &baseMsg); err != nil {
-		s.sendError(conn, "Invalid JSON format")
+	if err := s.decode(conn, messageData, &baseMsg); err != nil {
+		s.rejectMalformed(conn, fmt.Sprintf("Invalid %s format", strings.ToUpper(s.codecFor(conn).Name())))
 		return
 	}
+	if baseMsg.ID != "" {
+		s.beginRequest(conn, baseMsg.ID)
+		defer s.endRequest(conn)
+	}
 
+	strikes := s.strikeCount(conn)
+	if s.rejectOnReplica(conn, baseMsg.Type) {
+		s.forgive(conn, strikes)
+		return
+	}
 	switch baseMsg.Type {
+	case "HELLO":
+		s.handleHelloMessage(conn, messageData)
+	case "PING":
+		s.handlePingMessage(conn, messageData)
 	case "REGISTER":
 		s.handleRegisterMessage(ctx, conn, messageData)
+	case "UPDATE_CAPABILITIES":
+		s.handleUpdateCapabilitiesMessage(ctx, conn, messageData)
+	case "DEREGISTER":
+		s.handleDeregisterMessage(ctx, conn, messageData)
 	case "YIELD":
 		s.handleYieldMessage(ctx, conn, messageData)
+	case "VALIDATE_YIELD":
+		s.handleValidateYieldMessage(ctx, conn, messageData)
+	case "ACTIVATE_ACK":
+		s.handleActivateAckMessage(ctx, conn, messageData)
 	case "QUERY_AGENTS":
-		s.handleQueryAgentsMessage(ctx, conn)
+		s.handleQueryAgentsMessage(ctx, conn, messageData)
 	case "QUERY_STATUS":
 		s.handleQueryStatusMessage(ctx, conn)
+	case "SUBSCRIBE":
+		s.handleSubscribeMessage(ctx, conn)
+	case "QUERY_PIPELINE":
+		s.handleQueryPipelineMessage(ctx, conn)
+	case "QUERY_GROUPS":
+		s.handleQueryGroupsMessage(ctx, conn)
+	case "QUERY_STALENESS":
+		s.handleQueryStalenessMessage(ctx, conn)
+	case "QUERY_CONTEXT":
+		s.handleQueryContextMessage(ctx, conn)
+	case "QUERY_HISTORY":
+		s.handleQueryHistoryMessage(ctx, conn, messageData)
+	case "QUERY_ROLES_NEEDED":
+		s.handleQueryRolesNeededMessage(ctx, conn)
+	case "QUERY_CONNECTIONS":
+		s.handleQueryConnectionsMessage(ctx, conn)
+	case "QUERY_CAPABILITY":
+		s.handleQueryCapabilityMessage(ctx, conn, messageData)
+	case "REASSIGN":
+		s.handleReassignMessage(ctx, conn, messageData)
+	case "SET_AGENT_STATE":
+		s.handleSetAgentStateMessage(ctx, conn, messageData)
+	case "SET_TTL":
+		s.handleSetTTLMessage(ctx, conn, messageData)
+	case "GET_TTL":
+		s.handleGetTTLMessage(ctx, conn)
+	case "DRAIN":
+		s.handleDrainMessage(ctx, conn, messageData)
+	case "QUERY_DRAIN_STATUS":
+		s.handleQueryDrainStatusMessage(ctx, conn)
+	case "HALT":
+		s.handleHaltMessage(ctx, conn, messageData)
+	case "RESUME":
+		s.handleResumeMessage(ctx, conn, messageData)
 	default:
-		s.sendError(conn, fmt.Sprintf("Unknown message type: %s", baseMsg.Type))
+		s.rejectMalformed(conn, fmt.Sprintf("Unknown message type: %s", baseMsg.Type))
 	}
+	// A well-formed message clears the strikes of earlier malformed ones
+	s.forgive(conn, strikes)
 }
 
 // Implementation of CommandHandler interface methods
@@ -145,7 +541,8 @@ func (s *TCPServer) processMessage(ctx context.Context, conn net.Conn, messageDa
 // HandleRegister processes agent registration requests
 func (s *TCPServer) HandleRegister(ctx context.Context, role string, capabilities []string) (bool, string, error) {
 	agent := domain.NewAgentComrade(role, capabilities)
-	return s.sovietService.RegisterAgent(agent)
+	shouldActivate, payload, _, err := s.sovietService.RegisterAgent(agent)
+	return shouldActivate, payload, err
 }
 
 // HandleYield processes yield requests from agents or people
@@ -169,13 +566,24 @@ func (s *TCPServer) HandleQueryStatus(ctx context.Context) (domain.StatusRespons
 
 func (s *TCPServer) handleRegisterMessage(ctx context.Context, conn net.Conn, messageData string) {
 	var msg RegisterMessage
-	if err := json.Unmarshal([]byte(messageData), &msg); err != nil {
-		s.sendError(conn, "Invalid REGISTER message format")
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid REGISTER message format")
 		return
 	}
 
+	msg.Role = strings.TrimSpace(msg.Role)
 	if msg.Role == "" {
-		s.sendError(conn, "Role is required for registration")
+		s.sendErrorCode(conn, ErrorCodeInvalidRole, "Role is required for registration and cannot be blank")
+		return
+	}
+
+	if domain.IsReservedRole(msg.Role) {
+		s.sendError(conn, fmt.Sprintf("Role '%s' is reserved and cannot be registered", msg.Role))
+		return
+	}
+
+	if s.isDraining() {
+		s.dismiss(conn, ShutdownReasonDrain, "Server is draining for a restart; registrations are closed until it is back", true)
 		return
 	}
 
@@ -184,13 +592,58 @@ func (s *TCPServer) handleRegisterMessage(ctx context.Context, conn net.Conn, me
 		capabilities = []string{}
 	}
 
-	// Store connection for this role
+	agentType, err := domain.ParseAgentType(msg.AgentType)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	agent, err := domain.NewTypedAgentComrade(msg.Role, capabilities, agentType)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	if err := agent.SetDescription(msg.Description); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	if err := agent.SetTags(msg.Tags); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	if err := agent.SetInstanceID(msg.InstanceID); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	if msg.Weight != 0 {
+		if err := agent.SetWeight(msg.Weight); err != nil {
+			s.sendError(conn, err.Error())
+			return
+		}
+	}
+	agent.SetReservationToken(msg.ReservationToken)
+
+	// Store connection for this role, remembering which instance had it to tell a reconnection
+	// from another instance taking it over
 	s.mu.Lock()
+	previous, hadPrevious := s.connections[msg.Role]
+	previousInstance := s.instances[previous]
 	s.connections[msg.Role] = conn
+	s.instances[conn] = agent.InstanceID()
 	s.mu.Unlock()
 
-	shouldActivate, payload, err := s.HandleRegister(ctx, msg.Role, capabilities)
+	shouldActivate, payload, fromRole, err := s.sovietService.RegisterAgent(agent)
 	if err != nil {
+		// A refused registration, e.g. without a reserved role's token, must not take over the role's connection
+		s.mu.Lock()
+		delete(s.instances, conn)
+		if s.connections[msg.Role] == conn {
+			if hadPrevious {
+				s.connections[msg.Role] = previous
+			} else {
+				delete(s.connections, msg.Role)
+			}
+		}
+		s.mu.Unlock()
 		s.sendError(conn, err.Error())
 		return
 	}
@@ -203,63 +656,398 @@ func (s *TCPServer) handleRegisterMessage(ctx context.Context, conn net.Conn, me
 	}
 	s.sendMessage(conn, ackMsg)
 
+	// A different instance taking the role over tells the previous one, which would otherwise
+	// keep running without ever hearing from the server again
+	if hadPrevious && previous != conn && domain.IsInstanceTakeover(previousInstance, agent.InstanceID()) {
+		go s.dismiss(previous, ShutdownReasonReplaced,
+			fmt.Sprintf("Role '%s' was taken over by instance '%s'", msg.Role, agent.InstanceID()), false)
+	}
+
+	// Activations queued while the role was offline are superseded by the registration:
+	// the role resumes its work only if it still holds the barrel
+	queued := s.takeInbox(msg.Role)
+	if len(queued) > 0 {
+		s.logger.Info("Drained activation queue on registration", map[string]interface{}{
+			"role":    msg.Role,
+			"depth":   len(queued),
+			"resumed": shouldActivate,
+		})
+	}
+
 	// If should activate, send activation message
 	if shouldActivate {
 		activateMsg := ActivateMessage{
 			Type:     "ACTIVATE",
-			FromRole: "soviet", // Will be set properly based on actual from role
+			FromRole: fromRole,
 			Payload:  payload,
 		}
-		s.sendMessage(conn, activateMsg)
+		// The last queued activation carries the details of the hand-off when it is the current one
+		if len(queued) > 0 {
+			latest := queued[len(queued)-1]
+			if transfer, ok := s.sovietService.LastTransfer(); ok && latest.Receipt != nil && latest.Receipt.Sequence == transfer.Receipt.Sequence {
+				activateMsg = latest
+			}
+		}
+		// The activation is not the answer to REGISTER, so it does not echo the request id
+		s.pushMessage(conn, activateMsg)
+	}
+}
+
+func (s *TCPServer) handleUpdateCapabilitiesMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg UpdateCapabilitiesMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid UPDATE_CAPABILITIES message format")
+		return
+	}
+
+	if msg.Role == "" {
+		s.sendError(conn, "Role is required for capability update")
+		return
 	}
+
+	// Hold the lock so a concurrent re-registration cannot swap the connection mid-update
+	s.mu.Lock()
+	if registered, exists := s.connections[msg.Role]; !exists || registered != conn {
+		s.mu.Unlock()
+		s.sendError(conn, fmt.Sprintf("Connection is not registered as '%s'", msg.Role))
+		return
+	}
+	capabilities, err := s.sovietService.UpdateAgentCapabilities(msg.Role, msg.Capabilities)
+	s.mu.Unlock()
+
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+
+	s.sendMessage(conn, AckUpdateCapabilitiesMessage{
+		Type:         "ACK_UPDATE_CAPABILITIES",
+		Role:         msg.Role,
+		Capabilities: capabilities,
+	})
 }
 
 func (s *TCPServer) handleYieldMessage(ctx context.Context, conn net.Conn, messageData string) {
 	var msg YieldMessage
-	if err := json.Unmarshal([]byte(messageData), &msg); err != nil {
-		s.sendError(conn, "Invalid YIELD message format")
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid YIELD message format")
+		return
+	}
+
+	if !s.normalizeYieldRoles(conn, &msg) {
+		return
+	}
+
+	// A people yield can take the barrel from a working holder, so it is a privileged command
+	if domain.IsPeopleIdentity(msg.FromRole) && !s.checkReplay(conn, "YIELD", msg.Nonce, msg.SentAt) {
+		return
+	}
+
+	if domain.NormalizeRole(msg.ToRole) != "people" && s.isDraining() {
+		s.sendErrorCode(conn, ErrorCodeDraining, "Server is draining for a restart; the barrel can only return to the people")
+		return
+	}
+
+	yieldMsg, err := newDomainYield(msg)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+
+	if err := s.sovietService.ProcessYield(yieldMsg); err != nil {
+		// A sender whose clock runs ahead gets a code it can act on rather than just a message
+		var validationErr domain.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Code == domain.ValidationCodeClockSkew {
+			s.sendErrorCode(conn, ErrorCodeClockSkew, err.Error())
+			return
+		}
+		// So does a role yielding faster than the soviet allows
+		if errors.As(err, &validationErr) && validationErr.Code == domain.ValidationCodeRateLimited {
+			s.sendErrorCode(conn, ErrorCodeRateLimited, err.Error())
+			return
+		}
+		s.sendHaltAwareError(conn, err)
+		return
+	}
+
+	// The soviet may route the barrel elsewhere (e.g. requeued work), so activate the actual recipient
+	transfer, ok := s.sovietService.LastTransfer()
+	if !ok {
+		transfer = domain.TransferRecord{FromRole: msg.FromRole, ToRole: msg.ToRole, Message: msg.Payload, Metadata: msg.Metadata}
+	}
+
+	var receipt *ReceiptInfo
+	if ok {
+		receipt = newReceiptInfo(transfer.Receipt)
+	}
+
+	// Both parties get the receipt so each can log the hand-off
+	s.sendMessage(conn, YieldAckMessage{
+		Type:    "ACK_YIELD",
+		ToRole:  transfer.ToRole,
+		Receipt: receipt,
+	})
+
+	s.activateRecipient(transfer, receipt, msg.RequiredCapability, yieldMsg.ExpectedDuration())
+
+	// A holder that has returned the barrel during a drain has nothing left to do
+	if transfer.ToRole == "people" && !domain.IsPeopleIdentity(msg.FromRole) && s.isDraining() {
+		s.dismiss(conn, ShutdownReasonDrain, "Server is draining for a restart; reconnect once it is back", true)
+	}
+}
+
+// activateRecipient sends ACTIVATE to the agent that received the barrel, or queues it while the agent is not connected
+func (s *TCPServer) activateRecipient(transfer domain.TransferRecord, receipt *ReceiptInfo, requiredCapability string, expected time.Duration) {
+	if transfer.ToRole == "people" {
 		return
 	}
 
+	s.mu.RLock()
+	targetConn, exists := s.connections[transfer.ToRole]
+	s.mu.RUnlock()
+
+	activateMsg := ActivateMessage{
+		Type:               "ACTIVATE",
+		FromRole:           transfer.FromRole,
+		Payload:            transfer.Message,
+		RetryCount:         transfer.RetryCount,
+		Receipt:            receipt,
+		RequiredCapability: requiredCapability,
+		Metadata:           transfer.Metadata,
+	}
+	if expected > 0 {
+		activateMsg.ExpectedDuration = expected.String()
+	}
+	if !exists {
+		s.queueActivation(transfer.ToRole, activateMsg)
+		return
+	}
+	s.pushMessage(targetConn, activateMsg)
+}
+
+// handleReassignMessage moves work from a stuck holder to another role on behalf of the people
+func (s *TCPServer) handleReassignMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg ReassignMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid REASSIGN message format")
+		return
+	}
+
+	// Agents may not take work from each other; only the people's unregistered connections can
+	s.mu.RLock()
+	agentRole := s.roleFor(conn)
+	s.mu.RUnlock()
+	if agentRole != "" {
+		s.sendError(conn, fmt.Sprintf("REASSIGN is reserved for the people (connection is registered as '%s')", agentRole))
+		return
+	}
+
+	if !s.checkReplay(conn, "REASSIGN", msg.Nonce, msg.SentAt) {
+		return
+	}
+
+	msg.FromRole = strings.TrimSpace(msg.FromRole)
+	msg.ToRole = strings.TrimSpace(msg.ToRole)
 	if msg.FromRole == "" || msg.ToRole == "" {
-		s.sendError(conn, "FromRole and ToRole are required for yield")
+		s.sendErrorCode(conn, ErrorCodeInvalidRole, "FromRole and ToRole are required for reassign and cannot be blank")
+		return
+	}
+
+	if err := s.sovietService.ReassignBarrel(msg.FromRole, msg.ToRole); err != nil {
+		s.sendHaltAwareError(conn, err)
+		return
+	}
+
+	transfer, ok := s.sovietService.LastTransfer()
+	if !ok {
+		transfer = domain.TransferRecord{FromRole: msg.FromRole, ToRole: msg.ToRole}
+	}
+	var receipt *ReceiptInfo
+	if ok {
+		receipt = newReceiptInfo(transfer.Receipt)
+	}
+
+	s.logger.Warn("Work reassigned via REASSIGN", map[string]interface{}{
+		"from_role": transfer.FromRole,
+		"to_role":   transfer.ToRole,
+		"remote":    conn.RemoteAddr().String(),
+	})
+	s.sendMessage(conn, YieldAckMessage{
+		Type:    "ACK_YIELD",
+		ToRole:  transfer.ToRole,
+		Receipt: receipt,
+	})
+	s.activateRecipient(transfer, receipt, "", 0)
+}
+
+// handleValidateYieldMessage checks a yield without processing it and reports every problem
+func (s *TCPServer) handleValidateYieldMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg YieldMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid VALIDATE_YIELD message format")
 		return
 	}
 
-	err := s.HandleYield(ctx, msg.FromRole, msg.ToRole, msg.Payload)
+	if !s.normalizeYieldRoles(conn, &msg) {
+		return
+	}
+
+	yieldMsg, err := newDomainYield(msg)
 	if err != nil {
 		s.sendError(conn, err.Error())
 		return
 	}
 
-	// If yielding to an agent, send activation message
-	if msg.ToRole != "people" {
-		s.mu.RLock()
-		targetConn, exists := s.connections[msg.ToRole]
-		s.mu.RUnlock()
+	problems := s.agentService.ValidateYield(yieldMsg)
+	errorInfos := make([]ValidationErrorInfo, len(problems))
+	for i, problem := range problems {
+		errorInfos[i] = ValidationErrorInfo{
+			Code:    problem.Code,
+			Message: problem.Message,
+		}
+	}
 
-		if exists {
-			activateMsg := ActivateMessage{
-				Type:     "ACTIVATE",
-				FromRole: msg.FromRole,
-				Payload:  msg.Payload,
-			}
-			s.sendMessage(targetConn, activateMsg)
+	s.sendMessage(conn, ValidationResultMessage{
+		Type:   "VALIDATION_RESULT",
+		Valid:  len(problems) == 0,
+		Errors: errorInfos,
+	})
+}
+
+// normalizeYieldRoles trims the roles of a yield and rejects blank ones with INVALID_ROLE
+// Returns false if an error was sent
+func (s *TCPServer) normalizeYieldRoles(conn net.Conn, msg *YieldMessage) bool {
+	msg.FromRole = strings.TrimSpace(msg.FromRole)
+	msg.ToRole = strings.TrimSpace(msg.ToRole)
+	if msg.FromRole == "" || msg.ToRole == "" {
+		s.sendErrorCode(conn, ErrorCodeInvalidRole, "FromRole and ToRole are required for yield and cannot be blank")
+		return false
+	}
+	return true
+}
+
+// newDomainYield converts a protocol yield to a domain yield message
+func newDomainYield(msg YieldMessage) (domain.YieldMessage, error) {
+	yieldMsg := domain.NewYieldMessage(msg.FromRole, msg.ToRole, msg.Payload)
+	if msg.Failed {
+		yieldMsg = domain.NewFailedYieldMessage(msg.FromRole, msg.ToRole, msg.Payload)
+	}
+	if msg.SentAt != "" {
+		sentAt, err := time.Parse(time.RFC3339Nano, msg.SentAt)
+		if err != nil {
+			return domain.YieldMessage{}, fmt.Errorf("Invalid sent_at timestamp: %s", msg.SentAt)
+		}
+		yieldMsg = yieldMsg.WithSentAt(sentAt)
+	}
+	if msg.ExpectedDuration != "" {
+		expected, err := parseExpectedDuration(msg.ExpectedDuration)
+		if err != nil {
+			return domain.YieldMessage{}, err
+		}
+		yieldMsg = yieldMsg.WithExpectedDuration(expected)
+	}
+	if len(msg.Metadata) > 0 {
+		yieldMsg = yieldMsg.WithMetadata(msg.Metadata)
+	}
+	if msg.RequiredCapability != "" {
+		yieldMsg = yieldMsg.WithRequiredCapability(msg.RequiredCapability)
+	}
+	return yieldMsg, nil
+}
+
+// parseExpectedDuration parses an expected_duration field such as "30m"
+func parseExpectedDuration(value string) (time.Duration, error) {
+	expected, err := time.ParseDuration(value)
+	if err != nil || expected < 0 {
+		return 0, fmt.Errorf("Invalid expected_duration: %s", value)
+	}
+	return expected, nil
+}
+
+// newReceiptInfo converts a domain receipt to its protocol representation
+func newReceiptInfo(receipt domain.Receipt) *ReceiptInfo {
+	return &ReceiptInfo{
+		Sequence:     receipt.Sequence,
+		FromRole:     receipt.FromRole,
+		ToRole:       receipt.ToRole,
+		PayloadHash:  receipt.PayloadHash,
+		Timestamp:    receipt.Timestamp.Format(time.RFC3339Nano),
+		PreviousHash: receipt.PreviousHash,
+		Hash:         receipt.Hash,
+	}
+}
+
+func (s *TCPServer) handleActivateAckMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg ActivateAckMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid ACTIVATE_ACK message format")
+		return
+	}
+
+	if msg.Role == "" {
+		s.sendError(conn, "Role is required for activation acknowledgment")
+		return
+	}
+
+	if err := s.sovietService.AcknowledgeActivation(msg.Role); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+
+	if msg.ExpectedDuration != "" {
+		expected, err := parseExpectedDuration(msg.ExpectedDuration)
+		if err != nil {
+			s.sendError(conn, err.Error())
+			return
+		}
+		if err := s.sovietService.SetExpectedDuration(msg.Role, expected); err != nil {
+			s.sendError(conn, err.Error())
 		}
 	}
 }
 
-func (s *TCPServer) handleQueryAgentsMessage(ctx context.Context, conn net.Conn) {
+func (s *TCPServer) handleQueryAgentsMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg QueryAgentsMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid QUERY_AGENTS message format")
+		return
+	}
+
+	key, value, _ := strings.Cut(msg.Tag, "=")
+	if msg.Tag != "" && strings.TrimSpace(key) == "" {
+		s.sendError(conn, fmt.Sprintf("Invalid tag filter %q: expected key=value or key", msg.Tag))
+		return
+	}
+
 	details := s.agentService.GetAgentDetails()
-	
+	if msg.Tag != "" {
+		tagged := make(map[string]bool)
+		for _, role := range s.agentService.FindAgentsByTag(key, value) {
+			tagged[role] = true
+		}
+		filtered := details[:0:0]
+		for _, detail := range details {
+			if tagged[detail.Role] {
+				filtered = append(filtered, detail)
+			}
+		}
+		details = filtered
+	}
+
 	// Convert domain.AgentDetails to TCP protocol format
 	agentDetails := make([]AgentDetailInfo, len(details))
 	for i, detail := range details {
 		agentDetails[i] = AgentDetailInfo{
-			Role:         detail.Role,
-			Capabilities: detail.Capabilities,
-			State:        detail.State.String(),
-			Connected:    detail.Connected,
+			Role:            detail.Role,
+			AgentType:       detail.Type.String(),
+			Description:     detail.Description,
+			Weight:          detail.Weight,
+			Capabilities:    detail.Capabilities,
+			State:           detail.State.String(),
+			Connected:       detail.Connected,
+			RegistrationSeq: detail.RegistrationSeq,
+			Tags:            detail.Tags,
+			InstanceID:      detail.InstanceID,
 		}
 	}
 
@@ -271,11 +1059,20 @@ func (s *TCPServer) handleQueryAgentsMessage(ctx context.Context, conn net.Conn)
 }
 
 func (s *TCPServer) handleQueryStatusMessage(ctx context.Context, conn net.Conn) {
-	status, err := s.HandleQueryStatus(ctx)
+	response, err := s.statusMessage(ctx)
 	if err != nil {
 		s.sendError(conn, err.Error())
 		return
 	}
+	s.sendMessage(conn, response)
+}
+
+// statusMessage describes the state of the collective as a STATUS message
+func (s *TCPServer) statusMessage(ctx context.Context) (StatusMessage, error) {
+	status, err := s.HandleQueryStatus(ctx)
+	if err != nil {
+		return StatusMessage{}, err
+	}
 
 	// Convert domain.AgentState to string for TCP protocol
 	agentStates := make(map[string]string)
@@ -284,15 +1081,375 @@ func (s *TCPServer) handleQueryStatusMessage(ctx context.Context, conn net.Conn)
 	}
 
 	response := StatusMessage{
-		Type:             "STATUS",
-		BarrelHolder:     status.BarrelHolder,
-		RegisteredAgents: status.RegisteredAgents,
-		AgentStates:      agentStates,
-		ConnectedAgents:  status.ConnectedAgents,
+		Type:              "STATUS",
+		BarrelHolder:      status.BarrelHolder,
+		RegisteredAgents:  status.RegisteredAgents,
+		AgentStates:       agentStates,
+		ConnectedAgents:   status.ConnectedAgents,
+		AgentCapabilities: status.AgentCapabilities,
+		RegistrationSeqs:  status.RegistrationSeqs,
+		AgentWeights:      status.AgentWeights,
+		YieldChainDepth:   status.YieldChainDepth,
+		OverExpected:      status.OverExpected,
+
+		HolderUnregistered: status.HolderUnregistered,
+	}
+	if depths := s.QueueDepths(); len(depths) > 0 {
+		response.QueueDepths = depths
+	}
+	if len(status.AgentInstances) > 0 {
+		response.AgentInstances = status.AgentInstances
+	}
+	if len(status.AverageHolds) > 0 {
+		response.AverageHolds = make(map[string]string, len(status.AverageHolds))
+		for role, average := range status.AverageHolds {
+			response.AverageHolds[role] = average.Round(time.Second).String()
+		}
+	}
+	if status.ExpectedDuration > 0 {
+		response.ExpectedDuration = status.ExpectedDuration.String()
+	}
+	if len(status.BenchedAgents) > 0 {
+		response.BenchedAgents = make(map[string]string, len(status.BenchedAgents))
+		for role, until := range status.BenchedAgents {
+			response.BenchedAgents[role] = until.UTC().Format(time.RFC3339)
+		}
+	}
+	return response, nil
+}
+
+func (s *TCPServer) handleQueryPipelineMessage(ctx context.Context, conn net.Conn) {
+	status := s.agentService.GetPipelineStatus()
+
+	response := PipelineMessage{
+		Type:         "PIPELINE",
+		Roles:        status.Roles,
+		BarrelHolder: status.BarrelHolder,
+		StageIndex:   status.StageIndex,
+		NextRole:     status.NextRole,
+		OnPipeline:   status.OnPipeline,
+	}
+	s.sendMessage(conn, response)
+}
+
+func (s *TCPServer) handleQueryGroupsMessage(ctx context.Context, conn net.Conn) {
+	groups := s.agentService.GetGroups()
+
+	groupInfos := make([]GroupInfo, len(groups))
+	for i, group := range groups {
+		groupInfos[i] = GroupInfo{
+			Name:      group.Name,
+			Members:   group.Members,
+			Available: group.Available,
+			Weighted:  group.Weighted,
+		}
+	}
+
+	s.sendMessage(conn, GroupsMessage{
+		Type:   "GROUPS",
+		Groups: groupInfos,
+	})
+}
+
+func (s *TCPServer) handleQueryStalenessMessage(ctx context.Context, conn net.Conn) {
+	staleness := s.agentService.GetStaleness()
+
+	response := StalenessMessage{
+		Type:         "STALENESS",
+		BarrelHolder: staleness.BarrelHolder,
+		StaleFor:     staleness.Duration.Round(time.Second).String(),
+		StaleSeconds: int64(staleness.Duration / time.Second),
+		OverExpected: staleness.OverExpected,
+	}
+	if staleness.ExpectedDuration > 0 {
+		response.ExpectedDuration = staleness.ExpectedDuration.String()
+	}
+	if !staleness.Since.IsZero() {
+		response.Since = staleness.Since.Format(time.RFC3339)
+	}
+	s.sendMessage(conn, response)
+}
+
+func (s *TCPServer) handleQueryContextMessage(ctx context.Context, conn net.Conn) {
+	barrelContext := s.agentService.GetBarrelContext()
+
+	response := ContextMessage{
+		Type:         "CONTEXT",
+		BarrelHolder: barrelContext.BarrelHolder,
+		FromRole:     barrelContext.FromRole,
+		Payload:      barrelContext.Message,
+		RetryCount:   barrelContext.RetryCount,
+		OverExpected: barrelContext.OverExpected,
+		Metadata:     barrelContext.Metadata,
+	}
+	if !barrelContext.ReceivedAt.IsZero() {
+		response.ReceivedAt = barrelContext.ReceivedAt.Format(time.RFC3339)
+		response.HeldFor = time.Since(barrelContext.ReceivedAt).Round(time.Second).String()
+	}
+	if barrelContext.ExpectedDuration > 0 {
+		response.ExpectedDuration = barrelContext.ExpectedDuration.String()
+	}
+	if barrelContext.Receipt.Hash != "" {
+		response.Receipt = newReceiptInfo(barrelContext.Receipt)
+	}
+	s.sendMessage(conn, response)
+}
+
+func (s *TCPServer) handleQueryHistoryMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg QueryHistoryMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid QUERY_HISTORY message format")
+		return
+	}
+	if msg.Limit < 0 {
+		s.sendError(conn, fmt.Sprintf("history limit cannot be negative: %d", msg.Limit))
+		return
+	}
+
+	history := s.sovietService.TransferHistory(msg.Limit)
+	transfers := make([]TransferInfo, len(history))
+	for i, record := range history {
+		transfers[i] = TransferInfo{
+			FromRole:   record.FromRole,
+			ToRole:     record.ToRole,
+			Message:    record.Message,
+			Timestamp:  record.Timestamp.Format(time.RFC3339),
+			RetryCount: record.RetryCount,
+			Metadata:   record.Metadata,
+		}
+		if record.Receipt.Hash != "" {
+			transfers[i].Receipt = newReceiptInfo(record.Receipt)
+		}
+	}
+	s.sendMessage(conn, HistoryMessage{Type: "HISTORY", Transfers: transfers})
+}
+
+func (s *TCPServer) handleQueryConnectionsMessage(ctx context.Context, conn net.Conn) {
+	now := time.Now()
+	s.mu.RLock()
+	connections := make([]ConnectionInfo, 0, len(s.budgets))
+	for c, budget := range s.budgets {
+		total, inWindow := budget.counts(now)
+		connections = append(connections, ConnectionInfo{
+			Remote:      c.RemoteAddr().String(),
+			Role:        s.roleFor(c),
+			BytesRead:   total,
+			WindowBytes: inWindow,
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].Remote < connections[j].Remote
+	})
+
+	response := ConnectionsMessage{
+		Type:        "CONNECTIONS",
+		Connections: connections,
+		ByteBudget:  s.maxBytes,
+	}
+	if s.maxBytes > 0 {
+		response.Window = s.byteWindow.String()
+	}
+	s.sendMessage(conn, response)
+}
+
+func (s *TCPServer) handleQueryRolesNeededMessage(ctx context.Context, conn net.Conn) {
+	needed := s.agentService.GetRolesNeeded()
+
+	missing := make([]RoleNeedInfo, 0, len(needed.Missing))
+	for _, need := range needed.Missing {
+		missing = append(missing, RoleNeedInfo{
+			Role:   need.Role,
+			Reason: need.Reason,
+		})
+	}
+
+	s.sendMessage(conn, RolesNeededMessage{
+		Type:     "ROLES_NEEDED",
+		Required: needed.Required,
+		Missing:  missing,
+	})
+}
+
+// handleQueryCapabilityMessage tells the people which agents could take work needing a capability
+func (s *TCPServer) handleQueryCapabilityMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg QueryCapabilityMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid QUERY_CAPABILITY message format")
+		return
+	}
+	msg.Capability = strings.TrimSpace(msg.Capability)
+	msg.Role = strings.TrimSpace(msg.Role)
+	if msg.Capability == "" {
+		s.sendError(conn, "capability is required")
+		return
+	}
+
+	details := make(map[string]domain.AgentDetails)
+	for _, detail := range s.agentService.GetAgentDetails() {
+		details[detail.Role] = detail
+	}
+	if msg.Role != "" {
+		if _, exists := details[msg.Role]; !exists {
+			s.sendError(conn, fmt.Sprintf("Agent comrade not found: %s", msg.Role))
+			return
+		}
+	}
+
+	response := CapabilityMatchMessage{
+		Type:       "CAPABILITY_MATCH",
+		Capability: msg.Capability,
+		Role:       msg.Role,
+		Agents:     []CapableAgentInfo{},
+	}
+	for _, role := range s.agentService.FindAgentsByCapability(msg.Capability) {
+		if msg.Role != "" && role != msg.Role {
+			continue
+		}
+		detail, exists := details[role]
+		if !exists {
+			// Unregistered between the two queries
+			continue
+		}
+		response.Agents = append(response.Agents, CapableAgentInfo{
+			Role:      role,
+			State:     detail.State.String(),
+			Connected: detail.Connected,
+		})
+	}
+	response.Capable = len(response.Agents) > 0
+	s.sendMessage(conn, response)
+}
+
+func (s *TCPServer) handleSetAgentStateMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg SetAgentStateMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid SET_AGENT_STATE message format")
+		return
+	}
+
+	// Agent connections may not force states; only the people's unregistered connections can
+	s.mu.RLock()
+	agentRole := s.roleFor(conn)
+	s.mu.RUnlock()
+	if agentRole != "" {
+		s.sendError(conn, fmt.Sprintf("SET_AGENT_STATE is reserved for the people (connection is registered as '%s')", agentRole))
+		return
+	}
+
+	if !s.checkReplay(conn, "SET_AGENT_STATE", msg.Nonce, msg.SentAt) {
+		return
+	}
+
+	if msg.Role == "" {
+		s.sendError(conn, "Role is required to set agent state")
+		return
+	}
+
+	state, err := domain.ParseAgentState(msg.State)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
 	}
+
+	previous, err := s.agentService.GetAgentState(msg.Role)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+
+	if err := s.sovietService.ForceAgentState(msg.Role, state); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+
+	s.logger.Warn("Agent state forced via SET_AGENT_STATE", map[string]interface{}{
+		"role":     msg.Role,
+		"previous": previous.String(),
+		"state":    state.String(),
+		"remote":   conn.RemoteAddr().String(),
+	})
+	s.sendMessage(conn, AgentStateMessage{
+		Type:     "AGENT_STATE",
+		Role:     msg.Role,
+		Previous: previous.String(),
+		State:    state.String(),
+	})
+}
+
+func (s *TCPServer) handleSetTTLMessage(ctx context.Context, conn net.Conn, messageData string) {
+	var msg SetTTLMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid SET_TTL message format")
+		return
+	}
+
+	if s.rejectFromAgent(conn, "SET_TTL") {
+		return
+	}
+
+	if !s.checkReplay(conn, "SET_TTL", msg.Nonce, msg.SentAt) {
+		return
+	}
+
+	ttl, err := time.ParseDuration(msg.TTL)
+	if err != nil {
+		s.sendError(conn, fmt.Sprintf("Invalid TTL duration: %s", msg.TTL))
+		return
+	}
+
+	if err := s.sovietService.SetBarrelTTL(ttl); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+
+	s.logger.Info("Barrel TTL updated", map[string]interface{}{
+		"ttl": ttl.String(),
+	})
+	s.handleGetTTLMessage(ctx, conn)
+}
+
+func (s *TCPServer) handleGetTTLMessage(ctx context.Context, conn net.Conn) {
+	response := TTLMessage{
+		Type:         "TTL",
+		TTL:          s.sovietService.BarrelTTL().String(),
+		BarrelHolder: s.sovietService.QueryStatus().BarrelHolder,
+	}
+
+	if deadline := s.sovietService.BarrelDeadline(); !deadline.IsZero() {
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+		response.Deadline = deadline.Format(time.RFC3339)
+		response.Remaining = remaining.Round(time.Second).String()
+	}
+
 	s.sendMessage(conn, response)
 }
 
+// checkReplay rejects a privileged command that reuses a nonce or was sent outside the replay window
+// Returns false after sending the error to the client
+func (s *TCPServer) checkReplay(conn net.Conn, command, nonce, sentAt string) bool {
+	if s.replay == nil {
+		return true
+	}
+
+	code, err := s.replay.check(nonce, sentAt, time.Now())
+	if err == nil {
+		return true
+	}
+	s.logger.Warn("Rejected privileged command", map[string]interface{}{
+		"command": command,
+		"code":    code,
+		"error":   err.Error(),
+		"remote":  conn.RemoteAddr().String(),
+	})
+	s.sendErrorCode(conn, code, fmt.Sprintf("%s rejected: %s", command, err.Error()))
+	return false
+}
+
 func (s *TCPServer) sendError(conn net.Conn, message string) {
 	errorMsg := ErrorMessage{
 		Type:    "ERROR",
@@ -301,15 +1458,224 @@ func (s *TCPServer) sendError(conn net.Conn, message string) {
 	s.sendMessage(conn, errorMsg)
 }
 
+// sendErrorCode sends an error with a machine-readable code
+func (s *TCPServer) sendErrorCode(conn net.Conn, code, message string) {
+	s.sendMessage(conn, ErrorMessage{
+		Type:    "ERROR",
+		Message: message,
+		Code:    code,
+	})
+}
+
+// sendRateLimited tells a client its message was dropped and when it may send again
+func (s *TCPServer) sendRateLimited(conn net.Conn, retryAfter time.Duration) {
+	s.sendMessage(conn, ErrorMessage{
+		Type:       "ERROR",
+		Message:    fmt.Sprintf("Rate limit exceeded: at most %g messages per second", s.messageRate),
+		Code:       ErrorCodeRateLimited,
+		RetryAfter: retryAfter.Round(time.Millisecond).String(),
+	})
+}
+
+// sendMessage answers the request being processed on conn, echoing its id when it had one
 func (s *TCPServer) sendMessage(conn net.Conn, message interface{}) {
-	data, err := json.Marshal(message)
+	s.pushMessage(conn, WithRequestID(message, s.requestID(conn)))
+}
+
+// pushMessage sends a message that answers no request, such as ACTIVATE, so it never carries an id
+func (s *TCPServer) pushMessage(conn net.Conn, message interface{}) {
+	data, err := s.codecFor(conn).Encode(message)
 	if err != nil {
 		log.Printf("Failed to marshal message: %v", err)
 		return
 	}
 
-	_, err = conn.Write(append(data, '\n'))
-	if err != nil {
+	if err := WriteFrame(conn, data); err != nil {
 		log.Printf("Failed to send message: %v", err)
+		return
+	}
+	s.touch(conn)
+}
+
+// closeConn closes a connection so the client can still read the last message sent on it
+// Closing a socket with unread input makes the kernel answer with a reset, which can discard
+// a final ERROR before the client reads it; the write side is shut first and unread input drained
+func (s *TCPServer) closeConn(conn net.Conn) {
+	if s.closeLinger > 0 {
+		deadline := time.Now().Add(s.closeLinger)
+		_ = conn.SetWriteDeadline(deadline)
+		if halfCloser, ok := conn.(interface{ CloseWrite() error }); ok && halfCloser.CloseWrite() == nil {
+			_ = conn.SetReadDeadline(deadline)
+			_, _ = io.Copy(io.Discard, conn)
+		}
 	}
+	_ = conn.Close()
+}
+
+// touch records activity on a connection, pushing back the idle deadline of people connections
+// Registered agent connections have no read deadline
+func (s *TCPServer) touch(conn net.Conn) {
+	if s.peopleIdleTimeout == 0 {
+		return
+	}
+
+	s.mu.RLock()
+	registered := s.roleFor(conn) != ""
+	s.mu.RUnlock()
+
+	deadline := time.Time{}
+	if !registered {
+		deadline = time.Now().Add(s.peopleIdleTimeout)
+	}
+	_ = conn.SetReadDeadline(deadline)
+}
+
+// handleHelloMessage negotiates the codec used for the rest of the connection
+// The acknowledgment is still sent with the old codec; the switch applies afterwards
+func (s *TCPServer) handleHelloMessage(conn net.Conn, messageData string) {
+	var msg HelloMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid HELLO message format")
+		return
+	}
+
+	naming := msg.FieldNaming
+	if naming == "" && (msg.Codec == "" || msg.Codec == CodecJSON) {
+		s.mu.RLock()
+		naming = s.fieldNaming
+		s.mu.RUnlock()
+	}
+	codec, err := NewNamedCodec(msg.Codec, naming)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	// Framing is not negotiated: a JSON connection keeps the server's framing
+	if jsonCodec, ok := codec.(JSONCodec); ok {
+		s.mu.RLock()
+		jsonCodec.Framing = s.framing
+		s.mu.RUnlock()
+		codec = jsonCodec
+	}
+
+	ack := HelloAckMessage{
+		Type:  "HELLO_ACK",
+		Codec: codec.Name(),
+	}
+	if jsonCodec, ok := codec.(JSONCodec); ok {
+		ack.FieldNaming = FieldNamingSnake
+		if jsonCodec.FieldNaming != "" {
+			ack.FieldNaming = jsonCodec.FieldNaming
+		}
+	}
+	s.sendMessage(conn, ack)
+
+	s.mu.Lock()
+	s.codecs[conn] = codec
+	s.mu.Unlock()
+}
+
+// handlePingMessage answers a ping with the server version and time
+// A ping on an agent's connection also counts as the agent's heartbeat
+func (s *TCPServer) handlePingMessage(conn net.Conn, messageData string) {
+	var msg PingMessage
+	if err := s.decode(conn, messageData, &msg); err != nil {
+		s.rejectMalformed(conn, "Invalid PING message format")
+		return
+	}
+
+	// A ping from an agent's connection is its heartbeat
+	s.mu.RLock()
+	roles := s.rolesFor(conn)
+	s.mu.RUnlock()
+	for _, role := range roles {
+		if err := s.sovietService.RecordHeartbeat(role); err != nil {
+			s.logger.Error("Failed to record agent heartbeat", map[string]interface{}{
+				"role":  role,
+				"error": err.Error(),
+			})
+		}
+	}
+
+	s.sendMessage(conn, PongMessage{
+		Type:       "PONG",
+		Seq:        msg.Seq,
+		Version:    s.version,
+		ServerTime: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// loggedMessage returns the raw message as it may appear in logs
+// With redaction enabled the payload field and the values of the yield metadata are replaced, since
+// senders put the same kind of data in both; reservation tokens are always masked.
+// Undecodable messages are redacted entirely
+func (s *TCPServer) loggedMessage(conn net.Conn, messageData string) string {
+	hasToken := strings.Contains(messageData, "reservation_token") || strings.Contains(messageData, "reservationToken")
+	if !s.redactPayloads && !hasToken {
+		return messageData
+	}
+
+	var fields map[string]interface{}
+	if err := s.decode(conn, messageData, &fields); err != nil {
+		return domain.RedactPayload(messageData)
+	}
+
+	if s.redactPayloads {
+		if payload, ok := fields["payload"].(string); ok {
+			fields["payload"] = domain.RedactPayload(payload)
+		}
+		if metadata, ok := fields["metadata"].(map[string]interface{}); ok {
+			for key, value := range metadata {
+				metadata[key] = domain.RedactPayload(fmt.Sprint(value))
+			}
+		}
+	}
+	for _, key := range []string{"reservation_token", "reservationToken"} {
+		if _, ok := fields[key]; ok {
+			fields[key] = "[redacted]"
+		}
+	}
+
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return domain.RedactPayload(messageData)
+	}
+	return string(redacted)
+}
+
+// roleFor returns the role registered on a connection, or "" if none (caller holds s.mu)
+func (s *TCPServer) roleFor(conn net.Conn) string {
+	for role, registered := range s.connections {
+		if registered == conn {
+			return role
+		}
+	}
+	return ""
+}
+
+// rolesFor returns every role registered on a connection in name order (caller holds s.mu)
+func (s *TCPServer) rolesFor(conn net.Conn) []string {
+	var roles []string
+	for role, registered := range s.connections {
+		if registered == conn {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// codecFor returns the codec negotiated for a connection, defaulting to JSON
+func (s *TCPServer) codecFor(conn net.Conn) Codec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if codec, exists := s.codecs[conn]; exists {
+		return codec
+	}
+	return JSONCodec{FieldNaming: s.fieldNaming, Framing: s.framing}
+}
+
+// decode deserializes a frame using the connection's codec
+func (s *TCPServer) decode(conn net.Conn, messageData string, message interface{}) error {
+	return s.codecFor(conn).Decode([]byte(messageData), message)
 }
@@ -0,0 +1,149 @@
+package tcp
+
+import (
+	"context"
+	"net"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// statusNotifier is the EventPublisher through which the soviet tells the server that its
+// subscribers are due a STATUS. It is called with the soviet locked, so it only signals watchStatus,
+// which queries the status once the change is complete
+type statusNotifier struct {
+	changed chan struct{}
+}
+
+func newStatusNotifier() *statusNotifier {
+	return &statusNotifier{changed: make(chan struct{}, 1)}
+}
+
+// notify signals a change; changes that arrive before watchStatus wakes up are pushed as one STATUS
+func (n *statusNotifier) notify() {
+	select {
+	case n.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (n *statusNotifier) PublishAgentRegistered(agent domain.AgentDetails) error {
+	n.notify()
+	return nil
+}
+
+func (n *statusNotifier) PublishAgentDeregistered(role string) error {
+	n.notify()
+	return nil
+}
+
+func (n *statusNotifier) PublishBarrelTransferred(transfer domain.TransferRecord) error {
+	n.notify()
+	return nil
+}
+
+// statusSubscriber delivers pushed statuses to one connection from its own goroutine
+// Only the latest undelivered status is kept, so a slow subscriber skips to the current state
+// instead of holding up the connections that changed it
+type statusSubscriber struct {
+	pending chan StatusMessage
+}
+
+// handleSubscribeMessage adds the connection to the subscribers pushed a STATUS on every registration,
+// deregistration and barrel transfer the soviet publishes. The current status is sent right away
+func (s *TCPServer) handleSubscribeMessage(ctx context.Context, conn net.Conn) {
+	status, err := s.statusMessage(ctx)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+	s.mu.Lock()
+	_, subscribed := s.subscribers[conn]
+	if !subscribed {
+		subscriber := &statusSubscriber{pending: make(chan StatusMessage, 1)}
+		s.subscribers[conn] = subscriber
+		go func() {
+			for update := range subscriber.pending {
+				s.pushMessage(conn, update)
+			}
+		}()
+	}
+	s.mu.Unlock()
+	s.sendMessage(conn, status)
+}
+
+// unsubscribe stops pushing statuses to a connection, e.g. once it is closed
+func (s *TCPServer) unsubscribe(conn net.Conn) {
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if subscriber, ok := s.subscribers[conn]; ok {
+		close(subscriber.pending)
+		delete(s.subscribers, conn)
+	}
+}
+
+// EventPublisher returns the publisher that pushes a STATUS to SUBSCRIBE connections
+// Add it to the soviet with AddPublisher before the server starts
+func (s *TCPServer) EventPublisher() domain.EventPublisher {
+	return s.notifier
+}
+
+// publishStatus pushes the current status to every subscriber
+func (s *TCPServer) publishStatus(ctx context.Context) {
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+
+	s.mu.RLock()
+	subscribers := make([]*statusSubscriber, 0, len(s.subscribers))
+	for _, subscriber := range s.subscribers {
+		subscribers = append(subscribers, subscriber)
+	}
+	s.mu.RUnlock()
+	if len(subscribers) == 0 {
+		return
+	}
+
+	status, err := s.statusMessage(ctx)
+	if err != nil {
+		s.logger.Error("Failed to publish status", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	for _, subscriber := range subscribers {
+		subscriber.deliver(status)
+	}
+}
+
+// watchStatus pushes a STATUS to the subscribers after every change the soviet publishes, whether
+// made by a connection, the sweeper or a replicator, until ctx is cancelled
+func (s *TCPServer) watchStatus(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.notifier.changed:
+			s.publishStatus(ctx)
+		}
+	}
+}
+
+// deliver queues status for the subscriber, replacing a status it has not been sent yet
+// Callers hold publishMu, so nobody else fills the channel in between
+func (sub *statusSubscriber) deliver(status StatusMessage) {
+	for {
+		select {
+		case sub.pending <- status:
+			return
+		default:
+			select {
+			case <-sub.pending:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package tcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+func TestTCPServer_SubscribePushesStatusChanges(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+	soviet.AddPublisher(server.EventPublisher())
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+
+	watcher := dialDrainClient(t, server.Addr())
+	watcher.send(QueryMessage{Type: "SUBSCRIBE"})
+	var status StatusMessage
+	watcher.receive("STATUS", &status)
+	assert.Equal(t, "people", status.BarrelHolder)
+	assert.Empty(t, status.RegisteredAgents)
+
+	// awaitStatus reads pushed statuses until one satisfies done
+	awaitStatus := func(done func(StatusMessage) bool) {
+		for {
+			watcher.receive("STATUS", &status)
+			if done(status) {
+				return
+			}
+		}
+	}
+
+	developer := dialDrainClient(t, server.Addr())
+	developer.send(RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}})
+	developer.receive("ACK_REGISTER", nil)
+	awaitStatus(func(status StatusMessage) bool { return status.ConnectedAgents["developer"] })
+	assert.Equal(t, []string{"developer"}, status.RegisteredAgents)
+
+	people := dialDrainClient(t, server.Addr())
+	people.send(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", Payload: "Build it"})
+	people.receive("ACK_YIELD", nil)
+	awaitStatus(func(status StatusMessage) bool { return status.BarrelHolder == "developer" })
+
+	// Changes made outside any connection, like the sweeper's, are pushed as well
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("developer", "people", "Built")))
+	awaitStatus(func(status StatusMessage) bool { return status.BarrelHolder == "people" })
+
+	developer.send(DeregisterMessage{Type: "DEREGISTER", Role: "developer"})
+	developer.skipUntil("ACK_DEREGISTER", &AckDeregisterMessage{})
+	awaitStatus(func(status StatusMessage) bool { return len(status.RegisteredAgents) == 0 })
+
+	// A closed subscriber is pruned
+	require.NoError(t, watcher.conn.Close())
+	assert.Eventually(t, func() bool {
+		server.mu.RLock()
+		defer server.mu.RUnlock()
+		return len(server.subscribers) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -1,14 +1,19 @@
 package tcp
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/lonegunmanb/agentfarm/pkg/domain"
 )
@@ -18,9 +23,9 @@ type MockSovietService struct {
 	mock.Mock
 }
 
-func (m *MockSovietService) RegisterAgent(agent *domain.AgentComrade) (bool, string, error) {
+func (m *MockSovietService) RegisterAgent(agent *domain.AgentComrade) (bool, string, string, error) {
 	args := m.Called(agent)
-	return args.Bool(0), args.String(1), args.Error(2)
+	return args.Bool(0), args.String(1), args.String(2), args.Error(3)
 }
 
 func (m *MockSovietService) ProcessYield(message domain.YieldMessage) error {
@@ -28,16 +33,128 @@ func (m *MockSovietService) ProcessYield(message domain.YieldMessage) error {
 	return args.Error(0)
 }
 
+func (m *MockSovietService) LastTransfer() (domain.TransferRecord, bool) {
+	args := m.Called()
+	return args.Get(0).(domain.TransferRecord), args.Bool(1)
+}
+
+func (m *MockSovietService) TransferHistory(limit int) []domain.TransferRecord {
+	args := m.Called(limit)
+	return args.Get(0).([]domain.TransferRecord)
+}
+
 func (m *MockSovietService) DeregisterAgent(role string) error {
 	args := m.Called(role)
 	return args.Error(0)
 }
 
+func (m *MockSovietService) DisconnectAgent(role string) error {
+	args := m.Called(role)
+	return args.Error(0)
+}
+
+func (m *MockSovietService) ReclaimDisconnectedBarrel() (bool, error) {
+	args := m.Called()
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSovietService) RecordHeartbeat(role string) error {
+	args := m.Called(role)
+	return args.Error(0)
+}
+
+func (m *MockSovietService) DisconnectSilentAgents() ([]string, error) {
+	args := m.Called()
+	roles, _ := args.Get(0).([]string)
+	return roles, args.Error(1)
+}
+
+func (m *MockSovietService) ReconcileStates() ([]string, error) {
+	args := m.Called()
+	roles, _ := args.Get(0).([]string)
+	return roles, args.Error(1)
+}
+
+func (m *MockSovietService) PruneHistory() ([]domain.TransferRecord, error) {
+	args := m.Called()
+	records, _ := args.Get(0).([]domain.TransferRecord)
+	return records, args.Error(1)
+}
+
+func (m *MockSovietService) Halt(reason string) error {
+	args := m.Called(reason)
+	return args.Error(0)
+}
+
+func (m *MockSovietService) Resume() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockSovietService) HaltStatus() domain.HaltStatus {
+	args := m.Called()
+	status, _ := args.Get(0).(domain.HaltStatus)
+	return status
+}
+
 func (m *MockSovietService) QueryStatus() domain.StatusResponse {
 	args := m.Called()
 	return args.Get(0).(domain.StatusResponse)
 }
 
+func (m *MockSovietService) ForceAgentState(role string, state domain.AgentState) error {
+	args := m.Called(role, state)
+	return args.Error(0)
+}
+
+func (m *MockSovietService) ReassignBarrel(fromRole, toRole string) error {
+	args := m.Called(fromRole, toRole)
+	return args.Error(0)
+}
+
+func (m *MockSovietService) UpdateAgentCapabilities(role string, capabilities []string) ([]string, error) {
+	args := m.Called(role, capabilities)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSovietService) SetBarrelTTL(ttl time.Duration) error {
+	args := m.Called(ttl)
+	return args.Error(0)
+}
+
+func (m *MockSovietService) BarrelTTL() time.Duration {
+	args := m.Called()
+	return args.Get(0).(time.Duration)
+}
+
+func (m *MockSovietService) BarrelDeadline() time.Time {
+	args := m.Called()
+	return args.Get(0).(time.Time)
+}
+
+func (m *MockSovietService) ReclaimExpiredBarrel() (bool, error) {
+	args := m.Called()
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSovietService) AcknowledgeActivation(role string) error {
+	args := m.Called(role)
+	return args.Error(0)
+}
+
+func (m *MockSovietService) SetExpectedDuration(role string, expected time.Duration) error {
+	args := m.Called(role, expected)
+	return args.Error(0)
+}
+
+func (m *MockSovietService) ReclaimUnacknowledgedOffer() (bool, error) {
+	args := m.Called()
+	return args.Bool(0), args.Error(1)
+}
+
 // MockAgentService for testing
 type MockAgentService struct {
 	mock.Mock
@@ -63,6 +180,51 @@ func (m *MockAgentService) GetAgentDetails() []domain.AgentDetails {
 	return args.Get(0).([]domain.AgentDetails)
 }
 
+func (m *MockAgentService) FindAgentsByCapability(pattern string) []string {
+	args := m.Called(pattern)
+	return args.Get(0).([]string)
+}
+
+func (m *MockAgentService) FindAgentsByTag(key, value string) []string {
+	args := m.Called(key, value)
+	return args.Get(0).([]string)
+}
+
+func (m *MockAgentService) GetStats() *domain.SovietStats {
+	args := m.Called()
+	return args.Get(0).(*domain.SovietStats)
+}
+
+func (m *MockAgentService) GetPipelineStatus() domain.PipelineStatus {
+	args := m.Called()
+	return args.Get(0).(domain.PipelineStatus)
+}
+
+func (m *MockAgentService) GetGroups() []domain.GroupStatus {
+	args := m.Called()
+	return args.Get(0).([]domain.GroupStatus)
+}
+
+func (m *MockAgentService) GetStaleness() domain.Staleness {
+	args := m.Called()
+	return args.Get(0).(domain.Staleness)
+}
+
+func (m *MockAgentService) GetBarrelContext() domain.BarrelContext {
+	args := m.Called()
+	return args.Get(0).(domain.BarrelContext)
+}
+
+func (m *MockAgentService) GetRolesNeeded() domain.RolesNeeded {
+	args := m.Called()
+	return args.Get(0).(domain.RolesNeeded)
+}
+
+func (m *MockAgentService) ValidateYield(message domain.YieldMessage) []domain.ValidationError {
+	args := m.Called(message)
+	return args.Get(0).([]domain.ValidationError)
+}
+
 // MockMessageSender for testing
 type MockMessageSender struct {
 	mock.Mock
@@ -107,7 +269,7 @@ func TestTCPServer_HandleRegister(t *testing.T) {
 	t.Run("successful registration", func(t *testing.T) {
 		mockSoviet.On("RegisterAgent", mock.MatchedBy(func(agent *domain.AgentComrade) bool {
 			return agent.Role() == "developer" && len(agent.Capabilities()) == 2
-		})).Return(false, "", nil).Once()
+		})).Return(false, "", "", nil).Once()
 
 		shouldActivate, payload, err := server.HandleRegister(context.Background(), "developer", []string{"coding", "testing"})
 
@@ -121,7 +283,7 @@ func TestTCPServer_HandleRegister(t *testing.T) {
 	t.Run("registration with activation", func(t *testing.T) {
 		mockSoviet.On("RegisterAgent", mock.MatchedBy(func(agent *domain.AgentComrade) bool {
 			return agent.Role() == "tester"
-		})).Return(true, "Start testing", nil).Once()
+		})).Return(true, "Start testing", "", nil).Once()
 
 		shouldActivate, payload, err := server.HandleRegister(context.Background(), "tester", []string{"testing", "automation"})
 
@@ -132,6 +294,125 @@ func TestTCPServer_HandleRegister(t *testing.T) {
 	})
 }
 
+func TestTCPServer_ResumeActivationCarriesPreviousHolder(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+	mockSoviet.On("RegisterAgent", mock.Anything).Return(true, "Fix the flaky test", "tester", nil).Once()
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"REGISTER","role":"developer"}`)
+
+	decoder := json.NewDecoder(clientConn)
+	var ack AckRegisterMessage
+	require.NoError(t, decoder.Decode(&ack))
+	assert.Equal(t, "ACK_REGISTER", ack.Type)
+
+	var activate ActivateMessage
+	require.NoError(t, decoder.Decode(&activate))
+	assert.Equal(t, "ACTIVATE", activate.Type)
+	assert.Equal(t, "tester", activate.FromRole)
+	assert.Equal(t, "Fix the flaky test", activate.Payload)
+	mockSoviet.AssertExpectations(t)
+}
+
+func TestTCPServer_RegisterAgentType(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	mockSoviet.On("RegisterAgent", mock.MatchedBy(func(agent *domain.AgentComrade) bool {
+		return agent.Role() == "auditor" && agent.Type() == domain.AgentTypeObserver && agent.Description() == "Reviews every hand-off"
+	})).Return(false, "", "", nil).Once()
+	mockSoviet.On("RegisterAgent", mock.MatchedBy(func(agent *domain.AgentComrade) bool {
+		return agent.Role() == "builder" && agent.Weight() == 4
+	})).Return(false, "", "", nil).Once()
+	mockSoviet.On("RegisterAgent", mock.MatchedBy(func(agent *domain.AgentComrade) bool {
+		return agent.Role() == "trainer" && assert.ObjectsAreEqual(map[string]string{"region": "us-east", "gpu": "true"}, agent.Tags())
+	})).Return(false, "", "", nil).Once()
+
+	t.Run("observer", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"REGISTER","role":"auditor","agent_type":"observer","description":" Reviews every hand-off "}`)
+
+		var response AckRegisterMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "ACK_REGISTER", response.Type)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"REGISTER","role":"auditor","agent_type":"primary"}`)
+
+		var response ErrorMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "ERROR", response.Type)
+		assert.Contains(t, response.Message, "unknown agent type")
+	})
+
+	t.Run("weight", func(t *testing.T) {
+		for message, expected := range map[string]string{
+			`{"type":"REGISTER","role":"builder","weight":4}`:   "ACK_REGISTER",
+			`{"type":"REGISTER","role":"builder","weight":101}`: "ERROR",
+		} {
+			serverConn, clientConn := net.Pipe()
+			go server.processMessage(context.Background(), serverConn, message)
+
+			var response TCPMessage
+			require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+			assert.Equal(t, expected, response.Type, message)
+			serverConn.Close()
+			clientConn.Close()
+		}
+	})
+
+	t.Run("description too long", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		message, err := json.Marshal(RegisterMessage{Type: "REGISTER", Role: "auditor", Description: strings.Repeat("x", domain.MaxDescriptionLength+1)})
+		require.NoError(t, err)
+		go server.processMessage(context.Background(), serverConn, string(message))
+
+		var response ErrorMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "ERROR", response.Type)
+		assert.Contains(t, response.Message, "description")
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		for message, expected := range map[string]string{
+			`{"type":"REGISTER","role":"trainer","tags":{"region":"us-east"," gpu ":"true"}}`: "ACK_REGISTER",
+			`{"type":"REGISTER","role":"trainer","tags":{"a=b":"c"}}`:                         "ERROR",
+		} {
+			serverConn, clientConn := net.Pipe()
+			go server.processMessage(context.Background(), serverConn, message)
+
+			var response TCPMessage
+			require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+			assert.Equal(t, expected, response.Type, message)
+			serverConn.Close()
+			clientConn.Close()
+		}
+	})
+	mockSoviet.AssertExpectations(t)
+}
+
 func TestTCPServer_HandleYield(t *testing.T) {
 	// Setup
 	mockSoviet := &MockSovietService{}
@@ -174,6 +455,62 @@ func TestTCPServer_HandleQueryAgents(t *testing.T) {
 		assert.Equal(t, expectedAgents, agents)
 		mockAgent.AssertExpectations(t)
 	})
+
+	t.Run("agent details keep registration order", func(t *testing.T) {
+		mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+		mockAgent.On("GetAgentDetails").Return([]domain.AgentDetails{
+			{Role: "tester", Description: "Runs the integration suite", State: domain.AgentStateWaiting, Connected: true, RegistrationSeq: 2},
+			{Role: "developer", State: domain.AgentStateWorking, Connected: true, RegistrationSeq: 3},
+		}).Once()
+
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_AGENTS"}`)
+
+		var response AgentDetailsMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		require.Len(t, response.AgentDetails, 2)
+		assert.Equal(t, "tester", response.AgentDetails[0].Role)
+		assert.Equal(t, uint64(2), response.AgentDetails[0].RegistrationSeq)
+		assert.Equal(t, uint64(3), response.AgentDetails[1].RegistrationSeq)
+		assert.Equal(t, "Runs the integration suite", response.AgentDetails[0].Description)
+		assert.Empty(t, response.AgentDetails[1].Description)
+	})
+
+	t.Run("agent details filtered by tag", func(t *testing.T) {
+		mockAgent.On("GetAgentDetails").Return([]domain.AgentDetails{
+			{Role: "tester", Tags: map[string]string{"region": "eu-west"}, RegistrationSeq: 2},
+			{Role: "developer", Tags: map[string]string{"region": "us-east", "gpu": "true"}, RegistrationSeq: 3},
+		}).Once()
+		mockAgent.On("FindAgentsByTag", "region", "us-east").Return([]string{"developer"}).Once()
+
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_AGENTS","tag":"region=us-east"}`)
+
+		var response AgentDetailsMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		require.Len(t, response.AgentDetails, 1)
+		assert.Equal(t, "developer", response.AgentDetails[0].Role)
+		assert.Equal(t, map[string]string{"region": "us-east", "gpu": "true"}, response.AgentDetails[0].Tags)
+	})
+
+	t.Run("invalid tag filter", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_AGENTS","tag":"=us-east"}`)
+
+		var response ErrorMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "ERROR", response.Type)
+		assert.Contains(t, response.Message, "Invalid tag filter")
+	})
 }
 
 func TestTCPServer_HandleQueryStatus(t *testing.T) {
@@ -215,6 +552,32 @@ func TestTCPServer_HandleQueryStatus(t *testing.T) {
 	})
 }
 
+func TestTCPServer_QueryStatusReportsAverageHolds(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	mockSoviet.On("QueryStatus").Return(domain.StatusResponse{
+		BarrelHolder:     "developer",
+		RegisteredAgents: []string{"developer"},
+		AverageHolds:     map[string]time.Duration{"developer": 12*time.Minute + 30*time.Second + 400*time.Millisecond},
+	}).Once()
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_STATUS"}`)
+
+	var response StatusMessage
+	require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+	assert.Equal(t, "STATUS", response.Type)
+	assert.Equal(t, map[string]string{"developer": "12m30s"}, response.AverageHolds)
+	mockSoviet.AssertExpectations(t)
+}
+
 func TestTCPMessageSender(t *testing.T) {
 	sender := NewTCPMessageSender()
 
@@ -281,3 +644,906 @@ func TestTCPMessageSender(t *testing.T) {
 		assert.False(t, sender.IsConnected("nonexistent"))
 	})
 }
+
+func TestTCPServer_SetTTLMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	t.Run("valid ttl is applied and echoed", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		mockSoviet.On("SetBarrelTTL", 30*time.Minute).Return(nil).Once()
+		mockSoviet.On("BarrelTTL").Return(30 * time.Minute).Once()
+		mockSoviet.On("BarrelDeadline").Return(time.Time{}).Once()
+		mockSoviet.On("QueryStatus").Return(domain.StatusResponse{BarrelHolder: "people"}).Once()
+		mockLogger.On("Info", "Barrel TTL updated", mock.Anything).Once()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"SET_TTL","ttl":"30m"}`)
+
+		var response TTLMessage
+		assert.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "TTL", response.Type)
+		assert.Equal(t, "30m0s", response.TTL)
+		assert.Equal(t, "people", response.BarrelHolder)
+		mockSoviet.AssertExpectations(t)
+	})
+
+	t.Run("invalid duration is rejected", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"SET_TTL","ttl":"soon"}`)
+
+		var response ErrorMessage
+		assert.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "ERROR", response.Type)
+		assert.Contains(t, response.Message, "Invalid TTL duration")
+	})
+
+	t.Run("agent connections are refused", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+		server.connections["developer"] = serverConn
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"SET_TTL","ttl":"24h"}`)
+
+		var response ErrorMessage
+		assert.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Contains(t, response.Message, "SET_TTL is reserved for the people")
+		mockSoviet.AssertNotCalled(t, "SetBarrelTTL", 24*time.Hour)
+	})
+}
+
+func TestTCPServer_UpdateCapabilitiesMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	t.Run("registered connection updates its capabilities", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+		server.connections["developer"] = serverConn
+
+		mockSoviet.On("UpdateAgentCapabilities", "developer", []string{"go", "go", "rust"}).
+			Return([]string{"go", "rust"}, nil).Once()
+
+		go server.processMessage(context.Background(), serverConn,
+			`{"type":"UPDATE_CAPABILITIES","role":"developer","capabilities":["go","go","rust"]}`)
+
+		var response AckUpdateCapabilitiesMessage
+		assert.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "ACK_UPDATE_CAPABILITIES", response.Type)
+		assert.Equal(t, []string{"go", "rust"}, response.Capabilities)
+		mockSoviet.AssertExpectations(t)
+	})
+
+	t.Run("other connections cannot update the role", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn,
+			`{"type":"UPDATE_CAPABILITIES","role":"developer","capabilities":["go"]}`)
+
+		var response ErrorMessage
+		assert.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "ERROR", response.Type)
+		assert.Contains(t, response.Message, "not registered as 'developer'")
+	})
+}
+
+func TestTCPServer_RedactsPayloadInDebugLog(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	server.SetRedactPayloads(true)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	var logged string
+	mockLogger.On("Debug", "Received message", mock.Anything).Run(func(args mock.Arguments) {
+		fields := args.Get(1).([]map[string]interface{})
+		logged = fields[0]["message"].(string)
+	}).Once()
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"BOGUS","payload":"api-key=hunter2","metadata":{"token":"hunter3"}}`)
+
+	var response ErrorMessage
+	assert.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+	assert.NotContains(t, logged, "hunter2")
+	assert.Contains(t, logged, domain.RedactPayload("api-key=hunter2"))
+	assert.Contains(t, logged, `"type":"BOGUS"`)
+
+	// Metadata values carry the same kind of data as the payload; their keys stay readable
+	assert.NotContains(t, logged, "hunter3")
+	assert.Contains(t, logged, `"token":"`+domain.RedactPayload("hunter3")+`"`)
+}
+
+func TestTCPServer_DisconnectMarksRegisteredRole(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		server.handleConnection(context.Background(), serverConn)
+		close(done)
+	}()
+
+	mockSoviet.On("RegisterAgent", mock.Anything).Return(false, "", "", nil).Once()
+	mockSoviet.On("DisconnectAgent", "developer").Return(nil).Once()
+
+	encoder := json.NewEncoder(clientConn)
+	decoder := json.NewDecoder(clientConn)
+	require.NoError(t, encoder.Encode(RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}}))
+	var ack AckRegisterMessage
+	require.NoError(t, decoder.Decode(&ack))
+	assert.Equal(t, "success", ack.Status)
+
+	require.NoError(t, clientConn.Close())
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection handler did not exit")
+	}
+
+	mockSoviet.AssertExpectations(t)
+	server.mu.RLock()
+	_, exists := server.connections["developer"]
+	server.mu.RUnlock()
+	assert.False(t, exists)
+}
+
+func TestTCPServer_DisconnectForgetsEveryRoleOfConnection(t *testing.T) {
+	mockLogger := &MockLogger{}
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		mockLogger.On(level, mock.Anything, mock.Anything).Maybe()
+	}
+	soviet := domain.NewSovietState(domain.NewMemoryAgentRepository())
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	server := NewTCPServer(soviet, soviet, &MockMessageSender{}, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		server.handleConnection(context.Background(), serverConn)
+		close(done)
+	}()
+
+	encoder := json.NewEncoder(clientConn)
+	decoder := json.NewDecoder(clientConn)
+	for _, role := range []string{"developer", "tester"} {
+		require.NoError(t, encoder.Encode(RegisterMessage{Type: "REGISTER", Role: role, Capabilities: []string{"coding"}}))
+		var ack AckRegisterMessage
+		require.NoError(t, decoder.Decode(&ack))
+		require.Equal(t, "success", ack.Status)
+	}
+
+	require.NoError(t, clientConn.Close())
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection handler did not exit")
+	}
+
+	server.mu.RLock()
+	assert.Empty(t, server.connections)
+	server.mu.RUnlock()
+
+	peopleServer, peopleClient := net.Pipe()
+	defer peopleServer.Close()
+	defer peopleClient.Close()
+	go server.processMessage(context.Background(), peopleServer, `{"type":"YIELD","from_role":"people","to_role":"developer","payload":"Fix the login bug"}`)
+
+	var response ErrorMessage
+	require.NoError(t, json.NewDecoder(peopleClient).Decode(&response))
+	assert.Equal(t, "ERROR", response.Type)
+	assert.Contains(t, response.Message, "target agent 'developer' is not connected")
+	assert.True(t, soviet.GetBarrel().IsHeldBy("people"))
+}
+
+func TestTCPServer_SetAgentStateMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	t.Run("people can force a state", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		mockAgent.On("GetAgentState", "tester").Return(domain.AgentStateWorking, nil).Once()
+		mockSoviet.On("ForceAgentState", "tester", domain.AgentStateWaiting).Return(nil).Once()
+		mockLogger.On("Warn", "Agent state forced via SET_AGENT_STATE", mock.Anything).Once()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"SET_AGENT_STATE","role":"tester","state":"waiting"}`)
+
+		var response AgentStateMessage
+		assert.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "AGENT_STATE", response.Type)
+		assert.Equal(t, "working", response.Previous)
+		assert.Equal(t, "waiting", response.State)
+		mockSoviet.AssertExpectations(t)
+	})
+
+	t.Run("agent connections are refused", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+		server.connections["developer"] = serverConn
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"SET_AGENT_STATE","role":"tester","state":"waiting"}`)
+
+		var response ErrorMessage
+		assert.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Contains(t, response.Message, "reserved for the people")
+	})
+}
+
+func TestTCPServer_ReassignMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	t.Run("people reassign and the new holder is activated with the original message", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+		seniorServer, seniorClient := net.Pipe()
+		defer seniorServer.Close()
+		defer seniorClient.Close()
+		server.connections["senior"] = seniorServer
+		defer delete(server.connections, "senior")
+
+		mockSoviet.On("ReassignBarrel", "developer", "senior").Return(nil).Once()
+		mockSoviet.On("LastTransfer").Return(domain.TransferRecord{
+			FromRole: "developer",
+			ToRole:   "senior",
+			Message:  "Implement login",
+		}, true).Once()
+		mockLogger.On("Warn", "Work reassigned via REASSIGN", mock.Anything).Once()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"REASSIGN","from_role":"developer","to_role":" senior "}`)
+
+		var ack YieldAckMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&ack))
+		assert.Equal(t, "ACK_YIELD", ack.Type)
+		assert.Equal(t, "senior", ack.ToRole)
+
+		var activate ActivateMessage
+		require.NoError(t, json.NewDecoder(seniorClient).Decode(&activate))
+		assert.Equal(t, "ACTIVATE", activate.Type)
+		assert.Equal(t, "developer", activate.FromRole)
+		assert.Equal(t, "Implement login", activate.Payload)
+		mockSoviet.AssertExpectations(t)
+	})
+
+	t.Run("domain rejection is reported", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		mockSoviet.On("ReassignBarrel", "tester", "senior").Return(errors.New("'tester' does not hold the barrel (held by 'developer')")).Once()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"REASSIGN","from_role":"tester","to_role":"senior"}`)
+
+		var response ErrorMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Contains(t, response.Message, "does not hold the barrel")
+	})
+
+	t.Run("agent connections are refused", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+		server.connections["developer"] = serverConn
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"REASSIGN","from_role":"tester","to_role":"developer"}`)
+
+		var response ErrorMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Contains(t, response.Message, "reserved for the people")
+	})
+}
+
+func TestTCPServer_QueryStalenessMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	since := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	mockAgent.On("GetStaleness").Return(domain.Staleness{
+		BarrelHolder: "developer",
+		Since:        since,
+		Duration:     45*time.Minute + 300*time.Millisecond,
+
+		ExpectedDuration: 30 * time.Minute,
+		OverExpected:     true,
+	}).Once()
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_STALENESS"}`)
+
+	var response StalenessMessage
+	require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+	assert.Equal(t, "STALENESS", response.Type)
+	assert.Equal(t, "developer", response.BarrelHolder)
+	assert.Equal(t, since.Format(time.RFC3339), response.Since)
+	assert.Equal(t, "45m0s", response.StaleFor)
+	assert.Equal(t, int64(2700), response.StaleSeconds)
+	assert.Equal(t, "30m0s", response.ExpectedDuration)
+	assert.True(t, response.OverExpected)
+	mockAgent.AssertExpectations(t)
+}
+
+func TestTCPServer_QueryContextMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	receivedAt := time.Now().Add(-10 * time.Minute)
+	receipt := domain.NewReceipt(3, "people", "developer", "Fix the login bug", receivedAt, "previous")
+	mockAgent.On("GetBarrelContext").Return(domain.BarrelContext{
+		BarrelHolder:     "developer",
+		FromRole:         "people",
+		Message:          "Fix the login bug",
+		ReceivedAt:       receivedAt,
+		ExpectedDuration: 30 * time.Minute,
+		RetryCount:       1,
+		Receipt:          receipt,
+	}).Once()
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_CONTEXT"}`)
+
+	var response ContextMessage
+	require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+	assert.Equal(t, "CONTEXT", response.Type)
+	assert.Equal(t, "developer", response.BarrelHolder)
+	assert.Equal(t, "people", response.FromRole)
+	assert.Equal(t, "Fix the login bug", response.Payload)
+	assert.Equal(t, receivedAt.Format(time.RFC3339), response.ReceivedAt)
+	assert.Equal(t, "10m0s", response.HeldFor)
+	assert.Equal(t, "30m0s", response.ExpectedDuration)
+	assert.False(t, response.OverExpected)
+	assert.Equal(t, 1, response.RetryCount)
+	require.NotNil(t, response.Receipt)
+	assert.Equal(t, receipt.Hash, response.Receipt.Hash)
+	assert.Equal(t, 3, response.Receipt.Sequence)
+	mockAgent.AssertExpectations(t)
+}
+
+func TestTCPServer_QueryHistoryMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	decoder := json.NewDecoder(clientConn)
+
+	sentAt := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	receipt := domain.NewReceipt(4, "people", "developer", "Fix the login bug", sentAt, "previous")
+	mockSoviet.On("TransferHistory", 1).Return([]domain.TransferRecord{{
+		FromRole:  "people",
+		ToRole:    "developer",
+		Message:   "Fix the login bug",
+		Timestamp: sentAt,
+		Receipt:   receipt,
+	}}).Once()
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_HISTORY","limit":1}`)
+
+	var response HistoryMessage
+	require.NoError(t, decoder.Decode(&response))
+	assert.Equal(t, "HISTORY", response.Type)
+	require.Len(t, response.Transfers, 1)
+	assert.Equal(t, "people", response.Transfers[0].FromRole)
+	assert.Equal(t, "developer", response.Transfers[0].ToRole)
+	assert.Equal(t, "Fix the login bug", response.Transfers[0].Message)
+	assert.Equal(t, "2025-08-20T10:00:00Z", response.Transfers[0].Timestamp)
+	require.NotNil(t, response.Transfers[0].Receipt)
+	assert.Equal(t, 4, response.Transfers[0].Receipt.Sequence)
+
+	// An empty history is an empty list, not an error or null
+	mockSoviet.On("TransferHistory", 0).Return([]domain.TransferRecord{}).Once()
+	go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_HISTORY"}`)
+	var raw map[string]interface{}
+	require.NoError(t, decoder.Decode(&raw))
+	assert.Equal(t, "HISTORY", raw["type"])
+	assert.Equal(t, []interface{}{}, raw["transfers"])
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_HISTORY","limit":-1}`)
+	var errorMsg ErrorMessage
+	require.NoError(t, decoder.Decode(&errorMsg))
+	assert.Equal(t, "ERROR", errorMsg.Type)
+	mockSoviet.AssertExpectations(t)
+}
+
+func TestTCPServer_ExpectedDurationHints(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+	server := NewTCPServer(mockSoviet, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+
+	t.Run("activation acknowledgment carries the agent's estimate", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		mockSoviet.On("AcknowledgeActivation", "developer").Return(nil).Once()
+		mockSoviet.On("SetExpectedDuration", "developer", 45*time.Minute).Return(nil).Once()
+
+		server.processMessage(context.Background(), serverConn, `{"type":"ACTIVATE_ACK","role":"developer","expected_duration":"45m"}`)
+		mockSoviet.AssertExpectations(t)
+	})
+
+	t.Run("invalid estimate is rejected", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		mockSoviet.On("AcknowledgeActivation", "developer").Return(nil).Once()
+		go server.processMessage(context.Background(), serverConn, `{"type":"ACTIVATE_ACK","role":"developer","expected_duration":"soon"}`)
+
+		var response ErrorMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Contains(t, response.Message, "Invalid expected_duration")
+		mockSoviet.AssertNumberOfCalls(t, "SetExpectedDuration", 1) // Only the valid estimate above
+	})
+
+	t.Run("yield hint reaches the domain", func(t *testing.T) {
+		yieldMsg, err := newDomainYield(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", ExpectedDuration: "1h30m"})
+		require.NoError(t, err)
+		assert.Equal(t, 90*time.Minute, yieldMsg.ExpectedDuration())
+
+		_, err = newDomainYield(YieldMessage{Type: "YIELD", FromRole: "people", ToRole: "developer", ExpectedDuration: "-5m"})
+		assert.ErrorContains(t, err, "Invalid expected_duration")
+	})
+}
+
+func TestTCPServer_QueryRolesNeededMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	query := func() RolesNeededMessage {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"QUERY_ROLES_NEEDED"}`)
+
+		var response RolesNeededMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		return response
+	}
+
+	mockAgent.On("GetRolesNeeded").Return(domain.RolesNeeded{
+		Required: []string{"developer", "tester"},
+		Missing:  []domain.RoleNeed{{Role: "tester", Reason: domain.RoleNeedDisconnected}},
+	}).Once()
+	response := query()
+	assert.Equal(t, "ROLES_NEEDED", response.Type)
+	assert.Equal(t, []string{"developer", "tester"}, response.Required)
+	assert.Equal(t, []RoleNeedInfo{{Role: "tester", Reason: "disconnected"}}, response.Missing)
+
+	// All required roles present: an empty list, not null
+	mockAgent.On("GetRolesNeeded").Return(domain.RolesNeeded{
+		Required: []string{"developer", "tester"},
+		Missing:  []domain.RoleNeed{},
+	}).Once()
+	response = query()
+	assert.NotNil(t, response.Missing)
+	assert.Empty(t, response.Missing)
+	mockAgent.AssertExpectations(t)
+}
+
+func TestTCPServer_QueryCapabilityMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	query := func(message string) CapabilityMatchMessage {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go server.processMessage(context.Background(), serverConn, message)
+
+		var response CapabilityMatchMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		return response
+	}
+
+	mockAgent.On("GetAgentDetails").Return([]domain.AgentDetails{
+		{Role: "developer", Capabilities: []string{"coding"}, State: domain.AgentStateWorking, Connected: true},
+		{Role: "tester", Capabilities: []string{"test/unit"}, State: domain.AgentStateWaiting, Connected: true},
+		{Role: "qa", Capabilities: []string{"test/e2e"}, State: domain.AgentStateWaiting, Connected: false},
+	})
+	mockAgent.On("FindAgentsByCapability", "test/*").Return([]string{"tester", "qa"})
+	mockAgent.On("FindAgentsByCapability", "coding").Return([]string{"developer"})
+
+	t.Run("lists capable agents with their availability", func(t *testing.T) {
+		response := query(`{"type":"QUERY_CAPABILITY","capability":"test/*"}`)
+		assert.Equal(t, "CAPABILITY_MATCH", response.Type)
+		assert.True(t, response.Capable)
+		assert.Equal(t, []CapableAgentInfo{
+			{Role: "tester", State: "waiting", Connected: true},
+			{Role: "qa", State: "waiting", Connected: false},
+		}, response.Agents)
+	})
+
+	t.Run("answers for a single role", func(t *testing.T) {
+		response := query(`{"type":"QUERY_CAPABILITY","role":"qa","capability":"test/*"}`)
+		assert.True(t, response.Capable)
+		assert.Equal(t, "qa", response.Role)
+		assert.Len(t, response.Agents, 1)
+
+		response = query(`{"type":"QUERY_CAPABILITY","role":"tester","capability":"coding"}`)
+		assert.False(t, response.Capable)
+		assert.NotNil(t, response.Agents)
+		assert.Empty(t, response.Agents)
+	})
+
+	t.Run("unknown role and missing capability are errors", func(t *testing.T) {
+		for _, message := range []string{
+			`{"type":"QUERY_CAPABILITY","role":"ghost","capability":"coding"}`,
+			`{"type":"QUERY_CAPABILITY","capability":"  "}`,
+		} {
+			serverConn, clientConn := net.Pipe()
+			go server.processMessage(context.Background(), serverConn, message)
+
+			var response ErrorMessage
+			require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+			assert.Equal(t, "ERROR", response.Type)
+			serverConn.Close()
+			clientConn.Close()
+		}
+	})
+}
+
+func TestTCPServer_PeopleIdleTimeout(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	require.NoError(t, server.SetPeopleIdleTimeout(50*time.Millisecond))
+
+	t.Run("idle people connection is closed", func(t *testing.T) {
+		mockLogger.On("Info", "Closing idle people connection", mock.Anything).Once()
+
+		serverConn, clientConn := net.Pipe()
+		defer clientConn.Close()
+		done := make(chan struct{})
+		go func() {
+			server.handleConnection(context.Background(), serverConn)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("idle people connection was not closed")
+		}
+		mockLogger.AssertExpectations(t)
+	})
+
+	t.Run("registered agent connection stays open", func(t *testing.T) {
+		mockSoviet.On("RegisterAgent", mock.Anything).Return(false, "", "", nil).Once()
+		mockSoviet.On("DisconnectAgent", "developer").Return(nil).Once()
+
+		serverConn, clientConn := net.Pipe()
+		done := make(chan struct{})
+		go func() {
+			server.handleConnection(context.Background(), serverConn)
+			close(done)
+		}()
+
+		require.NoError(t, json.NewEncoder(clientConn).Encode(RegisterMessage{Type: "REGISTER", Role: "developer", Capabilities: []string{"coding"}}))
+		var ack AckRegisterMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&ack))
+
+		select {
+		case <-done:
+			t.Fatal("agent connection was closed for inactivity")
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		require.NoError(t, clientConn.Close())
+		<-done
+		mockSoviet.AssertExpectations(t)
+	})
+}
+
+func TestTCPServer_ValidateYieldMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	mockAgent.On("ValidateYield", mock.MatchedBy(func(message domain.YieldMessage) bool {
+		return message.FromRole() == "developer" && message.ToRole() == "ghost"
+	})).Return([]domain.ValidationError{
+		{Code: domain.ValidationCodeNotBarrelHolder, Message: "only current barrel holder can yield"},
+		{Code: domain.ValidationCodeInvalidTarget, Message: "target agent 'ghost' not found"},
+	}).Once()
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"VALIDATE_YIELD","from_role":"developer","to_role":"ghost"}`)
+
+	var response ValidationResultMessage
+	require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+	assert.Equal(t, "VALIDATION_RESULT", response.Type)
+	assert.False(t, response.Valid)
+	require.Len(t, response.Errors, 2)
+	assert.Equal(t, domain.ValidationCodeNotBarrelHolder, response.Errors[0].Code)
+	assert.Equal(t, domain.ValidationCodeInvalidTarget, response.Errors[1].Code)
+	mockSoviet.AssertNotCalled(t, "ProcessYield", mock.Anything)
+	mockAgent.AssertExpectations(t)
+}
+
+func TestTCPServer_PingMessage(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	server.SetVersion("4.0")
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go server.processMessage(context.Background(), serverConn, `{"type":"PING","seq":7}`)
+
+	var response PongMessage
+	require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+	assert.Equal(t, "PONG", response.Type)
+	assert.Equal(t, 7, response.Seq)
+	assert.Equal(t, "4.0", response.Version)
+	_, err := time.Parse(time.RFC3339, response.ServerTime)
+	assert.NoError(t, err)
+}
+
+func TestTCPServer_BlankRoles(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+
+	for name, message := range map[string]string{
+		"register":      `{"type":"REGISTER","role":"   ","capabilities":["coding"]}`,
+		"yield target":  `{"type":"YIELD","from_role":"people","to_role":" \t"}`,
+		"yield sender":  `{"type":"YIELD","from_role":" ","to_role":"developer"}`,
+		"validate only": `{"type":"VALIDATE_YIELD","from_role":"people","to_role":"  "}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			serverConn, clientConn := net.Pipe()
+			defer serverConn.Close()
+			defer clientConn.Close()
+
+			go server.processMessage(context.Background(), serverConn, message)
+
+			var response ErrorMessage
+			require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+			assert.Equal(t, "ERROR", response.Type)
+			assert.Equal(t, ErrorCodeInvalidRole, response.Code)
+			assert.Contains(t, response.Message, "cannot be blank")
+		})
+	}
+	mockSoviet.AssertNotCalled(t, "RegisterAgent", mock.Anything)
+	mockSoviet.AssertNotCalled(t, "ProcessYield", mock.Anything)
+
+	t.Run("surrounding whitespace is trimmed", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		mockSoviet.On("RegisterAgent", mock.MatchedBy(func(agent *domain.AgentComrade) bool {
+			return agent.Role() == "developer"
+		})).Return(false, "", "", nil).Once()
+
+		go server.processMessage(context.Background(), serverConn, `{"type":"REGISTER","role":" developer ","capabilities":["coding"]}`)
+
+		var ack AckRegisterMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&ack))
+		assert.Equal(t, "success", ack.Status)
+		mockSoviet.AssertExpectations(t)
+	})
+}
+
+func TestTCPServer_FinalErrorReadableBeforeEOF(t *testing.T) {
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+	mockLogger.On("Warn", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(&MockSovietService{}, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetByteBudget(64, time.Minute))
+	assert.Error(t, server.SetCloseLinger(-time.Second))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go server.handleConnection(context.Background(), serverConn)
+	go func() {
+		_, _ = clientConn.Write([]byte(`{"type":"PING","padding":"` + strings.Repeat("x", 128) + `"}` + "\n"))
+	}()
+
+	reader := bufio.NewReader(clientConn)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	var response ErrorMessage
+	require.NoError(t, json.Unmarshal([]byte(line), &response))
+	assert.Equal(t, "ERROR", response.Type)
+	assert.Contains(t, response.Message, "Byte budget exceeded")
+
+	// The error is the last thing on the connection
+	_, err = reader.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestTCPServer_CloseLingerBoundsUnreadFinalMessage(t *testing.T) {
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+	mockLogger.On("Warn", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(&MockSovietService{}, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+	require.NoError(t, server.SetByteBudget(64, time.Minute))
+	require.NoError(t, server.SetCloseLinger(50*time.Millisecond))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan struct{})
+	go func() {
+		server.handleConnection(context.Background(), serverConn)
+		close(done)
+	}()
+	go func() {
+		_, _ = clientConn.Write([]byte(`{"type":"PING","padding":"` + strings.Repeat("x", 128) + `"}` + "\n"))
+	}()
+
+	// The client never reads the final error, so only the linger lets the handler exit
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection handler blocked on an unread final message")
+	}
+}
+
+func TestTCPServer_YieldClockSkewCarriesCode(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockAgent := &MockAgentService{}
+	mockSender := &MockMessageSender{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, mockAgent, mockSender, mockLogger, 0)
+	require.NoError(t, server.SetClockSkewTolerance(time.Second))
+	assert.Error(t, server.SetClockSkewTolerance(-time.Second))
+
+	skewed := domain.ValidationError{Code: domain.ValidationCodeClockSkew, Message: "sent_at is ahead of the server clock"}
+	mockSoviet.On("ProcessYield", mock.Anything).Return(skewed).Once()
+	mockSoviet.On("ProcessYield", mock.Anything).Return(domain.ValidationError{Code: domain.ValidationCodeNotBarrelHolder, Message: "not the holder"}).Once()
+
+	// Only clock skew is forwarded as a code; other rejections keep the plain error
+	for _, expected := range []string{ErrorCodeClockSkew, ""} {
+		serverConn, clientConn := net.Pipe()
+		go server.processMessage(context.Background(), serverConn, `{"type":"YIELD","from_role":"people","to_role":"developer"}`)
+
+		var response ErrorMessage
+		require.NoError(t, json.NewDecoder(clientConn).Decode(&response))
+		assert.Equal(t, "ERROR", response.Type)
+		assert.Equal(t, expected, response.Code)
+		serverConn.Close()
+		clientConn.Close()
+	}
+}
+
+func TestTCPServer_RefusedRegistrationKeepsConnection(t *testing.T) {
+	mockSoviet := &MockSovietService{}
+	mockLogger := &MockLogger{}
+	mockLogger.On("Debug", mock.Anything, mock.Anything).Maybe()
+
+	server := NewTCPServer(mockSoviet, &MockAgentService{}, &MockMessageSender{}, mockLogger, 0)
+
+	register := func(conn, client net.Conn, message string) string {
+		go server.processMessage(context.Background(), conn, message)
+		line, err := bufio.NewReader(client).ReadString('\n')
+		require.NoError(t, err)
+		return line
+	}
+
+	ownerClient, ownerConn := net.Pipe()
+	defer ownerClient.Close()
+	defer ownerConn.Close()
+	mockSoviet.On("RegisterAgent", mock.Anything).Return(false, "", "", nil).Once()
+	line := register(ownerConn, ownerClient, `{"type":"REGISTER","role":"deployer","reservation_token":"s3cret"}`)
+	assert.Contains(t, line, "ACK_REGISTER")
+
+	squatterClient, squatterConn := net.Pipe()
+	defer squatterClient.Close()
+	defer squatterConn.Close()
+	mockSoviet.On("RegisterAgent", mock.Anything).Return(false, "", "", errors.New("role 'deployer' is reserved: the reservation token does not match")).Once()
+	line = register(squatterConn, squatterClient, `{"type":"REGISTER","role":"deployer","reservation_token":"guess"}`)
+	assert.Contains(t, line, "does not match")
+
+	server.mu.RLock()
+	assert.Same(t, ownerConn, server.connections["deployer"])
+	server.mu.RUnlock()
+
+	// Tokens never reach the logs
+	logged := server.loggedMessage(ownerConn, `{"type":"REGISTER","role":"deployer","reservation_token":"s3cret"}`)
+	assert.NotContains(t, logged, "s3cret")
+	assert.Contains(t, logged, "deployer")
+}
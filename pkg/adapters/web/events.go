@@ -0,0 +1,98 @@
+package web
+
+import (
+	"sync"
+)
+
+// Event is a single server-sent event delivered to browsers
+// IDs increase by one per event so a reconnecting client can resume with Last-Event-ID
+type Event struct {
+	ID   uint64      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped
+// A dropped browser reconnects and catches up from the replay buffer
+const subscriberBuffer = 64
+
+// EventPublisher fans events out to every subscriber and keeps a bounded replay buffer
+type EventPublisher struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event // oldest first, at most capacity events
+	capacity    int
+	subscribers map[chan Event]struct{}
+}
+
+// NewEventPublisher creates a publisher that remembers the last capacity events for replay
+func NewEventPublisher(capacity int) *EventPublisher {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &EventPublisher{
+		capacity:    capacity,
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish assigns the next ID to an event and delivers it to every subscriber
+// Subscribers that cannot keep up are closed instead of blocking the publisher
+func (p *EventPublisher) Publish(eventType string, data interface{}) Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastID++
+	event := Event{ID: p.lastID, Type: eventType, Data: data}
+	p.history = append(p.history, event)
+	if len(p.history) > p.capacity {
+		p.history = p.history[len(p.history)-p.capacity:]
+	}
+
+	for ch := range p.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(p.subscribers, ch)
+			close(ch)
+		}
+	}
+	return event
+}
+
+// Subscribe returns the buffered events after lastEventID and a channel receiving every later event
+// A lastEventID that is unknown to this publisher (e.g. from before a restart) replays the whole buffer
+// The channel is closed by cancel or when the subscriber falls too far behind
+func (p *EventPublisher) Subscribe(lastEventID uint64) ([]Event, <-chan Event, func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if lastEventID > p.lastID {
+		lastEventID = 0
+	}
+	var replay []Event
+	for _, event := range p.history {
+		if event.ID > lastEventID {
+			replay = append(replay, event)
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	p.subscribers[ch] = struct{}{}
+	cancel := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := p.subscribers[ch]; ok {
+			delete(p.subscribers, ch)
+			close(ch)
+		}
+	}
+	return replay, ch, cancel
+}
+
+// Subscribers returns how many clients are currently subscribed
+func (p *EventPublisher) Subscribers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.subscribers)
+}
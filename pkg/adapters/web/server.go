@@ -0,0 +1,143 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// shutdownTimeout bounds how long Stop waits for in-flight HTTP requests
+const shutdownTimeout = 5 * time.Second
+
+// StatusSnapshot is the JSON document served at /status.json
+type StatusSnapshot struct {
+	BarrelHolder      string               `json:"barrel_holder"`
+	RegisteredAgents  []string             `json:"registered_agents"`
+	AgentStates       map[string]string    `json:"agent_states"`
+	ConnectedAgents   map[string]bool      `json:"connected_agents"`
+	AgentCapabilities map[string][]string  `json:"agent_capabilities"`
+	YieldChainDepth   int                  `json:"yield_chain_depth"`
+	BenchedAgents     map[string]time.Time `json:"benched_agents"` // Role -> when the circuit breaker lets it work again
+	Stats             *domain.SovietStats  `json:"stats"`
+
+	// HolderUnregistered is true when barrel_holder is not a registered agent, e.g. after a snapshot restore
+	HolderUnregistered bool `json:"holder_unregistered"`
+}
+
+// StatusServer implements a lightweight HTTP adapter for curl-friendly observability
+// It serves read-only snapshots of the domain services and needs no scrape tooling
+type StatusServer struct {
+	sovietService domain.SovietService
+	agentService  domain.AgentService
+	logger        domain.Logger
+	port          int
+	server        *http.Server
+}
+
+// NewStatusServer creates a new HTTP status adapter
+func NewStatusServer(
+	sovietService domain.SovietService,
+	agentService domain.AgentService,
+	logger domain.Logger,
+	port int,
+) *StatusServer {
+	return &StatusServer{
+		sovietService: sovietService,
+		agentService:  agentService,
+		logger:        logger,
+		port:          port,
+	}
+}
+
+// Handler returns the HTTP handler serving the status endpoints
+func (s *StatusServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status.json", s.handleStatus)
+	return mux
+}
+
+// Start starts the HTTP server in the background
+func (s *StatusServer) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to start HTTP status server: %w", err)
+	}
+
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: shutdownTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+	s.logger.Info("HTTP status server started", map[string]interface{}{
+		"port":     s.port,
+		"endpoint": "/status.json",
+	})
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("HTTP status server failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}()
+	return nil
+}
+
+// Stop gracefully stops the HTTP server
+func (s *StatusServer) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+func (s *StatusServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(s.snapshot()); err != nil {
+		s.logger.Error("Failed to write status snapshot", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// snapshot combines the status and stats queries into a single document
+func (s *StatusServer) snapshot() StatusSnapshot {
+	status := s.sovietService.QueryStatus()
+
+	// Convert domain.AgentState to string for JSON consumers
+	agentStates := make(map[string]string, len(status.AgentStates))
+	for role, state := range status.AgentStates {
+		agentStates[role] = state.String()
+	}
+
+	return StatusSnapshot{
+		BarrelHolder:      status.BarrelHolder,
+		RegisteredAgents:  status.RegisteredAgents,
+		AgentStates:       agentStates,
+		ConnectedAgents:   status.ConnectedAgents,
+		AgentCapabilities: status.AgentCapabilities,
+		YieldChainDepth:   status.YieldChainDepth,
+		BenchedAgents:     status.BenchedAgents,
+		Stats:             s.agentService.GetStats(),
+
+		HolderUnregistered: status.HolderUnregistered,
+	}
+}
@@ -0,0 +1,65 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+	"github.com/lonegunmanb/agentfarm/pkg/mocks"
+)
+
+func newPopulatedSoviet(t *testing.T) *domain.SovietState {
+	soviet := domain.NewSovietStateWithDependencies(
+		domain.NewMemoryAgentRepository(),
+		mocks.NewMockMessageSender(),
+		mocks.NewMockLogger(),
+		nil,
+	)
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(domain.NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("people", "developer", "Build the feature")))
+	return soviet
+}
+
+func TestStatusServer_StatusJSON(t *testing.T) {
+	soviet := newPopulatedSoviet(t)
+	server := NewStatusServer(soviet, soviet, mocks.NewMockLogger(), 0)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/status.json", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var snapshot StatusSnapshot
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &snapshot))
+	assert.Equal(t, "developer", snapshot.BarrelHolder)
+	assert.ElementsMatch(t, []string{"developer", "tester"}, snapshot.RegisteredAgents)
+	assert.Equal(t, "working", snapshot.AgentStates["developer"])
+	assert.Equal(t, "waiting", snapshot.AgentStates["tester"])
+	assert.True(t, snapshot.ConnectedAgents["tester"])
+	require.NotNil(t, snapshot.Stats)
+	assert.Equal(t, 2, snapshot.Stats.TotalAgents)
+	assert.Equal(t, 2, snapshot.Stats.ConnectedAgents)
+	assert.Equal(t, "developer", snapshot.Stats.CurrentBarrelHolder)
+	assert.True(t, snapshot.Stats.IsActive)
+}
+
+func TestStatusServer_RejectsWrites(t *testing.T) {
+	soviet := newPopulatedSoviet(t)
+	server := NewStatusServer(soviet, soviet, mocks.NewMockLogger(), 0)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/status.json", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
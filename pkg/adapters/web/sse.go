@@ -0,0 +1,297 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+const (
+	// eventBufferSize bounds how many events a reconnecting browser can catch up on
+	eventBufferSize = 256
+
+	// eventPollInterval is how often the domain is checked for transfers and status changes
+	eventPollInterval = 200 * time.Millisecond
+
+	// keepAliveInterval keeps idle streams open through proxies that close silent connections
+	keepAliveInterval = 15 * time.Second
+)
+
+// Event types sent on the stream
+const (
+	EventTypeTransfer = "transfer"
+	EventTypeStatus   = "status"
+)
+
+// TransferEvent announces that the barrel changed hands
+// The payload is left out unless enabled with SetIncludePayloads, so streams can be shown to
+// anyone who can see the status page
+type TransferEvent struct {
+	Sequence  int       `json:"sequence"`
+	FromRole  string    `json:"from_role"`
+	ToRole    string    `json:"to_role"`
+	Payload   string    `json:"payload,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StatusEvent announces a change of the barrel holder or of any agent's state or connection
+type StatusEvent struct {
+	BarrelHolder    string            `json:"barrel_holder"`
+	AgentStates     map[string]string `json:"agent_states"`
+	ConnectedAgents map[string]bool   `json:"connected_agents"`
+}
+
+// EventStreamServer implements an HTTP adapter that pushes barrel events to browsers
+// It serves text/event-stream at /events so a live status page only needs EventSource
+type EventStreamServer struct {
+	sovietService domain.SovietService
+	logger        domain.Logger
+	port          int
+	publisher     *EventPublisher
+	server        *http.Server
+
+	// includePayloads adds the hand-off payload to transfer events
+	includePayloads bool
+
+	// Last observed domain state, only touched by poll
+	lastSequence int
+	lastStatus   *StatusEvent
+}
+
+// NewEventStreamServer creates a new server-sent events adapter
+func NewEventStreamServer(
+	sovietService domain.SovietService,
+	logger domain.Logger,
+	port int,
+) *EventStreamServer {
+	return &EventStreamServer{
+		sovietService: sovietService,
+		logger:        logger,
+		port:          port,
+		publisher:     NewEventPublisher(eventBufferSize),
+		lastSequence:  -1,
+	}
+}
+
+// SetIncludePayloads controls whether transfer events carry the hand-off payload
+// Only enable it when everyone who can reach the stream may read the work handed between agents
+func (s *EventStreamServer) SetIncludePayloads(include bool) {
+	s.includePayloads = include
+}
+
+// Publisher returns the fan-out the stream is fed from
+func (s *EventStreamServer) Publisher() *EventPublisher {
+	return s.publisher
+}
+
+// Handler returns the HTTP handler serving the event stream
+func (s *EventStreamServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.handleEvents)
+	return mux
+}
+
+// Start starts watching the domain and serving the event stream in the background
+func (s *EventStreamServer) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to start HTTP event stream server: %w", err)
+	}
+
+	// Record the current state so only later changes are published
+	s.poll()
+
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: shutdownTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+	s.logger.Info("HTTP event stream server started", map[string]interface{}{
+		"port":     s.port,
+		"endpoint": "/events",
+	})
+
+	go s.watch(ctx)
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("HTTP event stream server failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}()
+	return nil
+}
+
+// Stop gracefully stops the HTTP server
+// Open streams end when the context given to Start is cancelled
+func (s *EventStreamServer) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// watch polls the domain until ctx is cancelled
+// It runs on its own goroutine, so it reads the domain only through the service ports, which
+// serialize it with the connections changing the collective
+func (s *EventStreamServer) watch(ctx context.Context) {
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.poll()
+		}
+	}
+}
+
+// poll publishes the latest transfer and the current status when they changed since the last poll
+func (s *EventStreamServer) poll() {
+	if transfer, ok := s.sovietService.LastTransfer(); ok && transfer.Receipt.Sequence != s.lastSequence {
+		// The first poll only records where the history stands
+		if s.lastSequence >= 0 {
+			event := TransferEvent{
+				Sequence:  transfer.Receipt.Sequence,
+				FromRole:  transfer.FromRole,
+				ToRole:    transfer.ToRole,
+				Timestamp: transfer.Timestamp,
+			}
+			if s.includePayloads {
+				event.Payload = transfer.Message
+			}
+			s.publisher.Publish(EventTypeTransfer, event)
+		}
+		s.lastSequence = transfer.Receipt.Sequence
+	}
+
+	status := s.currentStatus()
+	if s.lastStatus != nil && !reflect.DeepEqual(*s.lastStatus, status) {
+		s.publisher.Publish(EventTypeStatus, status)
+	}
+	s.lastStatus = &status
+}
+
+// currentStatus reduces the domain status to the fields a status page reacts to
+func (s *EventStreamServer) currentStatus() StatusEvent {
+	status := s.sovietService.QueryStatus()
+
+	agentStates := make(map[string]string, len(status.AgentStates))
+	for role, state := range status.AgentStates {
+		agentStates[role] = state.String()
+	}
+	connected := make(map[string]bool, len(status.ConnectedAgents))
+	for role, isConnected := range status.ConnectedAgents {
+		connected[role] = isConnected
+	}
+
+	return StatusEvent{
+		BarrelHolder:    status.BarrelHolder,
+		AgentStates:     agentStates,
+		ConnectedAgents: connected,
+	}
+}
+
+func (s *EventStreamServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// EventSource sends Last-Event-ID on reconnect; the query parameter helps clients that cannot set headers
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var resumeFrom uint64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		resumeFrom = parsed
+	}
+
+	replay, events, cancel := s.publisher.Subscribe(resumeFrom)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// New clients start from the current status; the event has no ID so it does not move Last-Event-ID
+	if lastEventID == "" {
+		if err := writeEvent(w, Event{Type: EventTypeStatus, Data: s.currentStatus()}); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-events:
+			if !open {
+				// Fell too far behind; the browser reconnects and resumes from Last-Event-ID
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes one event in text/event-stream framing
+// Events with ID 0 are sent without an id field
+func writeEvent(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
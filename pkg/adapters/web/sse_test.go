@@ -0,0 +1,203 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+	"github.com/lonegunmanb/agentfarm/pkg/mocks"
+)
+
+// readEvent reads the next event from a text/event-stream body, skipping comments
+func readEvent(t *testing.T, reader *bufio.Reader) (id, eventType, data string) {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && eventType != "":
+			return id, eventType, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func openStream(t *testing.T, ctx context.Context, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/events", nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() { response.Body.Close() })
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	return bufio.NewReader(response.Body)
+}
+
+func TestEventPublisher_ReplayAfterLastEventID(t *testing.T) {
+	publisher := NewEventPublisher(2)
+	publisher.Publish(EventTypeStatus, "first")
+	publisher.Publish(EventTypeStatus, "second")
+	publisher.Publish(EventTypeStatus, "third")
+
+	replay, _, cancel := publisher.Subscribe(2)
+	defer cancel()
+	require.Len(t, replay, 1)
+	assert.Equal(t, uint64(3), replay[0].ID)
+
+	// Events older than the buffer are gone; the buffer is replayed in full
+	replay, _, cancelAll := publisher.Subscribe(0)
+	defer cancelAll()
+	require.Len(t, replay, 2)
+	assert.Equal(t, "second", replay[0].Data)
+
+	// IDs from before a restart are unknown and replay the whole buffer
+	replay, _, cancelUnknown := publisher.Subscribe(99)
+	defer cancelUnknown()
+	assert.Len(t, replay, 2)
+	assert.Equal(t, 3, publisher.Subscribers())
+}
+
+func TestEventPublisher_DropsSlowSubscribers(t *testing.T) {
+	publisher := NewEventPublisher(eventBufferSize)
+	_, events, cancel := publisher.Subscribe(0)
+	defer cancel()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		publisher.Publish(EventTypeStatus, i)
+	}
+
+	assert.Equal(t, 0, publisher.Subscribers())
+	received := 0
+	for range events {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+}
+
+func TestEventStreamServer_StreamsTransfers(t *testing.T) {
+	soviet := newPopulatedSoviet(t)
+	server := NewEventStreamServer(soviet, mocks.NewMockLogger(), 0)
+	server.poll()
+
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := openStream(t, ctx, httpServer.URL, "")
+	id, eventType, data := readEvent(t, stream)
+	assert.Empty(t, id)
+	assert.Equal(t, EventTypeStatus, eventType)
+	var status StatusEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &status))
+	assert.Equal(t, "developer", status.BarrelHolder)
+
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("developer", "tester", "Please test")))
+	server.poll()
+
+	id, eventType, data = readEvent(t, stream)
+	assert.Equal(t, "1", id)
+	assert.Equal(t, EventTypeTransfer, eventType)
+	var transfer TransferEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &transfer))
+	assert.Equal(t, "developer", transfer.FromRole)
+	assert.Equal(t, "tester", transfer.ToRole)
+	assert.NotContains(t, data, "Please test")
+
+	id, eventType, data = readEvent(t, stream)
+	assert.Equal(t, "2", id)
+	assert.Equal(t, EventTypeStatus, eventType)
+	require.NoError(t, json.Unmarshal([]byte(data), &status))
+	assert.Equal(t, "tester", status.BarrelHolder)
+	assert.Equal(t, "working", status.AgentStates["tester"])
+
+	// A browser resuming after the transfer only receives the missed status event
+	resumed := openStream(t, ctx, httpServer.URL, "1")
+	id, eventType, _ = readEvent(t, resumed)
+	assert.Equal(t, "2", id)
+	assert.Equal(t, EventTypeStatus, eventType)
+}
+
+func TestEventStreamServer_RejectsBadRequests(t *testing.T) {
+	soviet := newPopulatedSoviet(t)
+	server := NewEventStreamServer(soviet, mocks.NewMockLogger(), 0)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/events", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	request := httptest.NewRequest(http.MethodGet, "/events", nil)
+	request.Header.Set("Last-Event-ID", "not-a-number")
+	recorder = httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestEventStreamServer_IncludesPayloadsWhenEnabled(t *testing.T) {
+	soviet := newPopulatedSoviet(t)
+	server := NewEventStreamServer(soviet, mocks.NewMockLogger(), 0)
+	server.SetIncludePayloads(true)
+	server.poll()
+
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("developer", "tester", "Please test")))
+	server.poll()
+
+	replay, _, cancel := server.Publisher().Subscribe(0)
+	defer cancel()
+	require.NotEmpty(t, replay)
+	require.Equal(t, EventTypeTransfer, replay[0].Type)
+	assert.Equal(t, "Please test", replay[0].Data.(TransferEvent).Payload)
+}
+
+// Run with -race: the poller reads the domain from its own goroutine while agents yield
+func TestEventStreamServer_WatchesWhileAgentsYield(t *testing.T) {
+	soviet := newPopulatedSoviet(t)
+	server := NewEventStreamServer(soviet, mocks.NewMockLogger(), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.poll()
+	go server.watch(ctx)
+
+	holder := "developer"
+	for i := 0; i < 50; i++ {
+		next := "tester"
+		if holder == "tester" {
+			next = "developer"
+		}
+		require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage(holder, next, "Your turn")))
+		holder = next
+		time.Sleep(time.Millisecond)
+	}
+
+	last, ok := soviet.LastTransfer()
+	require.True(t, ok)
+	require.Eventually(t, func() bool {
+		replay, _, unsubscribe := server.Publisher().Subscribe(0)
+		defer unsubscribe()
+		for _, event := range replay {
+			if transfer, ok := event.Data.(TransferEvent); ok && transfer.Sequence == last.Receipt.Sequence {
+				return true
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+}
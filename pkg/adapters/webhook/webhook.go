@@ -0,0 +1,228 @@
+// Package webhook delivers domain events to an external HTTP endpoint, e.g. a dashboard
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+const (
+	// DefaultTimeout bounds a single delivery attempt when no timeout is configured
+	DefaultTimeout = 5 * time.Second
+
+	// DefaultQueueSize is how many events may wait for delivery before new ones are dropped
+	DefaultQueueSize = 256
+
+	// maxAttempts is how often an event is sent before it is given up
+	maxAttempts = 3
+
+	// defaultRetryDelay is the wait before the first retry; it doubles for every further one
+	defaultRetryDelay = 500 * time.Millisecond
+)
+
+// Delivery is the JSON body POSTed for every event
+// The ID increases by one per event so the receiver can spot gaps left by dropped events
+type Delivery struct {
+	ID   uint64    `json:"id"`
+	Time time.Time `json:"time"`
+	domain.PublishedEvent
+}
+
+// ValidateURL checks that target is an absolute http or https URL
+func ValidateURL(target string) error {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid webhook URL %s: expected an http or https URL", target)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("invalid webhook URL %s: no host", target)
+	}
+	return nil
+}
+
+// HTTPEventPublisher implements domain.EventPublisher by POSTing every event as JSON to a URL
+// Events are queued and delivered in order from a background goroutine, so a slow endpoint
+// never holds up the operation that published them. A full queue drops new events
+type HTTPEventPublisher struct {
+	url        string
+	client     *http.Client
+	logger     domain.Logger
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	lastID uint64
+	queue  chan Delivery
+	done   chan struct{} // closed once the delivery goroutine has returned
+	stop   context.CancelFunc
+}
+
+// NewHTTPEventPublisher creates a publisher POSTing to target, giving each attempt up to timeout
+// A timeout of 0 selects DefaultTimeout
+func NewHTTPEventPublisher(target string, timeout time.Duration, logger domain.Logger) (*HTTPEventPublisher, error) {
+	if err := ValidateURL(target); err != nil {
+		return nil, err
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("webhook timeout cannot be negative: %s", timeout)
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return &HTTPEventPublisher{
+		url:        target,
+		client:     &http.Client{Timeout: timeout},
+		logger:     logger,
+		retryDelay: defaultRetryDelay,
+		queue:      make(chan Delivery, DefaultQueueSize),
+	}, nil
+}
+
+// Start delivers queued events in the background until ctx is cancelled or Stop is called
+func (p *HTTPEventPublisher) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.mu.Lock()
+	p.stop, p.done = cancel, make(chan struct{})
+	p.mu.Unlock()
+
+	go func() {
+		defer close(p.done)
+		p.deliverAll(ctx)
+	}()
+	p.logger.Info("Webhook publisher started", map[string]interface{}{
+		"url": p.url,
+	})
+}
+
+// Stop stops delivering events; events still queued are dropped
+func (p *HTTPEventPublisher) Stop() {
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	stop()
+	<-done
+	if dropped := len(p.queue); dropped > 0 {
+		p.logger.Warn("Webhook publisher stopped with undelivered events", map[string]interface{}{
+			"url":     p.url,
+			"dropped": dropped,
+		})
+	}
+}
+
+// PublishAgentRegistered queues an agent registration for delivery
+func (p *HTTPEventPublisher) PublishAgentRegistered(agent domain.AgentDetails) error {
+	return p.enqueue(domain.PublishedEvent{Type: domain.EventAgentRegistered, Role: agent.Role, Agent: &agent})
+}
+
+// PublishAgentDeregistered queues an agent deregistration for delivery
+func (p *HTTPEventPublisher) PublishAgentDeregistered(role string) error {
+	return p.enqueue(domain.PublishedEvent{Type: domain.EventAgentDeregistered, Role: role})
+}
+
+// PublishBarrelTransferred queues a barrel transfer for delivery
+func (p *HTTPEventPublisher) PublishBarrelTransferred(transfer domain.TransferRecord) error {
+	return p.enqueue(domain.PublishedEvent{Type: domain.EventBarrelTransferred, Role: transfer.ToRole, Transfer: &transfer})
+}
+
+// enqueue numbers an event and queues it, logging and failing instead of blocking when the queue is full
+func (p *HTTPEventPublisher) enqueue(event domain.PublishedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delivery := Delivery{ID: p.lastID + 1, Time: time.Now().UTC(), PublishedEvent: event}
+	select {
+	case p.queue <- delivery:
+		p.lastID = delivery.ID
+		return nil
+	default:
+		p.logger.Warn("Webhook queue is full, dropping event", map[string]interface{}{
+			"url":   p.url,
+			"event": event.Type,
+		})
+		return fmt.Errorf("webhook queue is full (%d events), dropping %s event", cap(p.queue), event.Type)
+	}
+}
+
+// deliverAll sends queued events one at a time, in the order they were published
+func (p *HTTPEventPublisher) deliverAll(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-p.queue:
+			p.deliver(ctx, delivery)
+		}
+	}
+}
+
+// deliver POSTs one event, retrying with a doubling delay until it is accepted or attempts run out
+func (p *HTTPEventPublisher) deliver(ctx context.Context, delivery Delivery) {
+	body, err := json.Marshal(delivery)
+	if err != nil {
+		p.logger.Error("Failed to serialize webhook event", map[string]interface{}{
+			"event": delivery.Type,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	delay := p.retryDelay
+	attempts := 0
+	for {
+		attempts++
+		err = p.post(ctx, body)
+		if err == nil {
+			return
+		}
+		if attempts == maxAttempts || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	p.logger.Error("Failed to deliver webhook event", map[string]interface{}{
+		"url":      p.url,
+		"event":    delivery.Type,
+		"id":       delivery.ID,
+		"attempts": attempts,
+		"error":    err.Error(),
+	})
+}
+
+// post sends body once; any status other than 2xx is a failure
+func (p *HTTPEventPublisher) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Verify interface compliance
+var _ domain.EventPublisher = (*HTTPEventPublisher)(nil)
@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+	"github.com/lonegunmanb/agentfarm/pkg/mocks"
+)
+
+// startReceiver serves an endpoint that answers the first failures requests with 500 and hands
+// every accepted delivery to the returned channel
+func startReceiver(t *testing.T, failures int32) (*httptest.Server, <-chan Delivery, *int32) {
+	t.Helper()
+	deliveries := make(chan Delivery, 8)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var delivery Delivery
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&delivery)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		deliveries <- delivery
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, deliveries, &requests
+}
+
+func startPublisher(t *testing.T, url string, logger domain.Logger) *HTTPEventPublisher {
+	t.Helper()
+	publisher, err := NewHTTPEventPublisher(url, time.Second, logger)
+	require.NoError(t, err)
+	publisher.retryDelay = 10 * time.Millisecond
+	publisher.Start(context.Background())
+	t.Cleanup(publisher.Stop)
+	return publisher
+}
+
+func receive(t *testing.T, deliveries <-chan Delivery) Delivery {
+	t.Helper()
+	select {
+	case delivery := <-deliveries:
+		return delivery
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for webhook delivery")
+		return Delivery{}
+	}
+}
+
+func TestHTTPEventPublisher_PostsBarrelTransfer(t *testing.T) {
+	server, deliveries, _ := startReceiver(t, 0)
+	publisher := startPublisher(t, server.URL, mocks.NewMockLogger())
+
+	transfer := domain.TransferRecord{
+		FromRole:  "people",
+		ToRole:    "developer",
+		Message:   "Build it",
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Receipt:   domain.Receipt{Sequence: 1},
+		Metadata:  map[string]string{"ticket": "42"},
+	}
+	require.NoError(t, publisher.PublishBarrelTransferred(transfer))
+	require.NoError(t, publisher.PublishAgentDeregistered("developer"))
+
+	delivery := receive(t, deliveries)
+	assert.Equal(t, uint64(1), delivery.ID)
+	assert.False(t, delivery.Time.IsZero())
+	assert.Equal(t, domain.EventBarrelTransferred, delivery.Type)
+	assert.Equal(t, "developer", delivery.Role)
+	require.NotNil(t, delivery.Transfer)
+	assert.Equal(t, "people", delivery.Transfer.FromRole)
+	assert.Equal(t, "developer", delivery.Transfer.ToRole)
+	assert.Equal(t, "Build it", delivery.Transfer.Message)
+	assert.Equal(t, 1, delivery.Transfer.Receipt.Sequence)
+	assert.Equal(t, map[string]string{"ticket": "42"}, delivery.Transfer.Metadata)
+
+	delivery = receive(t, deliveries)
+	assert.Equal(t, uint64(2), delivery.ID)
+	assert.Equal(t, domain.EventAgentDeregistered, delivery.Type)
+	assert.Nil(t, delivery.Transfer)
+}
+
+func TestHTTPEventPublisher_RetriesFailedDelivery(t *testing.T) {
+	server, deliveries, requests := startReceiver(t, 2)
+	logger := mocks.NewMockLogger()
+	publisher := startPublisher(t, server.URL, logger)
+
+	require.NoError(t, publisher.PublishAgentRegistered(domain.AgentDetails{Role: "tester"}))
+
+	delivery := receive(t, deliveries)
+	assert.Equal(t, domain.EventAgentRegistered, delivery.Type)
+	assert.Equal(t, "tester", delivery.Agent.Role)
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+	assert.Empty(t, logger.GetLogsByLevel("ERROR"))
+}
+
+func TestHTTPEventPublisher_GivesUpAfterMaxAttempts(t *testing.T) {
+	server, _, requests := startReceiver(t, maxAttempts)
+	logger := mocks.NewMockLogger()
+	publisher := startPublisher(t, server.URL, logger)
+
+	require.NoError(t, publisher.PublishAgentDeregistered("tester"))
+
+	require.Eventually(t, func() bool {
+		return len(logger.GetLogsByLevel("ERROR")) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	failure := logger.GetLogsByLevel("ERROR")[0]
+	assert.Equal(t, "Failed to deliver webhook event", failure.Message)
+	assert.Equal(t, maxAttempts, failure.Fields["attempts"])
+	assert.Equal(t, int32(maxAttempts), atomic.LoadInt32(requests))
+}
+
+func TestHTTPEventPublisher_FullQueueRejectsEvents(t *testing.T) {
+	// Not started, so nothing drains the queue
+	publisher, err := NewHTTPEventPublisher("http://127.0.0.1:1/events", 0, mocks.NewMockLogger())
+	require.NoError(t, err)
+
+	for i := 0; i < DefaultQueueSize; i++ {
+		require.NoError(t, publisher.PublishAgentDeregistered("tester"))
+	}
+	err = publisher.PublishAgentDeregistered("tester")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "queue is full")
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://dashboard.example.com/hooks/agentfarm"))
+	assert.Error(t, ValidateURL("ftp://dashboard.example.com"))
+	assert.Error(t, ValidateURL("/hooks/agentfarm"))
+	assert.Error(t, ValidateURL("http://"))
+
+	_, err := NewHTTPEventPublisher("http://localhost:9000", -time.Second, mocks.NewMockLogger())
+	assert.Error(t, err)
+}
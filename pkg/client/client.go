@@ -0,0 +1,217 @@
+// Package client lets Go programs follow an Agent Farm server without speaking the wire protocol
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/web"
+)
+
+const (
+	// defaultReconnectDelay is how long a watch waits before resuming a dropped event stream
+	defaultReconnectDelay = time.Second
+
+	// changeBuffer is how many barrel changes a watcher may leave unread before the stream is paused
+	changeBuffer = 16
+)
+
+// BarrelChange reports that the barrel moved from one holder to another
+type BarrelChange struct {
+	// Sequence is the receipt sequence of the transfer; it increases by one per transfer
+	Sequence int
+
+	FromRole string
+	ToRole   string
+
+	// Message is the hand-off payload, empty unless the server runs with events_payloads
+	Message string
+
+	Timestamp time.Time
+}
+
+// StatusChange reports the barrel holder and the state and connection of every registered agent
+type StatusChange struct {
+	BarrelHolder    string
+	AgentStates     map[string]string // role -> "waiting", "offered", "working", "paused" or "error"
+	ConnectedAgents map[string]bool
+}
+
+// Client follows a server through its event stream
+type Client struct {
+	eventsURL      string
+	httpClient     *http.Client
+	reconnectDelay time.Duration
+}
+
+// NewClient creates a client for the event stream served at eventsURL, e.g. "http://localhost:8081"
+// The server must be started with an events port
+func NewClient(eventsURL string) *Client {
+	return &Client{
+		eventsURL:      strings.TrimSuffix(eventsURL, "/") + "/events",
+		httpClient:     http.DefaultClient,
+		reconnectDelay: defaultReconnectDelay,
+	}
+}
+
+// SetHTTPClient replaces the HTTP client used to open the event stream
+// It must not set a timeout, which would end every stream after that long
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetReconnectDelay sets how long a watch waits before resuming a dropped event stream
+func (c *Client) SetReconnectDelay(delay time.Duration) {
+	c.reconnectDelay = delay
+}
+
+// WatchBarrel emits a BarrelChange every time the barrel changes hands until ctx is cancelled
+// An error is returned when the event stream cannot be opened. Later drops are resumed from the
+// last event received, so no transfer the server still buffers is missed. The channel is closed
+// once ctx is cancelled
+func (c *Client) WatchBarrel(ctx context.Context) (<-chan BarrelChange, error) {
+	return watch(ctx, c, web.EventTypeTransfer, func(transfer web.TransferEvent) BarrelChange {
+		return BarrelChange{
+			Sequence:  transfer.Sequence,
+			FromRole:  transfer.FromRole,
+			ToRole:    transfer.ToRole,
+			Message:   transfer.Payload,
+			Timestamp: transfer.Timestamp,
+		}
+	})
+}
+
+// WatchStatus emits the current status and then a StatusChange every time the barrel holder or an
+// agent's state or connection changes, until ctx is cancelled. Errors and reconnects are handled
+// as by WatchBarrel
+func (c *Client) WatchStatus(ctx context.Context) (<-chan StatusChange, error) {
+	return watch(ctx, c, web.EventTypeStatus, func(status web.StatusEvent) StatusChange {
+		return StatusChange{
+			BarrelHolder:    status.BarrelHolder,
+			AgentStates:     status.AgentStates,
+			ConnectedAgents: status.ConnectedAgents,
+		}
+	})
+}
+
+// watch follows the event stream and emits every event of eventType, converted from its JSON data
+func watch[E, T any](ctx context.Context, c *Client, eventType string, convert func(E) T) (<-chan T, error) {
+	body, err := c.openStream(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(chan T, changeBuffer)
+	go func() {
+		defer close(changes)
+
+		var lastEventID uint64
+		for {
+			lastEventID = forwardEvents(ctx, body, lastEventID, eventType, convert, changes)
+			_ = body.Close()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(c.reconnectDelay):
+				}
+				if body, err = c.openStream(ctx, lastEventID); err == nil {
+					break
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// openStream requests the event stream, resuming after lastEventID when it is not 0
+func (c *Client) openStream(ctx context.Context, lastEventID uint64) (io.ReadCloser, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.eventsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event stream URL: %w", err)
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	if lastEventID != 0 {
+		request.Header.Set("Last-Event-ID", strconv.FormatUint(lastEventID, 10))
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event stream %s: %w", c.eventsURL, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("event stream %s answered %s", c.eventsURL, response.Status)
+	}
+	return response.Body, nil
+}
+
+// forwardEvents sends the events of one stream that have eventType as changes until the stream ends
+// It returns the ID of the last event read so the stream can be resumed after it
+func forwardEvents[E, T any](ctx context.Context, body io.Reader, lastEventID uint64, eventType string, convert func(E) T, changes chan<- T) uint64 {
+	reader := bufio.NewReader(body)
+	for {
+		id, readType, data, err := readEvent(reader)
+		if err != nil {
+			return lastEventID
+		}
+		if id != 0 {
+			lastEventID = id
+		}
+		if readType != eventType {
+			continue
+		}
+
+		var event E
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		select {
+		case changes <- convert(event):
+		case <-ctx.Done():
+			return lastEventID
+		}
+	}
+}
+
+// readEvent reads the next event in text/event-stream framing, skipping comments
+// Events without an id field report ID 0
+func readEvent(reader *bufio.Reader) (id uint64, eventType, data string, err error) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, "", "", err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch {
+		case line == "":
+			if eventType != "" || data != "" {
+				return id, eventType, data, nil
+			}
+		case field == "id":
+			id, _ = strconv.ParseUint(value, 10, 64)
+		case field == "event":
+			eventType = value
+		case field == "data":
+			if data != "" {
+				data += "\n"
+			}
+			data += value
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonegunmanb/agentfarm/pkg/adapters/web"
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+	"github.com/lonegunmanb/agentfarm/pkg/mocks"
+)
+
+// newWatchedSoviet serves the event stream of a soviet whose developer holds the barrel
+// The stream's poller reads the soviet from its own goroutine while the tests yield, so run with -race
+func newWatchedSoviet(t *testing.T) (*domain.SovietState, *httptest.Server) {
+	t.Helper()
+	soviet := domain.NewSovietStateWithDependencies(
+		domain.NewMemoryAgentRepository(),
+		mocks.NewMockMessageSender(),
+		mocks.NewMockLogger(),
+		nil,
+	)
+	require.NoError(t, soviet.SetBarrel(domain.NewBarrelOfGun()))
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(domain.NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("people", "developer", "Build the feature")))
+
+	events := web.NewEventStreamServer(soviet, mocks.NewMockLogger(), 0)
+	events.SetIncludePayloads(true)
+	// Start watches the domain for transfers; the stream is served from the test server
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, events.Start(ctx))
+	t.Cleanup(func() {
+		cancel()
+		_ = events.Stop()
+	})
+
+	httpServer := httptest.NewServer(events.Handler())
+	t.Cleanup(httpServer.Close)
+	return soviet, httpServer
+}
+
+// nextChange waits for the next barrel change
+func nextChange(t *testing.T, changes <-chan BarrelChange) BarrelChange {
+	t.Helper()
+	select {
+	case change, open := <-changes:
+		require.True(t, open, "watch ended early")
+		return change
+	case <-time.After(2 * time.Second):
+		t.Fatal("no barrel change received")
+		return BarrelChange{}
+	}
+}
+
+func TestWatchBarrel_EmitsTransfers(t *testing.T) {
+	soviet, httpServer := newWatchedSoviet(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := NewClient(httpServer.URL).WatchBarrel(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("developer", "tester", "Please test")))
+
+	change := nextChange(t, changes)
+	assert.Equal(t, 2, change.Sequence)
+	assert.Equal(t, "developer", change.FromRole)
+	assert.Equal(t, "tester", change.ToRole)
+	assert.Equal(t, "Please test", change.Message)
+	assert.False(t, change.Timestamp.IsZero())
+
+	cancel()
+	select {
+	case _, open := <-changes:
+		assert.False(t, open, "the channel is closed once the context is cancelled")
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch did not stop")
+	}
+}
+
+func TestWatchBarrel_ResumesDroppedStream(t *testing.T) {
+	soviet, httpServer := newWatchedSoviet(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher := NewClient(httpServer.URL)
+	watcher.SetReconnectDelay(10 * time.Millisecond)
+	changes, err := watcher.WatchBarrel(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("developer", "tester", "Please test")))
+	assert.Equal(t, "tester", nextChange(t, changes).ToRole)
+
+	// A transfer while the stream is down is replayed after the reconnect
+	httpServer.CloseClientConnections()
+	require.NoError(t, soviet.ProcessYield(domain.NewYieldMessage("tester", "people", "Tests pass")))
+
+	change := nextChange(t, changes)
+	assert.Equal(t, 3, change.Sequence)
+	assert.Equal(t, "tester", change.FromRole)
+	assert.Equal(t, "people", change.ToRole)
+}
+
+func TestWatchBarrel_FailsWhenStreamUnavailable(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	_, err := NewClient(notFound.URL).WatchBarrel(context.Background())
+	assert.ErrorContains(t, err, "404")
+}
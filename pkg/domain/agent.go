@@ -2,7 +2,21 @@ package domain
 
 import (
 	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
+)
+
+// MaxDescriptionLength is the longest agent description accepted, in characters
+const MaxDescriptionLength = 280
+
+// Agent weights share out the work of weighted groups; an agent of weight 3 gets three times
+// the assignments of an agent of weight 1
+const (
+	DefaultAgentWeight = 1
+	MaxAgentWeight     = 100
 )
 
 // AgentState represents the current state of an agent comrade
@@ -11,6 +25,9 @@ type AgentState int
 const (
 	AgentStateWaiting AgentState = iota
 	AgentStateWorking
+	AgentStateOffered // Barrel handed over but activation not yet acknowledged by the agent
+	AgentStatePaused  // Taken out of service by the people; receives no work until resumed
+	AgentStateError   // Failed while working; receives no work until resumed
 )
 
 // String returns the string representation of AgentState
@@ -20,22 +37,101 @@ func (s AgentState) String() string {
 		return "waiting"
 	case AgentStateWorking:
 		return "working"
+	case AgentStateOffered:
+		return "offered"
+	case AgentStatePaused:
+		return "paused"
+	case AgentStateError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseAgentState converts a state name such as "waiting" to an AgentState
+func ParseAgentState(name string) (AgentState, error) {
+	for _, state := range []AgentState{AgentStateWaiting, AgentStateWorking, AgentStateOffered, AgentStatePaused, AgentStateError} {
+		if state.String() == name {
+			return state, nil
+		}
+	}
+	return AgentStateWaiting, fmt.Errorf("unknown agent state: %s", name)
+}
+
+// AgentType describes the part an agent plays in the collective
+type AgentType int
+
+const (
+	AgentTypeWorker      AgentType = iota // Does the work it is handed; the default
+	AgentTypeObserver                     // Watches the collective and never receives the barrel
+	AgentTypeCoordinator                  // Plans work and hands it to other agents
+)
+
+// agentTypes lists every AgentType in declaration order
+var agentTypes = []AgentType{AgentTypeWorker, AgentTypeObserver, AgentTypeCoordinator}
+
+// String returns the string representation of AgentType
+func (t AgentType) String() string {
+	switch t {
+	case AgentTypeWorker:
+		return "worker"
+	case AgentTypeObserver:
+		return "observer"
+	case AgentTypeCoordinator:
+		return "coordinator"
 	default:
 		return "unknown"
 	}
 }
 
+// CanHoldBarrel reports whether agents of this type may receive the barrel
+func (t AgentType) CanHoldBarrel() bool {
+	return t != AgentTypeObserver
+}
+
+// ParseAgentType converts a type name such as "observer" to an AgentType
+// Names are case-insensitive; an empty name is a worker
+func ParseAgentType(name string) (AgentType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return AgentTypeWorker, nil
+	}
+	for _, agentType := range agentTypes {
+		if agentType.String() == name {
+			return agentType, nil
+		}
+	}
+	return AgentTypeWorker, fmt.Errorf("unknown agent type: %s (expected worker, observer or coordinator)", name)
+}
+
 // AgentComrade represents a worker in the Agent Farm collective.
 // Each agent has a role, capabilities, and follows the disciplined lifecycle.
 type AgentComrade struct {
 	role            string
+	agentType       AgentType
+	description     string // Free-text explanation of what the agent does, for human operators
+	weight          int    // Share of the assignments of weighted groups
 	capabilities    []string
 	state           AgentState
-	connected       bool
+	connected       atomic.Bool // Read lock-free by yield validation while a reconnection replaces the agent
 	createdAt       time.Time
 	lastConnectedAt time.Time
 	lastMessage     string
 	lastMessageTime time.Time
+	offeredAt       time.Time
+	disconnectedAt  time.Time
+	lastSeenAt      atomic.Int64 // UnixNano of the last sign of life; heartbeats write it while sweepers read it
+	registrationSeq uint64       // Assigned by the soviet on registration; 0 until registered
+	failureReason   string       // Why the agent entered the error state; empty otherwise
+
+	// tags are free-form key/value labels such as region=us-east, for routing and filtering
+	tags map[string]string
+
+	// reservationToken is presented at registration to claim a reserved role; never reported
+	reservationToken string
+
+	// instanceID tells the process running the agent apart from others registering the same role
+	instanceID string
 }
 
 // NewAgentComrade creates a new agent comrade with the specified role and capabilities
@@ -46,17 +142,83 @@ func NewAgentComrade(role string, capabilities []string) *AgentComrade {
 	return &AgentComrade{
 		role:         role,
 		capabilities: caps,
+		weight:       DefaultAgentWeight,
 		state:        AgentStateWaiting,
-		connected:    false,
 		createdAt:    nowFunc(),
 	}
 }
 
+// NewTypedAgentComrade creates a new agent comrade of the given type
+func NewTypedAgentComrade(role string, capabilities []string, agentType AgentType) (*AgentComrade, error) {
+	if agentType.String() == "unknown" {
+		return nil, fmt.Errorf("unknown agent type: %d", agentType)
+	}
+	agent := NewAgentComrade(role, capabilities)
+	agent.agentType = agentType
+	return agent, nil
+}
+
 // Role returns the agent's role
 func (a *AgentComrade) Role() string {
 	return a.role
 }
 
+// Type returns the agent's type
+func (a *AgentComrade) Type() AgentType {
+	return a.agentType
+}
+
+// Description returns the agent's free-text description, empty if it gave none
+func (a *AgentComrade) Description() string {
+	return a.description
+}
+
+// SetDescription sets the agent's free-text description
+// Surrounding whitespace is trimmed; descriptions longer than MaxDescriptionLength are rejected
+func (a *AgentComrade) SetDescription(description string) error {
+	description = strings.TrimSpace(description)
+	if err := ValidateDescription(description); err != nil {
+		return err
+	}
+	a.description = description
+	return nil
+}
+
+// SetReservationToken sets the token the agent presents to claim a role reserved with ReserveRole
+func (a *AgentComrade) SetReservationToken(token string) {
+	a.reservationToken = token
+}
+
+// ValidateDescription checks that an agent description fits within MaxDescriptionLength characters
+func ValidateDescription(description string) error {
+	if length := utf8.RuneCountInString(strings.TrimSpace(description)); length > MaxDescriptionLength {
+		return fmt.Errorf("agent description is %d characters long (max: %d)", length, MaxDescriptionLength)
+	}
+	return nil
+}
+
+// Weight returns the agent's share of the assignments of weighted groups
+func (a *AgentComrade) Weight() int {
+	return a.weight
+}
+
+// SetWeight sets the agent's share of the assignments of weighted groups
+func (a *AgentComrade) SetWeight(weight int) error {
+	if err := ValidateWeight(weight); err != nil {
+		return err
+	}
+	a.weight = weight
+	return nil
+}
+
+// ValidateWeight checks that an agent weight lies between 1 and MaxAgentWeight
+func ValidateWeight(weight int) error {
+	if weight < 1 || weight > MaxAgentWeight {
+		return fmt.Errorf("agent weight must be between 1 and %d: %d", MaxAgentWeight, weight)
+	}
+	return nil
+}
+
 // Capabilities returns a copy of the agent's capabilities
 func (a *AgentComrade) Capabilities() []string {
 	caps := make([]string, len(a.capabilities))
@@ -64,6 +226,13 @@ func (a *AgentComrade) Capabilities() []string {
 	return caps
 }
 
+// SetCapabilities replaces the agent's capability list in place
+func (a *AgentComrade) SetCapabilities(capabilities []string) {
+	caps := make([]string, len(capabilities))
+	copy(caps, capabilities)
+	a.capabilities = caps
+}
+
 // State returns the current state of the agent
 func (a *AgentComrade) State() AgentState {
 	return a.state
@@ -71,7 +240,7 @@ func (a *AgentComrade) State() AgentState {
 
 // IsConnected returns true if the agent is currently connected
 func (a *AgentComrade) IsConnected() bool {
-	return a.connected
+	return a.connected.Load()
 }
 
 // CreatedAt returns when the agent was created
@@ -94,12 +263,50 @@ func (a *AgentComrade) LastMessageTime() time.Time {
 	return a.lastMessageTime
 }
 
+// LastSeenAt returns when the agent last connected or sent a heartbeat, zero if it never did
+func (a *AgentComrade) LastSeenAt() time.Time {
+	nanos := a.lastSeenAt.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// markSeen records a sign of life from the agent
+func (a *AgentComrade) markSeen() {
+	a.lastSeenAt.Store(nowFunc().UnixNano())
+}
+
+// OfferedAt returns when the barrel was last offered to the agent
+func (a *AgentComrade) OfferedAt() time.Time {
+	return a.offeredAt
+}
+
+// DisconnectedAt returns when the agent last lost its connection
+func (a *AgentComrade) DisconnectedAt() time.Time {
+	return a.disconnectedAt
+}
+
+// RegistrationSeq returns the agent's registration sequence number
+// Agents registered earlier have lower numbers; 0 means the agent was never registered
+func (a *AgentComrade) RegistrationSeq() uint64 {
+	return a.registrationSeq
+}
+
+// setRegistrationSeq records the sequence number assigned when the agent registered
+func (a *AgentComrade) setRegistrationSeq(seq uint64) {
+	a.registrationSeq = seq
+}
+
 // SetConnected updates the connection state of the agent
 func (a *AgentComrade) SetConnected(connected bool) {
-	a.connected = connected
 	if connected {
 		a.lastConnectedAt = nowFunc()
+		a.markSeen()
+	} else if a.connected.Load() {
+		a.disconnectedAt = nowFunc()
 	}
+	a.connected.Store(connected)
 }
 
 // TransitionTo transitions the agent to a new state with validation
@@ -117,24 +324,64 @@ func (a *AgentComrade) TransitionTo(newState AgentState) error {
 func (a *AgentComrade) isValidTransition(from, to AgentState) bool {
 	switch from {
 	case AgentStateWaiting:
-		return to == AgentStateWorking
+		return to == AgentStateWorking || to == AgentStateOffered || to == AgentStatePaused
 	case AgentStateWorking:
+		return to == AgentStateWaiting || to == AgentStateError
+	case AgentStateOffered:
+		return to == AgentStateWorking || to == AgentStateWaiting
+	case AgentStatePaused, AgentStateError:
 		return to == AgentStateWaiting
 	default:
 		return false
 	}
 }
 
+// forceState sets the state without transition validation
+// Only administrative recovery may use it; regular flows go through TransitionTo
+func (a *AgentComrade) forceState(state AgentState) {
+	a.state = state
+	if state != AgentStateOffered {
+		a.offeredAt = time.Time{}
+	}
+}
+
 // HasCapability checks if the agent has a specific capability
+// The capability may be a pattern such as "test/*"; see MatchesCapability
 func (a *AgentComrade) HasCapability(capability string) bool {
+	return a.MatchesCapability(capability)
+}
+
+// MatchesCapability checks if any of the agent's capabilities matches a capability pattern
+func (a *AgentComrade) MatchesCapability(pattern string) bool {
 	for _, cap := range a.capabilities {
-		if cap == capability {
+		if CapabilityMatches(pattern, cap) {
 			return true
 		}
 	}
 	return false
 }
 
+// CapabilityMatches reports whether a capability satisfies a required capability pattern
+// Plain patterns match exactly. A trailing "*" after a plain prefix matches every capability
+// below it, so "test/*" matches "test/unit" and "test/unit/fast". Other patterns use path.Match
+// globbing, where "*" stays within one "/" segment
+func CapabilityMatches(pattern, capability string) bool {
+	if pattern == "" || capability == "" {
+		return false
+	}
+	if !strings.ContainsAny(pattern, "*?[\\") {
+		return pattern == capability
+	}
+
+	prefix := strings.TrimSuffix(pattern, "*")
+	if prefix != pattern && !strings.ContainsAny(prefix, "*?[\\") {
+		return len(capability) > len(prefix) && strings.HasPrefix(capability, prefix)
+	}
+
+	matched, err := path.Match(pattern, capability)
+	return err == nil && matched
+}
+
 // SetLastMessage updates the last message and timestamp
 func (a *AgentComrade) SetLastMessage(message string) {
 	a.lastMessage = message
@@ -152,6 +399,39 @@ func (a *AgentComrade) Activate(message string) error {
 	return nil
 }
 
+// Offer transitions the agent from waiting to offered state with a message
+// The agent starts working only after it acknowledges the activation
+func (a *AgentComrade) Offer(message string) error {
+	if a.state != AgentStateWaiting {
+		return fmt.Errorf("cannot offer barrel to agent in %s state, must be waiting", a.state)
+	}
+
+	a.state = AgentStateOffered
+	a.offeredAt = nowFunc()
+	a.SetLastMessage(message)
+	return nil
+}
+
+// AcceptOffer transitions the agent from offered to working once it acknowledges the activation
+func (a *AgentComrade) AcceptOffer() error {
+	if a.state != AgentStateOffered {
+		return fmt.Errorf("cannot accept offer in %s state, must be offered", a.state)
+	}
+
+	a.state = AgentStateWorking
+	return nil
+}
+
+// WithdrawOffer transitions the agent from offered back to waiting when the offer lapses
+func (a *AgentComrade) WithdrawOffer() error {
+	if a.state != AgentStateOffered {
+		return fmt.Errorf("cannot withdraw offer in %s state, must be offered", a.state)
+	}
+
+	a.state = AgentStateWaiting
+	return nil
+}
+
 // Yield transitions the agent from working back to waiting state
 // This represents the completion of work and voluntary yielding of the barrel
 func (a *AgentComrade) Yield() error {
@@ -168,7 +448,56 @@ func (a *AgentComrade) IsWorking() bool {
 	return a.state == AgentStateWorking
 }
 
+// IsOffered returns true if the agent has been offered the barrel but not yet acknowledged it
+func (a *AgentComrade) IsOffered() bool {
+	return a.state == AgentStateOffered
+}
+
 // IsWaiting returns true if the agent is currently waiting
 func (a *AgentComrade) IsWaiting() bool {
 	return a.state == AgentStateWaiting
 }
+
+// IsPaused returns true if the agent was paused by the people
+func (a *AgentComrade) IsPaused() bool {
+	return a.state == AgentStatePaused
+}
+
+// IsErrored returns true if the agent failed while working and was not resumed since
+func (a *AgentComrade) IsErrored() bool {
+	return a.state == AgentStateError
+}
+
+// FailureReason returns why the agent entered the error state, empty if it is not in it
+func (a *AgentComrade) FailureReason() string {
+	return a.failureReason
+}
+
+// Pause takes a waiting agent out of service until it is resumed
+func (a *AgentComrade) Pause() error {
+	if a.state != AgentStateWaiting {
+		return fmt.Errorf("cannot pause agent in %s state, must be waiting", a.state)
+	}
+	return a.TransitionTo(AgentStatePaused)
+}
+
+// Resume returns a paused or failed agent to waiting so it can receive work again
+func (a *AgentComrade) Resume() error {
+	if a.state != AgentStatePaused && a.state != AgentStateError {
+		return fmt.Errorf("cannot resume agent in %s state, must be paused or error", a.state)
+	}
+	a.failureReason = ""
+	return a.TransitionTo(AgentStateWaiting)
+}
+
+// Fail moves a working agent to the error state, recording why it failed
+func (a *AgentComrade) Fail(reason string) error {
+	if a.state != AgentStateWorking {
+		return fmt.Errorf("cannot fail agent in %s state, must be working", a.state)
+	}
+	if err := a.TransitionTo(AgentStateError); err != nil {
+		return err
+	}
+	a.failureReason = strings.TrimSpace(reason)
+	return nil
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,63 @@ func TestAgentComrade_NewAgentComrade(t *testing.T) {
 	assert.NotZero(t, agent.CreatedAt())
 }
 
+func TestParseAgentType(t *testing.T) {
+	for name, expected := range map[string]AgentType{
+		"":            AgentTypeWorker,
+		"worker":      AgentTypeWorker,
+		" Observer ":  AgentTypeObserver,
+		"COORDINATOR": AgentTypeCoordinator,
+	} {
+		agentType, err := ParseAgentType(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, agentType, name)
+	}
+
+	_, err := ParseAgentType("primary")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown agent type: primary")
+}
+
+func TestNewTypedAgentComrade(t *testing.T) {
+	assert.Equal(t, AgentTypeWorker, NewAgentComrade("developer", nil).Type())
+
+	observer, err := NewTypedAgentComrade("auditor", []string{"audit"}, AgentTypeObserver)
+	assert.NoError(t, err)
+	assert.Equal(t, AgentTypeObserver, observer.Type())
+	assert.Equal(t, []string{"audit"}, observer.Capabilities())
+	assert.False(t, observer.Type().CanHoldBarrel())
+	assert.True(t, AgentTypeCoordinator.CanHoldBarrel())
+
+	_, err = NewTypedAgentComrade("auditor", nil, AgentType(42))
+	assert.Error(t, err)
+}
+
+func TestAgentComrade_SetDescription(t *testing.T) {
+	agent := NewAgentComrade("developer", []string{"code"})
+	assert.Empty(t, agent.Description())
+
+	assert.NoError(t, agent.SetDescription("  Implements features from the backlog\n"))
+	assert.Equal(t, "Implements features from the backlog", agent.Description())
+
+	// The limit counts characters, not bytes
+	assert.NoError(t, agent.SetDescription(strings.Repeat("é", MaxDescriptionLength)))
+	err := agent.SetDescription(strings.Repeat("x", MaxDescriptionLength+1))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "max: 280")
+	assert.Equal(t, strings.Repeat("é", MaxDescriptionLength), agent.Description())
+}
+
+func TestAgentComrade_SetWeight(t *testing.T) {
+	agent := NewAgentComrade("builder", nil)
+	assert.Equal(t, DefaultAgentWeight, agent.Weight())
+
+	assert.NoError(t, agent.SetWeight(MaxAgentWeight))
+	assert.Equal(t, MaxAgentWeight, agent.Weight())
+	assert.Error(t, agent.SetWeight(0))
+	assert.Error(t, agent.SetWeight(MaxAgentWeight+1))
+	assert.Equal(t, MaxAgentWeight, agent.Weight())
+}
+
 func TestAgentComrade_SetConnected(t *testing.T) {
 	// RED: Test connection state management
 	agent := NewAgentComrade("tester", []string{"test", "validate"})
@@ -89,6 +147,28 @@ func TestAgentComrade_HasCapability(t *testing.T) {
 	assert.False(t, agent.HasCapability(""))
 }
 
+func TestAgentComrade_MatchesCapability(t *testing.T) {
+	agent := NewAgentComrade("tester", []string{"test/unit", "test/integration/api", "code"})
+
+	// Plain capabilities still match exactly
+	assert.True(t, agent.MatchesCapability("code"))
+	assert.False(t, agent.MatchesCapability("cod"))
+	assert.False(t, agent.MatchesCapability("test"))
+
+	// Prefix patterns match every capability below them
+	assert.True(t, agent.MatchesCapability("test/*"))
+	assert.True(t, agent.MatchesCapability("test/integration/*"))
+	assert.True(t, agent.HasCapability("test/*"))
+	assert.False(t, agent.MatchesCapability("deploy/*"))
+	assert.False(t, agent.MatchesCapability("test/unit/*"))
+
+	// Other globs stay within a single segment
+	assert.True(t, agent.MatchesCapability("test/?nit"))
+	assert.True(t, agent.MatchesCapability("*/unit"))
+	assert.False(t, agent.MatchesCapability("*/api"))
+	assert.False(t, agent.MatchesCapability("test/[a-c]*x"))
+}
+
 func TestAgentComrade_SetLastMessage(t *testing.T) {
 	// RED: Test message tracking
 	agent := NewAgentComrade("tester", []string{"test"})
@@ -109,6 +189,12 @@ func TestAgentState_String(t *testing.T) {
 	// RED: Test state string representation
 	assert.Equal(t, "waiting", AgentStateWaiting.String())
 	assert.Equal(t, "working", AgentStateWorking.String())
+	assert.Equal(t, "paused", AgentStatePaused.String())
+	assert.Equal(t, "error", AgentStateError.String())
+
+	state, err := ParseAgentState("paused")
+	assert.NoError(t, err)
+	assert.Equal(t, AgentStatePaused, state)
 }
 
 func TestAgentComrade_Activate(t *testing.T) {
@@ -161,3 +247,77 @@ func TestAgentComrade_Yield_InvalidState(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "cannot yield while in waiting state")
 }
+
+func TestAgentComrade_OfferLifecycle(t *testing.T) {
+	agent := NewAgentComrade("developer", []string{"code"})
+
+	err := agent.Offer("Work on task")
+	assert.NoError(t, err)
+	assert.True(t, agent.IsOffered())
+	assert.Equal(t, "offered", agent.State().String())
+	assert.Equal(t, "Work on task", agent.LastMessage())
+	assert.NotZero(t, agent.OfferedAt())
+
+	// Offered agents cannot yield until they accept
+	assert.Error(t, agent.Yield())
+
+	err = agent.AcceptOffer()
+	assert.NoError(t, err)
+	assert.True(t, agent.IsWorking())
+
+	// Accepting twice is invalid
+	assert.Error(t, agent.AcceptOffer())
+}
+
+func TestAgentComrade_WithdrawOffer(t *testing.T) {
+	agent := NewAgentComrade("developer", []string{"code"})
+	agent.Offer("Work on task")
+
+	err := agent.WithdrawOffer()
+	assert.NoError(t, err)
+	assert.True(t, agent.IsWaiting())
+
+	// Cannot withdraw an offer that does not exist
+	err = agent.WithdrawOffer()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be offered")
+}
+
+func TestAgentComrade_PauseAndResume(t *testing.T) {
+	agent := NewAgentComrade("developer", []string{"code"})
+
+	assert.NoError(t, agent.Pause())
+	assert.True(t, agent.IsPaused())
+	assert.Error(t, agent.Activate("Work on task"), "paused agents receive no work")
+	assert.Error(t, agent.TransitionTo(AgentStateWorking))
+
+	assert.NoError(t, agent.Resume())
+	assert.True(t, agent.IsWaiting())
+	err := agent.Resume()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be paused or error")
+
+	// Only waiting agents can be paused
+	assert.NoError(t, agent.Activate("Work on task"))
+	err = agent.Pause()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be waiting")
+}
+
+func TestAgentComrade_FailAndResume(t *testing.T) {
+	agent := NewAgentComrade("developer", []string{"code"})
+
+	err := agent.Fail("disk full")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be working")
+
+	assert.NoError(t, agent.Activate("Work on task"))
+	assert.NoError(t, agent.Fail(" disk full "))
+	assert.True(t, agent.IsErrored())
+	assert.Equal(t, "disk full", agent.FailureReason())
+	assert.Error(t, agent.TransitionTo(AgentStatePaused), "error only leads back to waiting")
+
+	assert.NoError(t, agent.Resume())
+	assert.True(t, agent.IsWaiting())
+	assert.Empty(t, agent.FailureReason())
+}
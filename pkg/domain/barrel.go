@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,48 +12,83 @@ var nowFunc = time.Now
 
 // TransferRecord represents a single barrel transfer in the revolutionary history
 type TransferRecord struct {
-	FromRole  string    `json:"from_role"`
-	ToRole    string    `json:"to_role"`
-	Message   string    `json:"message"`
-	Timestamp time.Time `json:"timestamp"`
+	FromRole   string    `json:"from_role"`
+	ToRole     string    `json:"to_role"`
+	Message    string    `json:"message"`
+	Timestamp  time.Time `json:"timestamp"`
+	RetryCount int       `json:"retry_count,omitempty"` // Retry attempt this transfer represents (0 for first attempts)
+	Receipt    Receipt   `json:"receipt"`               // Hash-chained receipt of this transfer
+
+	// Metadata is the structured data the sender attached to the message; it is not covered by the receipt
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // BarrelOfGun represents the sacred credential of labor in the Agent Farm collective.
 // Only one barrel exists, ensuring disciplined serial execution of all work.
 type BarrelOfGun struct {
-	currentHolder string
+	// currentHolder is read lock-free by the hot holder checks of every yield validation and status poll
+	currentHolder atomic.Pointer[string]
 	lastMessage   string
 	transferTime  time.Time
 	history       []TransferRecord
+	retryCount    int // Retries spent on the work currently carried by the barrel
+
+	// expectedDuration is how long the current holder is expected to keep the barrel (0 = no hint)
+	expectedDuration time.Duration
+
+	// holdTimes accumulates the completed holds of each role, see SovietState.recordHoldTime
+	holdTimes map[string]HoldTimeStats
 }
 
+// DefaultInitialMessage is the first message of a barrel created without one
+const DefaultInitialMessage = "Initial barrel creation"
+
 // NewBarrelOfGun creates a new barrel with initial ownership by the People
 func NewBarrelOfGun() *BarrelOfGun {
+	return NewBarrelOfGunWithMessage(DefaultInitialMessage)
+}
+
+// NewBarrelOfGunWithMessage creates a new barrel held by the People whose first message is message,
+// e.g. the task the collective is started for. An empty message falls back to DefaultInitialMessage
+func NewBarrelOfGunWithMessage(message string) *BarrelOfGun {
+	if message == "" {
+		message = DefaultInitialMessage
+	}
 	now := nowFunc()
 	barrel := &BarrelOfGun{
-		currentHolder: "people",
-		lastMessage:   "Initial barrel creation",
-		transferTime:  now,
+		lastMessage:  message,
+		transferTime: now,
 		history: []TransferRecord{
 			{
 				FromRole:  "",
 				ToRole:    "people",
-				Message:   "Initial barrel creation",
+				Message:   message,
 				Timestamp: now,
+				Receipt:   NewReceipt(0, "", "people", message, now, ""),
 			},
 		},
 	}
+	barrel.setHolder("people")
 	return barrel
 }
 
 // CurrentHolder returns the role that currently holds the barrel
+// It never blocks, so it is safe to call while a transfer is in progress
 func (b *BarrelOfGun) CurrentHolder() string {
-	return b.currentHolder
+	if holder := b.currentHolder.Load(); holder != nil {
+		return *holder
+	}
+	return ""
 }
 
 // IsHeldBy checks if the barrel is currently held by the specified role
 func (b *BarrelOfGun) IsHeldBy(role string) bool {
-	return b.currentHolder == role
+	return b.CurrentHolder() == role
+}
+
+// setHolder publishes the new holder to lock-free readers
+func (b *BarrelOfGun) setHolder(role string) {
+	b.currentHolder.Store(&role)
 }
 
 // LastTransferTime returns when the barrel was last transferred
@@ -65,35 +101,162 @@ func (b *BarrelOfGun) LastMessage() string {
 	return b.lastMessage
 }
 
+// LastFromRole returns the role that sent the last transfer, empty for a barrel nobody has transferred yet
+func (b *BarrelOfGun) LastFromRole() string {
+	return b.LastTransfer().FromRole
+}
+
 // TransferTo transfers the barrel to a new role with a message
 func (b *BarrelOfGun) TransferTo(toRole, message string) error {
+	return b.TransferToAs(b.CurrentHolder(), toRole, message)
+}
+
+// TransferToAs transfers the barrel like TransferTo, recording sender as the sender of the transfer
+// A people's representative such as "people:alice" may send a barrel held by the people; any other
+// sender must be the holder
+func (b *BarrelOfGun) TransferToAs(sender, toRole, message string) error {
 	// Validate input
 	if toRole == "" {
 		return fmt.Errorf("role cannot be empty")
 	}
 
-	if toRole == b.currentHolder {
+	holder := b.CurrentHolder()
+	if NormalizeRole(sender) != holder {
+		return fmt.Errorf("'%s' cannot transfer a barrel held by '%s'", sender, holder)
+	}
+	return b.transferFrom(sender, toRole, message)
+}
+
+// transferFrom records a transfer sent by sender whoever holds the barrel
+// Only the soviet uses it directly, for the people taking the barrel away from an agent
+func (b *BarrelOfGun) transferFrom(sender, toRole, message string) error {
+	if toRole == "" {
+		return fmt.Errorf("role cannot be empty")
+	}
+	if toRole == b.CurrentHolder() {
 		return fmt.Errorf("cannot transfer to same role: %s", toRole)
 	}
+	fromRole := sender
 
-	// Record the transfer
+	// Record the transfer, chaining its receipt to the previous one
 	now := nowFunc()
+	previous := b.LastTransfer().Receipt
 	record := TransferRecord{
-		FromRole:  b.currentHolder,
-		ToRole:    toRole,
-		Message:   message,
-		Timestamp: now,
+		FromRole:   fromRole,
+		ToRole:     toRole,
+		Message:    message,
+		Timestamp:  now,
+		RetryCount: b.retryCount,
+		Receipt:    NewReceipt(previous.Sequence+1, fromRole, toRole, message, now, previous.Hash),
 	}
 
 	// Update barrel state
-	b.currentHolder = toRole
+	b.setHolder(toRole)
 	b.lastMessage = message
 	b.transferTime = now
 	b.history = append(b.history, record)
+	b.expectedDuration = 0
 
 	return nil
 }
 
+// ExpectedDuration returns how long the current holder is expected to keep the barrel, 0 if nobody said
+// Every transfer clears the hint
+func (b *BarrelOfGun) ExpectedDuration() time.Duration {
+	return b.expectedDuration
+}
+
+// setExpectedDuration records the expected hold time of the current holder
+func (b *BarrelOfGun) setExpectedDuration(expected time.Duration) {
+	b.expectedDuration = expected
+}
+
+// setMetadata attaches the structured metadata of the message to the last transfer
+func (b *BarrelOfGun) setMetadata(metadata map[string]string) {
+	b.history[len(b.history)-1].Metadata = copyMetadata(metadata)
+}
+
+// RetryCount returns how many times the work carried by the barrel has been retried
+func (b *BarrelOfGun) RetryCount() int {
+	return b.retryCount
+}
+
+// LastTransfer returns the most recent transfer record
+func (b *BarrelOfGun) LastTransfer() TransferRecord {
+	return b.history[len(b.history)-1]
+}
+
+// incrementRetries records another retry attempt for the current work
+func (b *BarrelOfGun) incrementRetries() {
+	b.retryCount++
+}
+
+// resetRetries clears retry tracking once work completes or fails terminally
+func (b *BarrelOfGun) resetRetries() {
+	b.retryCount = 0
+}
+
+// GetReceipts returns the hash-chained receipts of every transfer, oldest first
+func (b *BarrelOfGun) GetReceipts() []Receipt {
+	receipts := make([]Receipt, len(b.history))
+	for i, record := range b.history {
+		receipts[i] = record.Receipt
+	}
+	return receipts
+}
+
+// pruneHistoryBefore drops transfer records older than cutoff and returns them, oldest first
+// The latest record is always kept: it is the barrel's last transfer and anchors the receipt chain
+func (b *BarrelOfGun) pruneHistoryBefore(cutoff time.Time) []TransferRecord {
+	keepFrom := 0
+	for keepFrom < len(b.history)-1 && b.history[keepFrom].Timestamp.Before(cutoff) {
+		keepFrom++
+	}
+	if keepFrom == 0 {
+		return nil
+	}
+
+	pruned := make([]TransferRecord, keepFrom)
+	copy(pruned, b.history[:keepFrom])
+	b.history = append([]TransferRecord(nil), b.history[keepFrom:]...)
+	return pruned
+}
+
+// HoldStatsByRole sums, per role, how long it held the barrel: its completed holds, however they
+// ended, plus the current holder's time up to now. The people's holds are not work and are left out
+func (b *BarrelOfGun) HoldStatsByRole() map[string]time.Duration {
+	totals := make(map[string]time.Duration, len(b.holdTimes)+1)
+	for role, stats := range b.holdTimes {
+		totals[role] = stats.Total
+	}
+	if role, held := b.currentHold(); role != "" {
+		totals[role] += held
+	}
+	return totals
+}
+
+// recordHold adds one completed hold by role and returns the role's updated stats
+func (b *BarrelOfGun) recordHold(role string, held time.Duration) HoldTimeStats {
+	if b.holdTimes == nil {
+		b.holdTimes = make(map[string]HoldTimeStats)
+	}
+	stats := b.holdTimes[role]
+	stats.Yields++
+	stats.Total += held
+	stats.Average = stats.Total / time.Duration(stats.Yields)
+	b.holdTimes[role] = stats
+	return stats
+}
+
+// currentHold returns the agent holding the barrel and how long it has held it so far
+// The role is empty when the people hold it
+func (b *BarrelOfGun) currentHold() (string, time.Duration) {
+	if b.IsHeldBy("people") {
+		return "", 0
+	}
+	return b.CurrentHolder(), nowFunc().Sub(b.transferTime)
+}
+
 // GetTransferHistory returns the complete history of barrel transfers
 func (b *BarrelOfGun) GetTransferHistory() []TransferRecord {
 	// Return a copy to prevent external modification
@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+// BarrelContext is everything the current holder has to work with, read in one go
+// A reconnecting agent or a monitoring tool gets the complete picture of the active task from it
+type BarrelContext struct {
+	// BarrelHolder indicates which role currently holds the barrel of gun
+	BarrelHolder string `json:"barrel_holder"`
+
+	// FromRole is who handed the barrel to the holder, empty for a barrel that never moved
+	FromRole string `json:"from_role"`
+
+	// Message is the payload the holder received with the barrel
+	Message string `json:"message"`
+
+	// ReceivedAt is when the holder received the barrel
+	ReceivedAt time.Time `json:"received_at"`
+
+	// ExpectedDuration is how long the holder is expected to keep the barrel (0 = no hint)
+	ExpectedDuration time.Duration `json:"expected_duration"`
+
+	// OverExpected is true when the holder has kept the barrel longer than ExpectedDuration
+	OverExpected bool `json:"over_expected"`
+
+	// RetryCount is how many times the work carried by the barrel has been retried
+	RetryCount int `json:"retry_count"`
+
+	// Receipt is the receipt of the hand-off that gave the holder the barrel
+	Receipt Receipt `json:"receipt"`
+
+	// Metadata is the structured data sent along with Message, nil if there was none
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// GetBarrelContext returns the holder's current task assembled from the barrel's last transfer
+// This implements the AgentService interface
+func (s *SovietState) GetBarrelContext() BarrelContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barrel == nil {
+		return BarrelContext{BarrelHolder: s.barrelStatus()}
+	}
+	last := s.barrel.LastTransfer()
+	staleness := s.staleness()
+	return BarrelContext{
+		BarrelHolder:     s.barrel.CurrentHolder(),
+		FromRole:         last.FromRole,
+		Message:          s.barrel.LastMessage(),
+		ReceivedAt:       s.barrel.LastTransferTime(),
+		ExpectedDuration: staleness.ExpectedDuration,
+		OverExpected:     staleness.OverExpected,
+		RetryCount:       s.barrel.RetryCount(),
+		Receipt:          last.Receipt,
+		Metadata:         copyMetadata(last.Metadata),
+	}
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BarrelStore keeps a snapshot of the barrel in a JSON file so a restarted server knows who held it
+// and when the holder's deadline falls. Without it, an agent reconnecting after a restart would find
+// the barrel back with the people
+type BarrelStore struct {
+	path   string
+	logger Logger
+}
+
+// NewBarrelStore creates a store saving the barrel to path; failed saves are logged to logger if it is not nil
+func NewBarrelStore(path string, logger Logger) *BarrelStore {
+	return &BarrelStore{path: path, logger: logger}
+}
+
+// Path returns the file the barrel is saved to
+func (s *BarrelStore) Path() string {
+	return s.path
+}
+
+// Save writes the snapshot to the file, replacing it atomically so a crash never leaves half a barrel
+func (s *BarrelStore) Save(snapshot BarrelSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize barrel: %w", err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save barrel: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to save barrel: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to save barrel: %w", err)
+	}
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save barrel: %w", err)
+	}
+	return nil
+}
+
+// Load reads the snapshot saved to the file, to be restored with RestoreBarrel
+// The error wraps os.ErrNotExist when nothing has been saved yet
+func (s *BarrelStore) Load() (BarrelSnapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return BarrelSnapshot{}, fmt.Errorf("failed to load barrel: %w", err)
+	}
+	var snapshot BarrelSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return BarrelSnapshot{}, fmt.Errorf("failed to load barrel from %s: %w", s.path, err)
+	}
+	return snapshot, nil
+}
+
+// SetBarrelStore saves the barrel to store now and after every transfer, TTL change or history prune
+// nil stops saving it
+func (s *SovietState) SetBarrelStore(store *BarrelStore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.barrelStore = store
+	if store == nil {
+		return nil
+	}
+	snapshot, err := s.snapshotBarrel()
+	if err != nil {
+		return err
+	}
+	return store.Save(snapshot)
+}
+
+// saveBarrel saves a snapshot of the barrel to the barrel store, if there is one; the caller holds the lock
+// A failed save is logged rather than failing the operation, which has already happened in memory
+func (s *SovietState) saveBarrel() {
+	if s.barrelStore == nil || s.barrel == nil {
+		return
+	}
+	snapshot, err := s.snapshotBarrel()
+	if err == nil {
+		err = s.barrelStore.Save(snapshot)
+	}
+	if err != nil && s.barrelStore.logger != nil {
+		s.barrelStore.logger.Error("Failed to save barrel", map[string]interface{}{
+			"path":  s.barrelStore.path,
+			"error": err.Error(),
+		})
+	}
+}
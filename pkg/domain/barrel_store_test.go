@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBarrelStore_SavesEveryChange(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	store := NewBarrelStore(filepath.Join(t.TempDir(), "barrel.json"), nil)
+	_, err := store.Load()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	_, _, _, err = soviet.RegisterAgent(NewAgentComrade("developer", []string{"code"}))
+	require.NoError(t, err)
+	require.NoError(t, soviet.SetBarrelStore(store))
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "people", loaded.Holder)
+
+	message := NewYieldMessage("people", "developer", "Build it").WithMetadata(map[string]string{"pr": "42"})
+	require.NoError(t, soviet.ProcessYield(message))
+	loaded, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "developer", loaded.Holder)
+	assert.Equal(t, "Build it", loaded.LastMessage)
+	assert.Equal(t, "42", loaded.History[len(loaded.History)-1].Metadata["pr"])
+
+	// A TTL changed by the people is saved with the deadline it gives the holder
+	require.NoError(t, soviet.SetBarrelTTL(10*time.Minute))
+	loaded, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, loaded.TTL)
+	assert.True(t, currentTime.Add(10*time.Minute).Equal(loaded.Deadline))
+
+	// The saved snapshot restores the barrel and carries on its receipt chain
+	restarted := newTestSoviet()
+	require.NoError(t, restarted.RestoreBarrel(loaded))
+	assert.Equal(t, soviet.GetBarrel().GetReceipts(), restarted.GetBarrel().GetReceipts())
+	assert.Equal(t, 10*time.Minute, restarted.BarrelTTL())
+
+	require.NoError(t, os.WriteFile(store.Path(), []byte("{not json"), 0o600))
+	_, err = store.Load()
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestBarrelStore_FailedSaveKeepsTransfer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"code"}))
+	require.NoError(t, err)
+	require.NoError(t, soviet.SetBarrelStore(NewBarrelStore(filepath.Join(dir, "barrel.json"), nil)))
+
+	require.NoError(t, os.RemoveAll(dir))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	assert.Equal(t, "developer", soviet.GetBarrelStatus())
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +20,26 @@ func TestBarrelOfGun_NewBarrelOfGun(t *testing.T) {
 	assert.NotZero(t, barrel.LastTransferTime())
 }
 
+func TestBarrelOfGun_NewBarrelOfGunWithMessage(t *testing.T) {
+	barrel := NewBarrelOfGunWithMessage("Ship the billing service")
+
+	assert.Equal(t, "people", barrel.CurrentHolder())
+	assert.Equal(t, "Ship the billing service", barrel.LastMessage())
+	history := barrel.GetTransferHistory()
+	assert.Len(t, history, 1)
+	assert.Equal(t, "Ship the billing service", history[0].Message)
+	assert.Equal(t, "people", history[0].ToRole)
+	assert.True(t, history[0].Receipt.MatchesPayload("Ship the billing service"))
+
+	// The transfer after it chains onto the custom first receipt
+	assert.NoError(t, barrel.TransferTo("developer", "Build it"))
+	assert.Equal(t, history[0].Receipt.Hash, barrel.LastTransfer().Receipt.PreviousHash)
+
+	// Without a message the default one is used
+	assert.Equal(t, DefaultInitialMessage, NewBarrelOfGunWithMessage("").LastMessage())
+	assert.Equal(t, DefaultInitialMessage, NewBarrelOfGun().GetTransferHistory()[0].Message)
+}
+
 func TestBarrelOfGun_TransferTo(t *testing.T) {
 	// RED: Test barrel transfer functionality
 
@@ -95,3 +116,140 @@ func TestBarrelOfGun_GetTransferHistory(t *testing.T) {
 	assert.Equal(t, "developer", history[2].FromRole)
 	assert.Equal(t, "Task completed", history[2].Message)
 }
+
+func TestBarrelOfGun_HoldStatsByRole(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	barrel := NewBarrelOfGun()
+	assert.Empty(t, barrel.HoldStatsByRole(), "the people's holds are not counted")
+
+	barrel.recordHold("developer", 10*time.Minute)
+	barrel.recordHold("developer", 20*time.Minute)
+	barrel.recordHold("tester", 5*time.Minute)
+	assert.Equal(t, HoldTimeStats{Yields: 2, Total: 30 * time.Minute, Average: 15 * time.Minute}, barrel.holdTimes["developer"])
+
+	// The current holder's time runs up to now
+	assert.NoError(t, barrel.TransferTo("tester", "Work"))
+	currentTime = currentTime.Add(7 * time.Minute)
+	assert.Equal(t, map[string]time.Duration{
+		"developer": 30 * time.Minute,
+		"tester":    12 * time.Minute,
+	}, barrel.HoldStatsByRole())
+}
+
+func TestBarrelOfGun_HolderReadsDuringTransfers(t *testing.T) {
+	// Run with -race: holder checks must not race with transfers
+	soviet := NewSovietState(NewMemoryAgentRepository())
+	barrel := NewBarrelOfGun()
+	assert.NoError(t, soviet.SetBarrel(barrel))
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					holder := soviet.CurrentBarrelHolder()
+					assert.True(t, holder == "people" || holder == "developer")
+					soviet.IsBarrelHeldBy("developer")
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		role := "developer"
+		if barrel.IsHeldBy(role) {
+			role = "people"
+		}
+		assert.NoError(t, barrel.TransferTo(role, "work"))
+	}
+	close(done)
+	readers.Wait()
+}
+
+// rwMutexHolder is the locked read path the lock-free holder replaces, kept for comparison
+type rwMutexHolder struct {
+	mu     sync.RWMutex
+	holder string
+}
+
+func (h *rwMutexHolder) isHeldBy(role string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.holder == role
+}
+
+func (h *rwMutexHolder) transferTo(role string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.holder = role
+}
+
+// BenchmarkBarrelHolderReads measures holder checks under concurrent validate+poll load
+// while another goroutine keeps transferring the barrel
+func BenchmarkBarrelHolderReads(b *testing.B) {
+	roles := []string{"people", "developer"}
+
+	b.Run("lock-free", func(b *testing.B) {
+		soviet := NewSovietState(NewMemoryAgentRepository())
+		barrel := NewBarrelOfGun()
+		if err := soviet.SetBarrel(barrel); err != nil {
+			b.Fatal(err)
+		}
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			for i := 1; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = barrel.TransferTo(roles[i%2], "work")
+					barrel.history = barrel.history[:1] // keep memory flat during long runs
+				}
+			}
+		}()
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				soviet.IsBarrelHeldBy("developer")
+				_ = soviet.CurrentBarrelHolder()
+			}
+		})
+	})
+
+	b.Run("rwmutex", func(b *testing.B) {
+		holder := &rwMutexHolder{holder: "people"}
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			for i := 1; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					holder.transferTo(roles[i%2])
+				}
+			}
+		}()
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				holder.isHeldBy("developer")
+				holder.isHeldBy("people")
+			}
+		})
+	})
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PersistencePolicy decides what happens when the repository fails to store an agent
+type PersistencePolicy int
+
+const (
+	// PersistenceStrict fails the operation when the repository cannot store an agent
+	PersistenceStrict PersistencePolicy = iota
+
+	// PersistenceBestEffort keeps the agent in memory and retries storing it on later repository access
+	PersistenceBestEffort
+)
+
+// String returns the string representation of PersistencePolicy
+func (p PersistencePolicy) String() string {
+	switch p {
+	case PersistenceStrict:
+		return "strict"
+	case PersistenceBestEffort:
+		return "best-effort"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePersistencePolicy converts "strict" or "best-effort" to a PersistencePolicy
+func ParsePersistencePolicy(name string) (PersistencePolicy, error) {
+	switch name {
+	case "strict":
+		return PersistenceStrict, nil
+	case "best-effort":
+		return PersistenceBestEffort, nil
+	default:
+		return PersistenceStrict, fmt.Errorf("unknown persistence policy: %s (expected strict or best-effort)", name)
+	}
+}
+
+// bestEffortRepository decorates a repository so that failed stores do not fail the caller
+// Agents that could not be stored are kept in memory, served from there, and flushed to the
+// underlying repository on every later access until a store succeeds
+type bestEffortRepository struct {
+	inner   AgentRepository
+	logger  Logger
+	pending map[string]*AgentComrade
+	mutex   sync.Mutex
+}
+
+func newBestEffortRepository(inner AgentRepository, logger Logger) *bestEffortRepository {
+	return &bestEffortRepository{
+		inner:   inner,
+		logger:  logger,
+		pending: make(map[string]*AgentComrade),
+	}
+}
+
+// Store persists an agent, keeping it in memory if the underlying repository fails
+func (r *bestEffortRepository) Store(agent *AgentComrade) error {
+	if agent == nil {
+		return fmt.Errorf("agent cannot be nil")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.flush()
+
+	role := agent.Role()
+	if err := r.inner.Store(agent); err != nil {
+		r.pending[role] = agent
+		if r.logger != nil {
+			r.logger.Warn("Repository store failed, keeping agent in memory", map[string]interface{}{
+				"role":  role,
+				"error": err.Error(),
+			})
+		}
+		return nil
+	}
+
+	delete(r.pending, role)
+	return nil
+}
+
+// GetByRole retrieves an agent, preferring agents that are still waiting to be stored
+func (r *bestEffortRepository) GetByRole(role string) (*AgentComrade, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.flush()
+
+	if agent, exists := r.pending[role]; exists {
+		return agent, nil
+	}
+	return r.inner.GetByRole(role)
+}
+
+// GetAll retrieves all agents, merging in agents that are still waiting to be stored
+func (r *bestEffortRepository) GetAll() ([]*AgentComrade, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.flush()
+
+	stored, err := r.inner.GetAll()
+	if err != nil && len(r.pending) == 0 {
+		return nil, err
+	}
+
+	agents := make([]*AgentComrade, 0, len(stored)+len(r.pending))
+	for _, agent := range stored {
+		if _, overridden := r.pending[agent.Role()]; !overridden {
+			agents = append(agents, agent)
+		}
+	}
+	for _, agent := range r.pending {
+		agents = append(agents, agent)
+	}
+	return agents, nil
+}
+
+// Delete removes an agent from memory and from the underlying repository
+func (r *bestEffortRepository) Delete(role string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, wasPending := r.pending[role]
+	delete(r.pending, role)
+
+	if err := r.inner.Delete(role); err != nil && !wasPending {
+		return err
+	}
+	return nil
+}
+
+// Exists checks whether an agent exists in memory or in the underlying repository
+func (r *bestEffortRepository) Exists(role string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.pending[role]; exists {
+		return true
+	}
+	return r.inner.Exists(role)
+}
+
+// flush retries storing pending agents (caller holds the mutex)
+func (r *bestEffortRepository) flush() {
+	for role, agent := range r.pending {
+		if err := r.inner.Store(agent); err == nil {
+			delete(r.pending, role)
+		}
+	}
+}
+
+// Ensure bestEffortRepository implements AgentRepository
+var _ AgentRepository = (*bestEffortRepository)(nil)
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyRepository fails the first failures calls to Store, then delegates to a memory repository
+type flakyRepository struct {
+	*MemoryAgentRepository
+	failures int
+}
+
+func (r *flakyRepository) Store(agent *AgentComrade) error {
+	if r.failures > 0 {
+		r.failures--
+		return fmt.Errorf("repository unavailable")
+	}
+	return r.MemoryAgentRepository.Store(agent)
+}
+
+func TestParsePersistencePolicy(t *testing.T) {
+	policy, err := ParsePersistencePolicy("strict")
+	require.NoError(t, err)
+	assert.Equal(t, PersistenceStrict, policy)
+
+	policy, err = ParsePersistencePolicy("best-effort")
+	require.NoError(t, err)
+	assert.Equal(t, PersistenceBestEffort, policy)
+	assert.Equal(t, "best-effort", policy.String())
+
+	_, err = ParsePersistencePolicy("eventual")
+	assert.Error(t, err)
+}
+
+func TestSovietState_RegisterAgent_StrictPersistenceFails(t *testing.T) {
+	repo := &flakyRepository{MemoryAgentRepository: NewMemoryAgentRepository(), failures: 1}
+	soviet := NewSovietState(repo)
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	assert.Equal(t, PersistenceStrict, soviet.PersistencePolicy())
+
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"coding"}))
+	assert.Error(t, err)
+	assert.Nil(t, soviet.GetAgent("developer"))
+}
+
+func TestSovietState_RegisterAgent_BestEffortPersistenceContinues(t *testing.T) {
+	inner := NewMemoryAgentRepository()
+	repo := &flakyRepository{MemoryAgentRepository: inner, failures: 1}
+	soviet := NewSovietState(repo)
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	require.NoError(t, soviet.SetPersistencePolicy(PersistenceBestEffort))
+	assert.Equal(t, PersistenceBestEffort, soviet.PersistencePolicy())
+
+	// Registration succeeds although the store failed; the agent lives in memory
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"coding"}))
+	require.NoError(t, err)
+	assert.NotNil(t, soviet.GetAgent("developer"))
+
+	// The agent is fully usable
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	agent := soviet.GetAgent("developer")
+	require.NotNil(t, agent)
+	assert.Equal(t, AgentStateWorking, agent.State())
+
+	// Once the repository recovers, the pending agent is flushed to it
+	_, _, _, err = soviet.RegisterAgent(NewAgentComrade("tester", []string{"testing"}))
+	require.NoError(t, err)
+	assert.True(t, inner.Exists("developer"))
+	assert.True(t, inner.Exists("tester"))
+
+	// Switching back to strict unwraps the repository
+	require.NoError(t, soviet.SetPersistencePolicy(PersistenceStrict))
+	assert.Equal(t, PersistenceStrict, soviet.PersistencePolicy())
+	assert.ElementsMatch(t, []string{"developer", "tester"}, soviet.GetAgentRoles())
+}
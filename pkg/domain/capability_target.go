@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CapabilityTargetPrefix marks a yield target naming a capability instead of a role,
+// e.g. "capability:testing" yields to a connected agent that can test
+const CapabilityTargetPrefix = "capability:"
+
+// ParseCapabilityTarget returns the capability named by a capability target
+// Returns false for targets that name a role or a group
+func ParseCapabilityTarget(toRole string) (string, bool) {
+	capability, ok := strings.CutPrefix(toRole, CapabilityTargetPrefix)
+	return strings.TrimSpace(capability), ok
+}
+
+// resolveCapabilityTarget picks the agent a capability target yields to: the first, in role order,
+// of the connected waiting agents with a matching capability. Capability patterns such as test/*
+// work as for required capabilities. Observers and benched roles are never picked
+func (s *SovietState) resolveCapabilityTarget(capability string) (string, error) {
+	if capability == "" {
+		return "", fmt.Errorf("capability target '%s' names no capability", CapabilityTargetPrefix)
+	}
+
+	agents, err := s.repo.GetAll()
+	if err != nil {
+		return "", fmt.Errorf("failed to list agents: %w", err)
+	}
+	var connected, available []string
+	for _, agent := range agents {
+		if !agent.IsConnected() || !agent.MatchesCapability(capability) {
+			continue
+		}
+		connected = append(connected, agent.Role())
+		if agent.IsWaiting() && agent.Type().CanHoldBarrel() && !s.IsBenched(agent.Role()) {
+			available = append(available, agent.Role())
+		}
+	}
+
+	if len(connected) == 0 {
+		return "", fmt.Errorf("no connected agent has capability '%s'", capability)
+	}
+	if len(available) == 0 {
+		sort.Strings(connected)
+		return "", fmt.Errorf("no agent with capability '%s' is available (connected: %v)", capability, connected)
+	}
+	sort.Strings(available)
+	return available[0], nil
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCapabilityTarget(t *testing.T) {
+	capability, ok := ParseCapabilityTarget("capability: testing ")
+	assert.True(t, ok)
+	assert.Equal(t, "testing", capability)
+
+	_, ok = ParseCapabilityTarget("tester")
+	assert.False(t, ok)
+}
+
+func TestSovietState_YieldToCapability(t *testing.T) {
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	require.NoError(t, soviet.SetBarrel(barrel))
+
+	for role, capabilities := range map[string][]string{
+		"developer": {"coding"},
+		"tester-b":  {"testing", "test/unit"},
+		"tester-a":  {"testing"},
+	} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, capabilities))
+		require.NoError(t, err)
+	}
+
+	// The first matching role in sorted order is picked, and the history records it, not the alias
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "capability:testing", "Run the suite")))
+	assert.Equal(t, "tester-a", barrel.CurrentHolder())
+	transfer, ok := soviet.LastTransfer()
+	require.True(t, ok)
+	assert.Equal(t, "tester-a", transfer.ToRole)
+
+	// The holder is busy, so the next capable agent is picked; patterns work too
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("tester-a", "capability:test/*", "Unit tests please")))
+	assert.Equal(t, "tester-b", barrel.CurrentHolder())
+
+	err := soviet.ProcessYield(NewYieldMessage("tester-b", "capability:deploying", "Ship it"))
+	assert.ErrorContains(t, err, "no connected agent has capability 'deploying'")
+	assert.Equal(t, 1, soviet.Rejections()[ValidationCodeInvalidTarget])
+
+	// Disconnected agents do not count as having the capability
+	require.NoError(t, soviet.DisconnectAgent("developer"))
+	err = soviet.ProcessYield(NewYieldMessage("tester-b", "capability:coding", "Fix it"))
+	assert.ErrorContains(t, err, "no connected agent has capability 'coding'")
+	assert.Equal(t, "tester-b", barrel.CurrentHolder())
+
+	problems := soviet.ValidateYield(NewYieldMessage("tester-b", "capability:", "Anyone"))
+	require.Len(t, problems, 1)
+	assert.Equal(t, ValidationCodeInvalidTarget, problems[0].Code)
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetCircuitBreaker benches a role for cooldown after threshold consecutive failed yields from it
+// Benched roles are skipped by routing and rejected as yield targets; a threshold of 0 disables the breaker
+func (s *SovietState) SetCircuitBreaker(threshold int, cooldown time.Duration) error {
+	if threshold < 0 {
+		return fmt.Errorf("circuit breaker threshold cannot be negative: %d", threshold)
+	}
+	if cooldown < 0 {
+		return fmt.Errorf("circuit breaker cooldown cannot be negative: %s", cooldown)
+	}
+	if threshold > 0 && cooldown == 0 {
+		return fmt.Errorf("circuit breaker cooldown is required when the threshold is set")
+	}
+
+	s.breakerThreshold = threshold
+	s.breakerCooldown = cooldown
+	return nil
+}
+
+// recordYieldOutcome counts consecutive failed yields per role and trips the breaker at the threshold
+// A successful yield resets the count and lifts any bench left on the role
+// The count starts over once the breaker trips, so a benched role needs threshold new failures to trip again
+func (s *SovietState) recordYieldOutcome(role string, failed bool) {
+	if s.breakerThreshold == 0 || role == "people" {
+		return
+	}
+
+	if !failed {
+		delete(s.consecutiveFailures, role)
+		delete(s.benchedUntil, role)
+		return
+	}
+
+	if s.consecutiveFailures == nil {
+		s.consecutiveFailures = make(map[string]int)
+	}
+	s.consecutiveFailures[role]++
+	if s.consecutiveFailures[role] < s.breakerThreshold {
+		return
+	}
+
+	delete(s.consecutiveFailures, role)
+	if s.benchedUntil == nil {
+		s.benchedUntil = make(map[string]time.Time)
+	}
+	until := nowFunc().Add(s.breakerCooldown)
+	s.benchedUntil[role] = until
+
+	if s.logger != nil {
+		s.logger.Warn("Circuit breaker tripped, role benched", map[string]interface{}{
+			"role":     role,
+			"failures": s.breakerThreshold,
+			"until":    until.Format(time.RFC3339),
+		})
+	}
+}
+
+// IsBenched reports whether the circuit breaker keeps role from receiving the barrel
+func (s *SovietState) IsBenched(role string) bool {
+	until, benched := s.benchedUntil[role]
+	return benched && nowFunc().Before(until)
+}
+
+// BenchedRoles returns the roles the circuit breaker currently benches and when each returns to service
+func (s *SovietState) BenchedRoles() map[string]time.Time {
+	benched := make(map[string]time.Time, len(s.benchedUntil))
+	for role, until := range s.benchedUntil {
+		if nowFunc().Before(until) {
+			benched[role] = until
+		}
+	}
+	return benched
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_SetCircuitBreaker_Validation(t *testing.T) {
+	soviet := newTestSoviet()
+
+	assert.Error(t, soviet.SetCircuitBreaker(-1, time.Minute))
+	assert.Error(t, soviet.SetCircuitBreaker(3, -time.Minute))
+	assert.Contains(t, soviet.SetCircuitBreaker(3, 0).Error(), "cooldown is required")
+	assert.NoError(t, soviet.SetCircuitBreaker(0, 0))
+	assert.NoError(t, soviet.SetCircuitBreaker(3, time.Minute))
+}
+
+func TestSovietState_CircuitBreakerTripsAndResets(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	require.NoError(t, soviet.SetBarrel(barrel))
+	require.NoError(t, soviet.SetCircuitBreaker(2, 5*time.Minute))
+	require.NoError(t, soviet.SetGroup("backend", []string{"developer", "backup"}))
+	for _, role := range []string{"developer", "backup"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"code"}))
+		require.NoError(t, err)
+	}
+
+	failOnce := func() {
+		require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Fix the bug")))
+		require.NoError(t, soviet.ProcessYield(NewFailedYieldMessage("developer", "people", "Still broken")))
+	}
+
+	failOnce()
+	assert.False(t, soviet.IsBenched("developer"))
+	failOnce()
+	assert.True(t, soviet.IsBenched("developer"))
+	assert.Equal(t, map[string]time.Time{"developer": currentTime.Add(5 * time.Minute)}, soviet.QueryStatus().BenchedAgents)
+
+	// A benched role is rejected as a target and skipped by group routing
+	err := soviet.ProcessYield(NewYieldMessage("people", "developer", "Try again"))
+	var validationErr ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, ValidationCodeInvalidTarget, validationErr.Code)
+	assert.Contains(t, err.Error(), "benched")
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "backend", "Anyone")))
+	assert.Equal(t, "backup", barrel.CurrentHolder())
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("backup", "people", "Done")))
+
+	// The bench lifts after the cooldown, and a successful yield clears the failure count
+	currentTime = currentTime.Add(5 * time.Minute)
+	assert.False(t, soviet.IsBenched("developer"))
+	assert.Empty(t, soviet.BenchedRoles())
+	failOnce()
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Fix the bug")))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", "people", "Fixed")))
+	failOnce()
+	assert.False(t, soviet.IsBenched("developer"))
+}
+
+func TestSovietState_CircuitBreakerStopsRetries(t *testing.T) {
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	require.NoError(t, soviet.SetBarrel(barrel))
+	require.NoError(t, soviet.SetRetryPolicy(5, nil))
+	require.NoError(t, soviet.SetCircuitBreaker(2, time.Hour))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"code"}))
+	require.NoError(t, err)
+
+	// The first failure is requeued to the developer; the second trips the breaker and ends the retries
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Fix the bug")))
+	require.NoError(t, soviet.ProcessYield(NewFailedYieldMessage("developer", "people", "Broken")))
+	assert.Equal(t, "developer", barrel.CurrentHolder())
+	require.NoError(t, soviet.ProcessYield(NewFailedYieldMessage("developer", "people", "Broken again")))
+	assert.Equal(t, "people", barrel.CurrentHolder())
+	assert.True(t, soviet.IsBenched("developer"))
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithinSkew reports whether a client timestamp is no further ahead of now than tolerance
+// Timestamps in the past are always within skew; how old they may be is decided by each time-based rule
+// A tolerance of 0 disables the check
+func WithinSkew(timestamp, now time.Time, tolerance time.Duration) bool {
+	return tolerance == 0 || !timestamp.After(now.Add(tolerance))
+}
+
+// SetClockSkewTolerance sets how far client clocks may run ahead of the server clock
+// It governs every check of a client timestamp; 0 disables the check
+func (s *SovietState) SetClockSkewTolerance(tolerance time.Duration) error {
+	if tolerance < 0 {
+		return fmt.Errorf("clock skew tolerance cannot be negative: %s", tolerance)
+	}
+	s.clockSkewTolerance = tolerance
+	return nil
+}
+
+// ClockSkewTolerance returns how far client clocks may run ahead of the server clock
+func (s *SovietState) ClockSkewTolerance() time.Duration {
+	return s.clockSkewTolerance
+}
+
+// WithinSkew reports whether a client timestamp lies within the clock skew tolerance of the server clock
+func (s *SovietState) WithinSkew(timestamp time.Time) bool {
+	return WithinSkew(timestamp, nowFunc(), s.clockSkewTolerance)
+}
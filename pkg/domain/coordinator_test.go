@@ -2,7 +2,9 @@ package domain
 
 import (
 	"testing"
+	"time"
 
+	"github.com/prashantv/gostub"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -63,7 +65,7 @@ func TestCoordinatorTestSuite(t *testing.T) {
 func (suite *CoordinatorTestSuite) Test_RegisterAgent_SuccessfulRegistration() {
 	agent := createTestAgent("developer")
 
-	shouldResume, lastMessage, err := suite.soviet.RegisterAgent(agent)
+	shouldResume, lastMessage, _, err := suite.soviet.RegisterAgent(agent)
 
 	assert.NoError(suite.T(), err)
 	assert.False(suite.T(), shouldResume) // New agent shouldn't resume work (barrel is with people)
@@ -79,12 +81,12 @@ func (suite *CoordinatorTestSuite) Test_RegisterAgent_DuplicateRole_ReplacesExis
 	agent2 := createTestAgent("developer") // Same role, different instance
 
 	// First registration should succeed
-	_, _, err := suite.soviet.RegisterAgent(agent1)
+	_, _, _, err := suite.soviet.RegisterAgent(agent1)
 	assert.NoError(suite.T(), err)
 	assert.True(suite.T(), agent1.IsConnected())
 
 	// Second registration should replace the first
-	_, _, err = suite.soviet.RegisterAgent(agent2)
+	_, _, _, err = suite.soviet.RegisterAgent(agent2)
 	assert.NoError(suite.T(), err)
 	assert.False(suite.T(), agent1.IsConnected()) // Original agent disconnected
 	assert.True(suite.T(), agent2.IsConnected())  // New agent connected
@@ -104,11 +106,12 @@ func (suite *CoordinatorTestSuite) Test_RegisterAgent_WithBarrel_ShouldResume()
 	suite.barrel.TransferTo("developer", "Test message")
 
 	// Now register the agent
-	shouldResume, lastMessage, err := suite.soviet.RegisterAgent(agent)
+	shouldResume, lastMessage, lastFromRole, err := suite.soviet.RegisterAgent(agent)
 
 	assert.NoError(suite.T(), err)
 	assert.True(suite.T(), shouldResume)                      // Agent should resume work
 	assert.Equal(suite.T(), "Test message", lastMessage)      // Should get the last message
+	assert.Equal(suite.T(), "people", lastFromRole)           // Should learn who handed the barrel over
 	assert.Equal(suite.T(), AgentStateWorking, agent.State()) // Should be working
 }
 
@@ -178,3 +181,850 @@ func (suite *CoordinatorTestSuite) Test_GetBarrelStatus() {
 	status = suite.soviet.GetBarrelStatus()
 	assert.Equal(suite.T(), "developer", status)
 }
+
+// Test_AgentService_DetailedQueries tests the detailed queries through the AgentService port the TCP server uses
+func (suite *CoordinatorTestSuite) Test_AgentService_DetailedQueries() {
+	var service AgentService = suite.soviet
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+
+	assert.Equal(suite.T(), "developer", service.GetBarrelStatus())
+	details := service.GetAgentDetails()
+	suite.Require().Len(details, 1)
+	assert.Equal(suite.T(), "developer", details[0].Role)
+	assert.Equal(suite.T(), developer.Capabilities(), details[0].Capabilities)
+	assert.Equal(suite.T(), AgentStateWorking, details[0].State)
+}
+
+// Test_ProcessYield_DisconnectedTarget_Rejected tests that the coordinator refuses to strand the barrel on an offline agent
+func (suite *CoordinatorTestSuite) Test_ProcessYield_DisconnectedTarget_Rejected() {
+	toAgent := createTestAgent("tester")
+	suite.soviet.RegisterAgent(toAgent)
+
+	// Target stays registered but loses its connection
+	toAgent.SetConnected(false)
+
+	err := suite.soviet.ProcessYield(NewYieldMessage("people", "tester", "Test the release"))
+
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "not connected")
+	assert.True(suite.T(), suite.soviet.IsAgentRegistered("tester"))
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder()) // Barrel must not move
+	assert.Equal(suite.T(), AgentStateWaiting, toAgent.State())
+}
+
+// Test_ProcessYield_PeopleIntervention_ReturnsHolderToWaiting tests that the people can take the barrel from a working agent
+func (suite *CoordinatorTestSuite) Test_ProcessYield_PeopleIntervention_ReturnsHolderToWaiting() {
+	developer := createTestAgent("developer")
+	tester := createTestAgent("tester")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(tester)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	suite.Require().Equal(AgentStateWorking, developer.State())
+
+	// Yielding the barrel back to its holder would leave it working on nothing
+	err := suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it again"))
+	assert.ErrorContains(suite.T(), err, "same role")
+	assert.Equal(suite.T(), AgentStateWorking, developer.State())
+
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people:alice", "tester", "Test the release instead")))
+
+	assert.Equal(suite.T(), "tester", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), AgentStateWaiting, developer.State())
+	assert.Equal(suite.T(), AgentStateWorking, tester.State())
+	assert.Equal(suite.T(), "people:alice", suite.barrel.LastTransfer().FromRole)
+	assert.Equal(suite.T(), AgentStateWaiting, suite.soviet.QueryStatus().AgentStates["developer"])
+	assert.Equal(suite.T(), 1, suite.soviet.HoldTimes()["developer"].Yields)
+}
+
+// Test_ProcessYield_MaxYieldChainDepth tests that runaway agent-to-agent loops are stopped
+func (suite *CoordinatorTestSuite) Test_ProcessYield_MaxYieldChainDepth() {
+	developer := createTestAgent("developer")
+	tester := createTestAgent("tester")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(tester)
+	suite.Require().NoError(suite.soviet.SetMaxYieldChainDepth(2))
+
+	// People start the chain without extending it
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+	assert.Equal(suite.T(), 0, suite.soviet.YieldChainDepth())
+
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "tester", "Round 1")))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("tester", "developer", "Round 2")))
+	assert.Equal(suite.T(), 2, suite.soviet.YieldChainDepth())
+	assert.Equal(suite.T(), 2, suite.soviet.QueryStatus().YieldChainDepth)
+
+	// Third agent-to-agent yield exceeds the limit
+	err := suite.soviet.ProcessYield(NewYieldMessage("developer", "tester", "Round 3"))
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "yield chain depth limit exceeded")
+	assert.Equal(suite.T(), "developer", suite.barrel.CurrentHolder())
+
+	// Returning the barrel to the people resets the chain
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+	assert.Equal(suite.T(), 0, suite.soviet.YieldChainDepth())
+}
+
+// Test_SetMaxYieldChainDepth_Negative tests that negative depth limits are rejected
+func (suite *CoordinatorTestSuite) Test_SetMaxYieldChainDepth_Negative() {
+	err := suite.soviet.SetMaxYieldChainDepth(-1)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), 0, suite.soviet.MaxYieldChainDepth())
+}
+
+// Test_BarrelTTL_ReclaimAfterDeadline tests that an expired holder loses the barrel to the people
+func (suite *CoordinatorTestSuite) Test_BarrelTTL_ReclaimAfterDeadline() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.SetBarrelTTL(10 * time.Minute))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+	assert.Equal(suite.T(), currentTime.Add(10*time.Minute), suite.soviet.BarrelDeadline())
+
+	// Before the deadline nothing happens
+	currentTime = currentTime.Add(5 * time.Minute)
+	reclaimed, err := suite.soviet.ReclaimExpiredBarrel()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), reclaimed)
+
+	// Shortening the TTL moves the current holder's deadline
+	suite.Require().NoError(suite.soviet.SetBarrelTTL(3 * time.Minute))
+	reclaimed, err = suite.soviet.ReclaimExpiredBarrel()
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), reclaimed)
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), AgentStateWaiting, developer.State())
+	assert.True(suite.T(), suite.soviet.BarrelDeadline().IsZero())
+}
+
+// Test_BarrelTTL_TransferResetsDeadline tests that a hand-off just before the deadline gives the new holder a full TTL
+func (suite *CoordinatorTestSuite) Test_BarrelTTL_TransferResetsDeadline() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	suite.soviet.RegisterAgent(createTestAgent("developer"))
+	suite.soviet.RegisterAgent(createTestAgent("tester"))
+	suite.Require().NoError(suite.soviet.SetBarrelTTL(10 * time.Minute))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+
+	currentTime = currentTime.Add(10*time.Minute - time.Second)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "tester", "Test it")))
+	assert.Equal(suite.T(), currentTime.Add(10*time.Minute), suite.soviet.BarrelDeadline())
+
+	currentTime = currentTime.Add(time.Minute)
+	reclaimed, err := suite.soviet.ReclaimExpiredBarrel()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), reclaimed)
+	assert.Equal(suite.T(), "tester", suite.barrel.CurrentHolder())
+}
+
+// Test_GetStaleness_MeasuresTimeSinceLastTransfer tests that staleness resets whenever the barrel moves
+func (suite *CoordinatorTestSuite) Test_GetStaleness_MeasuresTimeSinceLastTransfer() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	currentTime = currentTime.Add(30 * time.Minute)
+	staleness := suite.soviet.GetStaleness()
+	assert.Equal(suite.T(), "developer", staleness.BarrelHolder)
+	assert.Equal(suite.T(), currentTime.Add(-30*time.Minute), staleness.Since)
+	assert.Equal(suite.T(), 30*time.Minute, staleness.Duration)
+
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+	staleness = suite.soviet.GetStaleness()
+	assert.Equal(suite.T(), "people", staleness.BarrelHolder)
+	assert.Equal(suite.T(), time.Duration(0), staleness.Duration)
+}
+
+// Test_GetStaleness_FlagsHoldersOverExpectedDuration tests that an overrun of the expected duration is flagged, not reclaimed
+func (suite *CoordinatorTestSuite) Test_GetStaleness_FlagsHoldersOverExpectedDuration() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.ProcessYield(
+		NewYieldMessage("people", "developer", "Start").WithExpectedDuration(20 * time.Minute)))
+
+	currentTime = currentTime.Add(20 * time.Minute)
+	staleness := suite.soviet.GetStaleness()
+	assert.Equal(suite.T(), 20*time.Minute, staleness.ExpectedDuration)
+	assert.False(suite.T(), staleness.OverExpected)
+
+	currentTime = currentTime.Add(time.Second)
+	assert.True(suite.T(), suite.soviet.GetStaleness().OverExpected)
+	status := suite.soviet.QueryStatus()
+	assert.Equal(suite.T(), 20*time.Minute, status.ExpectedDuration)
+	assert.True(suite.T(), status.OverExpected)
+	assert.Equal(suite.T(), "developer", status.BarrelHolder)
+
+	// The holder may revise its own estimate; nobody else may
+	suite.Require().NoError(suite.soviet.SetExpectedDuration("developer", time.Hour))
+	assert.False(suite.T(), suite.soviet.GetStaleness().OverExpected)
+	assert.Error(suite.T(), suite.soviet.SetExpectedDuration("people", time.Hour))
+	assert.Error(suite.T(), suite.soviet.SetExpectedDuration("developer", -time.Minute))
+
+	// The hint belongs to one hold and is cleared by the next transfer
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+	staleness = suite.soviet.GetStaleness()
+	assert.Equal(suite.T(), time.Duration(0), staleness.ExpectedDuration)
+	assert.False(suite.T(), staleness.OverExpected)
+}
+
+// Test_GetBarrelContext_DescribesTheHoldersTask tests that the context follows the last hand-off
+func (suite *CoordinatorTestSuite) Test_GetBarrelContext_DescribesTheHoldersTask() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.ProcessYield(
+		NewYieldMessage("people", "developer", "Fix the login bug").WithExpectedDuration(20 * time.Minute)))
+	received := currentTime
+
+	currentTime = currentTime.Add(25 * time.Minute)
+	barrelContext := suite.soviet.GetBarrelContext()
+	assert.Equal(suite.T(), "developer", barrelContext.BarrelHolder)
+	assert.Equal(suite.T(), "people", barrelContext.FromRole)
+	assert.Equal(suite.T(), "Fix the login bug", barrelContext.Message)
+	assert.Equal(suite.T(), received, barrelContext.ReceivedAt)
+	assert.Equal(suite.T(), 20*time.Minute, barrelContext.ExpectedDuration)
+	assert.True(suite.T(), barrelContext.OverExpected)
+	assert.Equal(suite.T(), suite.soviet.GetBarrel().LastTransfer().Receipt, barrelContext.Receipt)
+
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+	barrelContext = suite.soviet.GetBarrelContext()
+	assert.Equal(suite.T(), "people", barrelContext.BarrelHolder)
+	assert.Equal(suite.T(), "developer", barrelContext.FromRole)
+	assert.Equal(suite.T(), "Done", barrelContext.Message)
+	assert.Equal(suite.T(), time.Duration(0), barrelContext.ExpectedDuration)
+}
+
+// Test_ProcessYield_CarriesMetadata tests that structured metadata travels with the payload it was sent with
+func (suite *CoordinatorTestSuite) Test_ProcessYield_CarriesMetadata() {
+	developer := createTestAgent("developer")
+	tester := createTestAgent("tester")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(tester)
+
+	metadata := map[string]string{"pr": "42", "branch": "main"}
+	suite.Require().NoError(suite.soviet.ProcessYield(
+		NewYieldMessage("people", "developer", "Review the PR").WithMetadata(metadata)))
+	metadata["pr"] = "43" // The message keeps its own copy
+	assert.Equal(suite.T(), map[string]string{"pr": "42", "branch": "main"}, suite.barrel.LastTransfer().Metadata)
+	assert.Equal(suite.T(), map[string]string{"pr": "42", "branch": "main"}, suite.soviet.GetBarrelContext().Metadata)
+
+	// Reassigned work is the same work, so its metadata comes along
+	suite.Require().NoError(suite.soviet.ReassignBarrel("developer", "tester"))
+	assert.Equal(suite.T(), "42", suite.barrel.LastTransfer().Metadata["pr"])
+
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("tester", "people", "Done")))
+	assert.Nil(suite.T(), suite.barrel.LastTransfer().Metadata)
+
+	err := suite.soviet.ProcessYield(
+		NewYieldMessage("people", "developer", "Start").WithMetadata(map[string]string{" ": "x"}))
+	assert.ErrorContains(suite.T(), err, "metadata key cannot be empty")
+}
+
+// Test_ProcessYield_EmptyPayload_UsesDefaultMessage tests that only truly empty payloads get the configured default
+func (suite *CoordinatorTestSuite) Test_ProcessYield_EmptyPayload_UsesDefaultMessage() {
+	developer := createTestAgent("developer")
+	tester := createTestAgent("tester")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(tester)
+	suite.Require().NoError(suite.soviet.SetDefaultYieldMessages("Check the backlog", map[string]string{
+		"tester": "Run the full test suite",
+	}))
+
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "")))
+	assert.Equal(suite.T(), "Check the backlog", suite.barrel.LastMessage())
+
+	// Per-role defaults override the global default
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "tester", "")))
+	assert.Equal(suite.T(), "Run the full test suite", suite.barrel.LastMessage())
+
+	// Yields back to the people are never filled in
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("tester", "people", "")))
+	assert.Equal(suite.T(), "", suite.barrel.LastMessage())
+
+	// Anything the sender wrote is delivered unchanged
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", " ")))
+	assert.Equal(suite.T(), " ", suite.barrel.LastMessage())
+}
+
+// Test_SetDefaultYieldMessages_Invalid tests that per-role defaults must name a real role and message
+func (suite *CoordinatorTestSuite) Test_SetDefaultYieldMessages_Invalid() {
+	assert.Error(suite.T(), suite.soviet.SetDefaultYieldMessages("", map[string]string{"": "Start"}))
+	assert.Error(suite.T(), suite.soviet.SetDefaultYieldMessages("", map[string]string{"people": "Start"}))
+	assert.Error(suite.T(), suite.soviet.SetDefaultYieldMessages("", map[string]string{"developer": ""}))
+}
+
+// Test_RegisterAgent_AssignsIncreasingRegistrationSeq tests seniority ordering, including replacements
+func (suite *CoordinatorTestSuite) Test_RegisterAgent_AssignsIncreasingRegistrationSeq() {
+	developer := createTestAgent("developer")
+	tester := createTestAgent("tester")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(tester)
+	assert.Less(suite.T(), developer.RegistrationSeq(), tester.RegistrationSeq())
+	assert.NotZero(suite.T(), developer.RegistrationSeq())
+
+	// A replacement registration joins the end of the order
+	replacement := createTestAgent("developer")
+	suite.soviet.RegisterAgent(replacement)
+	assert.Greater(suite.T(), replacement.RegistrationSeq(), tester.RegistrationSeq())
+
+	details := suite.soviet.GetAgentDetails()
+	suite.Require().Len(details, 2)
+	assert.Equal(suite.T(), "tester", details[0].Role)
+	assert.Equal(suite.T(), "developer", details[1].Role)
+	assert.Equal(suite.T(), replacement.RegistrationSeq(), details[1].RegistrationSeq)
+
+	status := suite.soviet.QueryStatus()
+	assert.Equal(suite.T(), tester.RegistrationSeq(), status.RegistrationSeqs["tester"])
+	assert.Equal(suite.T(), replacement.RegistrationSeq(), status.RegistrationSeqs["developer"])
+}
+
+// Test_ValidateYield_ReportsAllProblems tests that every broken rule is reported with its code
+func (suite *CoordinatorTestSuite) Test_ValidateYield_ReportsAllProblems() {
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+
+	// Valid yields report nothing and transfer nothing
+	assert.Empty(suite.T(), suite.soviet.ValidateYield(NewYieldMessage("people", "developer", "Start")))
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder())
+
+	problems := suite.soviet.ValidateYield(NewYieldMessage("developer", "ghost", "Done"))
+	codes := make([]string, len(problems))
+	for i, problem := range problems {
+		codes[i] = problem.Code
+	}
+	assert.Equal(suite.T(), []string{ValidationCodeNotBarrelHolder, ValidationCodeInvalidTarget}, codes)
+	assert.Contains(suite.T(), problems[1].Message, "target agent 'ghost' not found")
+}
+
+// Test_ProcessYield_RecordsHoldTimePerRole tests that yields accumulate total and average hold times
+func (suite *CoordinatorTestSuite) Test_ProcessYield_RecordsHoldTimePerRole() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+
+	for _, held := range []time.Duration{10 * time.Minute, 20 * time.Minute} {
+		suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Work")))
+		currentTime = currentTime.Add(held)
+		suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+		currentTime = currentTime.Add(time.Hour) // Time with the people is not counted
+	}
+
+	holdTimes := suite.soviet.GetStats().HoldTimes
+	assert.Equal(suite.T(), HoldTimeStats{Yields: 2, Total: 30 * time.Minute, Average: 15 * time.Minute}, holdTimes["developer"])
+	_, recorded := holdTimes["people"]
+	assert.False(suite.T(), recorded)
+}
+
+// Test_AverageHolds_CountsCurrentHold tests that average holds and hold durations include the current holder's time
+func (suite *CoordinatorTestSuite) Test_AverageHolds_CountsCurrentHold() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	suite.Require().NoError(suite.soviet.SetBarrel(NewBarrelOfGun()))
+	suite.soviet.RegisterAgent(createTestAgent("developer"))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Work")))
+	currentTime = currentTime.Add(10 * time.Minute)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "More work")))
+	currentTime = currentTime.Add(30 * time.Minute)
+
+	assert.Equal(suite.T(), map[string]time.Duration{"developer": 40 * time.Minute}, suite.soviet.GetStats().HoldDurations)
+	assert.Equal(suite.T(), map[string]time.Duration{"developer": 20 * time.Minute}, suite.soviet.QueryStatus().AverageHolds)
+
+	// Both come from the accumulated hold times, so pruning the history changes neither
+	suite.Require().NoError(suite.soviet.SetHistoryRetention(time.Minute, false))
+	pruned, err := suite.soviet.PruneHistory()
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(pruned)
+	stats := suite.soviet.GetStats()
+	assert.Equal(suite.T(), 10*time.Minute, stats.HoldTimes["developer"].Total)
+	assert.Equal(suite.T(), map[string]time.Duration{"developer": 40 * time.Minute}, stats.HoldDurations)
+	assert.Equal(suite.T(), map[string]time.Duration{"developer": 20 * time.Minute}, suite.soviet.QueryStatus().AverageHolds)
+}
+
+// Test_ReclaimExpiredBarrel_RecordsHoldTime tests that a hold ended by taking the barrel back is recorded like a yield
+func (suite *CoordinatorTestSuite) Test_ReclaimExpiredBarrel_RecordsHoldTime() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	suite.Require().NoError(suite.soviet.SetBarrel(NewBarrelOfGun()))
+	suite.soviet.RegisterAgent(createTestAgent("developer"))
+	suite.Require().NoError(suite.soviet.SetBarrelTTL(30 * time.Minute))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Work")))
+	currentTime = currentTime.Add(45 * time.Minute)
+
+	reclaimed, err := suite.soviet.ReclaimExpiredBarrel()
+	suite.Require().NoError(err)
+	suite.Require().True(reclaimed)
+	currentTime = currentTime.Add(time.Hour) // Time with the people is not counted
+
+	stats := suite.soviet.GetStats()
+	assert.Equal(suite.T(), HoldTimeStats{Yields: 1, Total: 45 * time.Minute, Average: 45 * time.Minute}, stats.HoldTimes["developer"])
+	assert.Equal(suite.T(), map[string]time.Duration{"developer": 45 * time.Minute}, stats.HoldDurations)
+	assert.Equal(suite.T(), map[string]time.Duration{"developer": 45 * time.Minute}, suite.soviet.GetBarrel().HoldStatsByRole())
+}
+
+// Test_GetStats_CountsRejectionsByCode tests that rejected yields and registrations are counted by reason
+func (suite *CoordinatorTestSuite) Test_GetStats_CountsRejectionsByCode() {
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	assert.Empty(suite.T(), suite.soviet.GetStats().Rejections)
+
+	err := suite.soviet.ProcessYield(NewYieldMessage("developer", "people", "Not mine to give"))
+	var validationErr ValidationError
+	suite.Require().ErrorAs(err, &validationErr)
+	assert.Equal(suite.T(), ValidationCodeNotBarrelHolder, validationErr.Code)
+	assert.Contains(suite.T(), err.Error(), "only current barrel holder can yield")
+
+	assert.Error(suite.T(), suite.soviet.ProcessYield(NewYieldMessage("people", "ghost", "Start")))
+	_, _, _, err = suite.soviet.RegisterAgent(NewAgentComrade("soviet", nil))
+	assert.Error(suite.T(), err)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	assert.Equal(suite.T(), map[string]int{
+		ValidationCodeNotBarrelHolder: 1,
+		ValidationCodeInvalidTarget:   1,
+		ValidationCodeInvalidRole:     1,
+	}, suite.soviet.GetStats().Rejections)
+}
+
+// Test_GetStats_CountsRegistrations tests that fresh registrations, replacements and barrel resumes are counted
+func (suite *CoordinatorTestSuite) Test_GetStats_CountsRegistrations() {
+	_, _, _, err := suite.soviet.RegisterAgent(createTestAgent("developer"))
+	suite.Require().NoError(err)
+	_, _, _, err = suite.soviet.RegisterAgent(createTestAgent("tester"))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), RegistrationStats{Fresh: 2}, suite.soviet.GetStats().Registrations)
+
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+	suite.Require().NoError(suite.soviet.DisconnectAgent("developer"))
+	shouldResume, _, _, err := suite.soviet.RegisterAgent(createTestAgent("developer"))
+	suite.Require().NoError(err)
+	suite.Require().True(shouldResume)
+
+	// A reconnection without the barrel replaces the role without resuming anything
+	suite.Require().NoError(suite.soviet.DisconnectAgent("tester"))
+	_, _, _, err = suite.soviet.RegisterAgent(createTestAgent("tester"))
+	suite.Require().NoError(err)
+
+	assert.Equal(suite.T(), RegistrationStats{Fresh: 2, Replacements: 2, Resumes: 1}, suite.soviet.GetStats().Registrations)
+}
+
+// Test_SetBarrelTTL_Negative tests that negative TTLs are rejected
+func (suite *CoordinatorTestSuite) Test_SetBarrelTTL_Negative() {
+	err := suite.soviet.SetBarrelTTL(-time.Second)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), time.Duration(0), suite.soviet.BarrelTTL())
+}
+
+// Test_RegisterAgent_ReservedRole_Refused tests that clients cannot register as internal protocol identities
+func (suite *CoordinatorTestSuite) Test_RegisterAgent_ReservedRole_Refused() {
+	for _, role := range []string{"soviet", "people"} {
+		_, _, _, err := suite.soviet.RegisterAgent(NewAgentComrade(role, nil))
+
+		assert.Error(suite.T(), err, role)
+		assert.Contains(suite.T(), err.Error(), "is reserved")
+		assert.False(suite.T(), suite.soviet.IsAgentRegistered(role))
+	}
+}
+
+// Test_ProcessYield_FailedWork_RequeuedUntilRetriesExhausted tests the retry policy for failed work
+func (suite *CoordinatorTestSuite) Test_ProcessYield_FailedWork_RequeuedUntilRetriesExhausted() {
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.SetRetryPolicy(2, nil))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Implement login")))
+
+	// First two failures requeue the original task to the same role
+	for attempt := 1; attempt <= 2; attempt++ {
+		err := suite.soviet.ProcessYield(NewFailedYieldMessage("developer", "people", "build broke"))
+		suite.Require().NoError(err)
+
+		assert.Equal(suite.T(), "developer", suite.barrel.CurrentHolder())
+		assert.Equal(suite.T(), "Implement login", suite.barrel.LastMessage())
+		assert.Equal(suite.T(), attempt, suite.barrel.RetryCount())
+		assert.Equal(suite.T(), AgentStateWorking, developer.State())
+
+		transfer, ok := suite.soviet.LastTransfer()
+		suite.Require().True(ok)
+		assert.Equal(suite.T(), "developer", transfer.ToRole)
+		assert.Equal(suite.T(), attempt, transfer.RetryCount)
+	}
+
+	// Retries exhausted: the failure is terminal and the barrel stays with the people
+	err := suite.soviet.ProcessYield(NewFailedYieldMessage("developer", "people", "still broken"))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), 0, suite.barrel.RetryCount())
+	assert.Equal(suite.T(), AgentStateWaiting, developer.State())
+}
+
+// Test_ProcessYield_FailedWork_RequeuedToFallback tests that a configured fallback role takes over retries
+func (suite *CoordinatorTestSuite) Test_ProcessYield_FailedWork_RequeuedToFallback() {
+	developer := createTestAgent("developer")
+	reviewer := createTestAgent("reviewer")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(reviewer)
+	suite.Require().NoError(suite.soviet.SetRetryPolicy(1, map[string]string{"developer": "reviewer"}))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Fix the bug")))
+
+	err := suite.soviet.ProcessYield(NewFailedYieldMessage("developer", "people", "cannot reproduce"))
+
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "reviewer", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), "Fix the bug", suite.barrel.LastMessage())
+	assert.Equal(suite.T(), AgentStateWaiting, developer.State())
+	assert.Equal(suite.T(), AgentStateWorking, reviewer.State())
+}
+
+// Test_ProcessYield_FailedWork_NoRetryPolicy tests that failed yields behave normally without a policy
+func (suite *CoordinatorTestSuite) Test_ProcessYield_FailedWork_NoRetryPolicy() {
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Implement login")))
+
+	err := suite.soviet.ProcessYield(NewFailedYieldMessage("developer", "people", "build broke"))
+
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), "build broke", suite.barrel.LastMessage())
+}
+
+// Test_ProcessYield_ActivationAck_OfferedUntilAcknowledged tests the offered intermediate state
+func (suite *CoordinatorTestSuite) Test_ProcessYield_ActivationAck_OfferedUntilAcknowledged() {
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.SetActivationAckTimeout(30 * time.Second))
+
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+	assert.Equal(suite.T(), AgentStateOffered, developer.State())
+	assert.Equal(suite.T(), "developer", suite.barrel.CurrentHolder())
+
+	suite.Require().NoError(suite.soviet.AcknowledgeActivation("developer"))
+	assert.Equal(suite.T(), AgentStateWorking, developer.State())
+
+	// Acknowledging again is harmless
+	assert.NoError(suite.T(), suite.soviet.AcknowledgeActivation("developer"))
+
+	reclaimed, err := suite.soviet.ReclaimUnacknowledgedOffer()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), reclaimed)
+}
+
+// Test_ProcessYield_ActivationAck_YieldAcknowledgesOffer tests an offered holder yielding before it acknowledged
+func (suite *CoordinatorTestSuite) Test_ProcessYield_ActivationAck_YieldAcknowledgesOffer() {
+	developer := createTestAgent("developer")
+	tester := createTestAgent("tester")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(tester)
+	suite.Require().NoError(suite.soviet.SetActivationAckTimeout(30 * time.Second))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+	suite.Require().Equal(AgentStateOffered, developer.State())
+
+	yield := NewYieldMessage("developer", "tester", "Done")
+	assert.Empty(suite.T(), suite.soviet.ValidateYield(yield))
+	suite.Require().NoError(suite.soviet.ProcessYield(yield))
+	assert.Equal(suite.T(), AgentStateWaiting, developer.State())
+	assert.Equal(suite.T(), AgentStateOffered, tester.State())
+	assert.Equal(suite.T(), "tester", suite.barrel.CurrentHolder())
+
+	// Failed work requeued before the acknowledgment is acknowledged the same way
+	suite.Require().NoError(suite.soviet.SetRetryPolicy(1, nil))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewFailedYieldMessage("tester", "people", "Broke")))
+	assert.Equal(suite.T(), "tester", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), AgentStateOffered, tester.State())
+}
+
+// Test_ReclaimUnacknowledgedOffer_ReturnsBarrelToPeople tests the ack timeout branch
+func (suite *CoordinatorTestSuite) Test_ReclaimUnacknowledgedOffer_ReturnsBarrelToPeople() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.SetActivationAckTimeout(30 * time.Second))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	currentTime = currentTime.Add(10 * time.Second)
+	reclaimed, err := suite.soviet.ReclaimUnacknowledgedOffer()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), reclaimed)
+
+	currentTime = currentTime.Add(30 * time.Second)
+	reclaimed, err = suite.soviet.ReclaimUnacknowledgedOffer()
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), reclaimed)
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), AgentStateWaiting, developer.State())
+
+	// Late acknowledgments are refused
+	err = suite.soviet.AcknowledgeActivation("developer")
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "does not hold the barrel")
+}
+
+// Test_UpdateAgentCapabilities_ReplacesInPlace tests capability updates without re-registration
+func (suite *CoordinatorTestSuite) Test_UpdateAgentCapabilities_ReplacesInPlace() {
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	capabilities, err := suite.soviet.UpdateAgentCapabilities("developer", []string{"coding", "plugins", "coding"})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []string{"coding", "plugins"}, capabilities)
+	assert.Equal(suite.T(), []string{"coding", "plugins"}, developer.Capabilities())
+
+	// The agent keeps working and keeps the barrel
+	assert.Equal(suite.T(), AgentStateWorking, developer.State())
+	assert.Equal(suite.T(), "developer", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), []string{"coding", "plugins"}, suite.soviet.QueryStatus().AgentCapabilities["developer"])
+
+	_, err = suite.soviet.UpdateAgentCapabilities("developer", []string{})
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "cannot be empty")
+
+	_, err = suite.soviet.UpdateAgentCapabilities("designer", []string{"ui"})
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "not found")
+}
+
+// Test_ProcessYield_NoBarrel tests that yields fail cleanly when no barrel was set
+func (suite *CoordinatorTestSuite) Test_ProcessYield_NoBarrel() {
+	soviet := NewSovietState(NewMemoryAgentRepository())
+	developer := createTestAgent("developer")
+	soviet.RegisterAgent(developer)
+
+	err := soviet.ProcessYield(NewYieldMessage("people", "developer", "Start"))
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "no barrel set")
+	assert.Equal(suite.T(), AgentStateWaiting, developer.State())
+}
+
+// Test_ReclaimDisconnectedBarrel_ReconnectWithinGrace tests that a holder reconnecting in time resumes work
+func (suite *CoordinatorTestSuite) Test_ReclaimDisconnectedBarrel_ReconnectWithinGrace() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	suite.soviet.RegisterAgent(createTestAgent("developer"))
+	suite.Require().NoError(suite.soviet.SetReconnectGracePeriod(30 * time.Second))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	suite.Require().NoError(suite.soviet.DisconnectAgent("developer"))
+	currentTime = currentTime.Add(10 * time.Second)
+	reclaimed, err := suite.soviet.ReclaimDisconnectedBarrel()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), reclaimed)
+
+	// The holder reconnects in time and resumes
+	reconnected := createTestAgent("developer")
+	shouldResume, payload, _, err := suite.soviet.RegisterAgent(reconnected)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), shouldResume)
+	assert.Equal(suite.T(), "Start", payload)
+
+	// The original deadline has no effect once reconnected
+	currentTime = currentTime.Add(time.Minute)
+	reclaimed, err = suite.soviet.ReclaimDisconnectedBarrel()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), reclaimed)
+	assert.Equal(suite.T(), "developer", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), AgentStateWorking, reconnected.State())
+}
+
+// Test_ReclaimDisconnectedBarrel_GraceExpired tests that the barrel returns to the people after the grace period
+func (suite *CoordinatorTestSuite) Test_ReclaimDisconnectedBarrel_GraceExpired() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	developer := createTestAgent("developer")
+	suite.soviet.RegisterAgent(developer)
+	suite.Require().NoError(suite.soviet.SetReconnectGracePeriod(30 * time.Second))
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	// A connected holder is never reclaimed
+	currentTime = currentTime.Add(time.Hour)
+	reclaimed, err := suite.soviet.ReclaimDisconnectedBarrel()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), reclaimed)
+
+	suite.Require().NoError(suite.soviet.DisconnectAgent("developer"))
+	currentTime = currentTime.Add(31 * time.Second)
+	reclaimed, err = suite.soviet.ReclaimDisconnectedBarrel()
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), reclaimed)
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), AgentStateWaiting, developer.State())
+	assert.True(suite.T(), suite.soviet.IsAgentRegistered("developer"))
+
+	assert.Error(suite.T(), suite.soviet.SetReconnectGracePeriod(-time.Second))
+}
+
+// Test_ProcessYield_StaleMessageRejected tests the stale-message guard
+func (suite *CoordinatorTestSuite) Test_ProcessYield_StaleMessageRejected() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	suite.soviet.RegisterAgent(createTestAgent("developer"))
+	suite.Require().NoError(suite.soviet.SetMaxMessageAge(5 * time.Second))
+
+	// Sent 10 seconds ago, e.g. delayed in flight after a retry already succeeded
+	stale := NewYieldMessage("people", "developer", "Start").WithSentAt(currentTime.Add(-10 * time.Second))
+	err := suite.soviet.ProcessYield(stale)
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "stale yield")
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder())
+
+	fresh := NewYieldMessage("people", "developer", "Start").WithSentAt(currentTime.Add(-2 * time.Second))
+	assert.NoError(suite.T(), suite.soviet.ProcessYield(fresh))
+	assert.Equal(suite.T(), "developer", suite.barrel.CurrentHolder())
+
+	// Messages without a send time are not subject to the guard
+	assert.NoError(suite.T(), suite.soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+	assert.Error(suite.T(), suite.soviet.SetMaxMessageAge(-time.Second))
+}
+
+// Test_ProcessYield_ClockSkewTolerance tests that future timestamps are accepted within the tolerance only
+func (suite *CoordinatorTestSuite) Test_ProcessYield_ClockSkewTolerance() {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	suite.soviet.RegisterAgent(createTestAgent("developer"))
+	suite.Require().NoError(suite.soviet.SetMaxMessageAge(5 * time.Second))
+	suite.Require().NoError(suite.soviet.SetClockSkewTolerance(2 * time.Second))
+
+	// A sender whose clock runs 3 seconds fast
+	ahead := NewYieldMessage("people", "developer", "Start").WithSentAt(currentTime.Add(3 * time.Second))
+	err := suite.soviet.ProcessYield(ahead)
+	var validationErr ValidationError
+	suite.Require().ErrorAs(err, &validationErr)
+	assert.Equal(suite.T(), ValidationCodeClockSkew, validationErr.Code)
+	assert.Equal(suite.T(), "people", suite.barrel.CurrentHolder())
+	assert.Equal(suite.T(), 1, suite.soviet.Rejections()[ValidationCodeClockSkew])
+
+	slightlyAhead := NewYieldMessage("people", "developer", "Start").WithSentAt(currentTime.Add(time.Second))
+	assert.NoError(suite.T(), suite.soviet.ProcessYield(slightlyAhead))
+
+	// A sender whose clock runs slow gets the same slack on the stale-message guard
+	behind := NewYieldMessage("developer", "people", "Done").WithSentAt(currentTime.Add(-6 * time.Second))
+	assert.NoError(suite.T(), suite.soviet.ProcessYield(behind))
+
+	assert.Error(suite.T(), suite.soviet.SetClockSkewTolerance(-time.Second))
+	assert.Equal(suite.T(), 2*time.Second, suite.soviet.ClockSkewTolerance())
+}
+
+// Test_ForceAgentState_RespectsBarrelOwnership tests the people's recovery escape hatch
+func (suite *CoordinatorTestSuite) Test_ForceAgentState_RespectsBarrelOwnership() {
+	developer := createTestAgent("developer")
+	tester := createTestAgent("tester")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(tester)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	// Simulate a wedged state: tester reports working without the barrel
+	tester.forceState(AgentStateWorking)
+	suite.Require().NoError(suite.soviet.ForceAgentState("tester", AgentStateWaiting))
+	assert.Equal(suite.T(), AgentStateWaiting, tester.State())
+
+	err := suite.soviet.ForceAgentState("tester", AgentStateWorking)
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "does not hold the barrel")
+
+	err = suite.soviet.ForceAgentState("developer", AgentStateWaiting)
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "holds the barrel")
+
+	developer.forceState(AgentStateWaiting)
+	suite.Require().NoError(suite.soviet.ForceAgentState("developer", AgentStateWorking))
+	assert.Equal(suite.T(), AgentStateWorking, developer.State())
+
+	assert.Error(suite.T(), suite.soviet.ForceAgentState("developer", AgentStateOffered))
+	assert.Error(suite.T(), suite.soviet.ForceAgentState("designer", AgentStateWaiting))
+}
+
+// Test_ForceAgentState_PausesFailsAndResumes tests that the people can take agents out of service
+func (suite *CoordinatorTestSuite) Test_ForceAgentState_PausesFailsAndResumes() {
+	developer := createTestAgent("developer")
+	tester := createTestAgent("tester")
+	suite.soviet.RegisterAgent(developer)
+	suite.soviet.RegisterAgent(tester)
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	// A paused agent receives no work until it is resumed
+	suite.Require().NoError(suite.soviet.ForceAgentState("tester", AgentStatePaused))
+	assert.Equal(suite.T(), AgentStatePaused, tester.State())
+	assert.Error(suite.T(), suite.soviet.ProcessYield(NewYieldMessage("developer", "tester", "Test it")))
+	suite.Require().NoError(suite.soviet.ForceAgentState("tester", AgentStateWaiting))
+	assert.Equal(suite.T(), AgentStateWaiting, tester.State())
+
+	err := suite.soviet.ForceAgentState("developer", AgentStatePaused)
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "holds the barrel")
+
+	// A failed holder hands its barrel back to the people, so nothing is left stuck with it
+	suite.Require().NoError(suite.soviet.ForceAgentState("developer", AgentStateError))
+	assert.Equal(suite.T(), AgentStateError, developer.State())
+	assert.Equal(suite.T(), "failed by the people", developer.FailureReason())
+	assert.Equal(suite.T(), "people", suite.soviet.GetBarrelStatus())
+	assert.Contains(suite.T(), suite.soviet.GetBarrel().LastMessage(), "Agent 'developer' failed")
+	assert.Error(suite.T(), suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Retry")))
+
+	suite.Require().NoError(suite.soviet.ForceAgentState("developer", AgentStateWaiting))
+	assert.Empty(suite.T(), developer.FailureReason())
+	suite.Require().NoError(suite.soviet.ProcessYield(NewYieldMessage("people", "developer", "Retry")))
+	assert.Equal(suite.T(), AgentStateWorking, developer.State())
+
+	assert.Error(suite.T(), suite.soviet.ForceAgentState("tester", AgentStateError), "only working agents can fail")
+}
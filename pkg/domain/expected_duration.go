@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetExpectedDuration records how long the current holder expects to keep the barrel
+// Only the holder may set it; a zero duration clears the hint. Unlike the barrel TTL,
+// an overrun is only reported by staleness queries and never reclaims the barrel
+func (s *SovietState) SetExpectedDuration(role string, expected time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expected < 0 {
+		return fmt.Errorf("expected duration cannot be negative: %s", expected)
+	}
+	if s.barrel == nil {
+		return fmt.Errorf("no barrel set in soviet state")
+	}
+	if !s.IsBarrelHeldBy(role) {
+		return fmt.Errorf("agent '%s' does not hold the barrel", role)
+	}
+
+	s.barrel.setExpectedDuration(expected)
+	if s.logger != nil {
+		s.logger.Info("Expected hold duration set", map[string]interface{}{
+			"role":              role,
+			"expected_duration": expected.String(),
+		})
+	}
+	return nil
+}
+
+// isOverExpected reports whether the holder kept the barrel longer than its expected duration hint
+func isOverExpected(staleness Staleness) bool {
+	return staleness.ExpectedDuration > 0 && staleness.Duration > staleness.ExpectedDuration
+}
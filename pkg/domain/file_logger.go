@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel represents the minimum severity a logger will output
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// String returns the string representation of LogLevel
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ParseLogLevel converts a level name (debug, info, warn, error) into a LogLevel
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return LogLevelDebug, nil
+	case "info":
+		return LogLevelInfo, nil
+	case "warn", "warning":
+		return LogLevelWarn, nil
+	case "error":
+		return LogLevelError, nil
+	default:
+		return LogLevelInfo, fmt.Errorf("unknown log level: %s", level)
+	}
+}
+
+// FileLogger implements Logger interface by appending to a file
+// When the file grows beyond maxSize it is rotated to "<path>.1" and a fresh file is started
+type FileLogger struct {
+	path     string
+	minLevel LogLevel
+	maxSize  int64 // 0 disables rotation
+	file     *os.File
+	size     int64
+	mu       sync.Mutex
+}
+
+// NewFileLogger creates a new file logger writing entries at or above minLevel to path
+func NewFileLogger(path string, minLevel LogLevel, maxSize int64) (*FileLogger, error) {
+	if path == "" {
+		return nil, fmt.Errorf("log file path cannot be empty")
+	}
+	if maxSize < 0 {
+		return nil, fmt.Errorf("max log file size cannot be negative: %d", maxSize)
+	}
+
+	logger := &FileLogger{
+		path:     path,
+		minLevel: minLevel,
+		maxSize:  maxSize,
+	}
+	if err := logger.open(); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// Info logs an informational message
+func (f *FileLogger) Info(message string, fields ...map[string]interface{}) {
+	f.logWithLevel(LogLevelInfo, message, fields...)
+}
+
+// Error logs an error message
+func (f *FileLogger) Error(message string, fields ...map[string]interface{}) {
+	f.logWithLevel(LogLevelError, message, fields...)
+}
+
+// Debug logs a debug message
+func (f *FileLogger) Debug(message string, fields ...map[string]interface{}) {
+	f.logWithLevel(LogLevelDebug, message, fields...)
+}
+
+// Warn logs a warning message
+func (f *FileLogger) Warn(message string, fields ...map[string]interface{}) {
+	f.logWithLevel(LogLevelWarn, message, fields...)
+}
+
+// Close closes the underlying log file
+func (f *FileLogger) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens (or creates) the log file in append mode and records its current size
+func (f *FileLogger) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate moves the current log file aside and starts a new one
+func (f *FileLogger) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	f.file = nil
+
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return f.open()
+}
+
+// logWithLevel writes a formatted log line with level, timestamp and optional fields
+func (f *FileLogger) logWithLevel(level LogLevel, message string, fields ...map[string]interface{}) {
+	if level < f.minLevel {
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	logMsg := fmt.Sprintf("[%s] %s - %s", level, timestamp, message)
+
+	// Add fields in a stable order so log files are easy to diff and grep
+	if len(fields) > 0 && fields[0] != nil {
+		keys := make([]string, 0, len(fields[0]))
+		for key := range fields[0] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			logMsg += fmt.Sprintf(" | %s=%v", key, fields[0][key])
+		}
+	}
+	line := []byte(logMsg + "\n")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return
+	}
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(line)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "file logger: %v\n", err)
+			if f.file == nil {
+				return
+			}
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "file logger: failed to write log entry: %v\n", err)
+	}
+}
+
+// Ensure FileLogger implements Logger
+var _ Logger = (*FileLogger)(nil)
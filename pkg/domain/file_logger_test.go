@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, LogLevelWarn, level)
+
+	_, err = ParseLogLevel("verbose")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown log level")
+}
+
+func TestFileLogger_LevelFiltering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	logger, err := NewFileLogger(path, LogLevelInfo, 0)
+	require.NoError(t, err)
+
+	logger.Debug("hidden debug entry")
+	logger.Info("Agent registered", map[string]interface{}{
+		"role":   "developer",
+		"server": "localhost:53646",
+	})
+	logger.Error("Connection lost")
+	require.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	output := string(content)
+
+	assert.NotContains(t, output, "hidden debug entry")
+	assert.Contains(t, output, "[INFO]")
+	assert.Contains(t, output, "Agent registered | role=developer | server=localhost:53646")
+	assert.Contains(t, output, "[ERROR]")
+}
+
+func TestFileLogger_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	logger, err := NewFileLogger(path, LogLevelDebug, 200)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		logger.Info(strings.Repeat("x", 50))
+	}
+	require.NoError(t, logger.Close())
+
+	current, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, current.Size(), int64(200))
+
+	rotated, err := os.Stat(path + ".1")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, rotated.Size(), int64(200))
+}
+
+func TestNewFileLogger_InvalidArguments(t *testing.T) {
+	_, err := NewFileLogger("", LogLevelInfo, 0)
+	assert.Error(t, err)
+
+	_, err = NewFileLogger(filepath.Join(t.TempDir(), "agent.log"), LogLevelInfo, -1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// agentJSON is the serialized form of an agent
+type agentJSON struct {
+	Role            string            `json:"role"`
+	Type            string            `json:"type"`
+	Description     string            `json:"description,omitempty"`
+	Weight          int               `json:"weight"`
+	Capabilities    []string          `json:"capabilities"`
+	State           string            `json:"state"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	InstanceID      string            `json:"instance_id,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	LastConnectedAt time.Time         `json:"last_connected_at"`
+	LastMessage     string            `json:"last_message,omitempty"`
+	LastMessageTime time.Time         `json:"last_message_time"`
+	DisconnectedAt  time.Time         `json:"disconnected_at"`
+}
+
+// MarshalJSON serializes the role, type, description, weight, capabilities, state, tags,
+// instance ID and timestamps. The reservation token is a secret and is never written out
+func (a *AgentComrade) MarshalJSON() ([]byte, error) {
+	return json.Marshal(agentJSON{
+		Role:            a.role,
+		Type:            a.agentType.String(),
+		Description:     a.description,
+		Weight:          a.weight,
+		Capabilities:    a.capabilities,
+		State:           a.state.String(),
+		Tags:            a.tags,
+		InstanceID:      a.instanceID,
+		CreatedAt:       a.createdAt,
+		LastConnectedAt: a.lastConnectedAt,
+		LastMessage:     a.lastMessage,
+		LastMessageTime: a.lastMessageTime,
+		DisconnectedAt:  a.disconnectedAt,
+	})
+}
+
+// UnmarshalJSON restores an agent serialized by MarshalJSON
+// The agent comes back disconnected: it has to register again to receive the barrel
+func (a *AgentComrade) UnmarshalJSON(data []byte) error {
+	var decoded agentJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Role == "" {
+		return fmt.Errorf("serialized agent has no role")
+	}
+	agentType, err := ParseAgentType(decoded.Type)
+	if err != nil {
+		return fmt.Errorf("serialized agent '%s': %w", decoded.Role, err)
+	}
+	state, err := ParseAgentState(decoded.State)
+	if err != nil {
+		return fmt.Errorf("serialized agent '%s': %w", decoded.Role, err)
+	}
+	if decoded.Weight == 0 {
+		decoded.Weight = DefaultAgentWeight
+	}
+
+	a.role = decoded.Role
+	a.agentType = agentType
+	a.description = decoded.Description
+	a.weight = decoded.Weight
+	a.capabilities = append([]string{}, decoded.Capabilities...)
+	a.state = state
+	a.tags = decoded.Tags
+	a.instanceID = decoded.InstanceID
+	a.createdAt = decoded.CreatedAt
+	a.lastConnectedAt = decoded.LastConnectedAt
+	a.lastMessage = decoded.LastMessage
+	a.lastMessageTime = decoded.LastMessageTime
+	a.disconnectedAt = decoded.DisconnectedAt
+	a.connected.Store(false)
+	return nil
+}
+
+// FileAgentRepository implements AgentRepository by keeping agents in memory and writing them
+// all to a JSON file on every change, so registered agents survive a server restart
+type FileAgentRepository struct {
+	path   string
+	agents map[string]*AgentComrade
+	mutex  sync.RWMutex
+}
+
+// NewFileAgentRepository creates a repository saving agents to path, loading the agents already
+// saved there. A missing file is an empty repository; an unreadable one is an error
+func NewFileAgentRepository(path string) (*FileAgentRepository, error) {
+	if path == "" {
+		return nil, fmt.Errorf("agent repository file path cannot be empty")
+	}
+	repo := &FileAgentRepository{
+		path:   path,
+		agents: make(map[string]*AgentComrade),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return repo, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load agents: %w", err)
+	}
+	var agents []*AgentComrade
+	if err := json.Unmarshal(data, &agents); err != nil {
+		return nil, fmt.Errorf("failed to load agents from %s: %w", path, err)
+	}
+	for _, agent := range agents {
+		if _, duplicate := repo.agents[agent.Role()]; duplicate {
+			return nil, fmt.Errorf("failed to load agents from %s: role '%s' appears twice", path, agent.Role())
+		}
+		repo.agents[agent.Role()] = agent
+	}
+	return repo, nil
+}
+
+// Path returns the file the agents are saved to
+func (f *FileAgentRepository) Path() string {
+	return f.path
+}
+
+// Store persists an agent to the repository and saves the file
+// If the file cannot be saved the repository is left as it was
+func (f *FileAgentRepository) Store(agent *AgentComrade) error {
+	if agent == nil {
+		return fmt.Errorf("agent cannot be nil")
+	}
+
+	role := agent.Role()
+	if role == "" {
+		return fmt.Errorf("agent role cannot be empty")
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	previous, existed := f.agents[role]
+	f.agents[role] = agent
+	if err := f.save(); err != nil {
+		if existed {
+			f.agents[role] = previous
+		} else {
+			delete(f.agents, role)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByRole retrieves an agent by their role
+func (f *FileAgentRepository) GetByRole(role string) (*AgentComrade, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	agent, exists := f.agents[role]
+	if !exists {
+		return nil, fmt.Errorf("agent with role '%s' not found", role)
+	}
+	return agent, nil
+}
+
+// GetAll retrieves all agents
+func (f *FileAgentRepository) GetAll() ([]*AgentComrade, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	agents := make([]*AgentComrade, 0, len(f.agents))
+	for _, agent := range f.agents {
+		agents = append(agents, agent)
+	}
+	return agents, nil
+}
+
+// Delete removes an agent from the repository and saves the file
+// If the file cannot be saved the agent is kept
+func (f *FileAgentRepository) Delete(role string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	agent, exists := f.agents[role]
+	if !exists {
+		return fmt.Errorf("agent with role '%s' not found", role)
+	}
+
+	delete(f.agents, role)
+	if err := f.save(); err != nil {
+		f.agents[role] = agent
+		return err
+	}
+	return nil
+}
+
+// Exists checks if an agent with the given role exists
+func (f *FileAgentRepository) Exists(role string) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	_, exists := f.agents[role]
+	return exists
+}
+
+// save writes every agent to the file in role order, replacing it atomically so a crash never
+// leaves a half-written file. The caller holds the write lock
+func (f *FileAgentRepository) save() error {
+	agents := make([]*AgentComrade, 0, len(f.agents))
+	for _, agent := range f.agents {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].Role() < agents[j].Role()
+	})
+
+	data, err := json.MarshalIndent(agents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize agents: %w", err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save agents: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to save agents: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to save agents: %w", err)
+	}
+	if err := os.Rename(temp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to save agents: %w", err)
+	}
+	return nil
+}
+
+// Ensure FileAgentRepository implements AgentRepository
+var _ AgentRepository = (*FileAgentRepository)(nil)
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileAgentRepository_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agents.json")
+	repo, err := NewFileAgentRepository(path)
+	require.NoError(t, err)
+	all, err := repo.GetAll()
+	require.NoError(t, err)
+	assert.Empty(t, all, "a missing file is an empty repository")
+
+	developer := NewAgentComrade("developer", []string{"code", "test"})
+	require.NoError(t, developer.SetDescription("Writes the code"))
+	require.NoError(t, developer.SetWeight(3))
+	require.NoError(t, developer.SetTags(map[string]string{"region": "us-east"}))
+	developer.SetReservationToken("s3cret")
+	developer.SetConnected(true)
+	require.NoError(t, developer.TransitionTo(AgentStateWorking))
+	require.NoError(t, repo.Store(developer))
+	require.NoError(t, repo.Store(NewAgentComrade("tester", []string{"test"})))
+	require.NoError(t, repo.Delete("tester"))
+	assert.Error(t, repo.Delete("tester"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cret", "reservation tokens are never written out")
+
+	reloaded, err := NewFileAgentRepository(path)
+	require.NoError(t, err)
+	assert.False(t, reloaded.Exists("tester"))
+	agent, err := reloaded.GetByRole("developer")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code", "test"}, agent.Capabilities())
+	assert.Equal(t, AgentTypeWorker, agent.Type())
+	assert.Equal(t, "Writes the code", agent.Description())
+	assert.Equal(t, 3, agent.Weight())
+	assert.Equal(t, map[string]string{"region": "us-east"}, agent.Tags())
+	assert.Equal(t, AgentStateWorking, agent.State())
+	assert.True(t, developer.CreatedAt().Equal(agent.CreatedAt()))
+	assert.False(t, agent.IsConnected(), "reloaded agents must register again")
+}
+
+func TestFileAgentRepository_RejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agents.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"role": "developer", "type": "worker", "state": "dozing"}]`), 0o600))
+
+	_, err := NewFileAgentRepository(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown agent state")
+
+	_, err = NewFileAgentRepository("")
+	assert.Error(t, err)
+}
+
+func TestFileAgentRepository_FailedSaveKeepsState(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+	repo, err := NewFileAgentRepository(filepath.Join(dir, "agents.json"))
+	require.NoError(t, err)
+	require.NoError(t, repo.Store(NewAgentComrade("developer", []string{"code"})))
+
+	require.NoError(t, os.RemoveAll(dir))
+	assert.Error(t, repo.Store(NewAgentComrade("tester", []string{"test"})))
+	assert.False(t, repo.Exists("tester"))
+	assert.Error(t, repo.Delete("developer"))
+	assert.True(t, repo.Exists("developer"))
+}
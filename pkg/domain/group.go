@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Group is a named team of roles that can be yielded to as a unit
+// Members are ordered by priority: when several members are available, the earliest one wins.
+// Weighted groups instead share assignments among available members in proportion to their weights
+type Group struct {
+	name     string
+	roles    []string
+	weighted bool
+	credit   map[string]int // Smooth weighted round-robin state of weighted groups: role -> current credit
+}
+
+// GroupStatus describes a group and which of its members could receive the barrel right now
+type GroupStatus struct {
+	// Name is the group name used as a yield target
+	Name string `json:"name"`
+
+	// Members lists the group's roles in priority order
+	Members []string `json:"members"`
+
+	// Available lists the connected, waiting members in priority order
+	Available []string `json:"available"`
+
+	// Weighted is true when assignments are shared by member weight instead of priority
+	Weighted bool `json:"weighted"`
+}
+
+// NewGroup creates a group, validating that the name and members are non-empty, unique and not reserved
+func NewGroup(name string, roles []string) (*Group, error) {
+	if name == "" {
+		return nil, fmt.Errorf("group name cannot be empty")
+	}
+	if IsReservedRole(name) {
+		return nil, fmt.Errorf("group name '%s' is reserved", name)
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("group '%s' must contain at least one role", name)
+	}
+
+	seen := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		switch {
+		case role == "":
+			return nil, fmt.Errorf("group '%s' has an empty role", name)
+		case role == name:
+			return nil, fmt.Errorf("group '%s' cannot contain itself", name)
+		case IsReservedRole(role):
+			return nil, fmt.Errorf("group '%s' cannot contain reserved role '%s'", name, role)
+		case seen[role]:
+			return nil, fmt.Errorf("group '%s' lists role '%s' more than once", name, role)
+		}
+		seen[role] = true
+	}
+
+	members := make([]string, len(roles))
+	copy(members, roles)
+	return &Group{name: name, roles: members}, nil
+}
+
+// Name returns the group name
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Roles returns a copy of the members in priority order
+func (g *Group) Roles() []string {
+	roles := make([]string, len(g.roles))
+	copy(roles, g.roles)
+	return roles
+}
+
+// SetGroup defines or replaces a named group of roles that can be used as a yield target
+func (s *SovietState) SetGroup(name string, roles []string) error {
+	group, err := NewGroup(name, roles)
+	if err != nil {
+		return err
+	}
+	if s.IsAgentRegistered(name) {
+		return fmt.Errorf("group name '%s' conflicts with a registered agent role", name)
+	}
+
+	if s.groups == nil {
+		s.groups = make(map[string]*Group)
+	}
+	s.groups[name] = group
+	return nil
+}
+
+// SetGroupWeighted switches a group between priority routing and weighted round-robin routing
+func (s *SovietState) SetGroupWeighted(name string, weighted bool) error {
+	group, exists := s.groups[name]
+	if !exists {
+		return fmt.Errorf("group '%s' is not configured", name)
+	}
+	group.weighted = weighted
+	group.credit = nil
+	return nil
+}
+
+// IsGroup checks whether a name refers to a configured group
+func (s *SovietState) IsGroup(name string) bool {
+	_, exists := s.groups[name]
+	return exists
+}
+
+// GetGroups returns every configured group with its currently available members, sorted by name
+func (s *SovietState) GetGroups() []GroupStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]GroupStatus, 0, len(s.groups))
+	for _, group := range s.groups {
+		groups = append(groups, GroupStatus{
+			Name:      group.Name(),
+			Members:   group.Roles(),
+			Available: s.availableMembers(group),
+			Weighted:  group.weighted,
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// availableMembers returns the connected, waiting members of a group in priority order
+// Observers and roles benched by the circuit breaker are never available
+func (s *SovietState) availableMembers(group *Group) []string {
+	available := make([]string, 0, len(group.roles))
+	for _, role := range group.roles {
+		agent := s.GetAgent(role)
+		if agent != nil && agent.IsConnected() && agent.IsWaiting() && agent.Type().CanHoldBarrel() && !s.IsBenched(role) {
+			available = append(available, role)
+		}
+	}
+	return available
+}
+
+// resolveYieldTarget maps a group target to its highest-priority available member, or for a
+// weighted group to the member whose turn it is, and a capability target to an agent with the
+// capability. Other targets are returned unchanged
+func (s *SovietState) resolveYieldTarget(toRole string) (string, error) {
+	if toRole == NextTarget {
+		return s.resolveNextTarget()
+	}
+	if capability, ok := ParseCapabilityTarget(toRole); ok {
+		return s.resolveCapabilityTarget(capability)
+	}
+
+	group, exists := s.groups[toRole]
+	if !exists {
+		return toRole, nil
+	}
+
+	available := s.availableMembers(group)
+	if len(available) == 0 {
+		return "", fmt.Errorf("no member of group '%s' is available (members: %v)", toRole, group.roles)
+	}
+	if group.weighted {
+		return s.nextWeightedMember(group, available), nil
+	}
+	return available[0], nil
+}
+
+// nextWeightedMember picks an available member by smooth weighted round-robin
+// Every available member earns its weight in credit; the richest is picked and pays back the total,
+// so over time each member is picked in proportion to its weight without long runs of the same member
+func (s *SovietState) nextWeightedMember(group *Group, available []string) string {
+	if group.credit == nil {
+		group.credit = make(map[string]int)
+	}
+
+	picked, total := "", 0
+	for _, role := range available {
+		weight := DefaultAgentWeight
+		if agent := s.GetAgent(role); agent != nil {
+			weight = agent.Weight()
+		}
+		total += weight
+		group.credit[role] += weight
+		if picked == "" || group.credit[role] > group.credit[picked] {
+			picked = role
+		}
+	}
+	group.credit[picked] -= total
+	return picked
+}
@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGroup_Validation(t *testing.T) {
+	_, err := NewGroup("", []string{"api"})
+	assert.Error(t, err)
+
+	_, err = NewGroup("people", []string{"api"})
+	assert.Contains(t, err.Error(), "reserved")
+
+	_, err = NewGroup("backend", nil)
+	assert.Contains(t, err.Error(), "at least one role")
+
+	_, err = NewGroup("backend", []string{"api", "api"})
+	assert.Contains(t, err.Error(), "more than once")
+
+	_, err = NewGroup("backend", []string{"api", "backend"})
+	assert.Contains(t, err.Error(), "cannot contain itself")
+}
+
+func TestSovietState_ObserversNeverReceiveTheBarrel(t *testing.T) {
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	require.NoError(t, soviet.SetBarrel(barrel))
+	require.NoError(t, soviet.SetGroup("backend", []string{"auditor", "api"}))
+
+	auditor, err := NewTypedAgentComrade("auditor", []string{"backend"}, AgentTypeObserver)
+	require.NoError(t, err)
+	_, _, _, err = soviet.RegisterAgent(auditor)
+	require.NoError(t, err)
+	_, _, _, err = soviet.RegisterAgent(NewAgentComrade("api", []string{"backend"}))
+	require.NoError(t, err)
+
+	err = soviet.ProcessYield(NewYieldMessage("people", "auditor", "Watch this"))
+	var validationErr ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, ValidationCodeInvalidTarget, validationErr.Code)
+	assert.Contains(t, err.Error(), "observer")
+	assert.Equal(t, "people", barrel.CurrentHolder())
+
+	// Group routing skips the observer even though it has the higher priority
+	assert.Equal(t, []string{"api"}, soviet.GetGroups()[0].Available)
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "backend", "Fix the API")))
+	assert.Equal(t, "api", barrel.CurrentHolder())
+
+	err = soviet.ReassignBarrel("api", "auditor")
+	assert.Error(t, err)
+	assert.Equal(t, "api", barrel.CurrentHolder())
+}
+
+func TestSovietState_WeightedGroupSharesWorkByWeight(t *testing.T) {
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	require.NoError(t, soviet.SetBarrel(barrel))
+	require.NoError(t, soviet.SetGroup("fleet", []string{"small", "medium", "large"}))
+	assert.Error(t, soviet.SetGroupWeighted("missing", true))
+	require.NoError(t, soviet.SetGroupWeighted("fleet", true))
+
+	weights := map[string]int{"small": 1, "medium": 2, "large": 5}
+	for role, weight := range weights {
+		agent := NewAgentComrade(role, []string{"build"})
+		require.NoError(t, agent.SetWeight(weight))
+		_, _, _, err := soviet.RegisterAgent(agent)
+		require.NoError(t, err)
+	}
+	assert.True(t, soviet.GetGroups()[0].Weighted)
+	assert.Equal(t, weights, soviet.QueryStatus().AgentWeights)
+
+	const assignments = 800
+	counts := make(map[string]int)
+	longestRun, run, previous := 0, 0, ""
+	for i := 0; i < assignments; i++ {
+		require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "fleet", "Build it")))
+		holder := barrel.CurrentHolder()
+		counts[holder]++
+		if holder == previous {
+			run++
+		} else {
+			run, previous = 1, holder
+		}
+		if run > longestRun {
+			longestRun = run
+		}
+		require.NoError(t, soviet.ProcessYield(NewYieldMessage(holder, "people", "Built")))
+	}
+
+	// Each member's share approximates its share of the total weight
+	for role, weight := range weights {
+		expected := float64(assignments) * float64(weight) / 8
+		assert.InDelta(t, expected, float64(counts[role]), expected*0.05, role)
+	}
+	// Smooth round-robin interleaves members rather than handing out long runs
+	assert.LessOrEqual(t, longestRun, 2)
+}
+
+func TestSovietState_WeightedGroupSkipsBusyMembers(t *testing.T) {
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	require.NoError(t, soviet.SetBarrel(barrel))
+	require.NoError(t, soviet.SetGroup("fleet", []string{"small", "large"}))
+	require.NoError(t, soviet.SetGroupWeighted("fleet", true))
+
+	large := NewAgentComrade("large", nil)
+	require.NoError(t, large.SetWeight(10))
+	for _, agent := range []*AgentComrade{NewAgentComrade("small", nil), large} {
+		_, _, _, err := soviet.RegisterAgent(agent)
+		require.NoError(t, err)
+	}
+
+	// The heavier member gets the barrel first; while it works the group falls back to the rest
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "fleet", "First")))
+	assert.Equal(t, "large", barrel.CurrentHolder())
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("large", "fleet", "Second")))
+	assert.Equal(t, "small", barrel.CurrentHolder())
+}
+
+func TestSovietState_YieldToGroup(t *testing.T) {
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	require.NoError(t, soviet.SetBarrel(barrel))
+	require.NoError(t, soviet.SetGroup("backend", []string{"api", "db", "cache"}))
+
+	for _, role := range []string{"api", "db", "cache"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"backend"}))
+		require.NoError(t, err)
+	}
+
+	// The highest-priority available member wins
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "backend", "Fix the API")))
+	assert.Equal(t, "api", barrel.CurrentHolder())
+
+	// A busy member is skipped, as is a disconnected one
+	require.NoError(t, soviet.DisconnectAgent("db"))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("api", "backend", "Warm the cache")))
+	assert.Equal(t, "cache", barrel.CurrentHolder())
+	assert.Equal(t, "cache", barrel.LastTransfer().ToRole)
+
+	groups := soviet.GetGroups()
+	require.Len(t, groups, 1)
+	assert.Equal(t, []string{"api", "db", "cache"}, groups[0].Members)
+	assert.Equal(t, []string{"api"}, groups[0].Available)
+
+	// No available members
+	require.NoError(t, soviet.DisconnectAgent("api"))
+	err := soviet.ProcessYield(NewYieldMessage("cache", "backend", "Anyone?"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no member of group 'backend' is available")
+	assert.Equal(t, "cache", barrel.CurrentHolder())
+}
+
+func TestSovietState_GroupNamesAreNotRoles(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetGroup("backend", []string{"api"}))
+
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("backend", nil))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is a group name")
+
+	_, _, _, err = soviet.RegisterAgent(NewAgentComrade("api", nil))
+	require.NoError(t, err)
+	err = soviet.SetGroup("api", []string{"db"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "conflicts with a registered agent role")
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HaltStatus describes an emergency halt of the collective
+type HaltStatus struct {
+	// Halted is true from Halt until Resume
+	Halted bool `json:"halted"`
+
+	// Reason is what the people gave for halting, empty when not halted
+	Reason string `json:"reason,omitempty"`
+
+	// Since is when the collective was halted, zero when not halted
+	Since time.Time `json:"since,omitempty"`
+}
+
+// Halt freezes the barrel where it is until Resume: yields and reassignments are refused and
+// the reclaim sweeps leave the holder alone. Halting an already halted collective updates the reason
+func (s *SovietState) Halt(reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return fmt.Errorf("halt reason cannot be empty")
+	}
+
+	if !s.halt.Halted {
+		s.halt = HaltStatus{Halted: true, Since: nowFunc()}
+	}
+	s.halt.Reason = reason
+	if s.logger != nil {
+		s.logger.Warn("Collective halted", map[string]interface{}{
+			"reason":        reason,
+			"barrel_holder": s.barrelStatus(),
+		})
+	}
+	return nil
+}
+
+// Resume lifts a halt so the barrel moves again; it returns false if the collective was not halted
+func (s *SovietState) Resume() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.halt.Halted {
+		return false
+	}
+
+	halted := s.halt
+	s.halt = HaltStatus{}
+	if s.logger != nil {
+		s.logger.Info("Collective resumed", map[string]interface{}{
+			"reason":     halted.Reason,
+			"halted_for": nowFunc().Sub(halted.Since).Round(time.Second).String(),
+		})
+	}
+	return true
+}
+
+// HaltStatus reports whether the collective is halted and why
+func (s *SovietState) HaltStatus() HaltStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.halt
+}
+
+// haltedError returns the validation error for barrel moves refused by a halt, nil when not halted
+func (s *SovietState) haltedError() error {
+	if !s.halt.Halted {
+		return nil
+	}
+	return newValidationError(ValidationCodeHalted,
+		fmt.Errorf("collective is halted by the people (%s); the barrel stays with '%s' until they resume",
+			s.halt.Reason, s.barrelStatus()))
+}
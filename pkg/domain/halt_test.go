@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_HaltFreezesBarrelUntilResume(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, nil))
+		require.NoError(t, err)
+	}
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Deploy")))
+
+	assert.Error(t, soviet.Halt(" "))
+	assert.False(t, soviet.HaltStatus().Halted)
+
+	require.NoError(t, soviet.Halt("Bad deploy"))
+	assert.Equal(t, HaltStatus{Halted: true, Reason: "Bad deploy", Since: currentTime}, soviet.HaltStatus())
+
+	// Neither the holder nor the people can move the barrel
+	err := soviet.ProcessYield(NewYieldMessage("developer", "tester", "Test it"))
+	var validationErr ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, ValidationCodeHalted, validationErr.Code)
+	assert.Contains(t, err.Error(), "Bad deploy")
+	assert.Error(t, soviet.ProcessYield(NewYieldMessage("people", "tester", "Take over")))
+	assert.Error(t, soviet.ReassignBarrel("developer", "tester"))
+	assert.Equal(t, "developer", soviet.GetBarrelStatus())
+	assert.Equal(t, 2, soviet.Rejections()[ValidationCodeHalted])
+
+	problems := soviet.ValidateYield(NewYieldMessage("developer", "tester", "Test it"))
+	require.NotEmpty(t, problems)
+	assert.Equal(t, ValidationCodeHalted, problems[0].Code)
+
+	// Halting again only updates the reason
+	currentTime = currentTime.Add(time.Minute)
+	require.NoError(t, soviet.Halt("Still investigating"))
+	assert.Equal(t, HaltStatus{Halted: true, Reason: "Still investigating", Since: currentTime.Add(-time.Minute)}, soviet.HaltStatus())
+
+	assert.True(t, soviet.Resume())
+	assert.Equal(t, HaltStatus{}, soviet.HaltStatus())
+	assert.False(t, soviet.Resume(), "resuming twice reports nothing was halted")
+
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", "tester", "Test it")))
+	assert.Equal(t, "tester", soviet.GetBarrelStatus())
+}
+
+func TestSovietState_HaltSkipsReclaims(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	require.NoError(t, soviet.SetBarrelTTL(time.Minute))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", nil))
+	require.NoError(t, err)
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Deploy")))
+	require.NoError(t, soviet.Halt("Bad deploy"))
+
+	// A halted holder keeps the barrel past its TTL
+	currentTime = currentTime.Add(time.Hour)
+	reclaimed, err := soviet.ReclaimExpiredBarrel()
+	require.NoError(t, err)
+	assert.False(t, reclaimed)
+	assert.Equal(t, "developer", soviet.GetBarrelStatus())
+
+	// The TTL applies again once the people resume
+	soviet.Resume()
+	reclaimed, err = soviet.ReclaimExpiredBarrel()
+	require.NoError(t, err)
+	assert.True(t, reclaimed)
+	assert.Equal(t, "people", soviet.GetBarrelStatus())
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SetHeartbeatTimeout sets how long a connected agent may go without a heartbeat before it is
+// marked disconnected. 0 disables the check, so only a closed connection disconnects an agent
+func (s *SovietState) SetHeartbeatTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("heartbeat timeout cannot be negative: %s", timeout)
+	}
+	s.heartbeatTimeout = timeout
+	return nil
+}
+
+// HeartbeatTimeout returns the configured heartbeat timeout
+func (s *SovietState) HeartbeatTimeout() time.Duration {
+	return s.heartbeatTimeout
+}
+
+// RecordHeartbeat notes that an agent is alive
+// An agent marked disconnected for missing heartbeats is connected again
+func (s *SovietState) RecordHeartbeat(role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agent := s.GetAgent(role)
+	if agent == nil {
+		return fmt.Errorf("agent with role '%s' not found", role)
+	}
+
+	if !agent.IsConnected() {
+		agent.SetConnected(true)
+		if s.logger != nil {
+			s.logger.Info("Agent heartbeat resumed", map[string]interface{}{
+				"role": role,
+			})
+		}
+	}
+	agent.markSeen()
+	return nil
+}
+
+// DisconnectSilentAgents marks connected agents whose last heartbeat is older than the heartbeat
+// timeout as disconnected. A barrel holder keeps the barrel as with DisconnectAgent, so the
+// reconnect grace period decides when it returns to the people. Returns the roles in name order
+func (s *SovietState) DisconnectSilentAgents() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.heartbeatTimeout == 0 {
+		return nil, nil
+	}
+
+	now := nowFunc()
+	var silent []string
+	for role, agent := range s.RegisteredAgents() {
+		if agent.IsConnected() && now.Sub(agent.LastSeenAt()) > s.heartbeatTimeout {
+			silent = append(silent, role)
+		}
+	}
+	sort.Strings(silent)
+
+	for _, role := range silent {
+		agent := s.GetAgent(role)
+		lastSeen := agent.LastSeenAt()
+		agent.SetConnected(false)
+
+		if s.logger != nil {
+			s.logger.Warn("Agent missed its heartbeat, marking it disconnected", map[string]interface{}{
+				"role":         role,
+				"last_seen":    lastSeen.Format(time.RFC3339),
+				"holds_barrel": s.IsBarrelHeldBy(role),
+			})
+		}
+	}
+	return silent, nil
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_DisconnectSilentAgents(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	assert.Error(t, soviet.SetHeartbeatTimeout(-time.Second))
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	assert.WithinDuration(t, currentTime, soviet.GetAgent("developer").LastSeenAt(), 0, "registration is a sign of life")
+
+	// Without a timeout silence never disconnects anyone
+	currentTime = currentTime.Add(time.Hour)
+	silent, err := soviet.DisconnectSilentAgents()
+	require.NoError(t, err)
+	assert.Empty(t, silent)
+
+	require.NoError(t, soviet.SetHeartbeatTimeout(30*time.Second))
+	require.NoError(t, soviet.RecordHeartbeat("tester"))
+	assert.Error(t, soviet.RecordHeartbeat("ghost"))
+
+	currentTime = currentTime.Add(20 * time.Second)
+	require.NoError(t, soviet.RecordHeartbeat("tester"))
+	silent, err = soviet.DisconnectSilentAgents()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"developer"}, silent)
+	assert.False(t, soviet.GetAgent("developer").IsConnected())
+	assert.True(t, soviet.GetAgent("tester").IsConnected())
+	assert.True(t, soviet.IsBarrelHeldBy("developer"), "the reconnect grace period decides about the barrel")
+
+	// A late heartbeat brings the agent back
+	require.NoError(t, soviet.RecordHeartbeat("developer"))
+	assert.True(t, soviet.GetAgent("developer").IsConnected())
+	for _, detail := range soviet.GetAgentDetails() {
+		assert.WithinDuration(t, currentTime, detail.LastSeenAt, 0, detail.Role)
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetHistoryRetention sets how long transfer records stay in the barrel history; 0 keeps every record
+// With archive, each pruned record is logged before it is dropped, so a file logger keeps the audit trail
+func (s *SovietState) SetHistoryRetention(retention time.Duration, archive bool) error {
+	if retention < 0 {
+		return fmt.Errorf("history retention cannot be negative: %s", retention)
+	}
+	s.historyRetention = retention
+	s.archiveHistory = archive
+	return nil
+}
+
+// HistoryRetention returns how long transfer records stay in the barrel history
+func (s *SovietState) HistoryRetention() time.Duration {
+	return s.historyRetention
+}
+
+// PruneHistory drops transfer records older than the history retention and returns them, oldest first
+// The latest transfer is always kept. Receipts of the remaining records still chain, starting after
+// the last pruned one
+func (s *SovietState) PruneHistory() ([]TransferRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barrel == nil || s.historyRetention == 0 {
+		return nil, nil
+	}
+
+	pruned := s.barrel.pruneHistoryBefore(nowFunc().Add(-s.historyRetention))
+	if len(pruned) > 0 {
+		s.saveBarrel()
+	}
+	if len(pruned) == 0 || s.logger == nil {
+		return pruned, nil
+	}
+
+	if s.archiveHistory {
+		for _, record := range pruned {
+			s.logger.Info("Archived transfer record", map[string]interface{}{
+				"sequence":  record.Receipt.Sequence,
+				"from_role": record.FromRole,
+				"to_role":   record.ToRole,
+				"message":   s.loggedPayload(record.Message),
+				"timestamp": record.Timestamp.Format(time.RFC3339Nano),
+				"hash":      record.Receipt.Hash,
+			})
+		}
+	}
+	s.logger.Debug("Pruned transfer history", map[string]interface{}{
+		"pruned":    len(pruned),
+		"retention": s.historyRetention.String(),
+	})
+	return pruned, nil
+}
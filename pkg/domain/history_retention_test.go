@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_PruneHistory(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+
+	// Transfers at 10:00 (creation), 10:30, 11:00 and 11:30
+	for _, step := range []struct{ from, to string }{
+		{"people", "developer"},
+		{"developer", "tester"},
+		{"tester", "people"},
+	} {
+		currentTime = currentTime.Add(30 * time.Minute)
+		require.NoError(t, soviet.ProcessYield(NewYieldMessage(step.from, step.to, "work")))
+	}
+	require.Len(t, soviet.GetBarrel().GetTransferHistory(), 4)
+
+	// Without a retention nothing is pruned
+	pruned, err := soviet.PruneHistory()
+	require.NoError(t, err)
+	assert.Empty(t, pruned)
+
+	require.NoError(t, soviet.SetHistoryRetention(45*time.Minute, false))
+	currentTime = currentTime.Add(10 * time.Minute) // 11:40, so records before 10:55 expire
+	pruned, err = soviet.PruneHistory()
+	require.NoError(t, err)
+	require.Len(t, pruned, 2)
+	assert.Equal(t, "people", pruned[0].ToRole)
+	assert.Equal(t, "developer", pruned[1].ToRole)
+
+	history := soviet.GetBarrel().GetTransferHistory()
+	require.Len(t, history, 2)
+	assert.Equal(t, "tester", history[0].ToRole)
+	assert.Equal(t, "people", history[1].ToRole)
+	assert.NoError(t, VerifyReceiptChain(soviet.GetBarrel().GetReceipts()))
+
+	// The latest transfer outlives the retention so LastTransfer keeps working
+	currentTime = currentTime.Add(24 * time.Hour)
+	pruned, err = soviet.PruneHistory()
+	require.NoError(t, err)
+	assert.Len(t, pruned, 1)
+	require.Len(t, soviet.GetBarrel().GetTransferHistory(), 1)
+	assert.Equal(t, 3, soviet.GetBarrel().LastTransfer().Receipt.Sequence)
+
+	// The next transfer still chains to the kept receipt
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "more work")))
+	assert.NoError(t, VerifyReceiptChain(soviet.GetBarrel().GetReceipts()))
+}
+
+func TestSovietState_PruneHistory_Archive(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	path := filepath.Join(t.TempDir(), "server.log")
+	logger, err := NewFileLogger(path, LogLevelInfo, 0)
+	require.NoError(t, err)
+	soviet := NewSovietStateWithDependencies(NewMemoryAgentRepository(), nil, logger, nil)
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGunWithMessage("Start the sprint")))
+	_, _, _, err = soviet.RegisterAgent(NewAgentComrade("developer", []string{"coding"}))
+	require.NoError(t, err)
+	currentTime = currentTime.Add(time.Hour)
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+
+	require.NoError(t, soviet.SetHistoryRetention(30*time.Minute, true))
+	_, err = soviet.PruneHistory()
+	require.NoError(t, err)
+
+	require.NoError(t, logger.Close())
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Archived transfer record")
+	assert.Contains(t, string(content), "message=Start the sprint")
+	assert.Len(t, soviet.GetBarrel().GetTransferHistory(), 1)
+
+	assert.Error(t, soviet.SetHistoryRetention(-time.Minute, false))
+	assert.Equal(t, 30*time.Minute, soviet.HistoryRetention())
+}
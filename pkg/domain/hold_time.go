@@ -0,0 +1,70 @@
+package domain
+
+import "time"
+
+// HoldTimeStats aggregates how long a role held the barrel, over every hold that has ended
+// Yields counts those holds: the role yielded the barrel or it was taken back, e.g. reclaimed
+type HoldTimeStats struct {
+	Yields  int           `json:"yields"`
+	Total   time.Duration `json:"total"`
+	Average time.Duration `json:"average"`
+}
+
+// recordHoldTime adds one completed hold of the barrel by role, which received it at heldSince
+// Every path that takes the barrel from a holder records its hold, so stalled holds count too.
+// The people's own holds are not work and are not recorded
+func (s *SovietState) recordHoldTime(role string, heldSince time.Time) {
+	if role == "people" {
+		return
+	}
+
+	held := nowFunc().Sub(heldSince)
+	stats := s.barrel.recordHold(role, held)
+
+	if s.logger != nil {
+		s.logger.Info("Barrel hold time recorded", map[string]interface{}{
+			"role":    role,
+			"held":    held.String(),
+			"average": stats.Average.String(),
+			"yields":  stats.Yields,
+		})
+	}
+}
+
+// HoldDurations returns how long each role held the barrel in total: its completed holds in
+// HoldTimes plus the current hold up to now
+func (s *SovietState) HoldDurations() map[string]time.Duration {
+	if s.barrel == nil {
+		return make(map[string]time.Duration)
+	}
+	return s.barrel.HoldStatsByRole()
+}
+
+// AverageHolds returns how long each role holds the barrel on average: its completed holds in
+// HoldTimes plus the current hold up to now
+func (s *SovietState) AverageHolds() map[string]time.Duration {
+	averages := make(map[string]time.Duration)
+	if s.barrel == nil {
+		return averages
+	}
+	for role, stats := range s.barrel.holdTimes {
+		averages[role] = stats.Average
+	}
+	if role, held := s.barrel.currentHold(); role != "" {
+		stats := s.barrel.holdTimes[role]
+		averages[role] = (stats.Total + held) / time.Duration(stats.Yields+1)
+	}
+	return averages
+}
+
+// HoldTimes returns the accumulated barrel hold times per role
+func (s *SovietState) HoldTimes() map[string]HoldTimeStats {
+	holdTimes := make(map[string]HoldTimeStats)
+	if s.barrel == nil {
+		return holdTimes
+	}
+	for role, stats := range s.barrel.holdTimes {
+		holdTimes[role] = stats
+	}
+	return holdTimes
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxInstanceIDLength is the longest instance ID an agent may register with, in characters
+const MaxInstanceIDLength = 128
+
+// InstanceID returns the ID of the process running the agent, empty if it gave none
+func (a *AgentComrade) InstanceID() string {
+	return a.instanceID
+}
+
+// ValidateInstanceID checks that an instance ID, once trimmed, has no inner whitespace and is at most
+// MaxInstanceIDLength characters long
+func ValidateInstanceID(instanceID string) error {
+	instanceID = strings.TrimSpace(instanceID)
+	if length := utf8.RuneCountInString(instanceID); length > MaxInstanceIDLength {
+		return fmt.Errorf("instance ID is %d characters long (max: %d)", length, MaxInstanceIDLength)
+	}
+	if strings.IndexFunc(instanceID, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("instance ID %q cannot contain whitespace", instanceID)
+	}
+	return nil
+}
+
+// SetInstanceID sets the ID that tells the process running the agent apart from others claiming its role
+// Surrounding whitespace is trimmed; IDs rejected by ValidateInstanceID leave the agent unchanged
+func (a *AgentComrade) SetInstanceID(instanceID string) error {
+	if err := ValidateInstanceID(instanceID); err != nil {
+		return err
+	}
+	a.instanceID = strings.TrimSpace(instanceID)
+	return nil
+}
+
+// IsInstanceTakeover reports whether a registration by instanceID replaces a different instance,
+// previousID, rather than resuming it. Agents without instance IDs cannot be told apart, so a
+// registration where either ID is empty is never a takeover
+func IsInstanceTakeover(previousID, instanceID string) bool {
+	return previousID != "" && instanceID != "" && previousID != instanceID
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentComrade_SetInstanceID(t *testing.T) {
+	agent := NewAgentComrade("developer", nil)
+	assert.Empty(t, agent.InstanceID())
+
+	require.NoError(t, agent.SetInstanceID("  build-host-4242 "))
+	assert.Equal(t, "build-host-4242", agent.InstanceID())
+
+	assert.Error(t, agent.SetInstanceID("build host"))
+	assert.Error(t, agent.SetInstanceID(strings.Repeat("x", MaxInstanceIDLength+1)))
+	assert.Equal(t, "build-host-4242", agent.InstanceID(), "a rejected ID keeps the old one")
+
+	require.NoError(t, agent.SetInstanceID(""))
+	assert.Empty(t, agent.InstanceID())
+}
+
+func TestIsInstanceTakeover(t *testing.T) {
+	assert.True(t, IsInstanceTakeover("host-a-1", "host-b-2"))
+	assert.False(t, IsInstanceTakeover("host-a-1", "host-a-1"))
+	assert.False(t, IsInstanceTakeover("", "host-b-2"))
+	assert.False(t, IsInstanceTakeover("host-a-1", ""))
+	assert.False(t, IsInstanceTakeover("", ""))
+}
+
+func TestSovietState_RegisterAgentWithInstanceID(t *testing.T) {
+	register := func(t *testing.T, soviet *SovietState, instanceID string) bool {
+		agent := NewAgentComrade("developer", nil)
+		require.NoError(t, agent.SetInstanceID(instanceID))
+		shouldResume, _, _, err := soviet.RegisterAgent(agent)
+		require.NoError(t, err)
+		return shouldResume
+	}
+
+	for name, secondID := range map[string]string{
+		"same instance resumes":              "host-a-1",
+		"different instance takes over role": "host-b-2",
+	} {
+		t.Run(name, func(t *testing.T) {
+			soviet := newTestSoviet()
+			require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+			register(t, soviet, "host-a-1")
+			require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+
+			// Either way the role keeps the barrel: the role, not the process, holds it
+			assert.True(t, register(t, soviet, secondID))
+			assert.Equal(t, "developer", soviet.GetBarrelStatus())
+
+			details := soviet.GetAgentDetails()
+			require.Len(t, details, 1)
+			assert.Equal(t, secondID, details[0].InstanceID)
+			assert.Equal(t, map[string]string{"developer": secondID}, soviet.QueryStatus().AgentInstances)
+		})
+	}
+}
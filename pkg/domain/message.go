@@ -1,34 +1,105 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
 	"time"
 )
 
+// RedactPayload replaces a payload with its length and a short hash so logs can correlate
+// payloads without revealing their contents
+func RedactPayload(payload string) string {
+	sum := sha256.Sum256([]byte(payload))
+	return fmt.Sprintf("[redacted len=%d sha256=%s]", len(payload), hex.EncodeToString(sum[:])[:12])
+}
+
 // YieldMessage represents a yield operation in the Agent Farm collective.
 // This is the only true domain message - carrying payload from one agent to another.
 // All other communications (register, activate, query, etc.) are operations, not messages.
 type YieldMessage struct {
 	fromRole  string
+	sender    string // Full identity of the sender, e.g. "people:alice" when fromRole is "people"
 	toRole    string
 	payload   string
 	timestamp time.Time
+	sentAt    time.Time // When the sender created the message; zero if the sender did not say
+	failed    bool
+
+	// expectedDuration is how long the recipient is expected to hold the barrel; zero if the sender did not say
+	expectedDuration time.Duration
+
+	// metadata is structured data travelling with the payload, e.g. a PR number or branch name
+	metadata map[string]string
+
+	// requiredCapability is the capability (or pattern) the target must have; empty if the sender did not say
+	requiredCapability string
 }
 
 // NewYieldMessage creates a new yield message
+// People's representatives such as "people:alice" act as "people"; the full identity is kept as the sender
 func NewYieldMessage(fromRole, toRole, payload string) YieldMessage {
 	return YieldMessage{
-		fromRole:  fromRole,
-		toRole:    toRole,
+		fromRole:  NormalizeRole(fromRole),
+		sender:    fromRole,
+		toRole:    NormalizeRole(toRole),
 		payload:   payload,
 		timestamp: nowFunc(),
 	}
 }
 
+// NewFailedYieldMessage creates a yield message reporting that the sender failed its work
+// Failed work may be requeued by the coordinator according to its retry policy
+func NewFailedYieldMessage(fromRole, toRole, payload string) YieldMessage {
+	message := NewYieldMessage(fromRole, toRole, payload)
+	message.failed = true
+	return message
+}
+
+// withToRole returns a copy of the message addressed to a different role
+func (m YieldMessage) withToRole(toRole string) YieldMessage {
+	m.toRole = toRole
+	return m
+}
+
+// WithSentAt returns a copy of the message stamped with the sender's send time
+func (m YieldMessage) WithSentAt(sentAt time.Time) YieldMessage {
+	m.sentAt = sentAt
+	return m
+}
+
+// WithExpectedDuration returns a copy of the message hinting how long the recipient should need the barrel
+func (m YieldMessage) WithExpectedDuration(expected time.Duration) YieldMessage {
+	m.expectedDuration = expected
+	return m
+}
+
+// WithMetadata returns a copy of the message carrying structured metadata alongside its payload
+func (m YieldMessage) WithMetadata(metadata map[string]string) YieldMessage {
+	m.metadata = copyMetadata(metadata)
+	return m
+}
+
+// WithRequiredCapability returns a copy of the message that only an agent with the capability may receive
+func (m YieldMessage) WithRequiredCapability(capability string) YieldMessage {
+	m.requiredCapability = strings.TrimSpace(capability)
+	return m
+}
+
 // FromRole returns the sender role
 func (m YieldMessage) FromRole() string {
 	return m.fromRole
 }
 
+// Sender returns the full identity of the sender, which differs from FromRole for people's representatives
+func (m YieldMessage) Sender() string {
+	if m.sender == "" {
+		return m.fromRole
+	}
+	return m.sender
+}
+
 // ToRole returns the recipient role
 func (m YieldMessage) ToRole() string {
 	return m.toRole
@@ -39,6 +110,31 @@ func (m YieldMessage) Payload() string {
 	return m.payload
 }
 
+// Failed returns true if the sender reported that its work failed
+func (m YieldMessage) Failed() bool {
+	return m.failed
+}
+
+// SentAt returns when the sender sent the message, or zero time if unknown
+func (m YieldMessage) SentAt() time.Time {
+	return m.sentAt
+}
+
+// ExpectedDuration returns how long the recipient is expected to hold the barrel, or zero if unknown
+func (m YieldMessage) ExpectedDuration() time.Duration {
+	return m.expectedDuration
+}
+
+// Metadata returns a copy of the message's structured metadata, or nil if it has none
+func (m YieldMessage) Metadata() map[string]string {
+	return copyMetadata(m.metadata)
+}
+
+// RequiredCapability returns the capability the target must have, or "" if any agent will do
+func (m YieldMessage) RequiredCapability() string {
+	return m.requiredCapability
+}
+
 // Timestamp returns when the message was created
 func (m YieldMessage) Timestamp() time.Time {
 	return m.timestamp
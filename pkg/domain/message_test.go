@@ -34,3 +34,11 @@ func TestYieldMessage_IsValid(t *testing.T) {
 	invalidMsg3 := YieldMessage{}
 	assert.False(t, invalidMsg3.IsValid())
 }
+
+func TestRedactPayload(t *testing.T) {
+	redacted := RedactPayload("api-key=hunter2")
+	assert.NotContains(t, redacted, "hunter2")
+	assert.Contains(t, redacted, "len=15")
+	assert.Equal(t, redacted, RedactPayload("api-key=hunter2"))
+	assert.NotEqual(t, redacted, RedactPayload("api-key=hunter3"))
+}
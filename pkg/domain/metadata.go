@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxMetadataEntries limits the key/value pairs a yield may attach to its payload
+const MaxMetadataEntries = 64
+
+// ValidateMetadata checks the structured metadata of a yield, e.g. {"pr": "42", "branch": "main"}
+// Keys must be non-blank; values may be anything, including empty
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataEntries {
+		return fmt.Errorf("yield has %d metadata entries (max: %d)", len(metadata), MaxMetadataEntries)
+	}
+	for key := range metadata {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("metadata key cannot be empty")
+		}
+	}
+	return nil
+}
+
+// copyMetadata returns a copy of metadata that callers cannot change behind the barrel's back
+// Empty metadata is returned as nil so records without any omit it
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
+
+// ParseMetadata parses a comma-separated list of key=value pairs such as "pr=42,branch=main"
+func ParseMetadata(list string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(list) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(list, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid metadata %q: expected key=value", strings.TrimSpace(pair))
+		}
+		if _, exists := metadata[key]; exists {
+			return nil, fmt.Errorf("duplicate metadata key %q", key)
+		}
+		metadata[key] = strings.TrimSpace(value)
+	}
+	return metadata, ValidateMetadata(metadata)
+}
+
+// SortedMetadataKeys returns the keys of metadata in order, for printing it stably
+func SortedMetadataKeys(metadata map[string]string) []string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
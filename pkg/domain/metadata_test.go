@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetadata(t *testing.T) {
+	metadata, err := ParseMetadata("pr=42, branch=main,draft")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pr": "42", "branch": "main", "draft": ""}, metadata)
+	assert.Equal(t, []string{"branch", "draft", "pr"}, SortedMetadataKeys(metadata))
+
+	metadata, err = ParseMetadata("")
+	require.NoError(t, err)
+	assert.Empty(t, metadata)
+
+	_, err = ParseMetadata("=42")
+	assert.Error(t, err)
+	_, err = ParseMetadata("pr=42,pr=43")
+	assert.Error(t, err)
+}
+
+func TestValidateMetadata(t *testing.T) {
+	assert.NoError(t, ValidateMetadata(nil))
+	assert.NoError(t, ValidateMetadata(map[string]string{"pr": ""}))
+	assert.Error(t, ValidateMetadata(map[string]string{"": "42"}))
+
+	tooMany := make(map[string]string, MaxMetadataEntries+1)
+	for i := 0; i <= MaxMetadataEntries; i++ {
+		tooMany[strconv.Itoa(i)] = "x"
+	}
+	assert.Error(t, ValidateMetadata(tooMany))
+}
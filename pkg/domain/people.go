@@ -0,0 +1,21 @@
+package domain
+
+import "strings"
+
+// peopleIdentityPrefix starts the identity of a single people's representative, e.g. "people:alice"
+const peopleIdentityPrefix = "people:"
+
+// IsPeopleIdentity reports whether role is the people or one of their representatives, e.g. "people:alice"
+// Representatives have the people's authority; their identity only tells operators apart in the history
+func IsPeopleIdentity(role string) bool {
+	return role == "people" || strings.HasPrefix(role, peopleIdentityPrefix)
+}
+
+// NormalizeRole maps every people's representative to "people" and leaves other roles as they are
+// Barrel ownership and authorization work on normalized roles
+func NormalizeRole(role string) string {
+	if IsPeopleIdentity(role) {
+		return "people"
+	}
+	return role
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRole(t *testing.T) {
+	assert.Equal(t, "people", NormalizeRole("people"))
+	assert.Equal(t, "people", NormalizeRole("people:alice"))
+	assert.Equal(t, "developer", NormalizeRole("developer"))
+	assert.Equal(t, "peoples", NormalizeRole("peoples"))
+	assert.True(t, IsReservedRole("people:alice"))
+	assert.False(t, IsPeopleIdentity("peoples"))
+}
+
+func TestSovietState_PeopleRepresentativesAreDistinguishable(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"coding"}))
+	require.NoError(t, err)
+
+	// Both operators act with the people's authority
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people:alice", "developer", "Build the login page")))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", "people:alice", "Done")))
+	assert.Equal(t, "people", soviet.GetBarrelStatus())
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people:bob", "developer", "Fix the typo")))
+	assert.Equal(t, "developer", soviet.GetBarrelStatus())
+
+	// The history tells them apart
+	history := soviet.GetBarrel().GetTransferHistory()
+	require.Len(t, history, 4)
+	assert.Equal(t, "people:alice", history[1].FromRole)
+	assert.Equal(t, "people", history[2].ToRole)
+	assert.Equal(t, "people:bob", history[3].FromRole)
+	assert.Equal(t, "people:bob", history[3].Receipt.FromRole)
+
+	// A representative cannot speak for an agent's barrel, nor can an agent pose as one
+	assert.Error(t, soviet.GetBarrel().TransferToAs("people:mallory", "people", "Taken"))
+	_, _, _, err = soviet.RegisterAgent(NewAgentComrade("people:mallory", []string{"coding"}))
+	assert.Error(t, err)
+}
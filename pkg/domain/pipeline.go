@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"fmt"
+)
+
+// NextTarget is the yield target naming the pipeline stage after the current barrel holder
+const NextTarget = "next"
+
+// Pipeline represents an ordered sequence of roles the barrel is expected to travel through
+// The people implicitly start the pipeline and receive the barrel after the last stage
+type Pipeline struct {
+	roles []string
+}
+
+// PipelineStatus describes where the barrel currently is relative to the configured pipeline
+type PipelineStatus struct {
+	// Roles is the configured stage order (empty when no pipeline is configured)
+	Roles []string `json:"roles"`
+
+	// BarrelHolder is the role currently holding the barrel
+	BarrelHolder string `json:"barrel_holder"`
+
+	// StageIndex is the holder's position in Roles, or -1 when the holder is not a stage
+	StageIndex int `json:"stage_index"`
+
+	// NextRole is the role the barrel would move to next (empty when off-pipeline)
+	NextRole string `json:"next_role"`
+
+	// OnPipeline is false when the barrel is held by a role outside the pipeline
+	OnPipeline bool `json:"on_pipeline"`
+}
+
+// NewPipeline creates a pipeline, validating that roles are non-empty, unique and not reserved
+func NewPipeline(roles []string) (*Pipeline, error) {
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("pipeline must contain at least one role")
+	}
+
+	seen := make(map[string]bool, len(roles))
+	for i, role := range roles {
+		if role == "" {
+			return nil, fmt.Errorf("pipeline stage %d has an empty role", i)
+		}
+		if IsReservedRole(role) {
+			return nil, fmt.Errorf("pipeline cannot contain reserved role '%s'", role)
+		}
+		if seen[role] {
+			return nil, fmt.Errorf("pipeline role '%s' appears more than once", role)
+		}
+		seen[role] = true
+	}
+
+	stages := make([]string, len(roles))
+	copy(stages, roles)
+	return &Pipeline{roles: stages}, nil
+}
+
+// Roles returns a copy of the pipeline stages in order
+func (p *Pipeline) Roles() []string {
+	roles := make([]string, len(p.roles))
+	copy(roles, p.roles)
+	return roles
+}
+
+// IndexOf returns the stage index of a role, or -1 if the role is not in the pipeline
+func (p *Pipeline) IndexOf(role string) int {
+	for i, stage := range p.roles {
+		if stage == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// Next returns the role that follows the given holder
+// The people hand off to the first stage and the last stage hands back to the people
+func (p *Pipeline) Next(role string) (string, error) {
+	if role == "people" {
+		return p.roles[0], nil
+	}
+
+	index := p.IndexOf(role)
+	if index == -1 {
+		return "", fmt.Errorf("role '%s' is not part of the pipeline", role)
+	}
+
+	if index == len(p.roles)-1 {
+		return "people", nil
+	}
+	return p.roles[index+1], nil
+}
+
+// resolveNextTarget resolves the next target to the stage after the current holder, or the people
+// after the last stage. It fails, leaving the barrel where it is, when no pipeline is configured
+// or the holder is not one of its stages
+func (s *SovietState) resolveNextTarget() (string, error) {
+	if s.pipeline == nil {
+		return "", fmt.Errorf("yield target '%s' requires a pipeline (see the server's -pipeline)", NextTarget)
+	}
+
+	next, err := s.pipeline.Next(s.barrel.CurrentHolder())
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve yield target '%s': %w", NextTarget, err)
+	}
+	return next, nil
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPipeline_Validation(t *testing.T) {
+	_, err := NewPipeline(nil)
+	assert.Error(t, err)
+
+	_, err = NewPipeline([]string{"developer", ""})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "empty role")
+
+	_, err = NewPipeline([]string{"developer", "tester", "developer"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "appears more than once")
+
+	_, err = NewPipeline([]string{"developer", "people"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "reserved role")
+}
+
+func TestPipeline_Next(t *testing.T) {
+	pipeline, err := NewPipeline([]string{"developer", "tester", "reviewer"})
+	require.NoError(t, err)
+
+	next, err := pipeline.Next("people")
+	assert.NoError(t, err)
+	assert.Equal(t, "developer", next)
+
+	next, err = pipeline.Next("tester")
+	assert.NoError(t, err)
+	assert.Equal(t, "reviewer", next)
+
+	next, err = pipeline.Next("reviewer")
+	assert.NoError(t, err)
+	assert.Equal(t, "people", next)
+
+	_, err = pipeline.Next("designer")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not part of the pipeline")
+}
+
+func TestSovietState_GetPipelineStatus(t *testing.T) {
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	require.NoError(t, soviet.SetBarrel(barrel))
+
+	// No pipeline configured
+	status := soviet.GetPipelineStatus()
+	assert.Empty(t, status.Roles)
+	assert.Equal(t, -1, status.StageIndex)
+	assert.False(t, status.OnPipeline)
+
+	pipeline, err := NewPipeline([]string{"developer", "tester"})
+	require.NoError(t, err)
+	soviet.SetPipeline(pipeline)
+
+	// People hold the barrel before the first stage
+	status = soviet.GetPipelineStatus()
+	assert.Equal(t, []string{"developer", "tester"}, status.Roles)
+	assert.Equal(t, -1, status.StageIndex)
+	assert.Equal(t, "developer", status.NextRole)
+	assert.True(t, status.OnPipeline)
+
+	// Holder in the middle of the pipeline
+	require.NoError(t, barrel.TransferTo("developer", "Build it"))
+	status = soviet.GetPipelineStatus()
+	assert.Equal(t, 0, status.StageIndex)
+	assert.Equal(t, "tester", status.NextRole)
+
+	// Holder outside the pipeline
+	require.NoError(t, barrel.TransferTo("designer", "Side quest"))
+	status = soviet.GetPipelineStatus()
+	assert.Equal(t, "designer", status.BarrelHolder)
+	assert.Equal(t, -1, status.StageIndex)
+	assert.Empty(t, status.NextRole)
+	assert.False(t, status.OnPipeline)
+}
+
+func TestSovietState_YieldToNextStage(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	for _, role := range []string{"developer", "tester", "designer"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+	assert.True(t, IsReservedRole(NextTarget))
+
+	// Without a pipeline there is no next stage
+	err := soviet.ProcessYield(NewYieldMessage("people", NextTarget, "Build it"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a pipeline")
+	assert.True(t, soviet.IsBarrelHeldBy("people"))
+
+	pipeline, err := NewPipeline([]string{"developer", "tester"})
+	require.NoError(t, err)
+	soviet.SetPipeline(pipeline)
+
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", NextTarget, "Build it")))
+	assert.True(t, soviet.IsBarrelHeldBy("developer"))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", NextTarget, "Test it")))
+	assert.True(t, soviet.IsBarrelHeldBy("tester"))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("tester", NextTarget, "Tested")))
+	assert.True(t, soviet.IsBarrelHeldBy("people"), "the last stage hands back to the people")
+
+	// A holder outside the pipeline has no next stage and keeps the barrel
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "designer", "Side quest")))
+	err = soviet.ProcessYield(NewYieldMessage("designer", NextTarget, "Done"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not part of the pipeline")
+	assert.True(t, soviet.IsBarrelHeldBy("designer"))
+}
@@ -0,0 +1,134 @@
+package domain
+
+import "errors"
+
+// EventPublisher defines the port for announcing domain events to outside observers, e.g. webhooks
+// Publishing is best-effort: a failed publish is logged and never undoes the operation it announces
+type EventPublisher interface {
+	// PublishAgentRegistered announces an agent that registered or reconnected
+	PublishAgentRegistered(agent AgentDetails) error
+
+	// PublishAgentDeregistered announces an agent that left the collective
+	PublishAgentDeregistered(role string) error
+
+	// PublishBarrelTransferred announces a barrel transfer, whatever caused it
+	PublishBarrelTransferred(transfer TransferRecord) error
+}
+
+// Event types of PublishedEvent
+const (
+	EventAgentRegistered   = "agent_registered"
+	EventAgentDeregistered = "agent_deregistered"
+	EventBarrelTransferred = "barrel_transferred"
+)
+
+// PublishedEvent represents an event that was published (for testing/monitoring)
+type PublishedEvent struct {
+	Type     string          `json:"type"`
+	Role     string          `json:"role,omitempty"`
+	Agent    *AgentDetails   `json:"agent,omitempty"`
+	Transfer *TransferRecord `json:"transfer,omitempty"`
+}
+
+// AddPublisher announces domain events to publisher as well, after any publisher added before it
+// Publishers are called while the soviet is locked, so they must not call back into it.
+// Add them before the soviet is shared between goroutines
+func (s *SovietState) AddPublisher(publisher EventPublisher) {
+	if publisher == nil {
+		panic("event publisher cannot be nil")
+	}
+	switch current := s.publisher.(type) {
+	case nil:
+		s.publisher = publisher
+	case publisherGroup:
+		s.publisher = append(current, publisher)
+	default:
+		s.publisher = publisherGroup{current, publisher}
+	}
+}
+
+// publisherGroup announces every event to each of its publishers, even when an earlier one fails
+type publisherGroup []EventPublisher
+
+func (g publisherGroup) PublishAgentRegistered(agent AgentDetails) error {
+	var errs []error
+	for _, publisher := range g {
+		errs = append(errs, publisher.PublishAgentRegistered(agent))
+	}
+	return errors.Join(errs...)
+}
+
+func (g publisherGroup) PublishAgentDeregistered(role string) error {
+	var errs []error
+	for _, publisher := range g {
+		errs = append(errs, publisher.PublishAgentDeregistered(role))
+	}
+	return errors.Join(errs...)
+}
+
+func (g publisherGroup) PublishBarrelTransferred(transfer TransferRecord) error {
+	var errs []error
+	for _, publisher := range g {
+		errs = append(errs, publisher.PublishBarrelTransferred(transfer))
+	}
+	return errors.Join(errs...)
+}
+
+// publishAgentRegistered announces a registered agent if the soviet has a publisher
+func (s *SovietState) publishAgentRegistered(agent *AgentComrade) {
+	if s.publisher == nil {
+		return
+	}
+	if err := s.publisher.PublishAgentRegistered(newAgentDetails(agent)); err != nil {
+		s.logPublishFailure(EventAgentRegistered, err)
+	}
+}
+
+// publishAgentDeregistered announces a deregistered agent if the soviet has a publisher
+func (s *SovietState) publishAgentDeregistered(role string) {
+	if s.publisher == nil {
+		return
+	}
+	if err := s.publisher.PublishAgentDeregistered(role); err != nil {
+		s.logPublishFailure(EventAgentDeregistered, err)
+	}
+}
+
+// barrelChanged saves the barrel and announces its new transfers; the caller holds the lock
+func (s *SovietState) barrelChanged() {
+	s.saveBarrel()
+	s.publishTransfers()
+}
+
+// publishTransfers announces every transfer recorded since the last announced one, oldest first
+// Operations such as requeueing failed work transfer the barrel more than once
+func (s *SovietState) publishTransfers() {
+	if s.barrel == nil {
+		return
+	}
+	history := s.barrel.GetTransferHistory()
+	latest := history[len(history)-1].Receipt.Sequence
+	if s.publisher == nil || latest <= s.publishedSequence {
+		s.publishedSequence = latest
+		return
+	}
+	for _, transfer := range history {
+		if transfer.Receipt.Sequence <= s.publishedSequence {
+			continue
+		}
+		if err := s.publisher.PublishBarrelTransferred(transfer); err != nil {
+			s.logPublishFailure(EventBarrelTransferred, err)
+		}
+	}
+	s.publishedSequence = latest
+}
+
+// logPublishFailure logs an event that could not be published
+func (s *SovietState) logPublishFailure(eventType string, err error) {
+	if s.logger != nil {
+		s.logger.Error("Failed to publish event", map[string]interface{}{
+			"event": eventType,
+			"error": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingPublisher counts the events it is given and fails every publish when err is set
+type countingPublisher struct {
+	events int
+	err    error
+}
+
+func (p *countingPublisher) PublishAgentRegistered(agent AgentDetails) error {
+	p.events++
+	return p.err
+}
+
+func (p *countingPublisher) PublishAgentDeregistered(role string) error {
+	p.events++
+	return p.err
+}
+
+func (p *countingPublisher) PublishBarrelTransferred(transfer TransferRecord) error {
+	p.events++
+	return p.err
+}
+
+func TestSovietState_AddPublisher(t *testing.T) {
+	failing := &countingPublisher{err: fmt.Errorf("webhook unreachable")}
+	first, second := &countingPublisher{}, &countingPublisher{}
+	soviet := NewSovietStateWithDependencies(NewMemoryAgentRepository(), nil, nil, failing)
+	soviet.AddPublisher(first)
+	soviet.AddPublisher(second)
+	assert.Panics(t, func() { soviet.AddPublisher(nil) })
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"coding"}))
+	require.NoError(t, err)
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	require.NoError(t, soviet.DeregisterAgent("developer"))
+
+	// registered, transferred to the developer, back to the people on deregistration, deregistered
+	assert.Equal(t, 4, failing.events)
+	assert.Equal(t, 4, first.events, "a failing publisher does not keep events from the others")
+	assert.Equal(t, 4, second.events)
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Receipt is a tamper-evident record of a single barrel hand-off
+// Each receipt embeds the hash of the previous one, so the receipts form a hash chain
+// that both parties can log for non-repudiation
+type Receipt struct {
+	Sequence     int       `json:"sequence"`
+	FromRole     string    `json:"from_role"`
+	ToRole       string    `json:"to_role"`
+	PayloadHash  string    `json:"payload_hash"`
+	Timestamp    time.Time `json:"timestamp"`
+	PreviousHash string    `json:"previous_hash"`
+	Hash         string    `json:"hash"`
+}
+
+// NewReceipt creates a receipt for a hand-off and computes its chained hash
+func NewReceipt(sequence int, fromRole, toRole, payload string, timestamp time.Time, previousHash string) Receipt {
+	payloadHash := sha256.Sum256([]byte(payload))
+	receipt := Receipt{
+		Sequence:     sequence,
+		FromRole:     fromRole,
+		ToRole:       toRole,
+		PayloadHash:  hex.EncodeToString(payloadHash[:]),
+		Timestamp:    timestamp.UTC(),
+		PreviousHash: previousHash,
+	}
+	receipt.Hash = receipt.computeHash()
+	return receipt
+}
+
+// computeHash hashes every field except Hash itself
+func (r Receipt) computeHash() string {
+	h := sha256.New()
+	for _, field := range []string{
+		strconv.Itoa(r.Sequence),
+		r.FromRole,
+		r.ToRole,
+		r.PayloadHash,
+		r.Timestamp.Format(time.RFC3339Nano),
+		r.PreviousHash,
+	} {
+		// Length-prefix each field so adjacent fields cannot be shifted into each other
+		fmt.Fprintf(h, "%d:%s;", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks that the receipt's hash matches its contents
+func (r Receipt) Verify() bool {
+	return r.Hash == r.computeHash()
+}
+
+// MatchesPayload checks whether the receipt was issued for the given payload
+func (r Receipt) MatchesPayload(payload string) bool {
+	payloadHash := sha256.Sum256([]byte(payload))
+	return r.PayloadHash == hex.EncodeToString(payloadHash[:])
+}
+
+// VerifyReceiptChain checks that every receipt is intact, sequenced and linked to its predecessor
+func VerifyReceiptChain(receipts []Receipt) error {
+	for i, receipt := range receipts {
+		if !receipt.Verify() {
+			return fmt.Errorf("receipt %d has been tampered with", receipt.Sequence)
+		}
+		if i == 0 {
+			continue
+		}
+
+		previous := receipts[i-1]
+		if receipt.Sequence != previous.Sequence+1 {
+			return fmt.Errorf("receipt %d does not follow receipt %d", receipt.Sequence, previous.Sequence)
+		}
+		if receipt.PreviousHash != previous.Hash {
+			return fmt.Errorf("receipt %d is not linked to receipt %d", receipt.Sequence, previous.Sequence)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceipt_VerifyDetectsTampering(t *testing.T) {
+	receipt := NewReceipt(1, "people", "developer", "Build it", time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC), "abc")
+	assert.True(t, receipt.Verify())
+	assert.True(t, receipt.MatchesPayload("Build it"))
+	assert.False(t, receipt.MatchesPayload("Build something else"))
+
+	receipt.ToRole = "tester"
+	assert.False(t, receipt.Verify())
+}
+
+func TestBarrelOfGun_ReceiptsFormChain(t *testing.T) {
+	barrel := NewBarrelOfGun()
+	require.NoError(t, barrel.TransferTo("developer", "Build it"))
+	require.NoError(t, barrel.TransferTo("tester", "Test it"))
+	require.NoError(t, barrel.TransferTo("people", "Done"))
+
+	receipts := barrel.GetReceipts()
+	require.Len(t, receipts, 4)
+	assert.NoError(t, VerifyReceiptChain(receipts))
+
+	last := barrel.LastTransfer().Receipt
+	assert.Equal(t, 3, last.Sequence)
+	assert.Equal(t, "tester", last.FromRole)
+	assert.Equal(t, "people", last.ToRole)
+	assert.Equal(t, receipts[2].Hash, last.PreviousHash)
+
+	// Rewriting an earlier hand-off breaks the chain even if its own hash is recomputed
+	receipts[1] = NewReceipt(1, "people", "developer", "Build something else", receipts[1].Timestamp, receipts[0].Hash)
+	err := VerifyReceiptChain(receipts)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not linked")
+}
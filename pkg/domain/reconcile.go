@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ReconcileStates returns agents that are working or offered without holding the barrel to waiting
+// Such agents are left behind when state and barrel diverge, e.g. after a crash. Returns the roles
+// that were reconciled in name order
+func (s *SovietState) ReconcileStates() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barrel == nil {
+		return nil, nil
+	}
+
+	var orphaned []string
+	for role, agent := range s.RegisteredAgents() {
+		// Paused and failed agents stay out of service until they are resumed
+		if !agent.IsWorking() && !agent.IsOffered() || s.barrel.IsHeldBy(role) {
+			continue
+		}
+		orphaned = append(orphaned, role)
+	}
+	sort.Strings(orphaned)
+
+	for _, role := range orphaned {
+		agent := s.GetAgent(role)
+		previous := agent.State()
+		agent.forceState(AgentStateWaiting)
+		if err := s.repo.Store(agent); err != nil {
+			return nil, fmt.Errorf("failed to store reconciled agent '%s': %w", role, err)
+		}
+
+		if s.logger != nil {
+			s.logger.Warn("Reconciled orphaned agent state", map[string]interface{}{
+				"role":          role,
+				"previous":      previous.String(),
+				"barrel_holder": s.barrel.CurrentHolder(),
+			})
+		}
+	}
+	return orphaned, nil
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_ReconcileStates(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	for _, role := range []string{"developer", "reviewer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	require.NoError(t, soviet.AcknowledgeActivation("developer"))
+
+	// Nothing diverges yet
+	reconciled, err := soviet.ReconcileStates()
+	require.NoError(t, err)
+	assert.Empty(t, reconciled)
+
+	// A crash leaves two agents believing they still have work
+	soviet.GetAgent("tester").forceState(AgentStateWorking)
+	soviet.GetAgent("reviewer").forceState(AgentStateOffered)
+	validator := NewProtocolValidator(soviet)
+	assert.Error(t, validator.ValidateAgentStateConsistency("tester"))
+
+	reconciled, err = soviet.ReconcileStates()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reviewer", "tester"}, reconciled)
+	for _, role := range []string{"reviewer", "tester"} {
+		state, err := soviet.GetAgentState(role)
+		require.NoError(t, err)
+		assert.Equal(t, AgentStateWaiting, state, role)
+		assert.NoError(t, validator.ValidateAgentStateConsistency(role))
+	}
+
+	// Paused agents stay out of service
+	require.NoError(t, soviet.GetAgent("reviewer").Pause())
+	reconciled, err = soviet.ReconcileStates()
+	require.NoError(t, err)
+	assert.Empty(t, reconciled)
+	assert.True(t, soviet.GetAgent("reviewer").IsPaused())
+
+	// The barrel holder keeps working
+	state, err := soviet.GetAgentState("developer")
+	require.NoError(t, err)
+	assert.Equal(t, AgentStateWorking, state)
+}
@@ -0,0 +1,30 @@
+package domain
+
+// RegistrationStats counts successful registrations by how they found their role
+// A frequently resuming role is one whose agent keeps crashing or losing its connection
+type RegistrationStats struct {
+	// Fresh counts registrations of roles that were not registered yet
+	Fresh int `json:"fresh"`
+	// Replacements counts registrations of roles already registered, such as reconnections
+	Replacements int `json:"replacements"`
+	// Resumes counts registrations that handed the role back the barrel it held
+	// Each is also counted as fresh or as a replacement
+	Resumes int `json:"resumes"`
+}
+
+// recordRegistration counts a successful registration
+func (s *SovietState) recordRegistration(replaced, resumed bool) {
+	if replaced {
+		s.registrations.Replacements++
+	} else {
+		s.registrations.Fresh++
+	}
+	if resumed {
+		s.registrations.Resumes++
+	}
+}
+
+// Registrations returns how many registrations were fresh, replacements or barrel resumes
+func (s *SovietState) Registrations() RegistrationStats {
+	return s.registrations
+}
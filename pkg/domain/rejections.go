@@ -0,0 +1,27 @@
+package domain
+
+// recordRejection counts an operation rejected by validation under its error code
+// Callers pass the code of the ValidationError that caused the rejection
+func (s *SovietState) recordRejection(code string) {
+	if s.rejections == nil {
+		s.rejections = make(map[string]int)
+	}
+	s.rejections[code]++
+}
+
+// rejectionCode returns the validation code of err, or INVALID_MESSAGE when it carries none
+func rejectionCode(err error) string {
+	if validationErr, ok := err.(ValidationError); ok {
+		return validationErr.Code
+	}
+	return ValidationCodeInvalidMessage
+}
+
+// Rejections returns how many yields and registrations were rejected, keyed by validation code
+func (s *SovietState) Rejections() map[string]int {
+	rejections := make(map[string]int, len(s.rejections))
+	for code, count := range s.rejections {
+		rejections[code] = count
+	}
+	return rejections
+}
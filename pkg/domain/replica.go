@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ReplicatedStatus is the part of a primary server's status that a read-only replica mirrors
+type ReplicatedStatus struct {
+	BarrelHolder    string
+	AgentStates     map[string]AgentState
+	ConnectedAgents map[string]bool
+}
+
+// MirrorStatus makes this soviet reflect the status of a primary server
+// Agents missing from the status are removed and new ones are registered without capabilities.
+// A change of holder is recorded as a transfer, so LastTransfer and the history follow the primary,
+// but receipts are this soviet's own. New and removed agents and the transfer are published.
+// Only replicas may call it: it bypasses every domain rule. Like the port methods it holds the
+// lock, as the replica's adapters read the soviet meanwhile
+func (s *SovietState) MirrorStatus(status ReplicatedStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barrel == nil {
+		return fmt.Errorf("no barrel set in soviet state: SetBarrel must be called before mirroring a primary")
+	}
+	if status.BarrelHolder == "" {
+		return fmt.Errorf("replicated status has no barrel holder")
+	}
+
+	for _, role := range s.GetAgentRoles() {
+		if _, exists := status.AgentStates[role]; !exists {
+			if err := s.UnregisterAgent(role); err != nil {
+				return err
+			}
+			s.publishAgentDeregistered(role)
+		}
+	}
+
+	roles := make([]string, 0, len(status.AgentStates))
+	for role := range status.AgentStates {
+		roles = append(roles, role)
+	}
+	// Registration order decides group priority ties, so keep it stable
+	sort.Strings(roles)
+	for _, role := range roles {
+		agent := s.GetAgent(role)
+		isNew := agent == nil
+		if isNew {
+			agent = NewAgentComrade(role, nil)
+			s.registrationSeq++
+			agent.setRegistrationSeq(s.registrationSeq)
+		}
+		agent.forceState(status.AgentStates[role])
+		agent.SetConnected(status.ConnectedAgents[role])
+		if err := s.repo.Store(agent); err != nil {
+			return fmt.Errorf("failed to store mirrored agent '%s': %w", role, err)
+		}
+		if isNew {
+			s.publishAgentRegistered(agent)
+		}
+	}
+
+	if !s.barrel.IsHeldBy(status.BarrelHolder) {
+		if err := s.barrel.TransferTo(status.BarrelHolder, ""); err != nil {
+			return fmt.Errorf("failed to mirror barrel transfer: %w", err)
+		}
+		s.barrelChanged()
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_MirrorStatus(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	require.NoError(t, soviet.SimpleRegisterAgent(NewAgentComrade("retired", nil)))
+
+	require.NoError(t, soviet.MirrorStatus(ReplicatedStatus{
+		BarrelHolder:    "developer",
+		AgentStates:     map[string]AgentState{"developer": AgentStateOffered, "tester": AgentStateWaiting},
+		ConnectedAgents: map[string]bool{"developer": true},
+	}))
+
+	status := soviet.QueryStatus()
+	assert.Equal(t, "developer", status.BarrelHolder)
+	assert.ElementsMatch(t, []string{"developer", "tester"}, status.RegisteredAgents)
+	assert.Equal(t, AgentStateOffered, status.AgentStates["developer"])
+	assert.True(t, status.ConnectedAgents["developer"])
+	assert.False(t, status.ConnectedAgents["tester"])
+
+	transfer, ok := soviet.LastTransfer()
+	require.True(t, ok)
+	assert.Equal(t, "people", transfer.FromRole)
+	assert.Equal(t, "developer", transfer.ToRole)
+
+	// An unchanged holder records no transfer
+	require.NoError(t, soviet.MirrorStatus(ReplicatedStatus{
+		BarrelHolder:    "developer",
+		AgentStates:     map[string]AgentState{"developer": AgentStateWorking, "tester": AgentStateWaiting},
+		ConnectedAgents: map[string]bool{"developer": true, "tester": true},
+	}))
+	again, _ := soviet.LastTransfer()
+	assert.Equal(t, transfer.Receipt.Sequence, again.Receipt.Sequence)
+	state, err := soviet.GetAgentState("developer")
+	require.NoError(t, err)
+	assert.Equal(t, AgentStateWorking, state)
+
+	assert.Error(t, soviet.MirrorStatus(ReplicatedStatus{}))
+	assert.Error(t, newTestSoviet().MirrorStatus(ReplicatedStatus{BarrelHolder: "people"}))
+}
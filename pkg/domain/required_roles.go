@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reasons a required role is not ready for work
+const (
+	RoleNeedNotRegistered = "not_registered"
+	RoleNeedDisconnected  = "disconnected"
+)
+
+// RoleNeed describes a required role that still has to come online
+type RoleNeed struct {
+	// Role is the required role
+	Role string `json:"role"`
+
+	// Reason is RoleNeedNotRegistered or RoleNeedDisconnected
+	Reason string `json:"reason"`
+}
+
+// RolesNeeded compares the roles a workflow expects with the agents that are online
+type RolesNeeded struct {
+	// Required lists the configured roles in configuration order
+	Required []string `json:"required"`
+
+	// Missing lists the required roles that are not registered or not connected, empty when all are online
+	Missing []RoleNeed `json:"missing"`
+}
+
+// SetRequiredRoles sets the roles the collective expects before work can proceed
+// Roles must be non-blank and unique; the people are always present and cannot be required
+func (s *SovietState) SetRequiredRoles(roles []string) error {
+	seen := make(map[string]bool, len(roles))
+	required := make([]string, 0, len(roles))
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			return fmt.Errorf("required role cannot be empty")
+		}
+		if role == "people" {
+			return fmt.Errorf("the people are always present and cannot be a required role")
+		}
+		if seen[role] {
+			return fmt.Errorf("required role '%s' is listed more than once", role)
+		}
+		seen[role] = true
+		required = append(required, role)
+	}
+
+	s.requiredRoles = required
+	return nil
+}
+
+// RequiredRoles returns a copy of the configured required roles
+func (s *SovietState) RequiredRoles() []string {
+	roles := make([]string, len(s.requiredRoles))
+	copy(roles, s.requiredRoles)
+	return roles
+}
+
+// GetRolesNeeded returns which required roles are not registered or not connected
+func (s *SovietState) GetRolesNeeded() RolesNeeded {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	needed := RolesNeeded{
+		Required: s.RequiredRoles(),
+		Missing:  []RoleNeed{},
+	}
+	for _, role := range s.requiredRoles {
+		agent := s.GetAgent(role)
+		switch {
+		case agent == nil:
+			needed.Missing = append(needed.Missing, RoleNeed{Role: role, Reason: RoleNeedNotRegistered})
+		case !agent.IsConnected():
+			needed.Missing = append(needed.Missing, RoleNeed{Role: role, Reason: RoleNeedDisconnected})
+		}
+	}
+	return needed
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_SetRequiredRoles_Validation(t *testing.T) {
+	soviet := newTestSoviet()
+
+	assert.Error(t, soviet.SetRequiredRoles([]string{"developer", " "}))
+	assert.Contains(t, soviet.SetRequiredRoles([]string{"people"}).Error(), "always present")
+	assert.Contains(t, soviet.SetRequiredRoles([]string{"developer", "developer"}).Error(), "more than once")
+
+	require.NoError(t, soviet.SetRequiredRoles([]string{" developer", "tester"}))
+	assert.Equal(t, []string{"developer", "tester"}, soviet.RequiredRoles())
+}
+
+func TestSovietState_GetRolesNeeded(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+
+	// Nothing configured means nothing is needed
+	needed := soviet.GetRolesNeeded()
+	assert.Empty(t, needed.Required)
+	assert.Empty(t, needed.Missing)
+
+	require.NoError(t, soviet.SetRequiredRoles([]string{"developer", "tester", "reviewer"}))
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"work"}))
+		require.NoError(t, err)
+	}
+	require.NoError(t, soviet.DisconnectAgent("tester"))
+
+	needed = soviet.GetRolesNeeded()
+	assert.Equal(t, []string{"developer", "tester", "reviewer"}, needed.Required)
+	assert.Equal(t, []RoleNeed{
+		{Role: "tester", Reason: RoleNeedDisconnected},
+		{Role: "reviewer", Reason: RoleNeedNotRegistered},
+	}, needed.Missing)
+
+	// Once everyone is online the result is empty
+	for _, role := range []string{"tester", "reviewer"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"work"}))
+		require.NoError(t, err)
+	}
+	needed = soviet.GetRolesNeeded()
+	assert.NotNil(t, needed.Missing)
+	assert.Empty(t, needed.Missing)
+}
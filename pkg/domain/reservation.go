@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReserveRole reserves a role so only agents presenting token at registration may claim it
+// Reserving a role again replaces its token. An agent already registered as the role keeps it
+// until it reconnects
+func (s *SovietState) ReserveRole(role, token string) error {
+	role = strings.TrimSpace(role)
+	if role == "" {
+		return fmt.Errorf("reserved role cannot be empty")
+	}
+	if IsReservedRole(role) {
+		return fmt.Errorf("role '%s' belongs to the people and cannot be reserved", role)
+	}
+	if token == "" {
+		return fmt.Errorf("reservation token for role '%s' cannot be empty", role)
+	}
+
+	if s.reservations == nil {
+		s.reservations = make(map[string]string)
+	}
+	s.reservations[role] = token
+	return nil
+}
+
+// ReservedRoles returns the roles reserved with ReserveRole in name order
+func (s *SovietState) ReservedRoles() []string {
+	roles := make([]string, 0, len(s.reservations))
+	for role := range s.reservations {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// checkReservation rejects an agent registering as a reserved role without its token
+func (s *SovietState) checkReservation(agent *AgentComrade) error {
+	token, reserved := s.reservations[agent.Role()]
+	if !reserved {
+		return nil
+	}
+	if agent.reservationToken == "" {
+		return fmt.Errorf("role '%s' is reserved: registration requires its reservation token", agent.Role())
+	}
+	if subtle.ConstantTimeCompare([]byte(agent.reservationToken), []byte(token)) != 1 {
+		return fmt.Errorf("role '%s' is reserved: the reservation token does not match", agent.Role())
+	}
+	return nil
+}
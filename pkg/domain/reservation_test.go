@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_ReserveRole(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	require.NoError(t, soviet.ReserveRole("deployer", "s3cret"))
+
+	register := func(role, token string) error {
+		agent := NewAgentComrade(role, []string{"deploy"})
+		agent.SetReservationToken(token)
+		_, _, _, err := soviet.RegisterAgent(agent)
+		return err
+	}
+
+	t.Run("missing or wrong token is rejected", func(t *testing.T) {
+		err := register("deployer", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requires its reservation token")
+
+		err = register("deployer", "guess")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match")
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, ValidationCodeInvalidRole, validationErr.Code)
+		assert.Nil(t, soviet.GetAgent("deployer"))
+	})
+
+	t.Run("matching token claims the role", func(t *testing.T) {
+		require.NoError(t, register("deployer", "s3cret"))
+		assert.NotNil(t, soviet.GetAgent("deployer"))
+
+		// A squatter cannot replace the registered agent either
+		assert.Error(t, register("deployer", "guess"))
+		assert.True(t, soviet.GetAgent("deployer").IsConnected())
+	})
+
+	t.Run("unreserved roles stay open", func(t *testing.T) {
+		assert.NoError(t, register("developer", ""))
+		assert.NoError(t, register("tester", "any-token"))
+	})
+
+	t.Run("invalid reservations", func(t *testing.T) {
+		assert.Error(t, soviet.ReserveRole("", "token"))
+		assert.Error(t, soviet.ReserveRole("reviewer", ""))
+		assert.Error(t, soviet.ReserveRole("people", "token"))
+		assert.Equal(t, []string{"deployer"}, soviet.ReservedRoles())
+	})
+}
@@ -1,11 +1,24 @@
 package domain
 
+import "time"
+
 // AgentDetails represents detailed information about an agent comrade
 type AgentDetails struct {
-	Role         string      `json:"role"`
-	Capabilities []string    `json:"capabilities"`
-	State        AgentState  `json:"state"`
-	Connected    bool        `json:"connected"`
+	Role            string     `json:"role"`
+	Type            AgentType  `json:"agent_type"`
+	Description     string     `json:"description,omitempty"`
+	Weight          int        `json:"weight"`
+	Capabilities    []string   `json:"capabilities"`
+	State           AgentState `json:"state"`
+	Connected       bool       `json:"connected"`
+	RegistrationSeq uint64     `json:"registration_seq"` // Lower numbers registered earlier
+
+	// Tags are the agent's key/value labels, e.g. region=us-east
+	Tags map[string]string `json:"tags,omitempty"`
+	// InstanceID identifies the process running the agent, empty if it gave none
+	InstanceID string `json:"instance_id,omitempty"`
+	// LastSeenAt is when the agent last connected or sent a heartbeat
+	LastSeenAt time.Time `json:"last_seen_at"`
 }
 
 // SovietService defines the primary port for commanding the Soviet coordinator
@@ -17,20 +30,98 @@ type SovietService interface {
 	// The system automatically handles both cases:
 	// - For new agents: registers and places in waiting state
 	// - For reconnections: replaces existing agent and resumes work if role holds barrel
-	// Returns: (shouldResume, lastMessage, error) where shouldResume indicates if agent should start working
-	RegisterAgent(agent *AgentComrade) (bool, string, error)
+	// Returns: (shouldResume, lastMessage, lastFromRole, error) where shouldResume indicates if agent should start working
+	// and lastFromRole is the role that handed the barrel over with lastMessage
+	RegisterAgent(agent *AgentComrade) (bool, string, string, error)
 
 	// ProcessYield handles yield requests and manages barrel transfers
 	// This is called when an agent comrade yields the barrel to another agent or to the people
 	ProcessYield(message YieldMessage) error
 
+	// LastTransfer returns the most recent barrel transfer
+	// Adapters use it after ProcessYield to learn which role actually received the barrel
+	LastTransfer() (TransferRecord, bool)
+
+	// TransferHistory returns the last limit barrel transfers, oldest first, or all of them when limit is 0
+	TransferHistory(limit int) []TransferRecord
+
 	// DeregisterAgent removes an agent from the collective
 	// This is called when an agent disconnects or is manually removed
 	DeregisterAgent(role string) error
 
+	// DisconnectAgent marks an agent as disconnected while keeping it registered
+	// A disconnected barrel holder may reconnect and resume within the reconnect grace period
+	DisconnectAgent(role string) error
+
 	// QueryStatus returns the current status of the collective including all agents and barrel state
 	// This is called by People's representatives to inspect the collective
 	QueryStatus() StatusResponse
+
+	// ForceAgentState forces an agent to waiting or working for recovery, or pauses, fails or resumes it,
+	// consistent with barrel ownership. Failing the holder returns the barrel to the people
+	// This bypasses the normal state machine and is reserved for the people
+	ForceAgentState(role string, state AgentState) error
+
+	// ReassignBarrel moves the barrel from its (possibly disconnected) holder to another role,
+	// carrying forward the barrel's last message. Reserved for the people
+	ReassignBarrel(fromRole, toRole string) error
+
+	// UpdateAgentCapabilities replaces a registered agent's capability list without re-registering it
+	// The list must be non-empty; duplicates are removed. Returns the stored capabilities
+	UpdateAgentCapabilities(role string, capabilities []string) ([]string, error)
+
+	// SetBarrelTTL changes how long an agent may hold the barrel before it is reclaimed
+	// A TTL of 0 disables the deadline; negative values are rejected
+	SetBarrelTTL(ttl time.Duration) error
+
+	// BarrelTTL returns the currently configured barrel TTL
+	BarrelTTL() time.Duration
+
+	// BarrelDeadline returns when the current holder's barrel will be reclaimed (zero if none)
+	BarrelDeadline() time.Time
+
+	// ReclaimExpiredBarrel returns the barrel to the people if the holder exceeded the TTL
+	// Returns true if the barrel was reclaimed
+	ReclaimExpiredBarrel() (bool, error)
+
+	// AcknowledgeActivation confirms an agent received its activation, moving it from offered to working
+	AcknowledgeActivation(role string) error
+
+	// SetExpectedDuration records how long the barrel holder expects to keep it, for staleness monitoring
+	SetExpectedDuration(role string, expected time.Duration) error
+
+	// ReclaimUnacknowledgedOffer returns the barrel to the people if the offered holder never acknowledged it
+	// Returns true if the barrel was reclaimed
+	ReclaimUnacknowledgedOffer() (bool, error)
+
+	// ReclaimDisconnectedBarrel returns the barrel to the people if its holder did not reconnect in time
+	// Returns true if the barrel was reclaimed
+	ReclaimDisconnectedBarrel() (bool, error)
+
+	// RecordHeartbeat notes that an agent is alive, connecting it again if it missed its heartbeats
+	RecordHeartbeat(role string) error
+
+	// DisconnectSilentAgents marks agents that sent no heartbeat within the heartbeat timeout as disconnected
+	// Returns the roles that were disconnected
+	DisconnectSilentAgents() ([]string, error)
+
+	// ReconcileStates returns agents that are working or offered without holding the barrel to waiting
+	// Returns the roles that were reconciled
+	ReconcileStates() ([]string, error)
+
+	// PruneHistory drops transfer records older than the configured history retention
+	// Returns the pruned records, oldest first
+	PruneHistory() ([]TransferRecord, error)
+
+	// Halt freezes the barrel with its current holder until Resume; the reason cannot be empty
+	// This is an emergency stop reserved for the people
+	Halt(reason string) error
+
+	// Resume lifts a halt; returns false if the collective was not halted
+	Resume() bool
+
+	// HaltStatus reports whether the collective is halted and why
+	HaltStatus() HaltStatus
 }
 
 // AgentService defines the primary port for querying agent and barrel information
@@ -52,6 +143,37 @@ type AgentService interface {
 	// GetAgentDetails returns detailed information about all registered agents including capabilities
 	// This provides a comprehensive view of all agents and their capabilities for the collective
 	GetAgentDetails() []AgentDetails
+
+	// FindAgentsByCapability returns the roles of agents with a capability matching the pattern, oldest first
+	// The pattern may use wildcards such as "test/*"; see CapabilityMatches
+	FindAgentsByCapability(pattern string) []string
+
+	// FindAgentsByTag returns the roles of agents tagged with key, oldest first
+	// An empty value matches any value of the key
+	FindAgentsByTag(key, value string) []string
+
+	// GetStats returns aggregate statistics about the collective
+	GetStats() *SovietStats
+
+	// GetPipelineStatus returns the configured pipeline, the current stage and the resolved next role
+	// When no pipeline is configured the returned roles are empty
+	GetPipelineStatus() PipelineStatus
+
+	// GetGroups returns every configured group with its members and currently available members
+	GetGroups() []GroupStatus
+
+	// GetStaleness returns how long the barrel has stayed with its current holder
+	GetStaleness() Staleness
+
+	// GetBarrelContext returns the current holder's task: who sent it, its message, when it arrived and its hints
+	GetBarrelContext() BarrelContext
+
+	// GetRolesNeeded returns the configured required roles and which of them are not registered or not connected
+	GetRolesNeeded() RolesNeeded
+
+	// ValidateYield returns every validation problem of a yield without processing it
+	// An empty list means ProcessYield would accept the message
+	ValidateYield(message YieldMessage) []ValidationError
 }
 
 // StatusResponse represents the current status of the Agent Farm collective
@@ -68,6 +190,57 @@ type StatusResponse struct {
 
 	// ConnectedAgents indicates which agents are currently connected
 	ConnectedAgents map[string]bool `json:"connected_agents"`
+
+	// AgentCapabilities maps agent roles to their current capabilities
+	AgentCapabilities map[string][]string `json:"agent_capabilities"`
+
+	// RegistrationSeqs maps agent roles to their registration sequence numbers (lower joined first)
+	RegistrationSeqs map[string]uint64 `json:"registration_seqs"`
+
+	// AgentWeights maps agent roles to their share of the assignments of weighted groups
+	AgentWeights map[string]int `json:"agent_weights"`
+
+	// AgentInstances maps agent roles to the instance ID they registered with, for agents that gave one
+	AgentInstances map[string]string `json:"agent_instances"`
+
+	// AverageHolds maps roles to how long they hold the barrel on average, counting the current hold so far
+	AverageHolds map[string]time.Duration `json:"average_holds"`
+
+	// YieldChainDepth counts consecutive agent-to-agent yields since the barrel last touched the people
+	YieldChainDepth int `json:"yield_chain_depth"`
+
+	// ExpectedDuration is how long the holder is expected to keep the barrel (0 = no hint)
+	ExpectedDuration time.Duration `json:"expected_duration"`
+
+	// OverExpected is true when the holder has kept the barrel longer than ExpectedDuration
+	OverExpected bool `json:"over_expected"`
+
+	// BenchedAgents maps roles benched by the circuit breaker to when they may receive the barrel again
+	BenchedAgents map[string]time.Time `json:"benched_agents"`
+
+	// HolderUnregistered is true when BarrelHolder is not a registered agent, e.g. after a snapshot restore
+	// The work waits until the role registers again or the people reclaim the barrel
+	HolderUnregistered bool `json:"holder_unregistered"`
+}
+
+// Staleness describes how long the barrel has sat with its current holder
+// A barrel that stops moving is the most common sign of a stalled workflow
+type Staleness struct {
+	// BarrelHolder indicates which role currently holds the barrel of gun
+	BarrelHolder string `json:"barrel_holder"`
+
+	// Since is when the barrel last moved
+	Since time.Time `json:"since"`
+
+	// Duration is how long ago the barrel last moved
+	Duration time.Duration `json:"duration"`
+
+	// ExpectedDuration is how long the holder was expected to keep the barrel (0 = no hint)
+	ExpectedDuration time.Duration `json:"expected_duration"`
+
+	// OverExpected is true when Duration exceeds a non-zero ExpectedDuration
+	// It only flags the overrun; reclaiming overdue barrels is the job of the barrel TTL
+	OverExpected bool `json:"over_expected"`
 }
 
 // CommandHandler defines the port for handling incoming commands from external sources
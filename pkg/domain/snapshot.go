@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// BarrelSnapshot is a serializable copy of the barrel, including the reclaim deadline of its holder
+// Restoring a snapshot after a restart lets the TTL sweep reclaim a stalled holder on time
+type BarrelSnapshot struct {
+	Holder       string           `json:"holder"`
+	LastMessage  string           `json:"last_message"`
+	TransferTime time.Time        `json:"transfer_time"`
+	RetryCount   int              `json:"retry_count"`
+	History      []TransferRecord `json:"history"`
+	TTL          time.Duration    `json:"ttl"`
+	Deadline     time.Time        `json:"deadline,omitempty"` // Zero when no deadline applies
+}
+
+// SnapshotBarrel captures the barrel and its TTL deadline
+func (s *SovietState) SnapshotBarrel() (BarrelSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotBarrel()
+}
+
+// snapshotBarrel implements SnapshotBarrel; the caller holds the lock
+func (s *SovietState) snapshotBarrel() (BarrelSnapshot, error) {
+	if s.barrel == nil {
+		return BarrelSnapshot{}, fmt.Errorf("no barrel set in soviet state")
+	}
+
+	return BarrelSnapshot{
+		Holder:       s.barrel.CurrentHolder(),
+		LastMessage:  s.barrel.LastMessage(),
+		TransferTime: s.barrel.LastTransferTime(),
+		RetryCount:   s.barrel.RetryCount(),
+		History:      s.barrel.GetTransferHistory(),
+		TTL:          s.barrelTTL,
+		Deadline:     s.barrelDeadline(),
+	}, nil
+}
+
+// RestoreBarrel replaces the barrel and TTL with a snapshot taken by SnapshotBarrel
+// The deadline is kept as it was, so time spent while the server was down counts against the holder
+func (s *SovietState) RestoreBarrel(snapshot BarrelSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot.Holder == "" {
+		return fmt.Errorf("barrel snapshot has no holder")
+	}
+	if len(snapshot.History) == 0 {
+		return fmt.Errorf("barrel snapshot has no transfer history")
+	}
+	if snapshot.TTL < 0 {
+		return fmt.Errorf("barrel snapshot TTL cannot be negative: %s", snapshot.TTL)
+	}
+	if !snapshot.Deadline.IsZero() && !snapshot.Deadline.Equal(snapshot.TransferTime.Add(snapshot.TTL)) {
+		return fmt.Errorf("barrel snapshot deadline %s does not match its transfer time and TTL of %s",
+			snapshot.Deadline.Format(time.RFC3339), snapshot.TTL)
+	}
+
+	history := make([]TransferRecord, len(snapshot.History))
+	copy(history, snapshot.History)
+	barrel := &BarrelOfGun{
+		lastMessage:  snapshot.LastMessage,
+		transferTime: snapshot.TransferTime,
+		history:      history,
+		retryCount:   snapshot.RetryCount,
+	}
+	barrel.setHolder(snapshot.Holder)
+	s.barrel = barrel
+	s.barrelTTL = snapshot.TTL
+	s.publishedSequence = barrel.LastTransfer().Receipt.Sequence
+
+	if s.IsHolderUnregistered() && s.logger != nil {
+		s.logger.Warn("Restored barrel is held by an unregistered role", map[string]interface{}{
+			"role": snapshot.Holder,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_RestoredBarrelStillReclaimsOnTime(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"code"}))
+	require.NoError(t, err)
+	require.NoError(t, soviet.SetBarrelTTL(10*time.Minute))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+
+	// Snapshot mid-hold and survive a restart through JSON
+	currentTime = currentTime.Add(4 * time.Minute)
+	snapshot, err := soviet.SnapshotBarrel()
+	require.NoError(t, err)
+	assert.Equal(t, currentTime.Add(6*time.Minute), snapshot.Deadline)
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+
+	var loaded BarrelSnapshot
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	restarted := newTestSoviet()
+	require.NoError(t, restarted.RestoreBarrel(loaded))
+	assert.Equal(t, "developer", restarted.GetBarrelStatus())
+	assert.True(t, snapshot.Deadline.Equal(restarted.BarrelDeadline()))
+	assert.Equal(t, soviet.GetBarrel().GetReceipts(), restarted.GetBarrel().GetReceipts())
+
+	// The original deadline still applies
+	currentTime = currentTime.Add(5 * time.Minute)
+	reclaimed, err := restarted.ReclaimExpiredBarrel()
+	require.NoError(t, err)
+	assert.False(t, reclaimed)
+
+	currentTime = currentTime.Add(time.Minute)
+	reclaimed, err = restarted.ReclaimExpiredBarrel()
+	require.NoError(t, err)
+	assert.True(t, reclaimed)
+	assert.Equal(t, "people", restarted.GetBarrelStatus())
+}
+
+func TestSovietState_RestoreBarrel_RejectsInconsistentSnapshot(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	snapshot, err := soviet.SnapshotBarrel()
+	require.NoError(t, err)
+
+	snapshot.TTL = time.Minute
+	snapshot.Deadline = snapshot.TransferTime.Add(time.Hour)
+	assert.Error(t, soviet.RestoreBarrel(snapshot))
+
+	snapshot.Deadline = time.Time{}
+	snapshot.History = nil
+	assert.Error(t, soviet.RestoreBarrel(snapshot))
+}
+
+// restoredWithUnregisteredHolder restores a snapshot whose holder has not registered since the restart
+func restoredWithUnregisteredHolder(t *testing.T) *SovietState {
+	t.Helper()
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"code"}))
+	require.NoError(t, err)
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Start")))
+	snapshot, err := soviet.SnapshotBarrel()
+	require.NoError(t, err)
+
+	restarted := newTestSoviet()
+	require.NoError(t, restarted.RestoreBarrel(snapshot))
+	_, _, _, err = restarted.RegisterAgent(NewAgentComrade("tester", []string{"test"}))
+	require.NoError(t, err)
+	return restarted
+}
+
+func TestSovietState_UnregisteredHolderIsReported(t *testing.T) {
+	soviet := restoredWithUnregisteredHolder(t)
+
+	assert.True(t, soviet.IsHolderUnregistered())
+	status := soviet.QueryStatus()
+	assert.Equal(t, "developer", status.BarrelHolder)
+	assert.True(t, status.HolderUnregistered)
+	assert.NotContains(t, status.RegisteredAgents, "developer")
+
+	// A yield claiming to come from the holder explains why it cannot be accepted yet
+	err := soviet.ProcessYield(NewYieldMessage("developer", "tester", "Done"))
+	var validationErr ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, ValidationCodeStateInconsistency, validationErr.Code)
+	assert.Contains(t, err.Error(), "held by unregistered role 'developer'")
+
+	// The role resumes its work once it registers again
+	resumed, payload, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"code"}))
+	require.NoError(t, err)
+	assert.True(t, resumed)
+	assert.Equal(t, "Start", payload)
+	assert.False(t, soviet.IsHolderUnregistered())
+	assert.False(t, soviet.QueryStatus().HolderUnregistered)
+}
+
+func TestSovietState_PeopleReclaimFromUnregisteredHolder(t *testing.T) {
+	soviet := restoredWithUnregisteredHolder(t)
+
+	require.NoError(t, soviet.ReassignBarrel("developer", "people"))
+	assert.Equal(t, "people", soviet.GetBarrelStatus())
+	assert.False(t, soviet.IsHolderUnregistered())
+
+	// The people can also hand the work straight to a registered agent
+	soviet = restoredWithUnregisteredHolder(t)
+	require.NoError(t, soviet.ReassignBarrel("developer", "tester"))
+	assert.Equal(t, "tester", soviet.GetBarrelStatus())
+	assert.Equal(t, AgentStateWorking, soviet.GetAgent("tester").State())
+	assert.Equal(t, "Start", soviet.GetBarrel().LastMessage())
+}
+
+func TestSovietState_PeopleHolderIsNeverUnregistered(t *testing.T) {
+	soviet := newTestSoviet()
+	assert.False(t, soviet.IsHolderUnregistered(), "no barrel")
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	assert.False(t, soviet.IsHolderUnregistered())
+}
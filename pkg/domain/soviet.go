@@ -2,6 +2,8 @@ package domain
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -13,21 +15,117 @@ type SovietStats struct {
 	IsActive            bool      `json:"is_active"`
 	CreatedAt           time.Time `json:"created_at"`
 	DeactivatedAt       time.Time `json:"deactivated_at,omitempty"`
+
+	// HoldTimes maps roles to how long they held the barrel before yielding it
+	HoldTimes map[string]HoldTimeStats `json:"hold_times"`
+
+	// HoldDurations maps roles to how long they held the barrel in total: HoldTimes plus the current
+	// holder's time so far
+	HoldDurations map[string]time.Duration `json:"hold_durations"`
+
+	// Rejections counts rejected yields and registrations by validation code, e.g. NOT_BARREL_HOLDER
+	Rejections map[string]int `json:"rejections"`
+
+	// Registrations counts fresh registrations, replacements and barrel resumes
+	Registrations RegistrationStats `json:"registrations"`
 }
 
 // SovietState represents the state of the collective, managing all agents and the barrel
 // Uses repository as single source of truth for agent data
+// The SovietService and AgentService methods hold mu, so adapters may call them from any goroutine.
+// Other exported methods configure the soviet before it is shared, or serve the domain itself
 type SovietState struct {
+	// mu serializes the port methods; nothing called while it is held may call a port method
+	mu sync.Mutex
+
 	barrel        *BarrelOfGun
 	active        bool
 	createdAt     time.Time
 	deactivatedAt time.Time
 	validator     *ProtocolValidator
 
+	// Yield chain tracking guards against runaway agent-to-agent loops
+	yieldChainDepth    int
+	maxYieldChainDepth int // 0 means unlimited
+
+	// barrelTTL bounds how long an agent may hold the barrel before it is reclaimed by the people
+	barrelTTL time.Duration // 0 means no deadline
+
+	// pipeline is the optional ordered role sequence for automated workflows
+	pipeline *Pipeline
+
+	// groups are named teams of roles that can be yielded to as a unit
+	groups map[string]*Group
+
+	// requiredRoles are the roles a workflow expects to be online before work can proceed
+	requiredRoles []string
+
+	// Retry policy for work reported as failed
+	maxRetries    int               // 0 disables requeueing
+	retryFallback map[string]string // failing role -> role that takes over the retry
+
+	// Circuit breaker benching roles that keep failing their work
+	breakerThreshold    int                  // Consecutive failed yields that trip the breaker (0 = disabled)
+	breakerCooldown     time.Duration        // How long a tripped role stays benched
+	consecutiveFailures map[string]int       // role -> failed yields since its last successful one
+	benchedUntil        map[string]time.Time // role -> when it may receive the barrel again
+
+	// activationAckTimeout enables the offered state: targets must acknowledge activation within it
+	activationAckTimeout time.Duration // 0 activates targets immediately
+
+	// maxMessageAge rejects yields whose sender timestamp is older than this (clock skew allowance)
+	maxMessageAge time.Duration // 0 disables the stale-message guard
+
+	// clockSkewTolerance bounds how far ahead of the server clock a client timestamp may be
+	clockSkewTolerance time.Duration // 0 disables the check
+
+	// redactPayloads keeps yield payload contents out of logs
+	redactPayloads bool
+
+	// Default payloads delivered instead of an empty yield payload
+	defaultYieldMessage string            // "" delivers empty payloads unchanged
+	roleYieldMessages   map[string]string // target role -> default overriding defaultYieldMessage
+
+	// reconnectGracePeriod is how long a disconnected holder keeps the barrel before it returns to the people
+	reconnectGracePeriod time.Duration // 0 keeps the barrel until the holder reconnects
+
+	// heartbeatTimeout is how long a connected agent may stay silent before it is marked disconnected
+	heartbeatTimeout time.Duration // 0 never marks agents disconnected for silence
+
+	// historyRetention is how long transfer records stay in the barrel history
+	historyRetention time.Duration // 0 keeps every record
+	archiveHistory   bool          // Log pruned records before dropping them
+
+	// maxYieldsPerMinute limits the yields of each role other than the people (0 = unlimited)
+	maxYieldsPerMinute int
+	yieldBuckets       map[string]*yieldBucket // role -> its remaining allowance
+
+	// rejections counts yields and registrations rejected by validation, keyed by validation code
+	rejections map[string]int
+
+	// registrations counts successful registrations by how they found their role
+	registrations RegistrationStats
+
+	// reservations are roles only agents presenting the matching token may register as
+	reservations map[string]string // role -> token
+
+	// registrationSeq is the last sequence number handed out by RegisterAgent
+	registrationSeq uint64
+
+	// halt freezes the barrel during an emergency stop called by the people
+	halt HaltStatus
+
+	// publishedSequence is the receipt sequence of the last transfer announced to the publisher
+	publishedSequence int
+
+	// barrelStore saves the barrel after every change, nil when it is only kept in memory
+	barrelStore *BarrelStore
+
 	// External dependencies (repo is mandatory, others optional)
-	repo   AgentRepository
-	sender MessageSender
-	logger Logger
+	repo      AgentRepository
+	sender    MessageSender
+	logger    Logger
+	publisher EventPublisher
 }
 
 // NewSovietState creates a new soviet state with a mandatory repository
@@ -45,10 +143,12 @@ func NewSovietState(repo AgentRepository) *SovietState {
 }
 
 // NewSovietStateWithDependencies creates a new soviet state with all external dependencies
+// The publisher is optional; without one, domain events are not announced
 func NewSovietStateWithDependencies(
 	repo AgentRepository,
 	sender MessageSender,
 	logger Logger,
+	publisher EventPublisher,
 ) *SovietState {
 	if repo == nil {
 		panic("repository cannot be nil - required for single source of truth")
@@ -59,6 +159,7 @@ func NewSovietStateWithDependencies(
 		repo:      repo,
 		sender:    sender,
 		logger:    logger,
+		publisher: publisher,
 	}
 	soviet.validator = NewProtocolValidator(soviet)
 	return soviet
@@ -91,12 +192,791 @@ func (s *SovietState) DeactivatedAt() time.Time {
 	return s.deactivatedAt
 }
 
+// SetMaxYieldChainDepth sets the maximum number of consecutive agent-to-agent yields
+// allowed before the barrel must return to the people (0 disables the limit)
+func (s *SovietState) SetMaxYieldChainDepth(depth int) error {
+	if depth < 0 {
+		return fmt.Errorf("max yield chain depth cannot be negative: %d", depth)
+	}
+	s.maxYieldChainDepth = depth
+	return nil
+}
+
+// MaxYieldChainDepth returns the configured maximum yield chain depth (0 means unlimited)
+func (s *SovietState) MaxYieldChainDepth() int {
+	return s.maxYieldChainDepth
+}
+
+// YieldChainDepth returns the number of consecutive agent-to-agent yields
+// since the barrel was last held by the people
+func (s *SovietState) YieldChainDepth() int {
+	return s.yieldChainDepth
+}
+
+// updateYieldChainDepth records a completed transfer in the yield chain counter
+func (s *SovietState) updateYieldChainDepth(fromRole, toRole string) {
+	if fromRole == "people" || toRole == "people" {
+		s.yieldChainDepth = 0
+		return
+	}
+	s.yieldChainDepth++
+}
+
+// SetBarrelTTL sets how long an agent may hold the barrel before it is reclaimed (0 disables the deadline)
+// The TTL is measured from the last transfer, so changing it also moves the current holder's deadline
+func (s *SovietState) SetBarrelTTL(ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ttl < 0 {
+		return fmt.Errorf("barrel TTL cannot be negative: %s", ttl)
+	}
+	s.barrelTTL = ttl
+	s.saveBarrel()
+	return nil
+}
+
+// BarrelTTL returns the configured barrel TTL (0 means no deadline)
+func (s *SovietState) BarrelTTL() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.barrelTTL
+}
+
+// BarrelDeadline returns when the current holder's barrel will be reclaimed
+// Returns zero time when no TTL is set, no barrel exists, or the people hold the barrel
+func (s *SovietState) BarrelDeadline() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.barrelDeadline()
+}
+
+// barrelDeadline implements BarrelDeadline; the caller holds the lock
+func (s *SovietState) barrelDeadline() time.Time {
+	if s.barrelTTL == 0 || s.barrel == nil || s.barrel.IsHeldBy("people") {
+		return time.Time{}
+	}
+	return s.barrel.LastTransferTime().Add(s.barrelTTL)
+}
+
+// GetStaleness returns how long the barrel has stayed with its current holder
+// This implements the AgentService interface
+func (s *SovietState) GetStaleness() Staleness {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.staleness()
+}
+
+// staleness implements GetStaleness; the caller holds the lock
+func (s *SovietState) staleness() Staleness {
+	if s.barrel == nil {
+		return Staleness{BarrelHolder: s.barrelStatus()}
+	}
+	since := s.barrel.LastTransferTime()
+	staleness := Staleness{
+		BarrelHolder:     s.barrel.CurrentHolder(),
+		Since:            since,
+		Duration:         nowFunc().Sub(since),
+		ExpectedDuration: s.barrel.ExpectedDuration(),
+	}
+	staleness.OverExpected = isOverExpected(staleness)
+	return staleness
+}
+
+// ReclaimExpiredBarrel returns the barrel to the people if the current holder exceeded the TTL
+// Returns true if the barrel was reclaimed
+func (s *SovietState) ReclaimExpiredBarrel() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := s.barrelDeadline()
+	if deadline.IsZero() || nowFunc().Before(deadline) || s.halt.Halted {
+		return false, nil
+	}
+
+	holder := s.barrel.CurrentHolder()
+	heldSince := s.barrel.LastTransferTime()
+	if err := s.returnHolderToWaiting(holder); err != nil {
+		return false, err
+	}
+
+	message := fmt.Sprintf("Barrel reclaimed from '%s' after TTL of %s expired", holder, s.barrelTTL)
+	if err := s.barrel.TransferTo("people", message); err != nil {
+		return false, fmt.Errorf("failed to reclaim barrel: %w", err)
+	}
+	s.yieldChainDepth = 0
+	s.barrel.resetRetries()
+	s.barrelChanged()
+	s.recordHoldTime(holder, heldSince)
+
+	if s.logger != nil {
+		s.logger.Warn("Barrel reclaimed after TTL expired", map[string]interface{}{
+			"role": holder,
+			"ttl":  s.barrelTTL.String(),
+		})
+	}
+
+	return true, nil
+}
+
+// returnHolderToWaiting moves the barrel holder back to waiting before the barrel is reclaimed
+func (s *SovietState) returnHolderToWaiting(holder string) error {
+	agent := s.GetAgent(holder)
+	if agent == nil {
+		return nil
+	}
+
+	var err error
+	switch {
+	case agent.IsWorking():
+		err = agent.Yield()
+	case agent.IsOffered():
+		err = agent.WithdrawOffer()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to return agent '%s' to waiting: %w", holder, err)
+	}
+	return nil
+}
+
+// SetReconnectGracePeriod sets how long a disconnected barrel holder may take to reconnect
+// before the barrel returns to the people. 0 keeps the barrel until the holder reconnects
+func (s *SovietState) SetReconnectGracePeriod(grace time.Duration) error {
+	if grace < 0 {
+		return fmt.Errorf("reconnect grace period cannot be negative: %s", grace)
+	}
+	s.reconnectGracePeriod = grace
+	return nil
+}
+
+// ReconnectGracePeriod returns the configured reconnect grace period
+func (s *SovietState) ReconnectGracePeriod() time.Duration {
+	return s.reconnectGracePeriod
+}
+
+// DisconnectAgent marks an agent as disconnected without removing it
+// A barrel holder keeps the barrel so it can resume when it re-registers
+func (s *SovietState) DisconnectAgent(role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agent := s.GetAgent(role)
+	if agent == nil {
+		return fmt.Errorf("agent with role '%s' not found", role)
+	}
+
+	agent.SetConnected(false)
+
+	if s.logger != nil {
+		s.logger.Info("Agent disconnected", map[string]interface{}{
+			"role":         role,
+			"holds_barrel": s.IsBarrelHeldBy(role),
+		})
+	}
+	return nil
+}
+
+// ReclaimDisconnectedBarrel returns the barrel to the people if its holder stayed disconnected
+// longer than the reconnect grace period. Returns true if the barrel was reclaimed
+func (s *SovietState) ReclaimDisconnectedBarrel() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reconnectGracePeriod == 0 || s.barrel == nil || s.barrel.IsHeldBy("people") || s.halt.Halted {
+		return false, nil
+	}
+
+	holder := s.barrel.CurrentHolder()
+	agent := s.GetAgent(holder)
+	if agent != nil && agent.IsConnected() {
+		return false, nil
+	}
+
+	// Holders removed from the repository are treated as disconnected since the transfer
+	heldSince := s.barrel.LastTransferTime()
+	disconnectedAt := heldSince
+	if agent != nil && agent.DisconnectedAt().After(disconnectedAt) {
+		disconnectedAt = agent.DisconnectedAt()
+	}
+	if nowFunc().Before(disconnectedAt.Add(s.reconnectGracePeriod)) {
+		return false, nil
+	}
+
+	if err := s.returnHolderToWaiting(holder); err != nil {
+		return false, err
+	}
+
+	message := fmt.Sprintf("Agent '%s' did not reconnect within %s, returning barrel to people", holder, s.reconnectGracePeriod)
+	if err := s.barrel.TransferTo("people", message); err != nil {
+		return false, fmt.Errorf("failed to reclaim barrel: %w", err)
+	}
+	s.yieldChainDepth = 0
+	s.barrel.resetRetries()
+	s.barrelChanged()
+	s.recordHoldTime(holder, heldSince)
+
+	if s.logger != nil {
+		s.logger.Warn("Barrel reclaimed from disconnected agent", map[string]interface{}{
+			"role":  holder,
+			"grace": s.reconnectGracePeriod.String(),
+		})
+	}
+	return true, nil
+}
+
+// ReassignBarrel moves in-progress work from a stuck agent to another without the holder's cooperation
+// The barrel keeps its last message, so the new holder receives the original task context
+// fromRole must hold the barrel; it may be disconnected. Group targets resolve as for yields
+func (s *SovietState) ReassignBarrel(fromRole, toRole string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barrel == nil {
+		return fmt.Errorf("no barrel set in soviet state: SetBarrel must be called before reassigning work")
+	}
+	if fromRole == "people" {
+		return fmt.Errorf("the people hold the barrel; yield it instead of reassigning")
+	}
+	if err := s.haltedError(); err != nil {
+		return err
+	}
+	if !s.barrel.IsHeldBy(fromRole) {
+		return fmt.Errorf("'%s' does not hold the barrel (held by '%s')", fromRole, s.barrel.CurrentHolder())
+	}
+
+	target, err := s.resolveYieldTarget(toRole)
+	if err != nil {
+		return err
+	}
+	if target == fromRole {
+		return fmt.Errorf("cannot reassign work from '%s' to itself", fromRole)
+	}
+	var targetAgent *AgentComrade
+	if target != "people" {
+		targetAgent = s.GetAgent(target)
+		if targetAgent == nil {
+			return fmt.Errorf("target agent '%s' is not registered", target)
+		}
+		if !targetAgent.IsWaiting() {
+			return fmt.Errorf("target agent '%s' is %s, must be waiting", target, targetAgent.State())
+		}
+		if !targetAgent.Type().CanHoldBarrel() {
+			return fmt.Errorf("target agent '%s' is an observer and cannot receive the barrel", target)
+		}
+		if s.IsBenched(target) {
+			return fmt.Errorf("target agent '%s' is benched by the circuit breaker", target)
+		}
+	}
+
+	payload := s.barrel.LastMessage()
+	metadata := s.barrel.LastTransfer().Metadata
+	heldSince := s.barrel.LastTransferTime()
+	if err := s.returnHolderToWaiting(fromRole); err != nil {
+		return err
+	}
+	if err := s.barrel.TransferTo(target, payload); err != nil {
+		return fmt.Errorf("failed to reassign barrel: %w", err)
+	}
+	s.barrel.setMetadata(metadata)
+	s.barrelChanged()
+	// The people directed this hand-off, so the agent-to-agent chain starts over
+	s.yieldChainDepth = 0
+	s.recordHoldTime(fromRole, heldSince)
+
+	if targetAgent != nil {
+		if err := s.handOver(targetAgent, payload); err != nil {
+			return fmt.Errorf("failed to activate target agent '%s': %w", target, err)
+		}
+	}
+
+	if s.logger != nil {
+		s.logger.Warn("Barrel reassigned by the people", map[string]interface{}{
+			"from_role": fromRole,
+			"to_role":   target,
+			"payload":   s.loggedPayload(payload),
+		})
+	}
+	return nil
+}
+
+// SetPipeline configures the ordered role sequence (nil removes the pipeline)
+func (s *SovietState) SetPipeline(pipeline *Pipeline) {
+	s.pipeline = pipeline
+}
+
+// Pipeline returns the configured pipeline, or nil if none is configured
+func (s *SovietState) Pipeline() *Pipeline {
+	return s.pipeline
+}
+
+// GetPipelineStatus returns the configured pipeline and the barrel's position within it
+// This implements the AgentService interface
+func (s *SovietState) GetPipelineStatus() PipelineStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	holder := s.barrelStatus()
+	status := PipelineStatus{
+		Roles:        []string{},
+		BarrelHolder: holder,
+		StageIndex:   -1,
+	}
+
+	if s.pipeline == nil {
+		return status
+	}
+
+	status.Roles = s.pipeline.Roles()
+	status.StageIndex = s.pipeline.IndexOf(holder)
+	if next, err := s.pipeline.Next(holder); err == nil {
+		status.NextRole = next
+		status.OnPipeline = true
+	}
+	return status
+}
+
+// SetRetryPolicy configures how failed work is requeued
+// maxRetries bounds retries per unit of work (0 disables requeueing); fallbacks map a failing
+// role to the role that should receive the retry instead of the failing role itself
+func (s *SovietState) SetRetryPolicy(maxRetries int, fallbacks map[string]string) error {
+	if maxRetries < 0 {
+		return fmt.Errorf("max retries cannot be negative: %d", maxRetries)
+	}
+
+	retryFallback := make(map[string]string, len(fallbacks))
+	for role, fallback := range fallbacks {
+		if role == "" || fallback == "" {
+			return fmt.Errorf("retry fallback roles cannot be empty")
+		}
+		if IsReservedRole(fallback) {
+			return fmt.Errorf("retry fallback cannot be reserved role '%s'", fallback)
+		}
+		retryFallback[role] = fallback
+	}
+
+	s.maxRetries = maxRetries
+	s.retryFallback = retryFallback
+	return nil
+}
+
+// MaxRetries returns the maximum number of retries for failed work
+func (s *SovietState) MaxRetries() int {
+	return s.maxRetries
+}
+
+// SetDefaultYieldMessages configures the payload delivered to an agent when a yield carries none
+// perRole overrides defaultMessage for specific target roles; an empty defaultMessage leaves other roles unchanged
+func (s *SovietState) SetDefaultYieldMessages(defaultMessage string, perRole map[string]string) error {
+	roleMessages := make(map[string]string, len(perRole))
+	for role, message := range perRole {
+		if role == "" {
+			return fmt.Errorf("default yield message role cannot be empty")
+		}
+		if IsReservedRole(role) {
+			return fmt.Errorf("default yield message cannot target reserved role '%s'", role)
+		}
+		if message == "" {
+			return fmt.Errorf("default yield message for role '%s' cannot be empty", role)
+		}
+		roleMessages[role] = message
+	}
+
+	s.defaultYieldMessage = defaultMessage
+	s.roleYieldMessages = roleMessages
+	return nil
+}
+
+// yieldPayload returns the payload delivered to toRole, substituting the configured default
+// Only truly empty payloads are replaced; anything the sender wrote, even whitespace, is kept
+func (s *SovietState) yieldPayload(toRole, payload string) string {
+	if payload != "" || toRole == "people" {
+		return payload
+	}
+	if message, exists := s.roleYieldMessages[toRole]; exists {
+		return message
+	}
+	return s.defaultYieldMessage
+}
+
+// retryTarget returns the role that should retry work failed by failedRole, if retries remain
+func (s *SovietState) retryTarget(failedRole string) (string, bool) {
+	if s.maxRetries == 0 || s.barrel == nil || s.barrel.RetryCount() >= s.maxRetries {
+		return "", false
+	}
+
+	if fallback, exists := s.retryFallback[failedRole]; exists {
+		if agent := s.GetAgent(fallback); agent != nil && agent.IsConnected() && agent.IsWaiting() && agent.Type().CanHoldBarrel() && !s.IsBenched(fallback) {
+			return fallback, true
+		}
+	}
+
+	if agent := s.GetAgent(failedRole); agent != nil && agent.IsConnected() && !s.IsBenched(failedRole) {
+		return failedRole, true
+	}
+	return "", false
+}
+
+// yieldSourceAgent moves the yielding barrel holder to waiting
+// An offered holder that yields has evidently received its activation, so the yield acknowledges it
+func (s *SovietState) yieldSourceAgent(role string) error {
+	agent := s.GetAgent(role)
+	if agent == nil {
+		return nil
+	}
+	if agent.IsOffered() {
+		if err := agent.AcceptOffer(); err != nil {
+			return fmt.Errorf("failed to acknowledge activation of agent '%s': %w", role, err)
+		}
+	}
+	if err := agent.Yield(); err != nil {
+		return fmt.Errorf("failed to yield agent '%s': %w", role, err)
+	}
+	return nil
+}
+
+// requeueFailedWork returns failed work to the people and immediately re-dispatches
+// the original task to the retry role
+func (s *SovietState) requeueFailedWork(message YieldMessage, retryRole string) error {
+	fromRole := message.FromRole()
+	task := s.barrel.LastMessage()
+	metadata := s.barrel.LastTransfer().Metadata
+
+	if err := s.yieldSourceAgent(fromRole); err != nil {
+		return err
+	}
+
+	failure := fmt.Sprintf("Work failed at '%s': %s", fromRole, message.Payload())
+	if err := s.barrel.TransferTo("people", failure); err != nil {
+		return err
+	}
+	s.barrel.setMetadata(message.Metadata())
+	s.updateYieldChainDepth(fromRole, "people")
+
+	s.barrel.incrementRetries()
+	if err := s.barrel.TransferTo(retryRole, task); err != nil {
+		return err
+	}
+	s.barrel.setMetadata(metadata)
+
+	if s.sender != nil {
+		if err := s.sender.SendActivation(retryRole, task); err != nil && s.logger != nil {
+			s.logger.Error("Failed to send activation message", map[string]interface{}{
+				"role":  retryRole,
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if s.logger != nil {
+		s.logger.Warn("Requeued failed work", map[string]interface{}{
+			"failed_role": fromRole,
+			"retry_role":  retryRole,
+			"retry_count": s.barrel.RetryCount(),
+			"max_retries": s.maxRetries,
+		})
+	}
+
+	if targetAgent := s.GetAgent(retryRole); targetAgent != nil {
+		if err := s.handOver(targetAgent, task); err != nil {
+			return fmt.Errorf("failed to activate retry agent '%s': %w", retryRole, err)
+		}
+	}
+	return nil
+}
+
+// SetActivationAckTimeout requires agents to acknowledge activations within the timeout
+// While pending, the target is reported as offered; 0 disables acknowledgments
+func (s *SovietState) SetActivationAckTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("activation ack timeout cannot be negative: %s", timeout)
+	}
+	s.activationAckTimeout = timeout
+	return nil
+}
+
+// ActivationAckTimeout returns the configured activation acknowledgment timeout
+func (s *SovietState) ActivationAckTimeout() time.Duration {
+	return s.activationAckTimeout
+}
+
+// SetMaxMessageAge sets how old a timestamped yield may be before it is rejected as stale
+// The value should cover network delay plus clock skew between agents and the server; 0 disables the guard
+func (s *SovietState) SetMaxMessageAge(maxAge time.Duration) error {
+	if maxAge < 0 {
+		return fmt.Errorf("max message age cannot be negative: %s", maxAge)
+	}
+	s.maxMessageAge = maxAge
+	return nil
+}
+
+// MaxMessageAge returns the configured maximum yield message age
+func (s *SovietState) MaxMessageAge() time.Duration {
+	return s.maxMessageAge
+}
+
+// SetRedactPayloads controls whether yield payloads are redacted in log output
+func (s *SovietState) SetRedactPayloads(redact bool) {
+	s.redactPayloads = redact
+}
+
+// loggedPayload returns the payload as it may appear in logs
+func (s *SovietState) loggedPayload(payload string) string {
+	if s.redactPayloads {
+		return RedactPayload(payload)
+	}
+	return payload
+}
+
+// handOver moves a target agent towards working after it receives the barrel
+// When acknowledgments are required the agent is only offered the barrel
+func (s *SovietState) handOver(agent *AgentComrade, payload string) error {
+	if s.activationAckTimeout > 0 {
+		return agent.Offer(payload)
+	}
+	return agent.Activate(payload)
+}
+
+// ForceAgentState is the people's escape hatch for agents whose state machine got wedged, and how
+// they take agents out of service. Every state is kept consistent with barrel ownership:
+// the holder can be forced to working, any other agent to waiting, which also resumes a paused or
+// failed agent. A waiting agent can be paused, and a working agent failed, see failAgent
+func (s *SovietState) ForceAgentState(role string, state AgentState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agent := s.GetAgent(role)
+	if agent == nil {
+		return fmt.Errorf("agent with role '%s' not found", role)
+	}
+
+	previous := agent.State()
+	holdsBarrel := s.IsBarrelHeldBy(role)
+	switch state {
+	case AgentStateWorking:
+		if !holdsBarrel {
+			return fmt.Errorf("cannot force '%s' to working: it does not hold the barrel", role)
+		}
+		agent.forceState(state)
+	case AgentStateWaiting:
+		if holdsBarrel {
+			return fmt.Errorf("cannot force '%s' to waiting: it holds the barrel, yield it to people instead", role)
+		}
+		if agent.IsPaused() || agent.IsErrored() {
+			if err := agent.Resume(); err != nil {
+				return err
+			}
+		} else {
+			agent.forceState(state)
+		}
+	case AgentStatePaused:
+		if holdsBarrel {
+			return fmt.Errorf("cannot pause '%s': it holds the barrel, yield it to people first", role)
+		}
+		if err := agent.Pause(); err != nil {
+			return err
+		}
+	case AgentStateError:
+		return s.failAgent(agent, "failed by the people")
+	default:
+		return fmt.Errorf("cannot force agent state to '%s': only waiting, working, paused and error are allowed", state)
+	}
+
+	if err := s.repo.Store(agent); err != nil {
+		return fmt.Errorf("failed to store agent: %w", err)
+	}
+
+	if s.logger != nil {
+		s.logger.Warn("Agent state forced by the people", map[string]interface{}{
+			"role":     role,
+			"previous": previous.String(),
+			"current":  state.String(),
+		})
+	}
+	return nil
+}
+
+// failAgent moves a working agent to the error state and hands the barrel it holds back to the
+// people, so its work is not left with an agent that can no longer yield. The agent receives no
+// work until the people resume it; the caller holds the lock
+func (s *SovietState) failAgent(agent *AgentComrade, reason string) error {
+	role := agent.Role()
+	if err := agent.Fail(reason); err != nil {
+		return err
+	}
+	if err := s.repo.Store(agent); err != nil {
+		return fmt.Errorf("failed to store agent: %w", err)
+	}
+
+	if s.IsBarrelHeldBy(role) {
+		heldSince := s.barrel.LastTransferTime()
+		message := fmt.Sprintf("Agent '%s' failed, returning barrel to people: %s", role, agent.FailureReason())
+		if err := s.barrel.TransferTo("people", message); err != nil {
+			return fmt.Errorf("failed to return barrel of failed agent '%s' to people: %w", role, err)
+		}
+		s.yieldChainDepth = 0
+		s.barrel.resetRetries()
+		s.barrelChanged()
+		s.recordHoldTime(role, heldSince)
+	}
+
+	if s.logger != nil {
+		s.logger.Warn("Agent failed", map[string]interface{}{
+			"role":   role,
+			"reason": agent.FailureReason(),
+		})
+	}
+	return nil
+}
+
+// UpdateAgentCapabilities replaces a registered agent's capabilities without re-registration
+// Duplicates are removed while preserving order; the agent's state and the barrel are untouched
+func (s *SovietState) UpdateAgentCapabilities(role string, capabilities []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validator.ValidateCapabilities(capabilities); err != nil {
+		return nil, err
+	}
+
+	agent := s.GetAgent(role)
+	if agent == nil {
+		return nil, fmt.Errorf("agent with role '%s' not found", role)
+	}
+
+	seen := make(map[string]bool, len(capabilities))
+	deduped := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if !seen[capability] {
+			seen[capability] = true
+			deduped = append(deduped, capability)
+		}
+	}
+
+	previous := agent.Capabilities()
+	agent.SetCapabilities(deduped)
+	if err := s.repo.Store(agent); err != nil {
+		return nil, fmt.Errorf("failed to store agent: %w", err)
+	}
+
+	if s.logger != nil {
+		s.logger.Info("Agent capabilities updated", map[string]interface{}{
+			"role":     role,
+			"previous": previous,
+			"current":  deduped,
+		})
+	}
+	return deduped, nil
+}
+
+// AcknowledgeActivation confirms that an agent received its activation
+// An offered barrel holder starts working; a holder that is already working is left unchanged
+func (s *SovietState) AcknowledgeActivation(role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agent := s.GetAgent(role)
+	if agent == nil {
+		return fmt.Errorf("agent with role '%s' not found", role)
+	}
+
+	if !s.IsBarrelHeldBy(role) {
+		return fmt.Errorf("agent '%s' does not hold the barrel", role)
+	}
+
+	if agent.IsWorking() {
+		return nil
+	}
+
+	if err := agent.AcceptOffer(); err != nil {
+		return err
+	}
+
+	if s.logger != nil {
+		s.logger.Info("Activation acknowledged", map[string]interface{}{
+			"role": role,
+		})
+	}
+	return nil
+}
+
+// ReclaimUnacknowledgedOffer returns the barrel to the people if the offered holder
+// did not acknowledge its activation in time. Returns true if the barrel was reclaimed
+func (s *SovietState) ReclaimUnacknowledgedOffer() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activationAckTimeout == 0 || s.barrel == nil || s.halt.Halted {
+		return false, nil
+	}
+
+	holder := s.barrel.CurrentHolder()
+	agent := s.GetAgent(holder)
+	if agent == nil || !agent.IsOffered() {
+		return false, nil
+	}
+
+	if nowFunc().Before(agent.OfferedAt().Add(s.activationAckTimeout)) {
+		return false, nil
+	}
+
+	if err := agent.WithdrawOffer(); err != nil {
+		return false, err
+	}
+
+	heldSince := s.barrel.LastTransferTime()
+	message := fmt.Sprintf("Activation not acknowledged by '%s' within %s", holder, s.activationAckTimeout)
+	if err := s.barrel.TransferTo("people", message); err != nil {
+		return false, fmt.Errorf("failed to reclaim barrel: %w", err)
+	}
+	s.yieldChainDepth = 0
+	s.barrel.resetRetries()
+	s.barrelChanged()
+	s.recordHoldTime(holder, heldSince)
+
+	if s.logger != nil {
+		s.logger.Warn("Barrel reclaimed after unacknowledged activation", map[string]interface{}{
+			"role":    holder,
+			"timeout": s.activationAckTimeout.String(),
+		})
+	}
+	return true, nil
+}
+
+// LastTransfer returns the most recent barrel transfer
+// Adapters use it to learn the concrete recipient after server-side routing
+func (s *SovietState) LastTransfer() (TransferRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barrel == nil {
+		return TransferRecord{}, false
+	}
+	return s.barrel.LastTransfer(), true
+}
+
+// TransferHistory returns the last limit barrel transfers, oldest first, or all of them when limit is 0
+// The result is empty, never nil, without a barrel
+func (s *SovietState) TransferHistory(limit int) []TransferRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barrel == nil {
+		return []TransferRecord{}
+	}
+	history := s.barrel.GetTransferHistory()
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history
+}
+
 // SetBarrel sets the barrel of gun for the soviet to manage
 func (s *SovietState) SetBarrel(barrel *BarrelOfGun) error {
 	if barrel == nil {
 		return fmt.Errorf("barrel cannot be nil")
 	}
 	s.barrel = barrel
+	s.publishedSequence = barrel.LastTransfer().Receipt.Sequence
 	return nil
 }
 
@@ -105,6 +985,34 @@ func (s *SovietState) GetBarrel() *BarrelOfGun {
 	return s.barrel
 }
 
+// SetPersistencePolicy chooses whether repository store failures fail the operation (strict, the default)
+// or are absorbed by keeping agents in memory until the repository recovers (best-effort)
+func (s *SovietState) SetPersistencePolicy(policy PersistencePolicy) error {
+	current, isBestEffort := s.repo.(*bestEffortRepository)
+
+	switch policy {
+	case PersistenceStrict:
+		if isBestEffort {
+			s.repo = current.inner
+		}
+	case PersistenceBestEffort:
+		if !isBestEffort {
+			s.repo = newBestEffortRepository(s.repo, s.logger)
+		}
+	default:
+		return fmt.Errorf("unknown persistence policy: %d", policy)
+	}
+	return nil
+}
+
+// PersistencePolicy returns the active persistence policy
+func (s *SovietState) PersistencePolicy() PersistencePolicy {
+	if _, isBestEffort := s.repo.(*bestEffortRepository); isBestEffort {
+		return PersistenceBestEffort
+	}
+	return PersistenceStrict
+}
+
 // UnregisterAgent removes an agent from the soviet
 func (s *SovietState) UnregisterAgent(role string) error {
 	if role == "" {
@@ -163,12 +1071,21 @@ func (s *SovietState) GetAgentRoles() []string {
 // GetRegisteredAgents returns a list of all currently registered agent roles
 // This implements the AgentService interface
 func (s *SovietState) GetRegisteredAgents() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.GetAgentRoles()
 }
 
 // GetAgentDetails returns detailed information about all registered agents including capabilities
 // This implements the AgentService interface
 func (s *SovietState) GetAgentDetails() []AgentDetails {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.agentDetails()
+}
+
+// agentDetails implements GetAgentDetails; the caller holds the lock
+func (s *SovietState) agentDetails() []AgentDetails {
 	agents, err := s.repo.GetAll()
 	if err != nil {
 		// Return empty slice if error - should not happen in normal operation
@@ -177,16 +1094,51 @@ func (s *SovietState) GetAgentDetails() []AgentDetails {
 	
 	details := make([]AgentDetails, 0, len(agents))
 	for _, agent := range agents {
-		details = append(details, AgentDetails{
-			Role:         agent.Role(),
-			Capabilities: agent.Capabilities(),
-			State:        agent.State(),
-			Connected:    agent.IsConnected(),
-		})
+		details = append(details, newAgentDetails(agent))
 	}
+
+	// Oldest registration first so the order does not depend on map iteration
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].RegistrationSeq < details[j].RegistrationSeq
+	})
 	return details
 }
 
+// newAgentDetails describes a single agent
+func newAgentDetails(agent *AgentComrade) AgentDetails {
+	return AgentDetails{
+		Role:            agent.Role(),
+		Type:            agent.Type(),
+		Description:     agent.Description(),
+		Weight:          agent.Weight(),
+		Capabilities:    agent.Capabilities(),
+		Tags:            agent.Tags(),
+		State:           agent.State(),
+		Connected:       agent.IsConnected(),
+		RegistrationSeq: agent.RegistrationSeq(),
+		InstanceID:      agent.InstanceID(),
+		LastSeenAt:      agent.LastSeenAt(),
+	}
+}
+
+// FindAgentsByCapability returns the roles of agents with a capability matching the pattern
+// Roles are ordered by registration so the oldest capable agent comes first
+func (s *SovietState) FindAgentsByCapability(pattern string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := []string{}
+	for _, detail := range s.agentDetails() {
+		for _, capability := range detail.Capabilities {
+			if CapabilityMatches(pattern, capability) {
+				roles = append(roles, detail.Role)
+				break
+			}
+		}
+	}
+	return roles
+}
+
 // CurrentBarrelHolder returns the role that currently holds the barrel
 func (s *SovietState) CurrentBarrelHolder() string {
 	if s.barrel == nil {
@@ -203,17 +1155,31 @@ func (s *SovietState) IsBarrelHeldBy(role string) bool {
 	return s.barrel.IsHeldBy(role)
 }
 
+// IsHolderUnregistered reports whether the barrel is held by a role that is not a registered agent
+// This happens after restoring a snapshot or when the holder is removed; the role resumes its work if
+// it registers again, or the people can reclaim the barrel with ReassignBarrel
+func (s *SovietState) IsHolderUnregistered() bool {
+	if s.barrel == nil || s.barrel.IsHeldBy("people") {
+		return false
+	}
+	return s.GetAgent(s.barrel.CurrentHolder()) == nil
+}
+
 // ProcessBarrelTransfer handles barrel transfer
+// fromRole is recorded as the sender and may be a people's representative such as "people:alice"
 func (s *SovietState) ProcessBarrelTransfer(fromRole, toRole, payload string) error {
 	if s.barrel == nil {
 		return fmt.Errorf("no barrel available for transfer")
 	}
 
-	return s.barrel.TransferTo(toRole, payload)
+	return s.barrel.TransferToAs(fromRole, toRole, payload)
 }
 
 // GetStats returns statistics about the current soviet state
 func (s *SovietState) GetStats() *SovietStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	agents, err := s.repo.GetAll()
 	if err != nil {
 		// Return stats with zero agents on error
@@ -229,6 +1195,10 @@ func (s *SovietState) GetStats() *SovietStats {
 			IsActive:            s.active,
 			CreatedAt:           s.createdAt,
 			DeactivatedAt:       s.deactivatedAt,
+			HoldTimes:           s.HoldTimes(),
+			HoldDurations:       s.HoldDurations(),
+			Rejections:          s.Rejections(),
+			Registrations:       s.Registrations(),
 		}
 	}
 	
@@ -253,6 +1223,10 @@ func (s *SovietState) GetStats() *SovietStats {
 		IsActive:            s.active,
 		CreatedAt:           s.createdAt,
 		DeactivatedAt:       s.deactivatedAt,
+		HoldTimes:           s.HoldTimes(),
+		HoldDurations:       s.HoldDurations(),
+		Rejections:          s.Rejections(),
+		Registrations:       s.Registrations(),
 	}
 }
 
@@ -260,62 +1234,90 @@ func (s *SovietState) GetStats() *SovietStats {
 
 // RegisterAgent registers a new agent or handles reconnection intelligently
 // This unified method handles both new registrations and reconnections automatically
-// Returns: (shouldResume, lastMessage, error) where shouldResume indicates if agent should start working
-func (s *SovietState) RegisterAgent(agent *AgentComrade) (bool, string, error) {
+// Returns: (shouldResume, lastMessage, lastFromRole, error) where shouldResume indicates if agent should start working
+// and lastFromRole is the role that handed the barrel over with lastMessage
+func (s *SovietState) RegisterAgent(agent *AgentComrade) (bool, string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if agent == nil {
-		return false, "", fmt.Errorf("agent cannot be nil")
+		return false, "", "", fmt.Errorf("agent cannot be nil")
 	}
 
 	role := agent.Role()
-
-	// Check if an agent with this role already exists
-	if existingAgent := s.GetAgent(role); existingAgent != nil {
-		// Disconnect the existing agent (replacement behavior)
-		existingAgent.SetConnected(false)
-
-		// Unregister the existing agent to make room for the new one
-		err := s.UnregisterAgent(role)
-		if err != nil {
-			return false, "", fmt.Errorf("failed to unregister existing agent: %w", err)
-		}
-	}
-
-	// Register the new agent
-	err := s.registerAgent(agent)
-	if err != nil {
-		return false, "", fmt.Errorf("failed to register agent: %w", err)
+	if err := s.validator.ValidateRegistrationRole(role); err != nil {
+		s.recordRejection(ValidationCodeInvalidRole)
+		return false, "", "", newValidationError(ValidationCodeInvalidRole, err)
 	}
-
-	if s.logger != nil {
-		s.logger.Info("Agent registered successfully", map[string]interface{}{
-			"role": role,
-		})
+	if err := s.checkReservation(agent); err != nil {
+		s.recordRejection(ValidationCodeInvalidRole)
+		return false, "", "", newValidationError(ValidationCodeInvalidRole, err)
 	}
 
+	// The new agent is fully prepared before it is stored, so nobody sees it half registered
 	// Set the agent as connected and ensure it's in waiting state initially
 	agent.SetConnected(true)
 	// Only transition to waiting if not already waiting (new agents start in waiting state)
 	if agent.State() != AgentStateWaiting {
-		err = agent.TransitionTo(AgentStateWaiting)
-		if err != nil {
-			return false, "", fmt.Errorf("failed to transition agent to waiting state: %w", err)
+		if err := agent.TransitionTo(AgentStateWaiting); err != nil {
+			return false, "", "", fmt.Errorf("failed to transition agent to waiting state: %w", err)
 		}
 	}
 
 	// Check if this agent role should resume work (if they hold the barrel)
-	barrel := s.GetBarrel()
-	if barrel != nil && barrel.IsHeldBy(role) {
+	shouldResume, lastMessage, lastFromRole := false, "", ""
+	if barrel := s.GetBarrel(); barrel != nil && barrel.IsHeldBy(role) {
 		// Agent should resume work - activate them
-		lastMessage := barrel.LastMessage()
-		err = agent.TransitionTo(AgentStateWorking)
+		shouldResume, lastMessage, lastFromRole = true, barrel.LastMessage(), barrel.LastFromRole()
+		var err error
+		if s.activationAckTimeout > 0 {
+			err = agent.Offer(lastMessage)
+		} else {
+			err = agent.TransitionTo(AgentStateWorking)
+		}
 		if err != nil {
-			return false, "", fmt.Errorf("failed to transition agent to working state: %w", err)
+			return false, "", "", fmt.Errorf("failed to transition agent to working state: %w", err)
 		}
-		return true, lastMessage, nil
 	}
 
-	// Agent doesn't hold barrel, remains in waiting state
-	return false, "", nil
+	// Every registration, including replacements, joins the end of the seniority order
+	s.registrationSeq++
+	agent.setRegistrationSeq(s.registrationSeq)
+
+	// Storing over an existing agent swaps it for the new one in a single step, so a yield
+	// racing a reconnection finds either agent and never an unregistered role
+	existingAgent := s.GetAgent(role)
+	evictedConnected := existingAgent != nil && existingAgent.IsConnected()
+	if err := s.repo.Store(agent); err != nil {
+		return false, "", "", fmt.Errorf("failed to register agent: %w", err)
+	}
+	if existingAgent != nil && existingAgent != agent {
+		// Disconnect the replaced agent
+		existingAgent.SetConnected(false)
+	}
+	s.recordRegistration(existingAgent != nil, shouldResume)
+	s.publishAgentRegistered(agent)
+
+	if s.logger != nil {
+		fields := map[string]interface{}{
+			"role": role,
+		}
+		if instanceID := agent.InstanceID(); instanceID != "" {
+			fields["instance_id"] = instanceID
+		}
+		switch {
+		case existingAgent != nil && IsInstanceTakeover(existingAgent.InstanceID(), agent.InstanceID()):
+			fields["evicted_instance_id"] = existingAgent.InstanceID()
+			fields["evicted_was_connected"] = evictedConnected
+			s.logger.Warn("Agent role taken over by a different instance", fields)
+		case existingAgent != nil && existingAgent.InstanceID() != "" && existingAgent.InstanceID() == agent.InstanceID():
+			s.logger.Info("Agent instance resumed", fields)
+		default:
+			s.logger.Info("Agent registered successfully", fields)
+		}
+	}
+
+	return shouldResume, lastMessage, lastFromRole, nil
 }
 
 // registerAgent is the internal registration method (renamed to avoid conflict)
@@ -345,6 +1347,9 @@ func (s *SovietState) SimpleRegisterAgent(agent *AgentComrade) error {
 // DeregisterAgent removes an agent from the collective
 // If the agent holds the barrel, it's transferred back to the people
 func (s *SovietState) DeregisterAgent(role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.IsAgentRegistered(role) {
 		return fmt.Errorf("agent with role '%s' not found", role)
 	}
@@ -354,10 +1359,14 @@ func (s *SovietState) DeregisterAgent(role string) error {
 		// Transfer barrel back to the people
 		barrel := s.GetBarrel()
 		if barrel != nil {
+			heldSince := barrel.LastTransferTime()
 			err := barrel.TransferTo("people", fmt.Sprintf("Agent '%s' deregistered, returning barrel to people", role))
 			if err != nil {
 				return fmt.Errorf("failed to transfer barrel to people during deregistration: %w", err)
 			}
+			s.yieldChainDepth = 0
+			barrel.resetRetries()
+			s.recordHoldTime(role, heldSince)
 		}
 	}
 
@@ -366,6 +1375,8 @@ func (s *SovietState) DeregisterAgent(role string) error {
 	if err != nil {
 		return fmt.Errorf("failed to deregister agent: %w", err)
 	}
+	s.barrelChanged()
+	s.publishAgentDeregistered(role)
 
 	if s.logger != nil {
 		s.logger.Info("Agent deregistered successfully", map[string]interface{}{
@@ -378,29 +1389,108 @@ func (s *SovietState) DeregisterAgent(role string) error {
 
 // ProcessYield handles yield requests and manages barrel transfers
 func (s *SovietState) ProcessYield(message YieldMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Every transfer path below assumes a barrel exists
+	if s.barrel == nil {
+		return fmt.Errorf("no barrel set in soviet state: SetBarrel must be called before processing yields")
+	}
+
+	// A halt freezes the barrel, whoever sends the yield
+	if err := s.haltedError(); err != nil {
+		s.recordRejection(ValidationCodeHalted)
+		return err
+	}
+
+	// Group and capability targets resolve to a concrete role, which is what the history records
+	target, err := s.resolveYieldTarget(message.ToRole())
+	if err != nil {
+		s.recordRejection(ValidationCodeInvalidTarget)
+		return err
+	}
+	if target != message.ToRole() {
+		if s.logger != nil {
+			s.logger.Info("Resolved yield target", map[string]interface{}{
+				"target": message.ToRole(),
+				"role":   target,
+			})
+		}
+		message = message.withToRole(target)
+	}
+
 	// Use the protocol validator for comprehensive validation
 	if err := s.validator.ValidateYieldWorkflow(message); err != nil {
+		s.recordRejection(rejectionCode(err))
+		return err
+	}
+
+	// The sender's allowance is only spent once the barrel has moved
+	if err := s.yieldRateError(message.FromRole()); err != nil {
+		s.recordRejection(ValidationCodeRateLimited)
 		return err
 	}
 
 	fromRole := message.FromRole()
 	toRole := message.ToRole()
 	payload := message.Payload()
-
-	// Get the source agent and transition it to waiting
-	sourceAgent := s.GetAgent(fromRole)
-	if sourceAgent != nil {
-		err := sourceAgent.Yield() // This transitions the agent to waiting state
-		if err != nil {
-			return fmt.Errorf("failed to yield agent '%s': %w", fromRole, err)
+	heldSince := s.barrel.LastTransferTime()
+	s.recordYieldOutcome(fromRole, message.Failed())
+
+	// Failed work is requeued while the retry policy allows it
+	if message.Failed() {
+		if retryRole, ok := s.retryTarget(fromRole); ok {
+			if err := s.requeueFailedWork(message, retryRole); err != nil {
+				return err
+			}
+			s.spendYield(fromRole)
+			s.barrel.setExpectedDuration(message.ExpectedDuration())
+			s.recordHoldTime(fromRole, heldSince)
+			s.barrelChanged()
+			return nil
+		}
+		if s.maxRetries > 0 && s.logger != nil {
+			s.logger.Error("Work failed terminally after exhausting retries", map[string]interface{}{
+				"role":        fromRole,
+				"retry_count": s.barrel.RetryCount(),
+				"payload":     s.loggedPayload(payload),
+			})
 		}
 	}
 
-	// Use SovietState to handle barrel transfer
-	err := s.ProcessBarrelTransfer(fromRole, toRole, payload)
-	if err != nil {
-		return err
+	payload = s.yieldPayload(toRole, payload)
+
+	holder := s.barrel.CurrentHolder()
+	if fromRole == "people" && holder != "people" {
+		// The people intervening take the barrel from its holder, which stops working on it
+		if toRole == holder {
+			return fmt.Errorf("cannot transfer to same role: %s", toRole)
+		}
+		if err := s.returnHolderToWaiting(holder); err != nil {
+			return err
+		}
+		if err := s.barrel.transferFrom(message.Sender(), toRole, payload); err != nil {
+			return err
+		}
+	} else {
+		// Transition the source agent to waiting
+		if err := s.yieldSourceAgent(fromRole); err != nil {
+			return err
+		}
+
+		// Use SovietState to handle barrel transfer
+		err = s.ProcessBarrelTransfer(message.Sender(), toRole, payload)
+		if err != nil {
+			return err
+		}
 	}
+	s.spendYield(fromRole)
+	s.updateYieldChainDepth(fromRole, toRole)
+	s.barrel.resetRetries()
+	s.barrel.setExpectedDuration(message.ExpectedDuration())
+	s.barrel.setMetadata(message.Metadata())
+	s.recordHoldTime(holder, heldSince)
+	s.barrelChanged()
 
 	// Handle external operations if dependencies are available
 
@@ -421,7 +1511,7 @@ func (s *SovietState) ProcessYield(message YieldMessage) error {
 		s.logger.Info("Barrel transferred successfully", map[string]interface{}{
 			"from_role": fromRole,
 			"to_role":   toRole,
-			"payload":   payload,
+			"payload":   s.loggedPayload(payload),
 		})
 	}
 
@@ -429,7 +1519,7 @@ func (s *SovietState) ProcessYield(message YieldMessage) error {
 	if toRole != "people" {
 		targetAgent := s.GetAgent(toRole)
 		if targetAgent != nil {
-			err := targetAgent.Activate(payload) // This transitions the agent to working state
+			err := s.handOver(targetAgent, payload) // This transitions the agent to working (or offered) state
 			if err != nil {
 				return fmt.Errorf("failed to activate target agent '%s': %w", toRole, err)
 			}
@@ -439,8 +1529,50 @@ func (s *SovietState) ProcessYield(message YieldMessage) error {
 	return nil
 }
 
+// ValidateYield reports every problem that would make ProcessYield reject the message, without transferring anything
+// Group targets are resolved first, as ProcessYield does; an empty result means the yield would be accepted
+// This implements the AgentService interface
+func (s *SovietState) ValidateYield(message YieldMessage) []ValidationError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barrel == nil {
+		return []ValidationError{{
+			Code:    ValidationCodeInvalidMessage,
+			Message: "no barrel set in soviet state: SetBarrel must be called before processing yields",
+		}}
+	}
+
+	problems := []ValidationError{}
+	if err := s.haltedError(); err != nil {
+		problems = append(problems, err.(ValidationError))
+	}
+	target, resolveErr := s.resolveYieldTarget(message.ToRole())
+	if resolveErr != nil {
+		problems = append(problems, newValidationError(ValidationCodeInvalidTarget, resolveErr))
+	} else {
+		message = message.withToRole(target)
+	}
+
+	for _, err := range s.validator.GetValidationErrors(message) {
+		problem := err.(ValidationError)
+		// An unresolvable group is already reported; its name is not an agent either
+		if resolveErr != nil && problem.Code == ValidationCodeInvalidTarget {
+			continue
+		}
+		problems = append(problems, problem)
+	}
+	if err := s.yieldRateError(message.FromRole()); err != nil {
+		problems = append(problems, err.(ValidationError))
+	}
+	return problems
+}
+
 // GetAgentState returns the current state of an agent
 func (s *SovietState) GetAgentState(role string) (AgentState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	agent := s.GetAgent(role)
 	if agent == nil {
 		return AgentStateWaiting, fmt.Errorf("agent with role '%s' not found", role)
@@ -451,6 +1583,13 @@ func (s *SovietState) GetAgentState(role string) (AgentState, error) {
 
 // GetBarrelStatus returns the role that currently holds the barrel
 func (s *SovietState) GetBarrelStatus() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.barrelStatus()
+}
+
+// barrelStatus implements GetBarrelStatus; the caller holds the lock
+func (s *SovietState) barrelStatus() string {
 	barrel := s.GetBarrel()
 	if barrel == nil {
 		return "people" // Default to people if no barrel
@@ -460,17 +1599,35 @@ func (s *SovietState) GetBarrelStatus() string {
 
 // QueryStatus returns the current status of the collective including all agents and barrel state
 func (s *SovietState) QueryStatus() StatusResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	agentStates := make(map[string]AgentState)
 	connectedAgents := make(map[string]bool)
+	agentCapabilities := make(map[string][]string)
+	registrationSeqs := make(map[string]uint64)
+	agentWeights := make(map[string]int)
+	agentInstances := make(map[string]string)
+	staleness := s.staleness()
 
 	agents, err := s.repo.GetAll()
 	if err != nil {
 		// Return empty status on error
 		return StatusResponse{
-			BarrelHolder:     s.GetBarrelStatus(),
-			RegisteredAgents: []string{},
-			AgentStates:      agentStates,
-			ConnectedAgents:  connectedAgents,
+			BarrelHolder:       s.barrelStatus(),
+			RegisteredAgents:   []string{},
+			AgentStates:        agentStates,
+			ConnectedAgents:    connectedAgents,
+			AgentCapabilities:  agentCapabilities,
+			RegistrationSeqs:   registrationSeqs,
+			AgentWeights:       agentWeights,
+			AgentInstances:     agentInstances,
+			AverageHolds:       s.AverageHolds(),
+			YieldChainDepth:    s.yieldChainDepth,
+			ExpectedDuration:   staleness.ExpectedDuration,
+			OverExpected:       staleness.OverExpected,
+			BenchedAgents:      s.BenchedRoles(),
+			HolderUnregistered: s.IsHolderUnregistered(),
 		}
 	}
 
@@ -478,12 +1635,34 @@ func (s *SovietState) QueryStatus() StatusResponse {
 		role := agent.Role()
 		agentStates[role] = agent.State()
 		connectedAgents[role] = agent.IsConnected()
+		agentCapabilities[role] = agent.Capabilities()
+		registrationSeqs[role] = agent.RegistrationSeq()
+		agentWeights[role] = agent.Weight()
+		if instanceID := agent.InstanceID(); instanceID != "" {
+			agentInstances[role] = instanceID
+		}
 	}
 
 	return StatusResponse{
-		BarrelHolder:     s.GetBarrelStatus(),
-		RegisteredAgents: s.GetAgentRoles(),
-		AgentStates:      agentStates,
-		ConnectedAgents:  connectedAgents,
+		BarrelHolder:       s.barrelStatus(),
+		RegisteredAgents:   s.GetAgentRoles(),
+		AgentStates:        agentStates,
+		ConnectedAgents:    connectedAgents,
+		AgentCapabilities:  agentCapabilities,
+		RegistrationSeqs:   registrationSeqs,
+		AgentWeights:       agentWeights,
+		AgentInstances:     agentInstances,
+		AverageHolds:       s.AverageHolds(),
+		YieldChainDepth:    s.yieldChainDepth,
+		ExpectedDuration:   staleness.ExpectedDuration,
+		OverExpected:       staleness.OverExpected,
+		BenchedAgents:      s.BenchedRoles(),
+		HolderUnregistered: s.IsHolderUnregistered(),
 	}
 }
+
+// Ensure SovietState implements both ports the TCP server is built on
+var (
+	_ SovietService = (*SovietState)(nil)
+	_ AgentService  = (*SovietState)(nil)
+)
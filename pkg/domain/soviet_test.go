@@ -1,9 +1,14 @@
 package domain
 
 import (
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Helper function to create soviet with repository for tests
@@ -207,3 +212,178 @@ func TestSovietState_GetStats(t *testing.T) {
 	assert.Equal(t, 2, stats.TotalAgents)
 	assert.Equal(t, 1, stats.ConnectedAgents)
 }
+
+func TestSovietState_FindAgentsByCapability(t *testing.T) {
+	soviet := newTestSoviet()
+	for _, agent := range []*AgentComrade{
+		NewAgentComrade("unit-tester", []string{"test/unit"}),
+		NewAgentComrade("developer", []string{"code"}),
+		NewAgentComrade("api-tester", []string{"test/integration"}),
+	} {
+		_, _, _, err := soviet.RegisterAgent(agent)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"unit-tester", "api-tester"}, soviet.FindAgentsByCapability("test/*"))
+	assert.Equal(t, []string{"developer"}, soviet.FindAgentsByCapability("code"))
+	assert.Empty(t, soviet.FindAgentsByCapability("test"))
+	assert.Empty(t, soviet.FindAgentsByCapability("deploy/*"))
+}
+
+func TestSovietState_ReassignBarrel(t *testing.T) {
+	soviet := newTestSoviet()
+	barrel := NewBarrelOfGun()
+	assert.NoError(t, soviet.SetBarrel(barrel))
+	for _, role := range []string{"developer", "senior", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"code"}))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Implement login")))
+	assert.NoError(t, soviet.DisconnectAgent("developer"))
+
+	// Only the actual holder's work can be reassigned
+	err := soviet.ReassignBarrel("tester", "senior")
+	assert.ErrorContains(t, err, "does not hold the barrel")
+	assert.ErrorContains(t, soviet.ReassignBarrel("people", "senior"), "yield it instead")
+	assert.ErrorContains(t, soviet.ReassignBarrel("developer", "developer"), "to itself")
+	assert.ErrorContains(t, soviet.ReassignBarrel("developer", "ghost"), "not registered")
+	assert.True(t, soviet.IsBarrelHeldBy("developer"))
+
+	// The disconnected holder's work moves on with its original message
+	assert.NoError(t, soviet.ReassignBarrel("developer", "senior"))
+	assert.True(t, soviet.IsBarrelHeldBy("senior"))
+	assert.Equal(t, "Implement login", barrel.LastMessage())
+	assert.Equal(t, AgentStateWorking, soviet.GetAgent("senior").State())
+	assert.Equal(t, AgentStateWaiting, soviet.GetAgent("developer").State())
+
+	transfer, ok := soviet.LastTransfer()
+	assert.True(t, ok)
+	assert.Equal(t, "developer", transfer.FromRole)
+	assert.Equal(t, "senior", transfer.ToRole)
+
+	// Work can also be handed back to the people
+	assert.NoError(t, soviet.ReassignBarrel("senior", "people"))
+	assert.True(t, soviet.IsBarrelHeldBy("people"))
+}
+
+func TestSovietState_TransferHistory(t *testing.T) {
+	soviet := newTestSoviet()
+	assert.NotNil(t, soviet.TransferHistory(0), "no barrel still yields an empty history")
+	assert.Empty(t, soviet.TransferHistory(0))
+
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", nil))
+	require.NoError(t, err)
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", "people", "Built")))
+
+	history := soviet.TransferHistory(0)
+	require.Len(t, history, 3)
+	assert.Equal(t, DefaultInitialMessage, history[0].Message)
+
+	last := soviet.TransferHistory(2)
+	require.Len(t, last, 2)
+	assert.Equal(t, "Build it", last[0].Message)
+	assert.Equal(t, "Built", last[1].Message)
+	assert.Len(t, soviet.TransferHistory(10), 3)
+}
+
+// yieldingRepository gives up the processor around every write, so concurrent readers run
+// between the steps of whatever operation is writing
+type yieldingRepository struct {
+	*MemoryAgentRepository
+}
+
+func (r yieldingRepository) Store(agent *AgentComrade) error {
+	runtime.Gosched()
+	defer runtime.Gosched()
+	return r.MemoryAgentRepository.Store(agent)
+}
+
+func (r yieldingRepository) Delete(role string) error {
+	runtime.Gosched()
+	defer runtime.Gosched()
+	return r.MemoryAgentRepository.Delete(role)
+}
+
+// Run with -race: a yield validated while its target reconnects must see the old or the new agent
+func TestSovietState_RegisterAgent_ReplacementKeepsRoleRegistered(t *testing.T) {
+	soviet := NewSovietState(yieldingRepository{NewMemoryAgentRepository()})
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"coding"}))
+	require.NoError(t, err)
+
+	const replacements = 500
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i := 0; i < replacements; i++ {
+			if _, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"coding"})); err != nil {
+				t.Errorf("replacement %d failed: %v", i, err)
+				return
+			}
+		}
+	}()
+
+	yield := NewYieldMessage("people", "developer", "Implement the feature")
+	for validated := 0; ; validated++ {
+		select {
+		case <-done:
+			wg.Wait()
+			assert.Empty(t, soviet.ValidateYield(yield))
+			assert.Positive(t, validated)
+			return
+		default:
+		}
+		for _, problem := range soviet.ValidateYield(yield) {
+			if strings.Contains(problem.Message, "not found") || strings.Contains(problem.Message, "not connected") {
+				t.Fatalf("yield saw the role mid-replacement: %s", problem.Message)
+			}
+		}
+		runtime.Gosched()
+	}
+}
+
+// Run with -race: the TCP adapter's sweeper reclaims barrels while connections yield them
+func TestSovietState_SweeperRunsAlongsideYields(t *testing.T) {
+	soviet := NewSovietState(yieldingRepository{NewMemoryAgentRepository()})
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	require.NoError(t, soviet.SetBarrelTTL(time.Nanosecond))
+	_, _, _, err := soviet.RegisterAgent(NewAgentComrade("developer", []string{"coding"}))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := soviet.ReclaimExpiredBarrel(); err != nil {
+				t.Errorf("sweep failed: %v", err)
+				return
+			}
+			soviet.QueryStatus()
+			runtime.Gosched()
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		// The sweeper may take the barrel back at any point, so only the hand-off is checked
+		if err := soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")); err != nil {
+			t.Fatalf("yield %d failed: %v", i, err)
+		}
+		soviet.ProcessYield(NewYieldMessage("developer", "people", "Built"))
+	}
+	close(done)
+	wg.Wait()
+	assert.True(t, soviet.IsBarrelHeldBy("people"))
+	assert.Equal(t, AgentStateWaiting, soviet.GetAgent("developer").State())
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxTags is the most tags an agent may carry
+const MaxTags = 32
+
+// Tags returns a copy of the agent's key/value tags, empty if it gave none
+func (a *AgentComrade) Tags() map[string]string {
+	tags := make(map[string]string, len(a.tags))
+	for key, value := range a.tags {
+		tags[key] = value
+	}
+	return tags
+}
+
+// SetTags replaces the agent's key/value tags, e.g. region=us-east or gpu=true
+// Keys and values are trimmed; keys must be non-blank and may not contain '=' or ','
+func (a *AgentComrade) SetTags(tags map[string]string) error {
+	if err := ValidateTags(tags); err != nil {
+		return err
+	}
+	trimmed := make(map[string]string, len(tags))
+	for key, value := range tags {
+		trimmed[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	a.tags = trimmed
+	return nil
+}
+
+// ValidateTags checks that tags fit within MaxTags and have keys that can be written as key=value
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > MaxTags {
+		return fmt.Errorf("agent has %d tags (max: %d)", len(tags), MaxTags)
+	}
+	seen := make(map[string]bool, len(tags))
+	for key := range tags {
+		trimmed := strings.TrimSpace(key)
+		if trimmed == "" {
+			return fmt.Errorf("tag key cannot be empty")
+		}
+		if strings.ContainsAny(trimmed, "=,") {
+			return fmt.Errorf("tag key %q cannot contain '=' or ','", key)
+		}
+		if seen[trimmed] {
+			return fmt.Errorf("duplicate tag key %q", trimmed)
+		}
+		seen[trimmed] = true
+	}
+	return nil
+}
+
+// ParseTags parses comma-separated key=value pairs such as "region=us-east,gpu=true"
+// A pair without '=' is a tag with an empty value
+func ParseTags(list string) (map[string]string, error) {
+	tags := make(map[string]string)
+	if strings.TrimSpace(list) == "" {
+		return tags, nil
+	}
+	for _, pair := range strings.Split(list, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", strings.TrimSpace(pair))
+		}
+		if _, exists := tags[key]; exists {
+			return nil, fmt.Errorf("duplicate tag key %q", key)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, ValidateTags(tags)
+}
+
+// FindAgentsByTag returns the roles of agents tagged with key, oldest registration first
+// An empty value matches every agent carrying the key, whatever its value
+func (s *SovietState) FindAgentsByTag(key, value string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	roles := []string{}
+	for _, detail := range s.agentDetails() {
+		if tagged, exists := detail.Tags[key]; exists && (value == "" || tagged == value) {
+			roles = append(roles, detail.Role)
+		}
+	}
+	return roles
+}
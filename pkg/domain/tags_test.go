@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentComrade_SetTags(t *testing.T) {
+	agent := NewAgentComrade("developer", nil)
+	assert.Empty(t, agent.Tags())
+
+	require.NoError(t, agent.SetTags(map[string]string{" region ": " us-east ", "gpu": "true"}))
+	assert.Equal(t, map[string]string{"region": "us-east", "gpu": "true"}, agent.Tags())
+
+	// Tags are returned as a copy
+	agent.Tags()["region"] = "eu-west"
+	assert.Equal(t, "us-east", agent.Tags()["region"])
+
+	assert.Error(t, agent.SetTags(map[string]string{" ": "x"}))
+	assert.Error(t, agent.SetTags(map[string]string{"a=b": "x"}))
+	assert.Error(t, agent.SetTags(map[string]string{"gpu": "true", "gpu ": "false"}))
+	assert.Equal(t, map[string]string{"region": "us-east", "gpu": "true"}, agent.Tags(), "a rejected update keeps the old tags")
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags("region=us-east, gpu=true,spot")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"region": "us-east", "gpu": "true", "spot": ""}, tags)
+
+	tags, err = ParseTags("")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	_, err = ParseTags("=us-east")
+	assert.Error(t, err)
+	_, err = ParseTags("gpu=true,gpu=false")
+	assert.Error(t, err)
+}
+
+func TestSovietState_FindAgentsByTag(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+
+	for role, tags := range map[string]map[string]string{
+		"developer": {"region": "us-east", "gpu": "true"},
+		"tester":    {"region": "eu-west"},
+		"reviewer":  {},
+	} {
+		agent := NewAgentComrade(role, nil)
+		require.NoError(t, agent.SetTags(tags))
+		_, _, _, err := soviet.RegisterAgent(agent)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"developer"}, soviet.FindAgentsByTag("region", "us-east"))
+	assert.Equal(t, []string{"developer"}, soviet.FindAgentsByTag("gpu", ""))
+	assert.ElementsMatch(t, []string{"developer", "tester"}, soviet.FindAgentsByTag(" region ", ""))
+	assert.Empty(t, soviet.FindAgentsByTag("region", "ap-south"))
+	assert.Empty(t, soviet.FindAgentsByTag("zone", ""))
+
+	for _, detail := range soviet.GetAgentDetails() {
+		if detail.Role == "tester" {
+			assert.Equal(t, map[string]string{"region": "eu-west"}, detail.Tags)
+		}
+	}
+}
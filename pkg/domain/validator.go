@@ -2,12 +2,70 @@ package domain
 
 import (
 	"fmt"
+	"time"
 )
 
+// reservedRoles are protocol identities that clients can never register as
+// "people" is the supreme authority, "soviet" is the Central Committee's own sender identity
+// and "next" is the yield target naming the following pipeline stage
+var reservedRoles = map[string]bool{
+	"people":   true,
+	"soviet":   true,
+	NextTarget: true,
+}
+
+// IsReservedRole checks if a role name is reserved for internal protocol use
+// Identities of people's representatives such as "people:alice" are reserved as well
+func IsReservedRole(role string) bool {
+	return reservedRoles[role] || IsPeopleIdentity(role)
+}
+
+// Validation error codes identify which rule a yield broke
+const (
+	ValidationCodeInvalidMessage     = "INVALID_MESSAGE"
+	ValidationCodeStaleMessage       = "STALE_MESSAGE"
+	ValidationCodeNotBarrelHolder    = "NOT_BARREL_HOLDER"
+	ValidationCodeInvalidTarget      = "INVALID_TARGET"
+	ValidationCodeStateInconsistency = "STATE_INCONSISTENCY"
+	ValidationCodeChainDepthExceeded = "CHAIN_DEPTH_EXCEEDED"
+	ValidationCodeInvalidRole        = "INVALID_ROLE"
+	ValidationCodeClockSkew          = "CLOCK_SKEW"
+	ValidationCodeHalted             = "HALTED"
+	ValidationCodeMissingCapability  = "MISSING_CAPABILITY"
+	ValidationCodePolicyViolation    = "POLICY_VIOLATION"
+	ValidationCodeRateLimited        = "RATE_LIMITED"
+)
+
+// ValidationError is a single validation problem with a machine-readable code
+type ValidationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the human-readable message
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// newValidationError attaches a code to a validation failure
+func newValidationError(code string, err error) ValidationError {
+	return ValidationError{Code: code, Message: err.Error()}
+}
+
+// YieldRule is a site-specific yield policy checked after the built-in rules, e.g. that the
+// reviewer always hands the barrel back to the people. A rule rejects a yield by returning an
+// error; a ValidationError keeps its code, any other error is reported as POLICY_VIOLATION.
+// Rules run while the soviet is locked: they may read it through methods such as GetAgent, but
+// calling its SovietService or AgentService methods would deadlock
+type YieldRule func(message YieldMessage, soviet *SovietState) error
+
 // ProtocolValidator enforces revolutionary discipline and validation rules
 // It provides comprehensive validation for yield messages and agent states
 type ProtocolValidator struct {
 	soviet *SovietState
+
+	// rules are the custom yield rules in the order they were added
+	rules []YieldRule
 }
 
 // NewProtocolValidator creates a new protocol validator with the given soviet state
@@ -20,6 +78,27 @@ func NewProtocolValidator(soviet *SovietState) *ProtocolValidator {
 	}
 }
 
+// AddRule adds a custom yield rule, checked after the built-in rules and any rule added before it
+func (v *ProtocolValidator) AddRule(rule YieldRule) {
+	if rule == nil {
+		panic("yield rule cannot be nil")
+	}
+	v.rules = append(v.rules, rule)
+}
+
+// AddYieldRule adds a custom rule that ProcessYield and ValidateYield apply after the built-in rules
+func (s *SovietState) AddYieldRule(rule YieldRule) {
+	s.validator.AddRule(rule)
+}
+
+// ruleError attaches POLICY_VIOLATION to a custom rule's error unless it carries a code already
+func ruleError(err error) ValidationError {
+	if validationErr, ok := err.(ValidationError); ok {
+		return validationErr
+	}
+	return newValidationError(ValidationCodePolicyViolation, err)
+}
+
 // ValidateYieldMessage validates the structure and content of a yield message
 func (v *ProtocolValidator) ValidateYieldMessage(message YieldMessage) error {
 	fromRole := message.FromRole()
@@ -34,6 +113,11 @@ func (v *ProtocolValidator) ValidateYieldMessage(message YieldMessage) error {
 		return fmt.Errorf("to_role cannot be empty")
 	}
 
+	// The soviet identity is used only by the Central Committee itself
+	if fromRole == "soviet" || toRole == "soviet" {
+		return fmt.Errorf("role 'soviet' is reserved and cannot take part in a yield")
+	}
+
 	// Check if message is valid (uses the domain's IsValid method)
 	if !message.IsValid() {
 		return fmt.Errorf("invalid yield message: missing required fields")
@@ -44,6 +128,42 @@ func (v *ProtocolValidator) ValidateYieldMessage(message YieldMessage) error {
 		return fmt.Errorf("agent cannot yield to itself: %s", fromRole)
 	}
 
+	if err := ValidateMetadata(message.metadata); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateRegistrationRole validates that a role may be registered by an agent comrade
+func (v *ProtocolValidator) ValidateRegistrationRole(role string) error {
+	if role == "" {
+		return fmt.Errorf("agent role cannot be empty")
+	}
+
+	if IsReservedRole(role) {
+		return fmt.Errorf("role '%s' is reserved and cannot be registered", role)
+	}
+
+	if v.soviet.IsGroup(role) {
+		return fmt.Errorf("role '%s' is a group name and cannot be registered", role)
+	}
+
+	return nil
+}
+
+// ValidateCapabilities validates a capability list sent by an agent comrade
+func (v *ProtocolValidator) ValidateCapabilities(capabilities []string) error {
+	if len(capabilities) == 0 {
+		return fmt.Errorf("capability list cannot be empty")
+	}
+
+	for i, capability := range capabilities {
+		if capability == "" {
+			return fmt.Errorf("capability %d cannot be empty", i)
+		}
+	}
+
 	return nil
 }
 
@@ -87,13 +207,90 @@ func (v *ProtocolValidator) ValidateTargetAgent(targetRole string) error {
 		return fmt.Errorf("target agent '%s' is not connected", targetRole)
 	}
 
+	if agent != nil && !agent.Type().CanHoldBarrel() {
+		return fmt.Errorf("target agent '%s' is an observer and cannot receive the barrel", targetRole)
+	}
+
+	if agent != nil && (agent.IsPaused() || agent.IsErrored()) {
+		return fmt.Errorf("target agent '%s' is in %s state and cannot receive the barrel until it is resumed", targetRole, agent.State())
+	}
+
+	if v.soviet.IsBenched(targetRole) {
+		return fmt.Errorf("target agent '%s' is benched by the circuit breaker after repeated failures", targetRole)
+	}
+
+	return nil
+}
+
+// ValidateTargetCapability validates that the target agent has the capability the work requires
+// The people and an empty requirement always pass; unknown targets are left to ValidateTargetAgent
+func (v *ProtocolValidator) ValidateTargetCapability(targetRole, capability string) error {
+	if targetRole == "people" || capability == "" {
+		return nil
+	}
+
+	agent := v.soviet.GetAgent(targetRole)
+	if agent != nil && !agent.HasCapability(capability) {
+		return fmt.Errorf("target agent '%s' lacks required capability '%s'", targetRole, capability)
+	}
 	return nil
 }
 
+// ValidateYieldChainDepth validates that an agent-to-agent yield does not exceed the configured chain depth
+func (v *ProtocolValidator) ValidateYieldChainDepth(message YieldMessage) error {
+	maxDepth := v.soviet.MaxYieldChainDepth()
+	if maxDepth == 0 {
+		return nil
+	}
+
+	// Yields involving the people never extend the chain
+	if message.FromRole() == "people" || message.ToRole() == "people" {
+		return nil
+	}
+
+	if v.soviet.YieldChainDepth()+1 > maxDepth {
+		return fmt.Errorf("yield chain depth limit exceeded (max: %d), barrel must be returned to people", maxDepth)
+	}
+
+	return nil
+}
+
+// ValidateMessageFreshness rejects messages sent longer ago than the configured maximum age
+// A sender clock running behind the server is allowed for up to the clock skew tolerance
+// Messages without a send time are always accepted
+func (v *ProtocolValidator) ValidateMessageFreshness(message YieldMessage) error {
+	maxAge := v.soviet.MaxMessageAge()
+	if maxAge == 0 || message.SentAt().IsZero() {
+		return nil
+	}
+
+	age := nowFunc().Sub(message.SentAt())
+	if age > maxAge+v.soviet.ClockSkewTolerance() {
+		return fmt.Errorf("stale yield from '%s' rejected: sent %s ago (max age: %s)",
+			message.FromRole(), age.Round(time.Millisecond), maxAge)
+	}
+
+	return nil
+}
+
+// ValidateMessageClock rejects messages stamped further in the future than the clock skew tolerance
+// Messages without a send time are always accepted
+func (v *ProtocolValidator) ValidateMessageClock(message YieldMessage) error {
+	if message.SentAt().IsZero() || v.soviet.WithinSkew(message.SentAt()) {
+		return nil
+	}
+	return fmt.Errorf("yield from '%s' rejected: sent_at %s is %s ahead of the server clock (tolerance: %s)",
+		message.FromRole(), message.SentAt().Format(time.RFC3339Nano),
+		message.SentAt().Sub(nowFunc()).Round(time.Millisecond), v.soviet.ClockSkewTolerance())
+}
+
 // ValidateAgentStateConsistency validates that agent state is consistent with barrel ownership
 func (v *ProtocolValidator) ValidateAgentStateConsistency(agentRole string) error {
 	// Get the agent
 	agent := v.soviet.GetAgent(agentRole)
+	if agent == nil && v.soviet.IsBarrelHeldBy(agentRole) {
+		return fmt.Errorf("barrel is held by unregistered role '%s': it must register again before yielding, or the people can reclaim the barrel", agentRole)
+	}
 	if agent == nil {
 		return fmt.Errorf("agent '%s' not found", agentRole)
 	}
@@ -104,42 +301,73 @@ func (v *ProtocolValidator) ValidateAgentStateConsistency(agentRole string) erro
 		return fmt.Errorf("no barrel available in soviet")
 	}
 
-	// Check consistency: if agent has barrel, they should be working
+	// Check consistency: if agent has barrel, they should be working (or offered, pending acknowledgment)
 	hasBarrel := barrel.IsHeldBy(agentRole)
 	isWorking := agent.State() == AgentStateWorking
+	isOffered := agent.State() == AgentStateOffered
 
-	if hasBarrel && !isWorking {
-		return fmt.Errorf("agent state inconsistency: agent '%s' has barrel but is waiting", agentRole)
+	if hasBarrel && !isWorking && !isOffered {
+		return fmt.Errorf("agent state inconsistency: agent '%s' has barrel but is %s", agentRole, agent.State())
 	}
 
 	if !hasBarrel && isWorking {
 		return fmt.Errorf("agent state inconsistency: agent '%s' is working but doesn't have barrel", agentRole)
 	}
 
+	if !hasBarrel && isOffered {
+		return fmt.Errorf("agent state inconsistency: agent '%s' is offered but doesn't have barrel", agentRole)
+	}
+
 	return nil
 }
 
 // ValidateYieldWorkflow performs comprehensive validation of the entire yield workflow
+// The first failure is returned as a ValidationError carrying the code of the rule it broke
 func (v *ProtocolValidator) ValidateYieldWorkflow(message YieldMessage) error {
 	// 1. Validate message structure
 	if err := v.ValidateYieldMessage(message); err != nil {
-		return err
+		return newValidationError(ValidationCodeInvalidMessage, err)
+	}
+
+	// 2. Reject delayed duplicates before they are mistaken for current requests
+	if err := v.ValidateMessageClock(message); err != nil {
+		return newValidationError(ValidationCodeClockSkew, err)
+	}
+	if err := v.ValidateMessageFreshness(message); err != nil {
+		return newValidationError(ValidationCodeStaleMessage, err)
 	}
 
-	// 2. Validate barrel holder rights
+	// 3. Validate barrel holder rights
 	if err := v.ValidateBarrelHolderRights(message.FromRole()); err != nil {
-		return err
+		return newValidationError(ValidationCodeNotBarrelHolder, err)
 	}
 
-	// 3. Validate target agent
+	// 4. Validate target agent
 	if err := v.ValidateTargetAgent(message.ToRole()); err != nil {
-		return err
+		return newValidationError(ValidationCodeInvalidTarget, err)
+	}
+
+	// 5. Validate the target can do the work
+	if err := v.ValidateTargetCapability(message.ToRole(), message.RequiredCapability()); err != nil {
+		return newValidationError(ValidationCodeMissingCapability, err)
 	}
 
-	// 4. Validate state consistency (only for non-people agents)
+	// 6. Validate state consistency (only for non-people agents)
 	if message.FromRole() != "people" {
 		if err := v.ValidateAgentStateConsistency(message.FromRole()); err != nil {
-			return err
+			return newValidationError(ValidationCodeStateInconsistency, err)
+		}
+	}
+
+	// 7. Validate yield chain depth
+	if err := v.ValidateYieldChainDepth(message); err != nil {
+		return newValidationError(ValidationCodeChainDepthExceeded, err)
+	}
+
+	// 8. Apply the custom rules
+	for _, rule := range v.rules {
+		if err := rule(message, v.soviet); err != nil {
+			return ruleError(err)
 		}
 	}
 
@@ -147,25 +375,48 @@ func (v *ProtocolValidator) ValidateYieldWorkflow(message YieldMessage) error {
 }
 
 // GetValidationErrors collects all validation errors for a yield message
+// Every error is a ValidationError carrying the code of the rule it broke
 func (v *ProtocolValidator) GetValidationErrors(message YieldMessage) []error {
 	var errors []error
 
 	// Collect all validation errors without short-circuiting
 	if err := v.ValidateYieldMessage(message); err != nil {
-		errors = append(errors, err)
+		errors = append(errors, newValidationError(ValidationCodeInvalidMessage, err))
+	}
+
+	if err := v.ValidateMessageClock(message); err != nil {
+		errors = append(errors, newValidationError(ValidationCodeClockSkew, err))
+	}
+
+	if err := v.ValidateMessageFreshness(message); err != nil {
+		errors = append(errors, newValidationError(ValidationCodeStaleMessage, err))
 	}
 
 	if err := v.ValidateBarrelHolderRights(message.FromRole()); err != nil {
-		errors = append(errors, err)
+		errors = append(errors, newValidationError(ValidationCodeNotBarrelHolder, err))
 	}
 
 	if err := v.ValidateTargetAgent(message.ToRole()); err != nil {
-		errors = append(errors, err)
+		errors = append(errors, newValidationError(ValidationCodeInvalidTarget, err))
+	}
+
+	if err := v.ValidateTargetCapability(message.ToRole(), message.RequiredCapability()); err != nil {
+		errors = append(errors, newValidationError(ValidationCodeMissingCapability, err))
 	}
 
 	if message.FromRole() != "people" {
 		if err := v.ValidateAgentStateConsistency(message.FromRole()); err != nil {
-			errors = append(errors, err)
+			errors = append(errors, newValidationError(ValidationCodeStateInconsistency, err))
+		}
+	}
+
+	if err := v.ValidateYieldChainDepth(message); err != nil {
+		errors = append(errors, newValidationError(ValidationCodeChainDepthExceeded, err))
+	}
+
+	for _, rule := range v.rules {
+		if err := rule(message, v.soviet); err != nil {
+			errors = append(errors, ruleError(err))
 		}
 	}
 
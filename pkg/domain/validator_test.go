@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -148,6 +149,102 @@ func (suite *ProtocolValidatorTestSuite) TestValidateTargetAgent_DisconnectedAge
 	assert.Contains(suite.T(), err.Error(), "target agent 'developer' is not connected")
 }
 
+func (suite *ProtocolValidatorTestSuite) TestValidateTargetAgent_PausedOrErroredAgent() {
+	suite.Require().NoError(suite.testAgents["tester"].Pause())
+	err := suite.validator.ValidateTargetAgent("tester")
+	suite.Require().Error(err)
+	assert.Contains(suite.T(), err.Error(), "target agent 'tester' is in paused state")
+
+	suite.Require().NoError(suite.testAgents["developer"].Activate("Work on task"))
+	suite.Require().NoError(suite.testAgents["developer"].Fail("crashed"))
+	err = suite.validator.ValidateTargetAgent("developer")
+	suite.Require().Error(err)
+	assert.Contains(suite.T(), err.Error(), "target agent 'developer' is in error state")
+}
+
+// Test ValidateTargetCapability - Required Capability Validation
+func (suite *ProtocolValidatorTestSuite) TestValidateTargetCapability_CapabilityPresent() {
+	assert.NoError(suite.T(), suite.validator.ValidateTargetCapability("tester", "validate"))
+	assert.NoError(suite.T(), suite.validator.ValidateTargetCapability("tester", "val*"), "patterns match like HasCapability")
+}
+
+func (suite *ProtocolValidatorTestSuite) TestValidateTargetCapability_CapabilityMissing() {
+	err := suite.validator.ValidateTargetCapability("tester", "performance")
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "target agent 'tester' lacks required capability 'performance'", err.Error())
+}
+
+func (suite *ProtocolValidatorTestSuite) TestValidateTargetCapability_EmptyRequirementOrPeople() {
+	assert.NoError(suite.T(), suite.validator.ValidateTargetCapability("tester", ""))
+	assert.NoError(suite.T(), suite.validator.ValidateTargetCapability("people", "performance"))
+}
+
+func (suite *ProtocolValidatorTestSuite) TestValidateYieldWorkflow_RequiredCapability() {
+	suite.testBarrel.TransferTo("developer", "Initial work")
+	suite.testAgents["developer"].TransitionTo(AgentStateWorking)
+
+	message := NewYieldMessage("developer", "tester", "Profile it").WithRequiredCapability("performance")
+	err := suite.validator.ValidateYieldWorkflow(message)
+	suite.Require().Error(err)
+	validationErr, ok := err.(ValidationError)
+	suite.Require().True(ok)
+	assert.Equal(suite.T(), ValidationCodeMissingCapability, validationErr.Code)
+	assert.Contains(suite.T(), err.Error(), "lacks required capability 'performance'")
+
+	message = NewYieldMessage("developer", "tester", "Validate it").WithRequiredCapability("validate")
+	assert.NoError(suite.T(), suite.validator.ValidateYieldWorkflow(message))
+}
+
+// Test custom yield rules
+func (suite *ProtocolValidatorTestSuite) TestAddRule_RejectsYieldsToReviewer() {
+	suite.testBarrel.TransferTo("developer", "Initial work")
+	suite.testAgents["developer"].TransitionTo(AgentStateWorking)
+	toReviewer := NewYieldMessage("developer", "reviewer", "Review it")
+	suite.Require().NoError(suite.validator.ValidateYieldWorkflow(toReviewer), "no custom rules keeps the built-in behavior")
+
+	var checked *SovietState
+	suite.validator.AddRule(func(message YieldMessage, soviet *SovietState) error {
+		checked = soviet
+		if message.ToRole() == "reviewer" {
+			return fmt.Errorf("reviews are assigned by the people only")
+		}
+		return nil
+	})
+
+	err := suite.validator.ValidateYieldWorkflow(toReviewer)
+	suite.Require().Error(err)
+	assert.Equal(suite.T(), ValidationError{Code: ValidationCodePolicyViolation, Message: "reviews are assigned by the people only"}, err)
+	assert.Same(suite.T(), suite.soviet, checked)
+	assert.NoError(suite.T(), suite.validator.ValidateYieldWorkflow(NewYieldMessage("developer", "tester", "Test it")))
+
+	// Built-in and custom problems are reported together; a ValidationError keeps its code
+	suite.validator.AddRule(func(message YieldMessage, soviet *SovietState) error {
+		return ValidationError{Code: "FREEZE", Message: "release freeze"}
+	})
+	problems := suite.validator.GetValidationErrors(NewYieldMessage("tester", "reviewer", "Review it"))
+	codes := []string{}
+	for _, problem := range problems {
+		codes = append(codes, problem.(ValidationError).Code)
+	}
+	assert.Equal(suite.T(), []string{ValidationCodeNotBarrelHolder, ValidationCodePolicyViolation, "FREEZE"}, codes)
+}
+
+func (suite *ProtocolValidatorTestSuite) TestAddYieldRule_AppliesToProcessYield() {
+	suite.soviet.AddYieldRule(func(message YieldMessage, soviet *SovietState) error {
+		if message.ToRole() == "reviewer" {
+			return fmt.Errorf("reviews are assigned by the people only")
+		}
+		return nil
+	})
+
+	err := suite.soviet.ProcessYield(NewYieldMessage("people", "reviewer", "Review it"))
+	suite.Require().Error(err)
+	assert.Equal(suite.T(), 1, suite.soviet.Rejections()[ValidationCodePolicyViolation])
+	assert.True(suite.T(), suite.soviet.IsBarrelHeldBy("people"))
+	assert.NoError(suite.T(), suite.soviet.ProcessYield(NewYieldMessage("people", "tester", "Test it")))
+}
+
 // Test ValidateYieldWorkflow - Complete Workflow Validation
 func (suite *ProtocolValidatorTestSuite) TestValidateYieldWorkflow_CompleteValidWorkflow() {
 	// Set up valid scenario: developer has barrel and wants to yield to tester
@@ -169,3 +266,51 @@ func (suite *ProtocolValidatorTestSuite) TestValidateYieldWorkflow_InvalidMessag
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "from_role cannot be empty")
 }
+
+// Test reserved role handling
+func (suite *ProtocolValidatorTestSuite) TestValidateYieldMessage_SovietFromRoleRejected() {
+	message := NewYieldMessage("soviet", "tester", "Impersonating the committee")
+
+	err := suite.validator.ValidateYieldMessage(message)
+
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "role 'soviet' is reserved")
+}
+
+func (suite *ProtocolValidatorTestSuite) TestValidateYieldMessage_SovietToRoleRejected() {
+	message := NewYieldMessage("people", "soviet", "Hand it to the committee")
+
+	err := suite.validator.ValidateYieldMessage(message)
+
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "role 'soviet' is reserved")
+}
+
+func (suite *ProtocolValidatorTestSuite) TestValidateRegistrationRole_ReservedRolesRejected() {
+	for _, role := range []string{"soviet", "people"} {
+		err := suite.validator.ValidateRegistrationRole(role)
+
+		assert.Error(suite.T(), err, role)
+		assert.Contains(suite.T(), err.Error(), "is reserved")
+	}
+
+	assert.NoError(suite.T(), suite.validator.ValidateRegistrationRole("developer"))
+}
+
+func (suite *ProtocolValidatorTestSuite) TestValidateAgentStateConsistency_OfferedHolder() {
+	suite.testBarrel.TransferTo("developer", "Pending acknowledgment")
+	suite.testAgents["developer"].Offer("Pending acknowledgment")
+
+	err := suite.validator.ValidateAgentStateConsistency("developer")
+
+	assert.NoError(suite.T(), err, "Offered barrel holder is consistent")
+}
+
+func (suite *ProtocolValidatorTestSuite) TestValidateAgentStateConsistency_OfferedWithoutBarrel() {
+	suite.testAgents["developer"].Offer("Stale offer")
+
+	err := suite.validator.ValidateAgentStateConsistency("developer")
+
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "is offered but doesn't have barrel")
+}
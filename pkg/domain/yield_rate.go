@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// yieldBucket limits how often one role may yield
+// It holds up to burst tokens, refilled at rate tokens per second; each accepted yield spends one
+type yieldBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last refill
+func (b *yieldBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// SetMaxYieldsPerMinute limits how many yields each role may make per minute, so an agent yielding
+// in a tight loop cannot thrash the barrel. A role may use the whole allowance at once.
+// The people are exempt so they can always intervene. 0 disables the limit
+func (s *SovietState) SetMaxYieldsPerMinute(limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("max yields per minute cannot be negative: %d", limit)
+	}
+	s.maxYieldsPerMinute = limit
+	s.yieldBuckets = nil
+	return nil
+}
+
+// MaxYieldsPerMinute returns the configured per-role yield limit (0 = unlimited)
+func (s *SovietState) MaxYieldsPerMinute() int {
+	return s.maxYieldsPerMinute
+}
+
+// yieldRateError reports a role that used up its yield allowance, or nil if the role may yield
+// Checking spends nothing, so dry runs and yields that end up failing leave the allowance untouched
+func (s *SovietState) yieldRateError(role string) error {
+	bucket := s.yieldBucket(role)
+	if bucket != nil && bucket.tokens < 1 {
+		return newValidationError(ValidationCodeRateLimited, fmt.Errorf("yield rate exceeded for role '%s'", role))
+	}
+	return nil
+}
+
+// spendYield takes one token from the role's allowance once its yield has moved the barrel
+func (s *SovietState) spendYield(role string) {
+	if bucket := s.yieldBucket(role); bucket != nil && bucket.tokens >= 1 {
+		bucket.tokens--
+	}
+}
+
+// yieldBucket returns the role's refilled token bucket, or nil if the role is not rate limited
+func (s *SovietState) yieldBucket(role string) *yieldBucket {
+	if s.maxYieldsPerMinute == 0 || role == "people" {
+		return nil
+	}
+
+	now := nowFunc()
+	bucket, ok := s.yieldBuckets[role]
+	if !ok {
+		limit := float64(s.maxYieldsPerMinute)
+		bucket = &yieldBucket{rate: limit / 60, burst: limit, tokens: limit, last: now}
+		if s.yieldBuckets == nil {
+			s.yieldBuckets = make(map[string]*yieldBucket)
+		}
+		s.yieldBuckets[role] = bucket
+	}
+	bucket.refill(now)
+	return bucket
+}
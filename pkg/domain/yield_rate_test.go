@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSovietState_MaxYieldsPerMinute(t *testing.T) {
+	currentTime := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	stubs := gostub.Stub(&nowFunc, func() time.Time {
+		return currentTime
+	})
+	defer stubs.Reset()
+
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	assert.Error(t, soviet.SetMaxYieldsPerMinute(-1))
+	require.NoError(t, soviet.SetMaxYieldsPerMinute(2))
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+
+	// The people are exempt, however often they yield
+	for i := 0; i < 3; i++ {
+		require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+		require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "tester", "Test it")))
+	}
+
+	// The developer may yield twice, then must wait
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", "people", "Done")))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+	err := soviet.ProcessYield(NewYieldMessage("developer", "tester", "Test it"))
+	require.Error(t, err)
+	assert.Equal(t, "yield rate exceeded for role 'developer'", err.Error())
+	assert.True(t, soviet.IsBarrelHeldBy("developer"), "a rate-limited yield does not move the barrel")
+	assert.Equal(t, 1, soviet.Rejections()[ValidationCodeRateLimited])
+
+	problems := soviet.ValidateYield(NewYieldMessage("developer", "tester", "Test it"))
+	require.Len(t, problems, 1)
+	assert.Equal(t, ValidationCodeRateLimited, problems[0].Code)
+
+	// One token comes back every 30 seconds
+	currentTime = currentTime.Add(30 * time.Second)
+	assert.Empty(t, soviet.ValidateYield(NewYieldMessage("developer", "tester", "Test it")), "a dry run spends nothing")
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", "tester", "Test it")))
+
+	// Other roles have their own allowance
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("tester", "people", "Tested")))
+}
+
+func TestSovietState_FailedTransferKeepsYieldAllowance(t *testing.T) {
+	soviet := newTestSoviet()
+	require.NoError(t, soviet.SetBarrel(NewBarrelOfGun()))
+	require.NoError(t, soviet.SetMaxYieldsPerMinute(1))
+	for _, role := range []string{"developer", "tester"} {
+		_, _, _, err := soviet.RegisterAgent(NewAgentComrade(role, []string{"coding"}))
+		require.NoError(t, err)
+	}
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("people", "developer", "Build it")))
+
+	// The rule runs after validation and leaves the developer unable to yield, so the transfer fails
+	breakSender := true
+	soviet.AddYieldRule(func(message YieldMessage, soviet *SovietState) error {
+		if breakSender {
+			return soviet.GetAgent(message.FromRole()).TransitionTo(AgentStateWaiting)
+		}
+		return nil
+	})
+	require.Error(t, soviet.ProcessYield(NewYieldMessage("developer", "tester", "Test it")))
+	assert.True(t, soviet.IsBarrelHeldBy("developer"))
+
+	// The failed yield spent nothing, so the developer's only token is still there
+	breakSender = false
+	require.NoError(t, soviet.GetAgent("developer").TransitionTo(AgentStateWorking))
+	require.NoError(t, soviet.ProcessYield(NewYieldMessage("developer", "tester", "Test it")))
+	assert.True(t, soviet.IsBarrelHeldBy("tester"))
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/lonegunmanb/agentfarm/pkg/domain"
 )
 
@@ -18,7 +20,7 @@ func NewCoordinatorAdapter(soviet *domain.SovietState) *CoordinatorAdapter {
 }
 
 // RegisterAgent implements SovietService.RegisterAgent
-func (a *CoordinatorAdapter) RegisterAgent(agent *domain.AgentComrade) (bool, string, error) {
+func (a *CoordinatorAdapter) RegisterAgent(agent *domain.AgentComrade) (bool, string, string, error) {
 	return a.soviet.RegisterAgent(agent)
 }
 
@@ -27,6 +29,16 @@ func (a *CoordinatorAdapter) ProcessYield(message domain.YieldMessage) error {
 	return a.soviet.ProcessYield(message)
 }
 
+// LastTransfer implements SovietService.LastTransfer
+func (a *CoordinatorAdapter) LastTransfer() (domain.TransferRecord, bool) {
+	return a.soviet.LastTransfer()
+}
+
+// TransferHistory implements SovietService.TransferHistory
+func (a *CoordinatorAdapter) TransferHistory(limit int) []domain.TransferRecord {
+	return a.soviet.TransferHistory(limit)
+}
+
 // DeregisterAgent implements SovietService.DeregisterAgent
 func (a *CoordinatorAdapter) DeregisterAgent(role string) error {
 	return a.soviet.DeregisterAgent(role)
@@ -37,5 +49,100 @@ func (a *CoordinatorAdapter) QueryStatus() domain.StatusResponse {
 	return a.soviet.QueryStatus()
 }
 
+// ForceAgentState implements SovietService.ForceAgentState
+func (a *CoordinatorAdapter) ForceAgentState(role string, state domain.AgentState) error {
+	return a.soviet.ForceAgentState(role, state)
+}
+
+// ReassignBarrel implements SovietService.ReassignBarrel
+func (a *CoordinatorAdapter) ReassignBarrel(fromRole, toRole string) error {
+	return a.soviet.ReassignBarrel(fromRole, toRole)
+}
+
+// UpdateAgentCapabilities implements SovietService.UpdateAgentCapabilities
+func (a *CoordinatorAdapter) UpdateAgentCapabilities(role string, capabilities []string) ([]string, error) {
+	return a.soviet.UpdateAgentCapabilities(role, capabilities)
+}
+
+// DisconnectAgent implements SovietService.DisconnectAgent
+func (a *CoordinatorAdapter) DisconnectAgent(role string) error {
+	return a.soviet.DisconnectAgent(role)
+}
+
+// ReclaimDisconnectedBarrel implements SovietService.ReclaimDisconnectedBarrel
+func (a *CoordinatorAdapter) ReclaimDisconnectedBarrel() (bool, error) {
+	return a.soviet.ReclaimDisconnectedBarrel()
+}
+
+// RecordHeartbeat implements SovietService.RecordHeartbeat
+func (a *CoordinatorAdapter) RecordHeartbeat(role string) error {
+	return a.soviet.RecordHeartbeat(role)
+}
+
+// DisconnectSilentAgents implements SovietService.DisconnectSilentAgents
+func (a *CoordinatorAdapter) DisconnectSilentAgents() ([]string, error) {
+	return a.soviet.DisconnectSilentAgents()
+}
+
+// ReconcileStates implements SovietService.ReconcileStates
+func (a *CoordinatorAdapter) ReconcileStates() ([]string, error) {
+	return a.soviet.ReconcileStates()
+}
+
+// PruneHistory implements SovietService.PruneHistory
+func (a *CoordinatorAdapter) PruneHistory() ([]domain.TransferRecord, error) {
+	return a.soviet.PruneHistory()
+}
+
+// Halt implements SovietService.Halt
+func (a *CoordinatorAdapter) Halt(reason string) error {
+	return a.soviet.Halt(reason)
+}
+
+// Resume implements SovietService.Resume
+func (a *CoordinatorAdapter) Resume() bool {
+	return a.soviet.Resume()
+}
+
+// HaltStatus implements SovietService.HaltStatus
+func (a *CoordinatorAdapter) HaltStatus() domain.HaltStatus {
+	return a.soviet.HaltStatus()
+}
+
+// SetBarrelTTL implements SovietService.SetBarrelTTL
+func (a *CoordinatorAdapter) SetBarrelTTL(ttl time.Duration) error {
+	return a.soviet.SetBarrelTTL(ttl)
+}
+
+// BarrelTTL implements SovietService.BarrelTTL
+func (a *CoordinatorAdapter) BarrelTTL() time.Duration {
+	return a.soviet.BarrelTTL()
+}
+
+// BarrelDeadline implements SovietService.BarrelDeadline
+func (a *CoordinatorAdapter) BarrelDeadline() time.Time {
+	return a.soviet.BarrelDeadline()
+}
+
+// ReclaimExpiredBarrel implements SovietService.ReclaimExpiredBarrel
+func (a *CoordinatorAdapter) ReclaimExpiredBarrel() (bool, error) {
+	return a.soviet.ReclaimExpiredBarrel()
+}
+
+// AcknowledgeActivation implements SovietService.AcknowledgeActivation
+func (a *CoordinatorAdapter) AcknowledgeActivation(role string) error {
+	return a.soviet.AcknowledgeActivation(role)
+}
+
+// SetExpectedDuration implements SovietService.SetExpectedDuration
+func (a *CoordinatorAdapter) SetExpectedDuration(role string, expected time.Duration) error {
+	return a.soviet.SetExpectedDuration(role, expected)
+}
+
+// ReclaimUnacknowledgedOffer implements SovietService.ReclaimUnacknowledgedOffer
+func (a *CoordinatorAdapter) ReclaimUnacknowledgedOffer() (bool, error) {
+	return a.soviet.ReclaimUnacknowledgedOffer()
+}
+
 // Verify interface compliance
 var _ domain.SovietService = (*CoordinatorAdapter)(nil)
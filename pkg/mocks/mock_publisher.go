@@ -0,0 +1,77 @@
+package mocks
+
+import (
+	"sync"
+
+	"github.com/lonegunmanb/agentfarm/pkg/domain"
+)
+
+// MockEventPublisher implements EventPublisher interface for testing
+type MockEventPublisher struct {
+	mu     sync.RWMutex
+	events []domain.PublishedEvent
+	err    error
+}
+
+// NewMockEventPublisher creates a new mock event publisher
+func NewMockEventPublisher() *MockEventPublisher {
+	return &MockEventPublisher{
+		events: make([]domain.PublishedEvent, 0),
+	}
+}
+
+// PublishAgentRegistered records an agent registration
+func (m *MockEventPublisher) PublishAgentRegistered(agent domain.AgentDetails) error {
+	return m.record(domain.PublishedEvent{Type: domain.EventAgentRegistered, Role: agent.Role, Agent: &agent})
+}
+
+// PublishAgentDeregistered records an agent deregistration
+func (m *MockEventPublisher) PublishAgentDeregistered(role string) error {
+	return m.record(domain.PublishedEvent{Type: domain.EventAgentDeregistered, Role: role})
+}
+
+// PublishBarrelTransferred records a barrel transfer
+func (m *MockEventPublisher) PublishBarrelTransferred(transfer domain.TransferRecord) error {
+	return m.record(domain.PublishedEvent{Type: domain.EventBarrelTransferred, Role: transfer.ToRole, Transfer: &transfer})
+}
+
+// record keeps an event unless publishing is set to fail
+func (m *MockEventPublisher) record(event domain.PublishedEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+// SetError makes every publish fail with err; nil makes publishing succeed again (for testing)
+func (m *MockEventPublisher) SetError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.err = err
+}
+
+// GetPublishedEvents returns all published events (for testing)
+func (m *MockEventPublisher) GetPublishedEvents() []domain.PublishedEvent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]domain.PublishedEvent, len(m.events))
+	copy(result, m.events)
+	return result
+}
+
+// ClearEvents clears all published events (for testing)
+func (m *MockEventPublisher) ClearEvents() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = m.events[:0]
+}
+
+// Verify interface compliance
+var _ domain.EventPublisher = (*MockEventPublisher)(nil)
@@ -17,6 +17,7 @@ type WorkflowIntegrationTestSuite struct {
 	mockRepo      *MockAgentRepository
 	mockSender    *MockMessageSender
 	mockLogger    *MockLogger
+	mockPublisher *MockEventPublisher
 }
 
 // SetupTest sets up test environment for each test
@@ -25,12 +26,14 @@ func (suite *WorkflowIntegrationTestSuite) SetupTest() {
 	suite.mockRepo = NewMockAgentRepository()
 	suite.mockSender = NewMockMessageSender()
 	suite.mockLogger = NewMockLogger()
+	suite.mockPublisher = NewMockEventPublisher()
 
 	// Create soviet state with dependencies injected
 	suite.soviet = domain.NewSovietStateWithDependencies(
 		suite.mockRepo,
 		suite.mockSender,
 		suite.mockLogger,
+		suite.mockPublisher,
 	)
 
 	// Initialize barrel (required for tests)
@@ -54,7 +57,7 @@ func (suite *WorkflowIntegrationTestSuite) TestCompleteRevolutionaryWorkflow() {
 		// Test agent registration workflow
 	developerAgent := domain.NewAgentComrade("developer", []string{"coding", "testing"})
 
-	shouldResume, lastMessage, err := suite.sovietService.RegisterAgent(developerAgent)
+	shouldResume, lastMessage, _, err := suite.sovietService.RegisterAgent(developerAgent)
 	assert.NoError(suite.T(), err)
 	assert.False(suite.T(), shouldResume) // Should not resume initially (barrel with people)
 	assert.Empty(suite.T(), lastMessage)
@@ -72,7 +75,7 @@ func (suite *WorkflowIntegrationTestSuite) TestCompleteRevolutionaryWorkflow() {
 	// Phase 3: Register tester agent while developer is working
 	testerAgent := domain.NewAgentComrade("tester", []string{"testing", "validation"})
 
-	shouldResume, lastMessage, err = suite.sovietService.RegisterAgent(testerAgent)
+	shouldResume, lastMessage, _, err = suite.sovietService.RegisterAgent(testerAgent)
 	assert.NoError(suite.T(), err)
 	assert.False(suite.T(), shouldResume) // Should not resume (developer holds barrel)
 	assert.Empty(suite.T(), lastMessage)
@@ -111,10 +114,10 @@ func (suite *WorkflowIntegrationTestSuite) TestPeoplesInterventionAndStatusQuery
 	developerAgent := domain.NewAgentComrade("developer", []string{"coding"})
 	testerAgent := domain.NewAgentComrade("tester", []string{"testing"})
 
-	_, _, err := suite.sovietService.RegisterAgent(developerAgent)
+	_, _, _, err := suite.sovietService.RegisterAgent(developerAgent)
 	assert.NoError(suite.T(), err)
 
-	_, _, err = suite.sovietService.RegisterAgent(testerAgent)
+	_, _, _, err = suite.sovietService.RegisterAgent(testerAgent)
 	assert.NoError(suite.T(), err)
 
 	// People yield to developer
@@ -151,7 +154,7 @@ func (suite *WorkflowIntegrationTestSuite) TestDisconnectionRecoveryWithMocks()
 	// Phase 1: Register developer and give them the barrel
 	developerAgent := domain.NewAgentComrade("developer", []string{"coding"})
 
-	_, _, err := suite.sovietService.RegisterAgent(developerAgent)
+	_, _, _, err := suite.sovietService.RegisterAgent(developerAgent)
 	assert.NoError(suite.T(), err)
 
 	// People yield to developer
@@ -167,7 +170,7 @@ func (suite *WorkflowIntegrationTestSuite) TestDisconnectionRecoveryWithMocks()
 	// Phase 2: Simulate reconnection - developer reconnects
 	newDeveloperAgent := domain.NewAgentComrade("developer", []string{"coding"})
 
-	shouldResume, lastMessage, err := suite.sovietService.RegisterAgent(newDeveloperAgent)
+	shouldResume, lastMessage, _, err := suite.sovietService.RegisterAgent(newDeveloperAgent)
 	assert.NoError(suite.T(), err)
 	assert.True(suite.T(), shouldResume) // Should resume work since they hold the barrel
 	assert.Equal(suite.T(), "Work on critical feature", lastMessage)
@@ -187,7 +190,7 @@ func (suite *WorkflowIntegrationTestSuite) TestMockVerificationAndAssertion() {
 	// Register an agent and perform a complete workflow (SovietState handles all external operations)
 	developerAgent := domain.NewAgentComrade("developer", []string{"coding"})
 
-	_, _, err := suite.sovietService.RegisterAgent(developerAgent)
+	_, _, _, err := suite.sovietService.RegisterAgent(developerAgent)
 	assert.NoError(suite.T(), err)
 
 	// Yield barrel to agent (triggers messaging and events)
@@ -226,3 +229,54 @@ func (suite *WorkflowIntegrationTestSuite) TestMockVerificationAndAssertion() {
 	infoLogs := suite.mockLogger.GetLogsByLevel("INFO")
 	assert.GreaterOrEqual(suite.T(), len(infoLogs), 1)
 }
+
+func (suite *WorkflowIntegrationTestSuite) TestRedactedPayloadsStayOutOfLogs() {
+	suite.soviet.SetRedactPayloads(true)
+
+	developerAgent := domain.NewAgentComrade("developer", []string{"coding"})
+	_, _, _, err := suite.sovietService.RegisterAgent(developerAgent)
+	assert.NoError(suite.T(), err)
+
+	err = suite.sovietService.ProcessYield(domain.NewYieldMessage("people", "developer", "api-key=hunter2"))
+	assert.NoError(suite.T(), err)
+
+	var transferLogged bool
+	for _, entry := range suite.mockLogger.GetLogs() {
+		assert.NotContains(suite.T(), fmt.Sprint(entry.Fields), "hunter2")
+		if entry.Message == "Barrel transferred successfully" {
+			transferLogged = true
+			assert.Equal(suite.T(), domain.RedactPayload("api-key=hunter2"), entry.Fields["payload"])
+		}
+	}
+	assert.True(suite.T(), transferLogged)
+}
+
+// TestDomainEventsArePublished tests that registrations, deregistrations and every transfer reach the publisher
+func (suite *WorkflowIntegrationTestSuite) TestDomainEventsArePublished() {
+	_, _, _, err := suite.sovietService.RegisterAgent(domain.NewAgentComrade("developer", []string{"coding"}))
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.sovietService.ProcessYield(domain.NewYieldMessage("people", "developer", "Build it")))
+	suite.Require().NoError(suite.sovietService.DeregisterAgent("developer"))
+
+	events := suite.mockPublisher.GetPublishedEvents()
+	suite.Require().Len(events, 4)
+	assert.Equal(suite.T(), domain.EventAgentRegistered, events[0].Type)
+	assert.Equal(suite.T(), []string{"coding"}, events[0].Agent.Capabilities)
+	assert.Equal(suite.T(), domain.EventBarrelTransferred, events[1].Type)
+	assert.Equal(suite.T(), "Build it", events[1].Transfer.Message)
+	assert.Equal(suite.T(), 1, events[1].Transfer.Receipt.Sequence)
+	// The barrel held by the leaving agent goes back to the people before it is gone
+	assert.Equal(suite.T(), domain.EventBarrelTransferred, events[2].Type)
+	assert.Equal(suite.T(), "people", events[2].Transfer.ToRole)
+	assert.Equal(suite.T(), domain.PublishedEvent{Type: domain.EventAgentDeregistered, Role: "developer"}, events[3])
+
+	// A publisher failure is logged without undoing the operation
+	suite.mockPublisher.SetError(fmt.Errorf("webhook unreachable"))
+	_, _, _, err = suite.sovietService.RegisterAgent(domain.NewAgentComrade("tester", []string{"testing"}))
+	suite.Require().NoError(err)
+	assert.True(suite.T(), suite.soviet.IsAgentRegistered("tester"))
+	failures := suite.mockLogger.GetLogsByLevel("ERROR")
+	suite.Require().NotEmpty(failures)
+	assert.Equal(suite.T(), "Failed to publish event", failures[len(failures)-1].Message)
+	assert.Equal(suite.T(), domain.EventAgentRegistered, failures[len(failures)-1].Fields["event"])
+}